@@ -10,7 +10,7 @@
 //	cfg.S3        // S3-compatible object storage
 //	cfg.Cache     // Valkey/Redis cache
 //	cfg.Queue     // background jobs
-//	cfg.Email     // password reset email delivery
+//	cfg.Email     // password reset and verification email delivery
 //
 // Some env keys have fallback aliases (legacy names). The first-set key wins;
 // see Load() for the full list.
@@ -18,6 +18,8 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -35,6 +37,7 @@ type Config struct {
 	Queue      QueueConfig
 	OpenRouter OpenRouterConfig
 	GitHub     GitHubConfig
+	OAuth      OAuthConfig
 	Frontend   FrontendConfig
 	Email      EmailConfig
 	MarketData MarketDataConfig
@@ -65,14 +68,63 @@ type HTTPConfig struct {
 
 // AuthConfig contains authentication secrets.
 type AuthConfig struct {
-	// JWTSecret is the secret key used for JWT token signing and verification.
+	// JWTSecret is the secret key used for HS256 access tokens and for the
+	// keys of short-lived purpose tokens (2FA challenges, OAuth links).
 	JWTSecret string
+	// JWTSigningKeyFile is a PEM RSA or Ed25519 private key. When set, access
+	// tokens are signed RS256/EdDSA with it instead of HS256.
+	JWTSigningKeyFile string
+	// JWTRetiredKeyFiles are PEM keys from earlier rotations; they still verify
+	// tokens but never sign.
+	JWTRetiredKeyFiles []string
+	// JWTAcceptHS256 keeps HS256 access tokens valid after switching to a
+	// signing key, until they have expired.
+	JWTAcceptHS256 bool
 	// JWTExpiry is the duration for which JWT access tokens remain valid.
 	JWTExpiry time.Duration
 	// RefreshTokenExpiry is the duration for which refresh tokens remain valid.
 	RefreshTokenExpiry time.Duration
+	// TOTPIssuer is the issuer label shown in authenticator apps for 2FA.
+	TOTPIssuer string
+	// EmailVerification controls what an unverified account may do: one of
+	// EmailVerificationOff, EmailVerificationLogin or EmailVerificationWrites.
+	EmailVerification string
+	// LockoutThreshold is the number of failed logins within LockoutWindow
+	// that locks an account. 0 disables account lockout.
+	LockoutThreshold int
+	// LockoutBaseDuration is the first lock's length; each further failure
+	// doubles it up to LockoutMaxDuration.
+	LockoutBaseDuration time.Duration
+	// LockoutMaxDuration caps the lock length.
+	LockoutMaxDuration time.Duration
+	// LockoutWindow is how long failed logins are remembered.
+	LockoutWindow time.Duration
+	// AccountDeletionGrace is how long a self-service account deletion can
+	// be cancelled before the account is erased.
+	AccountDeletionGrace time.Duration
+	// DataExportTTL is how long a personal data export stays downloadable.
+	// It cannot exceed 7 days, the longest presigned S3 URL.
+	DataExportTTL time.Duration
+	// ImpersonationTTL is the lifetime of the access token an admin gets when
+	// impersonating a user.
+	ImpersonationTTL time.Duration
+	// UsernameChangeCooldown is the minimum time between two username changes.
+	UsernameChangeCooldown time.Duration
+	// UsernameReservation is how long a released username stays reserved for
+	// its previous owner before anyone else can take it.
+	UsernameReservation time.Duration
 }
 
+// Email verification enforcement modes.
+const (
+	// EmailVerificationOff sends verification emails but enforces nothing.
+	EmailVerificationOff = "off"
+	// EmailVerificationLogin rejects password logins until the email is verified.
+	EmailVerificationLogin = "login"
+	// EmailVerificationWrites allows login but blocks creating posts and comments.
+	EmailVerificationWrites = "writes"
+)
+
 // DatabaseConfig contains the PostgreSQL DSN and connection pool tuning.
 type DatabaseConfig struct {
 	// DSN is the PostgreSQL connection string (pgx / GORM DSN).
@@ -97,6 +149,9 @@ type S3Config struct {
 	Bucket string
 	// UseSSL determines whether to use SSL/TLS for S3 connections.
 	UseSSL bool
+	// PublicURL is the base URL under which bucket objects are served, such
+	// as a CDN in front of the bucket. Empty uses the endpoint and bucket.
+	PublicURL string
 }
 
 // CacheConfig contains Redis/Valkey cache settings.
@@ -143,12 +198,36 @@ type GitHubConfig struct {
 	RedirectURI  string
 }
 
+// OAuthConfig contains the OpenID Connect sign-in providers. A provider is
+// enabled when its client ID is set.
+type OAuthConfig struct {
+	// RedirectBaseURL is the API prefix the provider callbacks are served
+	// under; each provider redirects to <RedirectBaseURL>/<name>/callback.
+	RedirectBaseURL string
+	Google          OAuthClientConfig
+	GitLab          OAuthClientConfig
+	// OIDC lists the generic issuers named in OAUTH_OIDC_PROVIDERS.
+	OIDC []OAuthClientConfig
+}
+
+// OAuthClientConfig is one OAuth client registration.
+type OAuthClientConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+}
+
 // FrontendConfig contains frontend URL settings for OAuth redirects and cookies.
 type FrontendConfig struct {
-	URL              string
-	OAuthCallbackURL string
-	ResetPasswordURL string
-	MainDomain       string
+	URL               string
+	OAuthCallbackURL  string
+	ResetPasswordURL  string
+	ForgotPasswordURL string
+	VerifyEmailURL    string
+	MagicLinkURL      string
+	SecureAccountURL  string
+	MainDomain        string
 }
 
 // EmailConfig contains email delivery settings.
@@ -194,8 +273,24 @@ func Load() (*Config, error) {
 		},
 		Auth: AuthConfig{
 			JWTSecret:          envString([]string{"JWT_SECRET"}, ""),
+			JWTSigningKeyFile:  envString([]string{"JWT_SIGNING_KEY_FILE"}, ""),
+			JWTRetiredKeyFiles: parseList(envString([]string{"JWT_RETIRED_KEY_FILES"}, "")),
+			JWTAcceptHS256:     envBool([]string{"JWT_ACCEPT_HS256"}, true),
 			JWTExpiry:          time.Duration(envInt([]string{"JWT_EXPIRY_HOURS"}, 3)) * time.Hour,
 			RefreshTokenExpiry: time.Duration(envInt([]string{"REFRESH_TOKEN_EXPIRY_DAYS"}, 30)) * 24 * time.Hour,
+			TOTPIssuer:         envString([]string{"TOTP_ISSUER"}, "Pilput"),
+			EmailVerification:  strings.ToLower(envString([]string{"EMAIL_VERIFICATION_MODE"}, EmailVerificationOff)),
+
+			LockoutThreshold:    envInt([]string{"LOGIN_LOCKOUT_THRESHOLD"}, 5),
+			LockoutBaseDuration: envDuration([]string{"LOGIN_LOCKOUT_BASE"}, time.Minute),
+			LockoutMaxDuration:  envDuration([]string{"LOGIN_LOCKOUT_MAX"}, time.Hour),
+			LockoutWindow:       envDuration([]string{"LOGIN_LOCKOUT_WINDOW"}, 24*time.Hour),
+
+			AccountDeletionGrace:   envDuration([]string{"ACCOUNT_DELETION_GRACE"}, 30*24*time.Hour),
+			DataExportTTL:          envDuration([]string{"DATA_EXPORT_TTL"}, 7*24*time.Hour),
+			ImpersonationTTL:       envDuration([]string{"IMPERSONATION_TTL"}, 15*time.Minute),
+			UsernameChangeCooldown: envDuration([]string{"USERNAME_CHANGE_COOLDOWN"}, 30*24*time.Hour),
+			UsernameReservation:    envDuration([]string{"USERNAME_RESERVATION"}, 90*24*time.Hour),
 		},
 		Database: DatabaseConfig{
 			DSN:             envString([]string{"DATABASE_URL"}, ""),
@@ -209,6 +304,7 @@ func Load() (*Config, error) {
 			SecretKey: envString([]string{"S3_SECRET_KEY", "MINIO_SECRET_KEY"}, "minioadmin"),
 			Bucket:    envString([]string{"S3_BUCKET", "MINIO_BUCKET"}, "minio-bucket"),
 			UseSSL:    envBool([]string{"S3_USE_SSL", "MINIO_USE_SSL"}, true),
+			PublicURL: envString([]string{"S3_PUBLIC_URL"}, ""),
 		},
 		Cache: CacheConfig{
 			RedisURL:       envString([]string{"REDIS_URL", "VALKEY_URL"}, ""),
@@ -236,11 +332,30 @@ func Load() (*Config, error) {
 			ClientSecret: envString([]string{"GITHUB_CLIENT_SECRET"}, ""),
 			RedirectURI:  envString([]string{"GITHUB_REDIRECT_URI"}, "http://localhost:8080/api/auth/oauth/github/callback"),
 		},
+		OAuth: OAuthConfig{
+			RedirectBaseURL: strings.TrimRight(envString([]string{"OAUTH_REDIRECT_BASE_URL"}, "http://localhost:8080/api/auth/oauth"), "/"),
+			Google: OAuthClientConfig{
+				Name:         "google",
+				ClientID:     envString([]string{"GOOGLE_CLIENT_ID"}, ""),
+				ClientSecret: envString([]string{"GOOGLE_CLIENT_SECRET"}, ""),
+			},
+			GitLab: OAuthClientConfig{
+				Name:         "gitlab",
+				Issuer:       envString([]string{"GITLAB_ISSUER"}, "https://gitlab.com"),
+				ClientID:     envString([]string{"GITLAB_CLIENT_ID"}, ""),
+				ClientSecret: envString([]string{"GITLAB_CLIENT_SECRET"}, ""),
+			},
+			OIDC: loadOIDCProviders(envString([]string{"OAUTH_OIDC_PROVIDERS"}, "")),
+		},
 		Frontend: FrontendConfig{
-			URL:              envString([]string{"FRONTEND_URL"}, "http://localhost:3000"),
-			OAuthCallbackURL: envString([]string{"FRONTEND_OAUTH_CALLBACK_URL"}, "http://localhost:3000/auth/callback"),
-			ResetPasswordURL: envString([]string{"FRONTEND_RESET_PASSWORD_URL"}, "http://localhost:3000/reset-password"),
-			MainDomain:       envString([]string{"MAIN_DOMAIN"}, "localhost"),
+			URL:               envString([]string{"FRONTEND_URL"}, "http://localhost:3000"),
+			OAuthCallbackURL:  envString([]string{"FRONTEND_OAUTH_CALLBACK_URL"}, "http://localhost:3000/auth/callback"),
+			ResetPasswordURL:  envString([]string{"FRONTEND_RESET_PASSWORD_URL"}, "http://localhost:3000/reset-password"),
+			ForgotPasswordURL: envString([]string{"FRONTEND_FORGOT_PASSWORD_URL"}, "http://localhost:3000/forgot-password"),
+			VerifyEmailURL:    envString([]string{"FRONTEND_VERIFY_EMAIL_URL"}, "http://localhost:3000/verify-email"),
+			MagicLinkURL:      envString([]string{"FRONTEND_MAGIC_LINK_URL"}, "http://localhost:3000/magic-link"),
+			SecureAccountURL:  envString([]string{"FRONTEND_SECURE_ACCOUNT_URL"}, "http://localhost:3000/secure-account"),
+			MainDomain:        envString([]string{"MAIN_DOMAIN"}, "localhost"),
 		},
 		Email: EmailConfig{
 			SMTPHost:     envString([]string{"SMTP_HOST"}, ""),
@@ -272,12 +387,49 @@ func (c *Config) validate() error {
 	if len(c.Auth.JWTSecret) < 32 {
 		return errors.New("JWT_SECRET must be at least 32 characters long")
 	}
+	if !c.Auth.JWTAcceptHS256 && c.Auth.JWTSigningKeyFile == "" {
+		return errors.New("JWT_ACCEPT_HS256 can only be disabled when JWT_SIGNING_KEY_FILE is set")
+	}
 	if c.Auth.JWTExpiry <= 0 {
 		return errors.New("JWT_EXPIRY_HOURS must be > 0")
 	}
 	if c.Auth.RefreshTokenExpiry <= 0 {
 		return errors.New("REFRESH_TOKEN_EXPIRY_DAYS must be > 0")
 	}
+	switch c.Auth.EmailVerification {
+	case EmailVerificationOff, EmailVerificationLogin, EmailVerificationWrites:
+	default:
+		return errors.New("EMAIL_VERIFICATION_MODE must be one of off, login, writes")
+	}
+	if c.Auth.LockoutThreshold < 0 {
+		return errors.New("LOGIN_LOCKOUT_THRESHOLD must be >= 0")
+	}
+	if c.Auth.LockoutThreshold > 0 {
+		if c.Auth.LockoutBaseDuration <= 0 || c.Auth.LockoutMaxDuration < c.Auth.LockoutBaseDuration {
+			return errors.New("LOGIN_LOCKOUT_BASE must be > 0 and LOGIN_LOCKOUT_MAX must be >= LOGIN_LOCKOUT_BASE")
+		}
+		if c.Auth.LockoutWindow <= 0 {
+			return errors.New("LOGIN_LOCKOUT_WINDOW must be > 0")
+		}
+	}
+	if c.Auth.AccountDeletionGrace <= 0 {
+		return errors.New("ACCOUNT_DELETION_GRACE must be > 0")
+	}
+	if c.Auth.DataExportTTL <= 0 || c.Auth.DataExportTTL > 7*24*time.Hour {
+		return errors.New("DATA_EXPORT_TTL must be > 0 and at most 168h")
+	}
+	if c.Auth.ImpersonationTTL <= 0 || c.Auth.ImpersonationTTL > time.Hour {
+		return errors.New("IMPERSONATION_TTL must be > 0 and at most 1h")
+	}
+	if c.Auth.UsernameChangeCooldown < 0 {
+		return errors.New("USERNAME_CHANGE_COOLDOWN must be >= 0")
+	}
+	if c.Auth.UsernameReservation < 0 {
+		return errors.New("USERNAME_RESERVATION must be >= 0")
+	}
+	if err := c.OAuth.validate(); err != nil {
+		return err
+	}
 	if c.Database.DSN == "" {
 		return errors.New("DATABASE_URL is required")
 	}
@@ -314,6 +466,79 @@ func (c *Config) validate() error {
 	return nil
 }
 
+// reservedOAuthProviderNames are built-in providers and route segments under
+// /api/auth/oauth that a generic OIDC issuer cannot shadow.
+var reservedOAuthProviderNames = []string{"github", "google", "gitlab", "providers", "exchange"}
+
+func (c OAuthConfig) validate() error {
+	seen := make(map[string]bool, len(c.OIDC))
+	for _, p := range c.OIDC {
+		if !validOAuthProviderName(p.Name) {
+			return fmt.Errorf("OAUTH_OIDC_PROVIDERS: invalid provider name %q (use a-z, 0-9 and -)", p.Name)
+		}
+		if slices.Contains(reservedOAuthProviderNames, p.Name) || seen[p.Name] {
+			return fmt.Errorf("OAUTH_OIDC_PROVIDERS: provider name %q is already in use", p.Name)
+		}
+		seen[p.Name] = true
+
+		key := oidcEnvPrefix(p.Name)
+		if p.Issuer == "" {
+			return fmt.Errorf("%s_ISSUER is required", key)
+		}
+		if p.ClientID == "" {
+			return fmt.Errorf("%s_CLIENT_ID is required", key)
+		}
+	}
+	return nil
+}
+
+// loadOIDCProviders reads OAUTH_OIDC_<NAME>_* settings for each name in the
+// comma-separated list raw.
+func loadOIDCProviders(raw string) []OAuthClientConfig {
+	var providers []OAuthClientConfig
+	for part := range strings.SplitSeq(raw, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		key := oidcEnvPrefix(name)
+		providers = append(providers, OAuthClientConfig{
+			Name:         name,
+			Issuer:       envString([]string{key + "_ISSUER"}, ""),
+			ClientID:     envString([]string{key + "_CLIENT_ID"}, ""),
+			ClientSecret: envString([]string{key + "_CLIENT_SECRET"}, ""),
+		})
+	}
+	return providers
+}
+
+func oidcEnvPrefix(name string) string {
+	return "OAUTH_OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func validOAuthProviderName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseList splits a comma-separated value, dropping empty entries.
+func parseList(raw string) []string {
+	var out []string
+	for part := range strings.SplitSeq(raw, ",") {
+		if v := strings.TrimSpace(part); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // parseOrigins splits a comma-separated CORS origin list, trims whitespace,
 // and drops empty entries. Returns ["*"] when the raw value is empty or "*".
 func parseOrigins(raw string) []string {
@@ -1,11 +1,17 @@
 package apperror
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountLocked      = errors.New("account is temporarily locked")
+	ErrAccountSuspended   = errors.New("account is suspended")
 	ErrInvalidToken       = errors.New("invalid or expired token")
 	ErrTokenExpired       = errors.New("token has expired")
+	ErrSessionNotFound    = errors.New("session not found")
 
 	ErrUserNotFound     = errors.New("user not found")
 	ErrUserExists       = errors.New("user already exists")
@@ -13,15 +19,30 @@ var (
 	ErrAlreadyFollowing = errors.New("already following this user")
 	ErrCannotFollowSelf = errors.New("cannot follow yourself")
 	ErrNotFollowing     = errors.New("not following this user")
+	ErrUserBlocked      = errors.New("one of the users has blocked the other")
+	ErrCannotBlockSelf  = errors.New("cannot block or mute yourself")
+	ErrFollowRequested  = errors.New("follow request already sent")
+	ErrNoFollowRequest  = errors.New("follow request not found")
+	ErrPrivateAccount   = errors.New("this account is private")
+
+	ErrUsernameUnchanged      = errors.New("new username matches the current username")
+	ErrUsernameUnavailable    = errors.New("username is not available")
+	ErrUsernameChangeCooldown = errors.New("username was changed too recently")
+	ErrUsernameMoved          = errors.New("username has changed")
 
-	ErrPostNotFound    = errors.New("post not found")
-	ErrNotAuthor       = errors.New("not author")
-	ErrAlreadyLiked    = errors.New("user has already liked this post")
-	ErrNotLiked        = errors.New("user has not liked this post")
-	ErrCommentNotOwned = errors.New("not authorized to modify this comment")
-	ErrPostNotOwned    = errors.New("not authorized to modify this post")
-	ErrInvalidPostID   = errors.New("invalid post ID format")
-	ErrEmptyPostID     = errors.New("post ID cannot be empty")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrNotAuthor            = errors.New("not author")
+	ErrAlreadyLiked         = errors.New("user has already liked this post")
+	ErrNotLiked             = errors.New("user has not liked this post")
+	ErrCommentNotOwned      = errors.New("not authorized to modify this comment")
+	ErrPostNotOwned         = errors.New("not authorized to modify this post")
+	ErrInvalidPostID        = errors.New("invalid post ID format")
+	ErrEmptyPostID          = errors.New("post ID cannot be empty")
+	ErrEmptySearch          = errors.New("search query must contain a word")
+	ErrRevisionNotFound     = errors.New("post revision not found")
+	ErrPublishAtNotFuture   = errors.New("publish_at must be in the future")
+	ErrPostAlreadyPublished = errors.New("post is already published")
+	ErrSchedulingDisabled   = errors.New("scheduled publishing is not available")
 
 	ErrTagNotFound     = errors.New("tag not found")
 	ErrTagNameRequired = errors.New("tag name is required")
@@ -58,5 +79,84 @@ var (
 	ErrPasswordNoSpecial         = errors.New("password must contain at least one special character")
 	ErrPasswordResetTokenUsed    = errors.New("password reset token has already been used")
 	ErrPasswordResetTokenExpired = errors.New("password reset token has expired")
-	ErrOAuthNotConfigured        = errors.New("GitHub OAuth is not configured")
+
+	ErrOAuthProviderNotFound = errors.New("OAuth provider is not configured")
+	ErrOAuthExchangeFailed   = errors.New("failed to verify the OAuth sign-in with the provider")
+	ErrOAuthEmailInUse       = errors.New("an account already uses this email; sign in and link the provider instead")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to an account")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("cannot remove the only remaining sign-in method")
+
+	ErrTwoFactorRequired       = errors.New("two-factor authentication required")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+	ErrEmailUnchanged           = errors.New("new email matches the current email")
+	ErrVerificationTokenUsed    = errors.New("verification token has already been used")
+	ErrVerificationTokenExpired = errors.New("verification token has expired")
+
+	ErrAccessTokenNotFound     = errors.New("access token not found")
+	ErrInvalidAccessTokenScope = errors.New("unknown access token scope")
+	ErrAccessTokenLimitReached = errors.New("access token limit reached")
+
+	ErrCannotImpersonateSelf = errors.New("cannot impersonate yourself")
+	ErrCannotSuspendSelf     = errors.New("cannot suspend yourself")
+	ErrUserNotSuspended      = errors.New("user is not suspended")
+
+	ErrRoleNotFound = errors.New("role not found")
+	ErrLastAdmin    = errors.New("cannot remove the last admin")
+
+	ErrDataExportNotFound       = errors.New("data export not found")
+	ErrDataExportDisabled       = errors.New("data export requires the task queue and object storage")
+	ErrDeletionNotScheduled     = errors.New("account deletion is not scheduled")
+	ErrDeletionAlreadyScheduled = errors.New("account deletion is already scheduled")
 )
+
+// AccountLockedError reports a login refused because the account is locked.
+// It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// AccountSuspendedError reports a sign-in refused because an admin suspended
+// the account. A nil Until means the suspension has no end date. It matches
+// ErrAccountSuspended with errors.Is.
+type AccountSuspendedError struct {
+	Until  *time.Time
+	Reason string
+}
+
+func (e *AccountSuspendedError) Error() string {
+	return ErrAccountSuspended.Error()
+}
+
+func (e *AccountSuspendedError) Unwrap() error {
+	return ErrAccountSuspended
+}
+
+// UsernameCooldownError reports a username change refused because the
+// previous change is too recent. It matches ErrUsernameChangeCooldown with
+// errors.Is.
+type UsernameCooldownError struct {
+	Until time.Time
+}
+
+func (e *UsernameCooldownError) Error() string {
+	return ErrUsernameChangeCooldown.Error()
+}
+
+func (e *UsernameCooldownError) Unwrap() error {
+	return ErrUsernameChangeCooldown
+}
@@ -2,6 +2,8 @@ package di
 
 import (
 	"context"
+	"fmt"
+
 	"echobackend/config"
 	"echobackend/internal/handler"
 	"echobackend/internal/middleware"
@@ -13,7 +15,9 @@ import (
 	"echobackend/internal/repository"
 	"echobackend/internal/routes"
 	"echobackend/internal/service"
+	"echobackend/pkg/jwtkeys"
 	"echobackend/pkg/market"
+	"echobackend/pkg/oauth"
 
 	"gorm.io/gorm"
 )
@@ -28,6 +32,18 @@ type Container struct {
 
 // NewContainer creates a manually wired application container.
 func NewContainer(cfg *config.Config) (*Container, error) {
+	// Key files are read before any connection is opened so a bad key path
+	// fails startup without leaking resources.
+	tokenKeys, err := jwtkeys.Load(jwtkeys.Config{
+		SigningKeyFile:  cfg.Auth.JWTSigningKeyFile,
+		RetiredKeyFiles: cfg.Auth.JWTRetiredKeyFiles,
+		HMACSecret:      cfg.Auth.JWTSecret,
+		AcceptHMAC:      cfg.Auth.JWTAcceptHS256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+
 	cleanup := NewCleanupManager()
 
 	db := database.NewDatabase(cfg)
@@ -56,7 +72,6 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	cleanup.Register(func() error {
 		return emailService.Close()
 	})
-	taskQueue.Start()
 
 	userRepo := repository.NewUserRepository(db)
 	postRepo := repository.NewPostRepository(db)
@@ -65,6 +80,7 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	tagRepo := repository.NewTagRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
 	postViewRepo := repository.NewPostViewRepository(db)
+	postRevisionRepo := repository.NewPostRevisionRepository(db)
 	postLikeRepo := repository.NewPostLikeRepository(db)
 	userFollowRepo := repository.NewUserFollowRepository(db)
 	chatConversationRepo := repository.NewChatConversationRepository(db)
@@ -73,39 +89,83 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	notificationRepo := repository.NewNotificationRepository(db)
 	authActivityLogRepo := repository.NewAuthActivityLogRepository(db)
 	passwordResetTokenRepo := repository.NewPasswordResetTokenRepository(db)
+	emailVerificationTokenRepo := repository.NewEmailVerificationTokenRepository(db)
+	magicLinkTokenRepo := repository.NewMagicLinkTokenRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	accountLockoutRepo := repository.NewAccountLockoutRepository(db)
+	userSuspensionRepo := repository.NewUserSuspensionRepository(db)
+	accessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	knownDeviceRepo := repository.NewKnownDeviceRepository(db)
+	sessionRevokeTokenRepo := repository.NewSessionRevokeTokenRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	adminAuditLogRepo := repository.NewAdminAuditLogRepository(db)
 	reportRepo := repository.NewReportRepository(db)
 	corporateActionRepo := repository.NewCorporateActionRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	dataExportRepo := repository.NewDataExportRepository(db)
+	usernameHistoryRepo := repository.NewUsernameHistoryRepository(db)
+	profileRepo := repository.NewProfileRepository(db)
+	userBlockRepo := repository.NewUserBlockRepository(db)
 
 	authActivityService := service.NewAuthActivityService(authActivityLogRepo)
 	openRouterService := service.NewOpenRouterService(cfg.OpenRouter)
 	userService := service.NewUserService(userRepo)
 	tagService := service.NewTagService(tagRepo, redisCache)
-	postService := service.NewPostService(postRepo, tagService, s3Storage, redisCache)
-	authService := service.NewAuthService(authRepo, userRepo, sessionRepo, passwordResetTokenRepo, authActivityService, cfg, redisCache, emailService)
-	notificationService := service.NewNotificationService(notificationRepo)
-	commentService := service.NewCommentService(commentRepo, postRepo, notificationService)
+	postService := service.NewPostService(postRepo, tagService, s3Storage, redisCache, taskQueue)
+	userBlockService := service.NewUserBlockService(userBlockRepo, userFollowRepo, userRepo, redisCache)
+	notificationService := service.NewNotificationService(notificationRepo, userBlockService)
+	authService := service.NewAuthService(authRepo, userRepo, sessionRepo, passwordResetTokenRepo, emailVerificationTokenRepo, magicLinkTokenRepo, userIdentityRepo, twoFactorRepo, accountLockoutRepo, accessTokenRepo, knownDeviceRepo, sessionRevokeTokenRepo, userSuspensionRepo, authActivityService, notificationService, cfg, tokenKeys, newOAuthRegistry(cfg), redisCache, emailService)
+	commentService := service.NewCommentService(commentRepo, postRepo, notificationService, userBlockService)
 	postViewService := service.NewPostViewService(postViewRepo, postRepo, postLikeRepo)
+	postRevisionService := service.NewPostRevisionService(postRevisionRepo, postRepo, tagService)
 	postLikeService := service.NewPostLikeService(postLikeRepo, postRepo)
-	userFollowService := service.NewUserFollowService(userFollowRepo, userRepo, notificationService)
+	userFollowService := service.NewUserFollowService(userFollowRepo, userRepo, notificationService, userBlockService, redisCache)
 	chatConversationService := service.NewChatConversationService(chatConversationRepo, openRouterService, cfg)
 	yahooClient := market.NewYahooClient(nil)
 	holdingService := service.NewHoldingService(holdingRepo, yahooClient, redisCache)
 	exchangeRateService := service.NewExchangeRateService(yahooClient, redisCache)
 	bookmarkService := service.NewBookmarkService(bookmarkRepo, postRepo)
 	reportService := service.NewReportService(reportRepo)
+	roleService := service.NewRoleService(roleRepo, userRepo, redisCache)
+	adminAuditService := service.NewAdminAuditService(adminAuditLogRepo)
+	usernameService := service.NewUsernameService(userRepo, usernameHistoryRepo, authActivityService, cfg.Auth.UsernameChangeCooldown, cfg.Auth.UsernameReservation)
+
+	// A nil *S3Storage must not end up in a non-nil interface, or exports
+	// would be accepted and then fail in the worker.
+	var exportStorage service.ExportStorage
+	if s3Storage != nil {
+		exportStorage = s3Storage
+	}
+	var avatarStorage service.AvatarStorage
+	if s3Storage != nil {
+		avatarStorage = s3Storage
+	}
+	profileService := service.NewProfileService(profileRepo, userRepo, avatarStorage, redisCache)
+	accountService := service.NewAccountService(accountRepo, dataExportRepo, userRepo, authActivityService, exportStorage, taskQueue, emailService, cfg.Auth.AccountDeletionGrace, cfg.Auth.DataExportTTL)
+	taskQueue.Handle(service.TaskDataExport, accountService.HandleDataExportTask)
+	taskQueue.Handle(service.TaskPurgeAccounts, accountService.HandlePurgeTask)
+	taskQueue.Periodic("@hourly", service.TaskPurgeAccounts)
+	taskQueue.Handle(service.TaskLiftSuspensions, authService.HandleLiftSuspensionsTask)
+	taskQueue.Periodic("*/5 * * * *", service.TaskLiftSuspensions)
+	taskQueue.Handle(service.TaskPublishPost, postService.HandlePublishTask)
+	taskQueue.Handle(service.TaskPublishDuePosts, postService.HandlePublishDueTask)
+	taskQueue.Periodic("*/5 * * * *", service.TaskPublishDuePosts)
+	taskQueue.Start()
 
 	// Corporate actions: IDX
 	idxCorporateClient := market.NewRapidAPIIDXClient(cfg.MarketData.RapidAPIIDXKey, nil)
 	corporateActionService := service.NewCorporateActionService(idxCorporateClient, corporateActionRepo)
 
-	userHandler := handler.NewUserHandler(userService, userFollowService)
-	postHandler := handler.NewPostHandler(postService, postViewService)
+	userHandler := handler.NewUserHandler(userService, userFollowService, usernameService, profileService)
+	postHandler := handler.NewPostHandler(postService, postViewService, usernameService, postRevisionService)
 	authHandler := handler.NewAuthHandler(authService, authActivityService, cfg.Frontend)
 	tagHandler := handler.NewTagHandler(tagService)
 	commentHandler := handler.NewCommentHandler(commentService)
 	postViewHandler := handler.NewPostViewHandler(postViewService)
 	postLikeHandler := handler.NewPostLikeHandler(postLikeService)
 	userFollowHandler := handler.NewUserFollowHandler(userFollowService)
+	userBlockHandler := handler.NewUserBlockHandler(userBlockService)
 	chatConversationHandler := handler.NewChatConversationHandler(chatConversationService)
 	holdingHandler := handler.NewHoldingHandler(holdingService)
 	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService)
@@ -113,8 +173,12 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	notificationHandler := handler.NewNotificationHandler(notificationService)
 	reportHandler := handler.NewReportHandler(reportService)
 	corporateActionHandler := handler.NewCorporateActionHandler(corporateActionService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	adminAuditHandler := handler.NewAdminAuditHandler(adminAuditService)
+	accountHandler := handler.NewAccountHandler(accountService)
 
-	authMiddleware := middleware.NewAuthMiddleware(cfg, userService)
+	authMiddleware := middleware.NewAuthMiddleware(cfg, userService, authService, roleService, tokenKeys, adminAuditService, authService)
+	auditMiddleware := middleware.NewAuditMiddleware(adminAuditService)
 	appRoutes := routes.NewRoutes(
 		cfg,
 		redisCache,
@@ -122,6 +186,7 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 		postHandler,
 		authHandler,
 		authMiddleware,
+		auditMiddleware,
 		tagHandler,
 		commentHandler,
 		postViewHandler,
@@ -134,6 +199,10 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 		notificationHandler,
 		reportHandler,
 		corporateActionHandler,
+		roleHandler,
+		adminAuditHandler,
+		accountHandler,
+		userBlockHandler,
 	)
 
 	return &Container{
@@ -160,3 +229,40 @@ func GetCleanupManager(container *Container) (*CleanupManager, error) {
 	}
 	return container.Cleanup, nil
 }
+
+// newOAuthRegistry registers every sign-in provider that has a client ID
+// configured.
+func newOAuthRegistry(cfg *config.Config) *oauth.Registry {
+	var providers []oauth.Provider
+
+	clientConfig := func(name, clientID, clientSecret string) oauth.ClientConfig {
+		return oauth.ClientConfig{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURI:  cfg.OAuth.RedirectBaseURL + "/" + name + "/callback",
+		}
+	}
+
+	if cfg.GitHub.ClientID != "" {
+		providers = append(providers, oauth.NewGitHubProvider(oauth.ClientConfig{
+			ClientID:     cfg.GitHub.ClientID,
+			ClientSecret: cfg.GitHub.ClientSecret,
+			RedirectURI:  cfg.GitHub.RedirectURI,
+		}, nil))
+	}
+	if google := cfg.OAuth.Google; google.ClientID != "" {
+		providers = append(providers, oauth.NewGoogleProvider(clientConfig(google.Name, google.ClientID, google.ClientSecret), nil))
+	}
+	if gitlab := cfg.OAuth.GitLab; gitlab.ClientID != "" {
+		providers = append(providers, oauth.NewGitLabProvider(gitlab.Issuer, clientConfig(gitlab.Name, gitlab.ClientID, gitlab.ClientSecret), nil))
+	}
+	for _, p := range cfg.OAuth.OIDC {
+		providers = append(providers, oauth.NewOIDCProvider(oauth.OIDCConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientConfig: clientConfig(p.Name, p.ClientID, p.ClientSecret),
+		}, nil))
+	}
+
+	return oauth.NewRegistry(providers...)
+}
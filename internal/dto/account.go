@@ -0,0 +1,285 @@
+package dto
+
+import (
+	"time"
+
+	"echobackend/internal/model"
+)
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	FileSize    *int64     `json:"file_size"`
+	DownloadURL *string    `json:"download_url"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func DataExportToResponse(e *model.DataExport, downloadURL *string) *DataExportResponse {
+	if e == nil {
+		return nil
+	}
+	return &DataExportResponse{
+		ID:          e.ID,
+		Status:      e.Status,
+		FileSize:    e.FileSize,
+		DownloadURL: downloadURL,
+		ExpiresAt:   e.ExpiresAt,
+		CompletedAt: e.CompletedAt,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+// AccountData is everything stored about one user, as loaded for a personal
+// data export.
+type AccountData struct {
+	User            *model.User
+	Posts           []*model.Post
+	Comments        []*model.PostComment
+	Likes           []*model.PostLike
+	BookmarkFolders []*model.BookmarkFolder
+	Bookmarks       []*model.PostBookmark
+	Followers       []*model.UserFollow
+	Following       []*model.UserFollow
+	Notifications   []*model.Notification
+	Conversations   []*model.ChatConversation
+	Holdings        []*model.Holding
+	AuthActivity    []*model.AuthActivityLog
+}
+
+// ExportFile is one JSON document in a data export archive.
+type ExportFile struct {
+	Name    string
+	Content any
+}
+
+type ExportProfile struct {
+	ID              string         `json:"id"`
+	Email           string         `json:"email"`
+	Username        *string        `json:"username"`
+	FirstName       *string        `json:"first_name"`
+	LastName        *string        `json:"last_name"`
+	Image           *string        `json:"image"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	LastLoggedAt    *time.Time     `json:"last_logged_at"`
+	Profile         *model.Profile `json:"profile"`
+	CreatedAt       *time.Time     `json:"created_at"`
+	UpdatedAt       *time.Time     `json:"updated_at"`
+}
+
+type ExportPost struct {
+	ID            string     `json:"id"`
+	Title         *string    `json:"title"`
+	Slug          *string    `json:"slug"`
+	Body          *string    `json:"body"`
+	PhotoURL      *string    `json:"photo_url"`
+	Published     *bool      `json:"published"`
+	PublishedAt   *time.Time `json:"published_at"`
+	Tags          []string   `json:"tags"`
+	ViewCount     int64      `json:"view_count"`
+	LikeCount     int64      `json:"like_count"`
+	BookmarkCount int64      `json:"bookmark_count"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+type ExportComment struct {
+	ID              string     `json:"id"`
+	PostID          string     `json:"post_id"`
+	ParentCommentID *string    `json:"parent_comment_id"`
+	Text            string     `json:"text"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+type ExportLike struct {
+	PostID    string     `json:"post_id"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type ExportBookmarks struct {
+	Folders   []ExportBookmarkFolder `json:"folders"`
+	Bookmarks []ExportBookmark       `json:"bookmarks"`
+}
+
+type ExportBookmarkFolder struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+type ExportBookmark struct {
+	PostID    string     `json:"post_id"`
+	FolderID  *string    `json:"folder_id"`
+	Name      *string    `json:"name"`
+	Notes     *string    `json:"notes"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type ExportFollows struct {
+	Followers []ExportFollow `json:"followers"`
+	Following []ExportFollow `json:"following"`
+}
+
+type ExportFollow struct {
+	User      *UserBrief `json:"user"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type ExportConversation struct {
+	ID        string              `json:"id"`
+	Title     string              `json:"title"`
+	IsPinned  bool                `json:"is_pinned"`
+	Messages  []ExportChatMessage `json:"messages"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type ExportChatMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Model     *string   `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountDataToExportFiles lays out d as the files of a data export archive.
+func AccountDataToExportFiles(d *AccountData) []ExportFile {
+	profile := ExportProfile{
+		ID:              d.User.ID,
+		Email:           d.User.Email,
+		Username:        d.User.Username,
+		FirstName:       d.User.FirstName,
+		LastName:        d.User.LastName,
+		Image:           d.User.Image,
+		EmailVerifiedAt: d.User.EmailVerifiedAt,
+		LastLoggedAt:    d.User.LastLoggedAt,
+		Profile:         d.User.Profile,
+		CreatedAt:       d.User.CreatedAt,
+		UpdatedAt:       d.User.UpdatedAt,
+	}
+
+	posts := make([]ExportPost, 0, len(d.Posts))
+	for _, p := range d.Posts {
+		tags := make([]string, 0, len(p.Tags))
+		for _, t := range p.Tags {
+			tags = append(tags, t.Name)
+		}
+		posts = append(posts, ExportPost{
+			ID:            p.ID,
+			Title:         p.Title,
+			Slug:          p.Slug,
+			Body:          p.Body,
+			PhotoURL:      p.PhotoURL,
+			Published:     p.Published,
+			PublishedAt:   p.PublishedAt,
+			Tags:          tags,
+			ViewCount:     p.ViewCount,
+			LikeCount:     p.LikeCount,
+			BookmarkCount: p.BookmarkCount,
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+		})
+	}
+
+	comments := make([]ExportComment, 0, len(d.Comments))
+	for _, c := range d.Comments {
+		comments = append(comments, ExportComment{
+			ID:              c.ID,
+			PostID:          c.PostID,
+			ParentCommentID: c.ParentCommentID,
+			Text:            c.Text,
+			CreatedAt:       c.CreatedAt,
+			UpdatedAt:       c.UpdatedAt,
+		})
+	}
+
+	likes := make([]ExportLike, 0, len(d.Likes))
+	for _, l := range d.Likes {
+		likes = append(likes, ExportLike{PostID: l.PostID, CreatedAt: l.CreatedAt})
+	}
+
+	bookmarks := ExportBookmarks{
+		Folders:   make([]ExportBookmarkFolder, 0, len(d.BookmarkFolders)),
+		Bookmarks: make([]ExportBookmark, 0, len(d.Bookmarks)),
+	}
+	for _, f := range d.BookmarkFolders {
+		bookmarks.Folders = append(bookmarks.Folders, ExportBookmarkFolder{
+			ID:          f.ID,
+			Name:        f.Name,
+			Description: f.Description,
+			CreatedAt:   f.CreatedAt,
+		})
+	}
+	for _, b := range d.Bookmarks {
+		bookmarks.Bookmarks = append(bookmarks.Bookmarks, ExportBookmark{
+			PostID:    b.PostID,
+			FolderID:  b.FolderID,
+			Name:      b.Name,
+			Notes:     b.Notes,
+			CreatedAt: b.CreatedAt,
+		})
+	}
+
+	follows := ExportFollows{
+		Followers: make([]ExportFollow, 0, len(d.Followers)),
+		Following: make([]ExportFollow, 0, len(d.Following)),
+	}
+	for _, f := range d.Followers {
+		follows.Followers = append(follows.Followers, ExportFollow{User: UserToBrief(f.Follower), CreatedAt: f.CreatedAt})
+	}
+	for _, f := range d.Following {
+		follows.Following = append(follows.Following, ExportFollow{User: UserToBrief(f.Following), CreatedAt: f.CreatedAt})
+	}
+
+	conversations := make([]ExportConversation, 0, len(d.Conversations))
+	for _, c := range d.Conversations {
+		messages := make([]ExportChatMessage, 0, len(c.Messages))
+		for _, m := range c.Messages {
+			messages = append(messages, ExportChatMessage{
+				Role:      m.Role,
+				Content:   m.Content,
+				Model:     m.Model,
+				CreatedAt: m.CreatedAt,
+			})
+		}
+		conversations = append(conversations, ExportConversation{
+			ID:        c.ID,
+			Title:     c.Title,
+			IsPinned:  c.IsPinned,
+			Messages:  messages,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		})
+	}
+
+	return []ExportFile{
+		{Name: "profile.json", Content: profile},
+		{Name: "posts.json", Content: posts},
+		{Name: "comments.json", Content: comments},
+		{Name: "likes.json", Content: likes},
+		{Name: "bookmarks.json", Content: bookmarks},
+		{Name: "follows.json", Content: follows},
+		{Name: "notifications.json", Content: nonNil(d.Notifications)},
+		{Name: "chat_conversations.json", Content: conversations},
+		{Name: "holdings.json", Content: nonNil(d.Holdings)},
+		{Name: "auth_activity.json", Content: nonNil(d.AuthActivity)},
+	}
+}
+
+// nonNil makes empty sections encode as [] rather than null.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"echobackend/internal/model"
+)
+
+type AdminAuditLogResponse struct {
+	ID         string          `json:"id"`
+	Actor      *UserBrief      `json:"actor"`
+	ActorID    *string         `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   *string         `json:"target_id"`
+	Changes    json.RawMessage `json:"changes,omitempty"`
+	IPAddress  *string         `json:"ip_address"`
+	UserAgent  *string         `json:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func AdminAuditLogToResponse(l *model.AdminAuditLog) *AdminAuditLogResponse {
+	if l == nil {
+		return nil
+	}
+	resp := &AdminAuditLogResponse{
+		ID:         l.ID,
+		Actor:      UserToBrief(l.Actor),
+		ActorID:    l.ActorID,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		IPAddress:  l.IPAddress,
+		UserAgent:  l.UserAgent,
+		CreatedAt:  l.CreatedAt,
+	}
+	if l.Changes != nil {
+		resp.Changes = json.RawMessage(*l.Changes)
+	}
+	return resp
+}
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"echobackend/internal/model"
+)
+
 type LoginRequest struct {
 	Identifier string `json:"identifier" validate:"required"`
 	Password   string `json:"password" validate:"required,min=6"`
@@ -20,6 +26,31 @@ type ResetPasswordRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type RevokeSessionsWithTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password"`
+}
+
+type VerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
@@ -42,3 +73,180 @@ type AuthTokenResponse struct {
 	RefreshToken string     `json:"refresh_token"`
 	User         *UserBrief `json:"user"`
 }
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SessionResponse struct {
+	ID         string     `json:"id"`
+	IPAddress  *string    `json:"ip_address"`
+	UserAgent  *string    `json:"user_agent"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+type IdentityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     *string   `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OAuthLinkResponse struct {
+	URL string `json:"url"`
+}
+
+type LockedAccountResponse struct {
+	UserID         string    `json:"user_id"`
+	Email          string    `json:"email"`
+	Username       *string   `json:"username"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+	// ExpiresAt ends the suspension automatically; without it the suspension
+	// lasts until an admin lifts it.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Suspension describes an account suspension. A nil SuspendedUntil means it
+// has no end date.
+type Suspension struct {
+	Reason         string     `json:"reason"`
+	SuspendedAt    time.Time  `json:"suspended_at"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+}
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+type AccessTokenResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreatedAccessTokenResponse carries the plaintext token. It is returned only
+// once, when the token is created.
+type CreatedAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// ImpersonationResponse carries an access token that acts as User on behalf
+// of the admin who requested it. There is no refresh token.
+type ImpersonationResponse struct {
+	AccessToken string     `json:"access_token"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	User        *UserBrief `json:"user"`
+}
+
+type RevokeSessionsResponse struct {
+	RevokedCount int64 `json:"revoked_count"`
+}
+
+func SessionToResponse(s *model.Session, currentSessionID string) *SessionResponse {
+	if s == nil {
+		return nil
+	}
+	return &SessionResponse{
+		ID:         s.FamilyID,
+		IPAddress:  s.IPAddress,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentSessionID != "" && s.FamilyID == currentSessionID,
+	}
+}
+
+func IdentityToResponse(i *model.UserIdentity) *IdentityResponse {
+	if i == nil {
+		return nil
+	}
+	return &IdentityResponse{
+		Provider:  i.Provider,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+func AccessTokenToResponse(t *model.PersonalAccessToken) *AccessTokenResponse {
+	if t == nil {
+		return nil
+	}
+	return &AccessTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.ScopeList(),
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		LastUsedIP:  t.LastUsedIP,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// UserToSuspension returns the user's suspension, or nil if they are not
+// suspended.
+func UserToSuspension(u *model.User) *Suspension {
+	if u == nil || u.SuspendedAt == nil {
+		return nil
+	}
+	suspension := &Suspension{SuspendedAt: *u.SuspendedAt, SuspendedUntil: u.SuspendedUntil}
+	if u.SuspensionReason != nil {
+		suspension.Reason = *u.SuspensionReason
+	}
+	return suspension
+}
+
+func LockedAccountToResponse(l *model.AccountLockout) *LockedAccountResponse {
+	if l == nil {
+		return nil
+	}
+	resp := &LockedAccountResponse{
+		UserID:         l.UserID,
+		FailedAttempts: l.FailedAttempts,
+		LastFailedAt:   l.LastFailedAt,
+	}
+	if l.LockedUntil != nil {
+		resp.LockedUntil = *l.LockedUntil
+	}
+	if l.User != nil {
+		resp.Email = l.User.Email
+		resp.Username = l.User.Username
+	}
+	return resp
+}
@@ -12,6 +12,10 @@ type CreateNotificationRequest struct {
 	Title   string         `json:"title" validate:"required,max=255"`
 	Message *string        `json:"message"`
 	Data    map[string]any `json:"data"`
+	// ActorID is the user whose action caused the notification, if any. It
+	// is dropped when the recipient blocked or muted the actor, or the actor
+	// blocked the recipient.
+	ActorID string `json:"-"`
 }
 
 type NotificationResponse struct {
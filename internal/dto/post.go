@@ -9,18 +9,23 @@ type CreatePostRequest struct {
 	Title     string   `json:"title" validate:"required,min=7"`
 	PhotoURL  string   `json:"photo_url"`
 	Slug      string   `json:"slug" validate:"required,min=7"`
-	Body      string   `json:"body" validate:"required,min=10"`
+	Body      string   `json:"body" validate:"required,min=10,max=100000"`
 	Published bool     `json:"published"`
 	Tags      []string `json:"tags"`
+	// PublishAt schedules the post to be published later; Published must
+	// then be false.
+	PublishAt *time.Time `json:"publish_at"`
 }
 
 type UpdatePostRequest struct {
 	Title     string   `json:"title"`
 	PhotoURL  string   `json:"photo_url"`
 	Slug      string   `json:"slug"`
-	Body      string   `json:"body"`
+	Body      string   `json:"body" validate:"omitempty,max=100000"`
 	Published *bool    `json:"published"`
 	Tags      []string `json:"tags"`
+	// PublishAt schedules an unpublished post, or moves its schedule.
+	PublishAt *time.Time `json:"publish_at"`
 }
 
 type PostQueryFilter struct {
@@ -78,6 +83,7 @@ type PostResponse struct {
 	BookmarkCount int64         `json:"bookmark_count"`
 	Published     *bool         `json:"published"`
 	PublishedAt   *time.Time    `json:"published_at"`
+	PublishAt     *time.Time    `json:"publish_at,omitempty"`
 	User          *UserBrief    `json:"user,omitempty"`
 	Tags          []TagResponse `json:"tags,omitempty"`
 	CreatedAt     *time.Time    `json:"created_at"`
@@ -115,6 +121,7 @@ func PostToResponse(p *model.Post) *PostResponse {
 		BookmarkCount: p.BookmarkCount,
 		Published:     p.Published,
 		PublishedAt:   p.PublishedAt,
+		PublishAt:     p.PublishAt,
 		User:          userResp,
 		Tags:          tagResponses,
 		CreatedAt:     p.CreatedAt,
@@ -123,6 +130,31 @@ func PostToResponse(p *model.Post) *PostResponse {
 	}
 }
 
+// HeadlineStartSel and HeadlineStopSel surround the matches in the Headline
+// of a PostSearchHit. They are control characters that the search strips from
+// the body, so a post cannot forge them.
+const (
+	HeadlineStartSel = "\x01"
+	HeadlineStopSel  = "\x02"
+)
+
+// PostSearchHit is a post matched by a full-text search with its rank and a
+// snippet of the body around the matches, as plain text.
+type PostSearchHit struct {
+	Post     *model.Post
+	Rank     float64
+	Headline string
+}
+
+// PostSearchResult is a PostResponse with the rank and snippet of a search.
+// Headline is HTML: the snippet is escaped and its matches are wrapped in
+// <mark> and </mark>, the only markup it contains.
+type PostSearchResult struct {
+	*PostResponse
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
 // TruncatePostBodies truncates the Body of each post to maxRunes runes.
 // Safe for multi-byte UTF-8 characters.
 func TruncatePostBodies(posts []*PostResponse, maxRunes int) {
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"echobackend/internal/model"
+	"time"
+)
+
+// PostRevisionResponse is a revision of a post. Body is left out of
+// revision lists.
+type PostRevisionResponse struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Body         *string    `json:"body,omitempty"`
+	Tags         []string   `json:"tags"`
+	Editor       *UserBrief `json:"editor"`
+	RestoredFrom *int       `json:"restored_from"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func PostRevisionToResponse(r *model.PostRevision, withBody bool) *PostRevisionResponse {
+	if r == nil {
+		return nil
+	}
+	resp := &PostRevisionResponse{
+		Number:       r.Number,
+		Title:        r.Title,
+		Tags:         r.Tags,
+		Editor:       UserToBrief(r.Editor),
+		RestoredFrom: r.RestoredFrom,
+		CreatedAt:    r.CreatedAt,
+	}
+	if withBody {
+		resp.Body = &r.Body
+	}
+	return resp
+}
+
+// PostRevisionDiff is the unified diff from revision From to revision To.
+type PostRevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
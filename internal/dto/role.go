@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"echobackend/internal/model"
+)
+
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// UserAccessResponse lists a user's roles and the permissions they grant.
+type UserAccessResponse struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+func RoleToResponse(r *model.Role) *RoleResponse {
+	if r == nil {
+		return nil
+	}
+	resp := &RoleResponse{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+	for _, p := range r.Permissions {
+		resp.Permissions = append(resp.Permissions, p.Name)
+	}
+	return resp
+}
@@ -25,38 +25,44 @@ func UserToBrief(u *model.User) *UserBrief {
 }
 
 type UserResponse struct {
-	ID             string         `json:"id"`
-	Email          string         `json:"email,omitempty"`
-	Name           string         `json:"name"`
-	Username       *string        `json:"username"`
-	Image          *string        `json:"image"`
-	FirstName      *string        `json:"first_name"`
-	LastName       *string        `json:"last_name"`
-	FollowersCount int64          `json:"followers_count"`
-	FollowingCount int64          `json:"following_count"`
-	IsFollowing    *bool          `json:"is_following,omitempty"`
-	IsSuperAdmin   *bool          `json:"is_super_admin,omitempty"`
-	Profile        *model.Profile `json:"profile,omitempty"`
-	CreatedAt      *time.Time     `json:"created_at"`
-	UpdatedAt      *time.Time     `json:"updated_at"`
-	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`
-	LastLoggedAt   *time.Time     `json:"last_logged_at,omitempty"`
+	ID              string         `json:"id"`
+	Email           string         `json:"email,omitempty"`
+	Name            string         `json:"name"`
+	Username        *string        `json:"username"`
+	Image           *string        `json:"image"`
+	FirstName       *string        `json:"first_name"`
+	LastName        *string        `json:"last_name"`
+	FollowersCount  int64          `json:"followers_count"`
+	FollowingCount  int64          `json:"following_count"`
+	IsPrivate       bool           `json:"is_private"`
+	IsFollowing     *bool          `json:"is_following,omitempty"`
+	IsSuperAdmin    *bool          `json:"is_super_admin,omitempty"`
+	Profile         *model.Profile `json:"profile,omitempty"`
+	CreatedAt       *time.Time     `json:"created_at"`
+	UpdatedAt       *time.Time     `json:"updated_at"`
+	DeletedAt       *time.Time     `json:"deleted_at,omitempty"`
+	LastLoggedAt    *time.Time     `json:"last_logged_at,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	Suspension      *Suspension    `json:"suspension,omitempty"`
 }
 
 type CurrentUserResponse struct {
-	ID             string         `json:"id"`
-	Email          string         `json:"email"`
-	Name           string         `json:"name"`
-	Username       *string        `json:"username"`
-	Image          *string        `json:"image"`
-	FirstName      *string        `json:"first_name"`
-	LastName       *string        `json:"last_name"`
-	IsSuperAdmin   *bool          `json:"is_super_admin"`
-	FollowersCount int64          `json:"followers_count"`
-	FollowingCount int64          `json:"following_count"`
-	Profile        *model.Profile `json:"profile,omitempty"`
-	CreatedAt      *time.Time     `json:"created_at"`
-	UpdatedAt      *time.Time     `json:"updated_at"`
+	ID                  string         `json:"id"`
+	Email               string         `json:"email"`
+	Name                string         `json:"name"`
+	Username            *string        `json:"username"`
+	Image               *string        `json:"image"`
+	FirstName           *string        `json:"first_name"`
+	LastName            *string        `json:"last_name"`
+	IsSuperAdmin        *bool          `json:"is_super_admin"`
+	EmailVerifiedAt     *time.Time     `json:"email_verified_at"`
+	DeletionScheduledAt *time.Time     `json:"deletion_scheduled_at"`
+	FollowersCount      int64          `json:"followers_count"`
+	FollowingCount      int64          `json:"following_count"`
+	IsPrivate           bool           `json:"is_private"`
+	Profile             *model.Profile `json:"profile,omitempty"`
+	CreatedAt           *time.Time     `json:"created_at"`
+	UpdatedAt           *time.Time     `json:"updated_at"`
 }
 
 type PublicUserResponse struct {
@@ -68,6 +74,7 @@ type PublicUserResponse struct {
 	LastName       *string        `json:"last_name"`
 	FollowersCount int64          `json:"followers_count"`
 	FollowingCount int64          `json:"following_count"`
+	IsPrivate      bool           `json:"is_private"`
 	IsFollowing    *bool          `json:"is_following,omitempty"`
 	Profile        *model.Profile `json:"profile,omitempty"`
 	CreatedAt      *time.Time     `json:"created_at"`
@@ -91,6 +98,7 @@ func UserToResponse(u *model.User) *UserResponse {
 		LastName:       u.LastName,
 		FollowersCount: u.FollowersCount,
 		FollowingCount: u.FollowingCount,
+		IsPrivate:      u.IsPrivate,
 		Profile:        u.Profile,
 		CreatedAt:      u.CreatedAt,
 		UpdatedAt:      u.UpdatedAt,
@@ -103,6 +111,8 @@ func UserToAdminResponse(u *model.User) *UserResponse {
 		resp.Email = u.Email
 		resp.IsSuperAdmin = u.IsSuperAdmin
 		resp.LastLoggedAt = u.LastLoggedAt
+		resp.EmailVerifiedAt = u.EmailVerifiedAt
+		resp.Suspension = UserToSuspension(u)
 		if u.DeletedAt.Valid {
 			t := u.DeletedAt.Time
 			resp.DeletedAt = &t
@@ -128,6 +138,7 @@ func UserToPublicResponse(u *model.User) *PublicUserResponse {
 		LastName:       u.LastName,
 		FollowersCount: u.FollowersCount,
 		FollowingCount: u.FollowingCount,
+		IsPrivate:      u.IsPrivate,
 		Profile:        u.Profile,
 		CreatedAt:      u.CreatedAt,
 		UpdatedAt:      u.UpdatedAt,
@@ -143,19 +154,22 @@ func UserToCurrentUserResponse(u *model.User) *CurrentUserResponse {
 		name = *u.FirstName + " " + *u.LastName
 	}
 	return &CurrentUserResponse{
-		ID:             u.ID,
-		Email:          u.Email,
-		Name:           name,
-		Username:       u.Username,
-		Image:          u.Image,
-		FirstName:      u.FirstName,
-		LastName:       u.LastName,
-		IsSuperAdmin:   u.IsSuperAdmin,
-		FollowersCount: u.FollowersCount,
-		FollowingCount: u.FollowingCount,
-		Profile:        u.Profile,
-		CreatedAt:      u.CreatedAt,
-		UpdatedAt:      u.UpdatedAt,
+		ID:                  u.ID,
+		Email:               u.Email,
+		Name:                name,
+		Username:            u.Username,
+		Image:               u.Image,
+		FirstName:           u.FirstName,
+		LastName:            u.LastName,
+		IsSuperAdmin:        u.IsSuperAdmin,
+		EmailVerifiedAt:     u.EmailVerifiedAt,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		FollowersCount:      u.FollowersCount,
+		FollowingCount:      u.FollowingCount,
+		IsPrivate:           u.IsPrivate,
+		Profile:             u.Profile,
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
 	}
 }
 
@@ -179,3 +193,27 @@ func ParseUserDeletedFilter(value string) (UserDeletedFilter, error) {
 		return "", errors.New("deleted must be true, false, or all")
 	}
 }
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=30"`
+}
+
+// UsernameRedirectResponse is the body of a 301 for a username that was
+// changed: Username is the current one and Location the canonical URL.
+type UsernameRedirectResponse struct {
+	Username string `json:"username"`
+	Location string `json:"location"`
+}
+
+// UpdateProfileRequest changes the current user's profile. Omitted fields
+// are left unchanged; an empty string clears the field.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name" validate:"omitempty,max=255"`
+	LastName  *string `json:"last_name" validate:"omitempty,max=255"`
+	Bio       *string `json:"bio" validate:"omitempty,max=1000"`
+	Website   *string `json:"website" validate:"omitempty,max=255,http_url"`
+	Location  *string `json:"location" validate:"omitempty,max=255"`
+	// IsPrivate makes follows of the account need approval. Turning it off
+	// approves the pending requests.
+	IsPrivate *bool `json:"is_private"`
+}
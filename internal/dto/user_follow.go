@@ -4,8 +4,11 @@ type FollowRequest struct {
 	UserID string `json:"user_id" validate:"required,uuid"`
 }
 
+// FollowResponse reports the follow after a change. IsRequested is set
+// while a follow of a private account waits for approval.
 type FollowResponse struct {
 	IsFollowing bool   `json:"is_following"`
+	IsRequested bool   `json:"is_requested"`
 	Message     string `json:"message"`
 }
 
@@ -14,3 +17,20 @@ type UserFollowStats struct {
 	FollowersCount int64  `json:"followers_count"`
 	FollowingCount int64  `json:"following_count"`
 }
+
+// FollowSuggestion is an account the user may want to follow. MutualFollows
+// counts the people the user follows who follow it; SharedTags counts the
+// tags of its posts that the user liked posts in.
+type FollowSuggestion struct {
+	User          *UserResponse `json:"user"`
+	Reason        string        `json:"reason"`
+	MutualFollows int64         `json:"mutual_follows"`
+	SharedTags    int64         `json:"shared_tags"`
+}
+
+// FollowSuggestionCandidate is a ranked suggestion as read from the database.
+type FollowSuggestionCandidate struct {
+	UserID        string
+	MutualFollows int64
+	SharedTags    int64
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/service"
+	"echobackend/pkg/response"
+
+	"github.com/labstack/echo/v5"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+// GetDataExport returns the caller's latest data export. When there is none,
+// or it failed or expired, a new one is started.
+func (h *AccountHandler) GetDataExport(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	export, err := h.accountService.GetDataExport(c.Request().Context(), userID)
+	if err != nil && !errors.Is(err, apperrors.ErrDataExportNotFound) {
+		return response.InternalServerError(c, "Failed to retrieve data export", err)
+	}
+	if export != nil && export.Status != model.DataExportFailed && export.Status != model.DataExportExpired {
+		if export.Status == model.DataExportReady {
+			return response.Success(c, "Data export is ready", export)
+		}
+		return response.Accepted(c, "Data export is being prepared", export)
+	}
+
+	return h.requestDataExport(c, userID)
+}
+
+// RequestDataExport starts a new data export even if a finished one can still
+// be downloaded.
+func (h *AccountHandler) RequestDataExport(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	return h.requestDataExport(c, userID)
+}
+
+func (h *AccountHandler) requestDataExport(c *echo.Context, userID string) error {
+	export, created, err := h.accountService.RequestDataExport(c.Request().Context(), userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrDataExportDisabled) {
+		return response.ServiceUnavailable(c, "Data export is not available")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to start data export", err)
+	}
+
+	if !created {
+		return response.Accepted(c, "Data export is already being prepared", export)
+	}
+	return response.Accepted(c, "Data export started; a download link will be emailed when it is ready", export)
+}
+
+func (h *AccountHandler) DeleteAccount(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	at, err := h.accountService.ScheduleDeletion(c.Request().Context(), userID, req.Password, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrDeletionAlreadyScheduled) {
+		return response.Conflict(c, "Account deletion is already scheduled", err.Error())
+	}
+	if errors.Is(err, apperrors.ErrInvalidCredentials) {
+		return response.Unauthorized(c, "Current password is incorrect")
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to schedule account deletion", err)
+	}
+
+	return response.Success(c, "Account scheduled for deletion", dto.AccountDeletionResponse{DeletionScheduledAt: at})
+}
+
+func (h *AccountHandler) CancelDeletion(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	err := h.accountService.CancelDeletion(c.Request().Context(), userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrDeletionNotScheduled) {
+		return response.BadRequest(c, "Account deletion is not scheduled", err)
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to cancel account deletion", err)
+	}
+
+	return response.Success(c, "Account deletion cancelled", nil)
+}
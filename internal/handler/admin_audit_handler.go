@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"echobackend/internal/dto"
+	"echobackend/internal/repository"
+	"echobackend/internal/service"
+	"echobackend/pkg/response"
+
+	"github.com/labstack/echo/v5"
+)
+
+type AdminAuditHandler struct {
+	auditService service.AdminAuditService
+}
+
+func NewAdminAuditHandler(auditService service.AdminAuditService) *AdminAuditHandler {
+	return &AdminAuditHandler{auditService: auditService}
+}
+
+func (h *AdminAuditHandler) GetAuditLogs(c *echo.Context) error {
+	filter := repository.AdminAuditLogFilter{
+		ActorID:    c.QueryParam("actor_id"),
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(c, "from"); err != nil {
+		return response.BadRequest(c, "Invalid from parameter", err)
+	}
+	if filter.To, err = parseTimeParam(c, "to"); err != nil {
+		return response.BadRequest(c, "Invalid to parameter", err)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return response.BadRequest(c, "Invalid time range", errors.New("from must be before to"))
+	}
+
+	limit, offset := ParsePaginationParams(c, 20)
+	logs, totalCount, err := h.auditService.List(c.Request().Context(), filter, limit, offset)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get audit logs", err)
+	}
+
+	resp := make([]*dto.AdminAuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		resp = append(resp, dto.AdminAuditLogToResponse(l))
+	}
+
+	meta := response.CalculatePaginationMeta(totalCount, offset, limit)
+	return response.SuccessWithMeta(c, "Audit logs retrieved successfully", resp, meta)
+}
+
+// parseTimeParam reads an optional RFC3339 query parameter.
+func parseTimeParam(c *echo.Context, name string) (time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
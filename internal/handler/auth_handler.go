@@ -1,14 +1,12 @@
 package handler
 
 import (
-	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -21,6 +19,7 @@ import (
 	"echobackend/internal/model"
 	"echobackend/internal/service"
 	"echobackend/pkg/response"
+	"echobackend/pkg/validator"
 
 	"github.com/labstack/echo/v5"
 )
@@ -49,7 +48,10 @@ func (h *AuthHandler) Register(c *echo.Context) error {
 		return response.FromValidateError(c, err)
 	}
 
-	user, err := h.authService.Register(c.Request().Context(), req.Email, req.Username, req.Password)
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	user, err := h.authService.Register(c.Request().Context(), req.Email, req.Username, req.Password, ipAddress, userAgent)
 	if errors.Is(err, apperrors.ErrUserExists) {
 		return response.Conflict(c, "Registration failed", "Email or username already exists")
 	}
@@ -57,8 +59,6 @@ func (h *AuthHandler) Register(c *echo.Context) error {
 		return response.InternalServerError(c, "Registration failed", err)
 	}
 
-	ipAddress := c.RealIP()
-	userAgent := c.Request().UserAgent()
 	h.activityService.LogActivity(c.Request().Context(), &user.ID, model.ActivityRegister, model.StatusSuccess, ipAddress, userAgent, nil, nil)
 
 	return response.Created(c, "User registered successfully", map[string]any{
@@ -85,6 +85,18 @@ func (h *AuthHandler) Login(c *echo.Context) error {
 	if errors.Is(err, apperrors.ErrInvalidCredentials) {
 		return response.Unauthorized(c, "Invalid identifier or password")
 	}
+	if errors.Is(err, apperrors.ErrAccountLocked) {
+		return accountLocked(c, err)
+	}
+	if errors.Is(err, apperrors.ErrAccountSuspended) {
+		return accountSuspended(c, err)
+	}
+	if errors.Is(err, apperrors.ErrEmailNotVerified) {
+		return response.Forbidden(c, "Email address is not verified")
+	}
+	if errors.Is(err, apperrors.ErrTwoFactorRequired) {
+		return h.twoFactorChallenge(c, user)
+	}
 	if err != nil {
 		return response.InternalServerError(c, "Login failed", err)
 	}
@@ -100,6 +112,226 @@ func (h *AuthHandler) Login(c *echo.Context) error {
 	})
 }
 
+// accountLocked answers a login refused by account lockout, telling the
+// client when to retry.
+func accountLocked(c *echo.Context, err error) error {
+	var lockErr *apperrors.AccountLockedError
+	if errors.As(err, &lockErr) {
+		seconds := max(int(math.Ceil(time.Until(lockErr.Until).Seconds())), 1)
+		c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	return response.TooManyRequests(c, "Account temporarily locked after too many failed login attempts")
+}
+
+// accountSuspended answers a sign-in refused because an admin suspended the
+// account, with the reason and when the suspension ends.
+func accountSuspended(c *echo.Context, err error) error {
+	data := map[string]any{}
+	var suspendedErr *apperrors.AccountSuspendedError
+	if errors.As(err, &suspendedErr) {
+		data["reason"] = suspendedErr.Reason
+		data["suspended_until"] = suspendedErr.Until
+	}
+	return response.ForbiddenWithCode(c, "Account is suspended", "account_suspended", data)
+}
+
+// twoFactorChallenge answers a login whose first factor succeeded for an
+// account with 2FA enabled.
+func (h *AuthHandler) twoFactorChallenge(c *echo.Context, user *model.User) error {
+	challengeToken, err := h.authService.CreateTwoFactorChallenge(c.Request().Context(), user)
+	if err != nil {
+		return response.InternalServerError(c, "Login failed", err)
+	}
+	return response.Success(c, "Two-factor authentication required", map[string]any{
+		"two_factor_required": true,
+		"challenge_token":     challengeToken,
+		"expires_in":          int(service.TwoFactorChallengeTTL.Seconds()),
+	})
+}
+
+func (h *AuthHandler) RequestMagicLink(c *echo.Context) error {
+	var req dto.MagicLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	if err := h.authService.RequestMagicLink(c.Request().Context(), req.Email, ipAddress, userAgent); err != nil {
+		return response.InternalServerError(c, "Failed to send login link", err)
+	}
+
+	return response.Success(c, "If the email exists, a login link has been sent", nil)
+}
+
+func (h *AuthHandler) ConsumeMagicLink(c *echo.Context) error {
+	var req dto.MagicLinkConsumeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	token, refreshToken, user, err := h.authService.ConsumeMagicLink(c.Request().Context(), req.Token, ipAddress, userAgent)
+	if errors.Is(err, apperrors.ErrInvalidToken) {
+		return response.Unauthorized(c, "Invalid or expired login link")
+	}
+	if errors.Is(err, apperrors.ErrAccountSuspended) {
+		return accountSuspended(c, err)
+	}
+	if errors.Is(err, apperrors.ErrTwoFactorRequired) {
+		return h.twoFactorChallenge(c, user)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Login failed", err)
+	}
+
+	return response.Success(c, "Login successful", map[string]any{
+		"access_token":  token,
+		"refresh_token": refreshToken,
+		"user": map[string]any{
+			"id":       user.ID,
+			"email":    user.Email,
+			"username": user.Username,
+		},
+	})
+}
+
+func (h *AuthHandler) VerifyTwoFactor(c *echo.Context) error {
+	var req dto.TwoFactorVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	token, refreshToken, user, err := h.authService.VerifyTwoFactorLogin(c.Request().Context(), req.ChallengeToken, req.Code, ipAddress, userAgent)
+	if errors.Is(err, apperrors.ErrInvalidToken) {
+		return response.Unauthorized(c, "Invalid or expired challenge token")
+	}
+	if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+		return response.Unauthorized(c, "Invalid two-factor code")
+	}
+	if errors.Is(err, apperrors.ErrAccountLocked) {
+		return accountLocked(c, err)
+	}
+	if errors.Is(err, apperrors.ErrAccountSuspended) {
+		return accountSuspended(c, err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Login failed", err)
+	}
+
+	return response.Success(c, "Login successful", map[string]any{
+		"access_token":  token,
+		"refresh_token": refreshToken,
+		"user": map[string]any{
+			"id":       user.ID,
+			"email":    user.Email,
+			"username": user.Username,
+		},
+	})
+}
+
+func (h *AuthHandler) EnrollTwoFactor(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	enrollment, err := h.authService.EnrollTwoFactor(c.Request().Context(), userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrTwoFactorAlreadyEnabled) {
+		return response.Conflict(c, "Two-factor authentication is already enabled", err.Error())
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to start two-factor enrollment", err)
+	}
+
+	return response.Success(c, "Scan the secret with an authenticator app, then confirm with a code", enrollment)
+}
+
+func (h *AuthHandler) ConfirmTwoFactor(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	codes, err := h.authService.ConfirmTwoFactor(c.Request().Context(), userID, req.Code, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrTwoFactorNotEnrolled) {
+		return response.BadRequest(c, "Two-factor enrollment has not been started", err)
+	}
+	if errors.Is(err, apperrors.ErrTwoFactorAlreadyEnabled) {
+		return response.Conflict(c, "Two-factor authentication is already enabled", err.Error())
+	}
+	if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+		return response.BadRequest(c, "Invalid two-factor code", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to enable two-factor authentication", err)
+	}
+
+	return response.Success(c, "Two-factor authentication enabled", dto.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AuthHandler) DisableTwoFactor(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.TwoFactorDisableRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	err := h.authService.DisableTwoFactor(c.Request().Context(), userID, req.Password, req.Code, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrTwoFactorNotEnabled) {
+		return response.BadRequest(c, "Two-factor authentication is not enabled", err)
+	}
+	if errors.Is(err, apperrors.ErrInvalidCredentials) {
+		return response.Unauthorized(c, "Current password is incorrect")
+	}
+	if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+		return response.Unauthorized(c, "Invalid two-factor code")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to disable two-factor authentication", err)
+	}
+
+	return response.Success(c, "Two-factor authentication disabled", nil)
+}
+
 func (h *AuthHandler) ForgotPassword(c *echo.Context) error {
 	var req dto.ForgotPasswordRequest
 	if err := c.Bind(&req); err != nil {
@@ -151,6 +383,120 @@ func (h *AuthHandler) ResetPassword(c *echo.Context) error {
 	return response.Success(c, "Password reset successful", nil)
 }
 
+func (h *AuthHandler) VerifyEmail(c *echo.Context) error {
+	var req dto.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	err := h.authService.VerifyEmail(c.Request().Context(), req.Token, ipAddress, userAgent)
+	if errors.Is(err, apperrors.ErrInvalidToken) || errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.BadRequest(c, "Invalid or expired verification token", err)
+	}
+	if errors.Is(err, apperrors.ErrVerificationTokenUsed) {
+		return response.BadRequest(c, "Verification token has already been used", err)
+	}
+	if errors.Is(err, apperrors.ErrVerificationTokenExpired) {
+		return response.BadRequest(c, "Verification token has expired", err)
+	}
+	if errors.Is(err, apperrors.ErrUserExists) {
+		return response.Conflict(c, "Failed to verify email", "Email address is already in use")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to verify email", err)
+	}
+
+	return response.Success(c, "Email verified successfully", nil)
+}
+
+func (h *AuthHandler) ResendVerificationEmail(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	err := h.authService.ResendVerificationEmail(c.Request().Context(), userID, ipAddress, userAgent)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if errors.Is(err, apperrors.ErrEmailAlreadyVerified) {
+		return response.BadRequest(c, "Email address is already verified", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to send verification email", err)
+	}
+
+	return response.Success(c, "Verification email sent", nil)
+}
+
+func (h *AuthHandler) RequestVerificationEmail(c *echo.Context) error {
+	var req dto.VerificationEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	if err := h.authService.RequestVerificationEmail(c.Request().Context(), req.Email, ipAddress, userAgent); err != nil {
+		return response.InternalServerError(c, "Failed to send verification email", err)
+	}
+
+	return response.Success(c, "If the email exists and is not verified, a verification link has been sent", nil)
+}
+
+func (h *AuthHandler) ChangeEmail(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	err := h.authService.RequestEmailChange(c.Request().Context(), userID, req.Email, req.Password, ipAddress, userAgent)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if errors.Is(err, apperrors.ErrInvalidCredentials) {
+		return response.Unauthorized(c, "Password is incorrect")
+	}
+	if errors.Is(err, apperrors.ErrEmailUnchanged) {
+		return response.BadRequest(c, "New email matches the current email", err)
+	}
+	if errors.Is(err, apperrors.ErrUserExists) {
+		return response.Conflict(c, "Failed to change email", "Email address is already in use")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to change email", err)
+	}
+
+	return response.Success(c, "A confirmation link has been sent to the new email address", nil)
+}
+
 func (h *AuthHandler) RefreshToken(c *echo.Context) error {
 	var req dto.RefreshTokenRequest
 	if err := c.Bind(&req); err != nil {
@@ -171,6 +517,9 @@ func (h *AuthHandler) RefreshToken(c *echo.Context) error {
 	if errors.Is(err, apperrors.ErrTokenExpired) {
 		return response.Unauthorized(c, "Refresh token has expired")
 	}
+	if errors.Is(err, apperrors.ErrAccountSuspended) {
+		return accountSuspended(c, err)
+	}
 	if err != nil {
 		return response.InternalServerError(c, "Failed to refresh token", err)
 	}
@@ -226,10 +575,11 @@ func (h *AuthHandler) Logout(c *echo.Context) error {
 	}
 
 	userID, _ := GetUserIDFromClaims(c)
+	tokenID, expiresAt, _ := GetAccessTokenIDFromClaims(c)
 	ipAddress := c.RealIP()
 	userAgent := c.Request().UserAgent()
 
-	err := h.authService.Logout(c.Request().Context(), req.RefreshToken)
+	err := h.authService.Logout(c.Request().Context(), req.RefreshToken, tokenID, expiresAt)
 	if err != nil {
 		return response.Success(c, "Logout successful", nil)
 	}
@@ -239,6 +589,151 @@ func (h *AuthHandler) Logout(c *echo.Context) error {
 	return response.Success(c, "Logout successful", nil)
 }
 
+func (h *AuthHandler) GetSessions(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	currentSessionID, _ := GetSessionIDFromClaims(c)
+
+	sessions, err := h.authService.ListSessions(c.Request().Context(), userID, currentSessionID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get sessions", err)
+	}
+
+	return response.Success(c, "Sessions retrieved successfully", sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	sessionID := c.Param("id")
+	if !validator.IsValidUUID(sessionID) {
+		return response.BadRequest(c, "Invalid session ID", nil)
+	}
+
+	err := h.authService.RevokeSession(c.Request().Context(), userID, sessionID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrSessionNotFound) {
+		return response.NotFound(c, "Session not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to revoke session", err)
+	}
+
+	return response.Success(c, "Session revoked successfully", nil)
+}
+
+func (h *AuthHandler) RevokeOtherSessions(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	currentSessionID, ok := GetSessionIDFromClaims(c)
+	if !ok {
+		return response.BadRequest(c, "Current session is unknown, please sign in again", nil)
+	}
+
+	revoked, err := h.authService.RevokeOtherSessions(c.Request().Context(), userID, currentSessionID, c.RealIP(), c.Request().UserAgent())
+	if err != nil {
+		return response.InternalServerError(c, "Failed to revoke sessions", err)
+	}
+
+	return response.Success(c, "Other sessions revoked successfully", dto.RevokeSessionsResponse{RevokedCount: revoked})
+}
+
+// RevokeSessionsWithToken handles the "this wasn't me" link of a new sign-in
+// alert email. It needs no access token.
+func (h *AuthHandler) RevokeSessionsWithToken(c *echo.Context) error {
+	var req dto.RevokeSessionsWithTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	err := h.authService.RevokeSessionsWithToken(c.Request().Context(), req.Token, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrInvalidToken) {
+		return response.BadRequest(c, "Invalid or expired link", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to revoke sessions", err)
+	}
+
+	return response.Success(c, "All sessions revoked; change your password to secure your account", nil)
+}
+
+func (h *AuthHandler) GetAccessTokens(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	tokens, err := h.authService.ListAccessTokens(c.Request().Context(), userID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get access tokens", err)
+	}
+
+	return response.Success(c, "Access tokens retrieved successfully", tokens)
+}
+
+func (h *AuthHandler) CreateAccessToken(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.CreateAccessTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	token, err := h.authService.CreateAccessToken(c.Request().Context(), userID, &req, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrInvalidAccessTokenScope) {
+		return response.BadRequest(c, "Unknown scope; valid scopes are "+strings.Join(model.PersonalAccessTokenScopes, ", "), err)
+	}
+	if errors.Is(err, apperrors.ErrAccessTokenLimitReached) {
+		return response.Conflict(c, "Failed to create access token", "Revoke an existing access token first")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to create access token", err)
+	}
+
+	return response.Created(c, "Access token created; copy it now, it will not be shown again", token)
+}
+
+func (h *AuthHandler) RevokeAccessToken(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	tokenID := c.Param("id")
+	if !validator.IsValidUUID(tokenID) {
+		return response.BadRequest(c, "Invalid access token ID", nil)
+	}
+
+	err := h.authService.RevokeAccessToken(c.Request().Context(), userID, tokenID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrAccessTokenNotFound) {
+		return response.NotFound(c, "Access token not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to revoke access token", err)
+	}
+
+	return response.Success(c, "Access token revoked successfully", nil)
+}
+
 func (h *AuthHandler) GetProfile(c *echo.Context) error {
 	userID, ok := GetUserIDFromClaims(c)
 	if !ok {
@@ -327,178 +822,335 @@ func (h *AuthHandler) GetFailedLogins(c *echo.Context) error {
 	return response.SuccessWithMeta(c, "Failed logins retrieved successfully", logs, meta)
 }
 
-func (h *AuthHandler) GithubOAuthRedirect(c *echo.Context) error {
-	state, err := generateOAuthState()
+func (h *AuthHandler) GetLockedAccounts(c *echo.Context) error {
+	limit, offset := ParsePaginationParams(c, 20)
+
+	accounts, totalCount, err := h.authService.ListLockedAccounts(c.Request().Context(), limit, offset)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to start GitHub OAuth", err)
+		return response.InternalServerError(c, "Failed to get locked accounts", err)
 	}
 
-	c.SetCookie(&http.Cookie{
-		Name:     "github_oauth_state",
-		Value:    state,
-		Path:     "/api/auth/oauth/github",
-		MaxAge:   int((10 * time.Minute).Seconds()),
-		HttpOnly: true,
-		Secure:   strings.HasPrefix(h.frontendConfig.URL, "https://"),
-		SameSite: http.SameSiteLaxMode,
-	})
-
-	authURL := h.authService.GetGithubOAuthURL(state)
-	return c.Redirect(http.StatusTemporaryRedirect, authURL)
+	meta := response.CalculatePaginationMeta(totalCount, offset, limit)
+	return response.SuccessWithMeta(c, "Locked accounts retrieved successfully", accounts, meta)
 }
 
-func (h *AuthHandler) GithubOAuthCallback(c *echo.Context) error {
-	callbackURL := h.frontendConfig.OAuthCallbackURL
-	clearGithubOAuthStateCookie(c)
-
-	code := c.QueryParam("code")
-	if code == "" {
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=missing_code")
-	}
-	state := c.QueryParam("state")
-	stateCookie, err := c.Cookie("github_oauth_state")
-	if state == "" || err != nil || subtle.ConstantTimeCompare([]byte(state), []byte(stateCookie.Value)) != 1 {
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=invalid_state")
+func (h *AuthHandler) UnlockAccount(c *echo.Context) error {
+	adminID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
 	}
 
-	ipAddress := c.RealIP()
-	userAgent := c.Request().UserAgent()
-
-	githubToken, err := h.authService.GetGithubToken(c.Request().Context(), code)
-	if err != nil {
-		h.activityService.LogActivity(c.Request().Context(), nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"provider": "github", "error": err.Error()})
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=github_token_failed")
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
 	}
 
-	githubUser, err := fetchGithubUser(c.Request().Context(), githubToken)
+	err := h.authService.UnlockAccount(c.Request().Context(), adminID, userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
 	if err != nil {
-		h.activityService.LogActivity(c.Request().Context(), nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"provider": "github", "error": err.Error()})
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=github_user_failed")
+		return response.InternalServerError(c, "Failed to unlock account", err)
 	}
 
-	if githubUser.Email == nil || *githubUser.Email == "" {
-		email, err := fetchGithubUserEmail(c.Request().Context(), githubToken)
-		if err == nil && email != "" {
-			githubUser.Email = &email
-		}
+	return response.Success(c, "Account unlocked successfully", nil)
+}
+
+// ImpersonateUser issues a short-lived access token that acts as the user for
+// support. Requests made with it are audited under the admin's ID.
+func (h *AuthHandler) ImpersonateUser(c *echo.Context) error {
+	adminID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
 	}
 
-	accessToken, refreshToken, user, err := h.authService.SignInWithGithub(c.Request().Context(), githubUser, ipAddress, userAgent)
-	if err != nil {
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=oauth_login_failed")
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
 	}
 
-	exchangeCode, err := h.authService.CreateOAuthExchangeCode(c.Request().Context(), accessToken, refreshToken, user)
+	result, err := h.authService.Impersonate(c.Request().Context(), adminID, userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrCannotImpersonateSelf) {
+		return response.BadRequest(c, "Cannot impersonate yourself", err)
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
 	if err != nil {
-		return c.Redirect(http.StatusTemporaryRedirect, callbackURL+"?error=oauth_exchange_failed")
+		return response.InternalServerError(c, "Failed to impersonate user", err)
 	}
 
-	redirectURL := appendQueryParam(callbackURL, "code", exchangeCode)
-	return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+	return response.Success(c, "Impersonation token issued", result)
 }
 
-func (h *AuthHandler) ExchangeOAuthCode(c *echo.Context) error {
-	var req dto.OAuthExchangeRequest
+// SuspendUser suspends an account, optionally until a given time. The user
+// is signed out everywhere and cannot sign in while suspended.
+func (h *AuthHandler) SuspendUser(c *echo.Context) error {
+	adminID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
+	}
+
+	var req dto.SuspendUserRequest
 	if err := c.Bind(&req); err != nil {
 		return response.BadRequest(c, "Invalid request format", err)
 	}
-
+	req.Reason = strings.TrimSpace(req.Reason)
 	if err := c.Validate(req); err != nil {
 		return response.FromValidateError(c, err)
 	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return response.BadRequest(c, "expires_at must be in the future", nil)
+	}
 
-	accessToken, refreshToken, user, err := h.authService.ExchangeOAuthCode(c.Request().Context(), req.Code)
-	if errors.Is(err, apperrors.ErrInvalidToken) {
-		return response.Unauthorized(c, "Invalid or expired OAuth code")
+	suspension, err := h.authService.SuspendUser(c.Request().Context(), adminID, userID, req.Reason, req.ExpiresAt, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrCannotSuspendSelf) {
+		return response.BadRequest(c, "Cannot suspend yourself", err)
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
 	}
 	if err != nil {
-		return response.InternalServerError(c, "Failed to exchange OAuth code", err)
+		return response.InternalServerError(c, "Failed to suspend user", err)
 	}
 
-	return response.Success(c, "OAuth code exchanged successfully", map[string]any{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-		"user": map[string]any{
-			"id":       user.ID,
-			"email":    user.Email,
-			"username": user.Username,
-		},
+	return response.Success(c, "User suspended successfully", suspension)
+}
+
+func (h *AuthHandler) UnsuspendUser(c *echo.Context) error {
+	adminID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
+	}
+
+	err := h.authService.UnsuspendUser(c.Request().Context(), adminID, userID, c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if errors.Is(err, apperrors.ErrUserNotSuspended) {
+		return response.Conflict(c, "Failed to lift suspension", err.Error())
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to lift suspension", err)
+	}
+
+	return response.Success(c, "Suspension lifted successfully", nil)
+}
+
+const (
+	oauthStateCookie = "oauth_state"
+	oauthLinkCookie  = "oauth_link"
+	oauthCookieTTL   = 10 * time.Minute
+)
+
+// JWKS publishes the public keys that verify access tokens, so other
+// services can check tokens without the shared secret. The document is
+// served as-is rather than in the response envelope, as JWKS clients expect.
+func (h *AuthHandler) JWKS(c *echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.authService.JWKS())
+}
+
+func (h *AuthHandler) GetOAuthProviders(c *echo.Context) error {
+	return response.Success(c, "OAuth providers retrieved successfully", map[string]any{
+		"providers": h.authService.OAuthProviders(),
 	})
 }
 
-func fetchGithubUser(ctx context.Context, token string) (*service.GithubUser, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/user", nil)
+// OAuthRedirect sends the browser to the provider to sign in. It drops any
+// pending link, so linking only happens in the browser that asked for it
+// through CreateOAuthLink.
+func (h *AuthHandler) OAuthRedirect(c *echo.Context) error {
+	provider := c.Param("provider")
+
+	state, err := generateOAuthState()
 	if err != nil {
-		return nil, err
+		return response.InternalServerError(c, "Failed to start OAuth", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	authURL, err := h.authService.GetOAuthURL(c.Request().Context(), provider, state)
+	if errors.Is(err, apperrors.ErrOAuthProviderNotFound) {
+		return response.NotFound(c, "OAuth provider not found", err)
+	}
 	if err != nil {
-		return nil, err
+		return response.InternalServerError(c, "Failed to start OAuth", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	h.setOAuthCookie(c, provider, oauthStateCookie, state)
+	clearOAuthCookie(c, provider, oauthLinkCookie)
+
+	return c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+func (h *AuthHandler) OAuthCallback(c *echo.Context) error {
+	provider := c.Param("provider")
+	callbackURL := h.frontendConfig.OAuthCallbackURL
+
+	stateCookie, stateErr := c.Cookie(oauthStateCookie)
+	linkCookie, _ := c.Cookie(oauthLinkCookie)
+	clearOAuthCookie(c, provider, oauthStateCookie)
+	clearOAuthCookie(c, provider, oauthLinkCookie)
+
+	redirectError := func(code string) error {
+		return c.Redirect(http.StatusTemporaryRedirect, appendQueryParam(callbackURL, "error", code))
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	code := c.QueryParam("code")
+	if code == "" {
+		return redirectError("missing_code")
+	}
+	state := c.QueryParam("state")
+	if state == "" || stateErr != nil || subtle.ConstantTimeCompare([]byte(state), []byte(stateCookie.Value)) != 1 {
+		return redirectError("invalid_state")
+	}
+
+	ctx := c.Request().Context()
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	if linkCookie != nil && linkCookie.Value != "" {
+		err := h.authService.LinkOAuthIdentity(ctx, linkCookie.Value, provider, code, state, ipAddress, userAgent)
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidToken):
+			return redirectError("link_expired")
+		case errors.Is(err, apperrors.ErrIdentityAlreadyLinked):
+			return redirectError("identity_already_linked")
+		case errors.Is(err, apperrors.ErrOAuthExchangeFailed):
+			return redirectError("provider_failed")
+		case err != nil:
+			return redirectError("link_failed")
+		}
+		return c.Redirect(http.StatusTemporaryRedirect, appendQueryParam(callbackURL, "linked", provider))
 	}
 
-	var user service.GithubUser
-	if err := json.Unmarshal(body, &user); err != nil {
-		return nil, err
+	accessToken, refreshToken, user, err := h.authService.SignInWithOAuth(ctx, provider, code, state, ipAddress, userAgent)
+	switch {
+	case errors.Is(err, apperrors.ErrOAuthProviderNotFound):
+		return redirectError("unknown_provider")
+	case errors.Is(err, apperrors.ErrOAuthExchangeFailed):
+		return redirectError("provider_failed")
+	case errors.Is(err, apperrors.ErrOAuthEmailInUse):
+		return redirectError("account_exists")
+	case errors.Is(err, apperrors.ErrAccountSuspended):
+		return redirectError("account_suspended")
+	case err != nil:
+		return redirectError("oauth_login_failed")
 	}
 
-	return &user, nil
+	exchangeCode, err := h.authService.CreateOAuthExchangeCode(ctx, accessToken, refreshToken, user)
+	if err != nil {
+		return redirectError("oauth_exchange_failed")
+	}
+
+	return c.Redirect(http.StatusTemporaryRedirect, appendQueryParam(callbackURL, "code", exchangeCode))
 }
 
-func fetchGithubUserEmail(ctx context.Context, token string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/user/emails", nil)
+// CreateOAuthLink starts linking a provider to the caller's account. The
+// link token and state are set as cookies on this response, so the callback
+// only links in the browser that made the request; the returned URL is the
+// provider's authorization page.
+func (h *AuthHandler) CreateOAuthLink(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+	provider := c.Param("provider")
+
+	state, err := generateOAuthState()
 	if err != nil {
-		return "", err
+		return response.InternalServerError(c, "Failed to create link URL", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	authURL, err := h.authService.GetOAuthURL(c.Request().Context(), provider, state)
+	if errors.Is(err, apperrors.ErrOAuthProviderNotFound) {
+		return response.NotFound(c, "OAuth provider not found", err)
+	}
 	if err != nil {
-		return "", err
+		return response.InternalServerError(c, "Failed to create link URL", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	linkToken, err := h.authService.CreateOAuthLinkToken(c.Request().Context(), userID, provider)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to create link URL", err)
+	}
+
+	h.setOAuthCookie(c, provider, oauthStateCookie, state)
+	h.setOAuthCookie(c, provider, oauthLinkCookie, linkToken)
+
+	return response.Success(c, "Link URL created successfully", dto.OAuthLinkResponse{URL: authURL})
+}
+
+func (h *AuthHandler) GetIdentities(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	identities, err := h.authService.ListIdentities(c.Request().Context(), userID)
 	if err != nil {
-		return "", err
+		return response.InternalServerError(c, "Failed to get identities", err)
 	}
 
-	var emails []struct {
-		Email   string `json:"email"`
-		Primary bool   `json:"primary"`
+	return response.Success(c, "Identities retrieved successfully", identities)
+}
+
+func (h *AuthHandler) UnlinkIdentity(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
 	}
-	if err := json.Unmarshal(body, &emails); err != nil {
-		return "", err
+
+	err := h.authService.UnlinkIdentity(c.Request().Context(), userID, c.Param("provider"), c.RealIP(), c.Request().UserAgent())
+	if errors.Is(err, apperrors.ErrIdentityNotFound) {
+		return response.NotFound(c, "Identity not found", err)
+	}
+	if errors.Is(err, apperrors.ErrLastLoginMethod) {
+		return response.Conflict(c, "Failed to unlink identity", err.Error())
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to unlink identity", err)
 	}
 
-	for _, e := range emails {
-		if e.Primary {
-			return e.Email, nil
-		}
+	return response.Success(c, "Identity unlinked successfully", nil)
+}
+
+func (h *AuthHandler) ExchangeOAuthCode(c *echo.Context) error {
+	var req dto.OAuthExchangeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
 	}
-	if len(emails) > 0 {
-		return emails[0].Email, nil
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	accessToken, refreshToken, user, err := h.authService.ExchangeOAuthCode(c.Request().Context(), req.Code)
+	if errors.Is(err, apperrors.ErrInvalidToken) {
+		return response.Unauthorized(c, "Invalid or expired OAuth code")
 	}
-	return "", nil
+	if err != nil {
+		return response.InternalServerError(c, "Failed to exchange OAuth code", err)
+	}
+
+	return response.Success(c, "OAuth code exchanged successfully", map[string]any{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"user": map[string]any{
+			"id":       user.ID,
+			"email":    user.Email,
+			"username": user.Username,
+		},
+	})
 }
 
 func generateOAuthState() (string, error) {
@@ -509,11 +1161,29 @@ func generateOAuthState() (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func clearGithubOAuthStateCookie(c *echo.Context) {
+// oauthCookiePath scopes the flow cookies to one provider's endpoints so
+// concurrent flows with different providers do not overwrite each other.
+func oauthCookiePath(provider string) string {
+	return "/api/auth/oauth/" + url.PathEscape(provider)
+}
+
+func (h *AuthHandler) setOAuthCookie(c *echo.Context, provider, name, value string) {
+	c.SetCookie(&http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oauthCookiePath(provider),
+		MaxAge:   int(oauthCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.frontendConfig.URL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearOAuthCookie(c *echo.Context, provider, name string) {
 	c.SetCookie(&http.Cookie{
-		Name:     "github_oauth_state",
+		Name:     name,
 		Value:    "",
-		Path:     "/api/auth/oauth/github",
+		Path:     oauthCookiePath(provider),
 		MaxAge:   -1,
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
@@ -12,11 +12,14 @@ import (
 
 	"echobackend/config"
 	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
 	"echobackend/internal/model"
 	"echobackend/internal/service"
+	"echobackend/pkg/jwtkeys"
 	"echobackend/pkg/response"
 	"echobackend/pkg/validator"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v5"
 )
 
@@ -26,11 +29,19 @@ var (
 )
 
 type mockAuthService struct {
-	loginFn             func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error)
-	getGithubOAuthURLFn func(state string) string
+	loginFn                    func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error)
+	getOAuthURLFn              func(ctx context.Context, provider, state string) (string, error)
+	signInWithOAuthFn          func(ctx context.Context, provider, code, state, ipAddress, userAgent string) (string, string, *model.User, error)
+	linkOAuthIdentityFn        func(ctx context.Context, linkToken, provider, code, state, ipAddress, userAgent string) error
+	createTwoFactorChallengeFn func(ctx context.Context, user *model.User) (string, error)
+	verifyTwoFactorLoginFn     func(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (string, string, *model.User, error)
+	revokeSessionFn            func(ctx context.Context, userID, sessionID, ipAddress, userAgent string) error
+	revokeOtherSessionsFn      func(ctx context.Context, userID, currentSessionID, ipAddress, userAgent string) (int64, error)
+	verifyEmailFn              func(ctx context.Context, token, ipAddress, userAgent string) error
+	consumeMagicLinkFn         func(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error)
 }
 
-func (m *mockAuthService) Register(ctx context.Context, email, username, password string) (*model.User, error) {
+func (m *mockAuthService) Register(ctx context.Context, email, username, password, ipAddress, userAgent string) (*model.User, error) {
 	return nil, nil
 }
 
@@ -57,7 +68,7 @@ func (m *mockAuthService) ChangePassword(ctx context.Context, userID, currentPas
 	return nil
 }
 
-func (m *mockAuthService) Logout(ctx context.Context, refreshToken string) error {
+func (m *mockAuthService) Logout(ctx context.Context, refreshToken, accessTokenID string, accessTokenExpiresAt time.Time) error {
 	return nil
 }
 
@@ -65,21 +76,43 @@ func (m *mockAuthService) GetProfile(ctx context.Context, userID string) (*model
 	return nil, nil
 }
 
-func (m *mockAuthService) GetGithubOAuthURL(state string) string {
-	if m.getGithubOAuthURLFn != nil {
-		return m.getGithubOAuthURLFn(state)
-	}
-	return "https://github.com/login/oauth/authorize?state=" + state
+func (m *mockAuthService) OAuthProviders() []string {
+	return []string{"github"}
 }
 
-func (m *mockAuthService) GetGithubToken(_ context.Context, code string) (string, error) {
-	return "", nil
+func (m *mockAuthService) GetOAuthURL(ctx context.Context, provider, state string) (string, error) {
+	if m.getOAuthURLFn != nil {
+		return m.getOAuthURLFn(ctx, provider, state)
+	}
+	return "https://github.com/login/oauth/authorize?state=" + state, nil
 }
 
-func (m *mockAuthService) SignInWithGithub(ctx context.Context, githubUser *service.GithubUser, ipAddress, userAgent string) (string, string, *model.User, error) {
+func (m *mockAuthService) SignInWithOAuth(ctx context.Context, provider, code, state, ipAddress, userAgent string) (string, string, *model.User, error) {
+	if m.signInWithOAuthFn != nil {
+		return m.signInWithOAuthFn(ctx, provider, code, state, ipAddress, userAgent)
+	}
 	return "", "", nil, nil
 }
 
+func (m *mockAuthService) CreateOAuthLinkToken(ctx context.Context, userID, provider string) (string, error) {
+	return "link-token-for-" + userID, nil
+}
+
+func (m *mockAuthService) LinkOAuthIdentity(ctx context.Context, linkToken, provider, code, state, ipAddress, userAgent string) error {
+	if m.linkOAuthIdentityFn != nil {
+		return m.linkOAuthIdentityFn(ctx, linkToken, provider, code, state, ipAddress, userAgent)
+	}
+	return nil
+}
+
+func (m *mockAuthService) ListIdentities(ctx context.Context, userID string) ([]*dto.IdentityResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) UnlinkIdentity(ctx context.Context, userID, provider, ipAddress, userAgent string) error {
+	return nil
+}
+
 func (m *mockAuthService) CreateOAuthExchangeCode(ctx context.Context, accessToken, refreshToken string, user *model.User) (string, error) {
 	return "", nil
 }
@@ -88,6 +121,136 @@ func (m *mockAuthService) ExchangeOAuthCode(ctx context.Context, code string) (s
 	return "", "", nil, nil
 }
 
+func (m *mockAuthService) CreateTwoFactorChallenge(ctx context.Context, user *model.User) (string, error) {
+	if m.createTwoFactorChallengeFn != nil {
+		return m.createTwoFactorChallengeFn(ctx, user)
+	}
+	return "", nil
+}
+
+func (m *mockAuthService) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (string, string, *model.User, error) {
+	if m.verifyTwoFactorLoginFn != nil {
+		return m.verifyTwoFactorLoginFn(ctx, challengeToken, code, ipAddress, userAgent)
+	}
+	return "", "", nil, nil
+}
+
+func (m *mockAuthService) EnrollTwoFactor(ctx context.Context, userID, ipAddress, userAgent string) (*dto.TwoFactorEnrollResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) ConfirmTwoFactor(ctx context.Context, userID, code, ipAddress, userAgent string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) DisableTwoFactor(ctx context.Context, userID, password, code, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]*dto.SessionResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) RevokeSession(ctx context.Context, userID, sessionID, ipAddress, userAgent string) error {
+	if m.revokeSessionFn != nil {
+		return m.revokeSessionFn(ctx, userID, sessionID, ipAddress, userAgent)
+	}
+	return nil
+}
+
+func (m *mockAuthService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID, ipAddress, userAgent string) (int64, error) {
+	if m.revokeOtherSessionsFn != nil {
+		return m.revokeOtherSessionsFn(ctx, userID, currentSessionID, ipAddress, userAgent)
+	}
+	return 0, nil
+}
+
+func (m *mockAuthService) VerifyEmail(ctx context.Context, token, ipAddress, userAgent string) error {
+	if m.verifyEmailFn != nil {
+		return m.verifyEmailFn(ctx, token, ipAddress, userAgent)
+	}
+	return nil
+}
+
+func (m *mockAuthService) ResendVerificationEmail(ctx context.Context, userID, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) RequestVerificationEmail(ctx context.Context, email, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) RequestEmailChange(ctx context.Context, userID, newEmail, password, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) RequestMagicLink(ctx context.Context, email, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) ConsumeMagicLink(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error) {
+	if m.consumeMagicLinkFn != nil {
+		return m.consumeMagicLinkFn(ctx, token, ipAddress, userAgent)
+	}
+	return "", "", nil, nil
+}
+
+func (m *mockAuthService) UnlockAccount(ctx context.Context, adminID, userID, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) ListLockedAccounts(ctx context.Context, limit, offset int) ([]*dto.LockedAccountResponse, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockAuthService) CreateAccessToken(ctx context.Context, userID string, req *dto.CreateAccessTokenRequest, ipAddress, userAgent string) (*dto.CreatedAccessTokenResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) ListAccessTokens(ctx context.Context, userID string) ([]*dto.AccessTokenResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) RevokeAccessToken(ctx context.Context, userID, tokenID, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) RevokeSessionsWithToken(ctx context.Context, token, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) Impersonate(ctx context.Context, adminID, userID, ipAddress, userAgent string) (*dto.ImpersonationResponse, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) SuspendUser(ctx context.Context, adminID, userID, reason string, until *time.Time, ipAddress, userAgent string) (*dto.Suspension, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) UnsuspendUser(ctx context.Context, adminID, userID, ipAddress, userAgent string) error {
+	return nil
+}
+
+func (m *mockAuthService) LiftExpiredSuspensions(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockAuthService) HandleLiftSuspensionsTask(ctx context.Context, payload []byte) error {
+	return nil
+}
+
+func (m *mockAuthService) AuthenticateAccessToken(ctx context.Context, token, ipAddress string) (*model.PersonalAccessToken, error) {
+	return nil, nil
+}
+
+func (m *mockAuthService) IsAccessTokenRevoked(ctx context.Context, userID, tokenID string, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func (m *mockAuthService) JWKS() jwtkeys.JWKS {
+	return jwtkeys.JWKS{Keys: []jwtkeys.JWK{{Kty: "OKP", Kid: "key-1", Use: "sig", Alg: jwtkeys.AlgEdDSA, Crv: "Ed25519", X: "abc"}}}
+}
+
 type mockAuthActivityService struct{}
 
 func (m *mockAuthActivityService) LogActivity(ctx context.Context, userID *string, activityType, status, ipAddress, userAgent string, errorMessage *string, metadata map[string]any) {
@@ -179,6 +342,189 @@ func TestAuthHandlerLoginInvalidCredentials(t *testing.T) {
 	}
 }
 
+func TestAuthHandlerLoginEmailNotVerified(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		loginFn: func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", nil, apperrors.ErrEmailNotVerified
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/login", `{"identifier":"cecep","password":"secret123"}`)
+
+	if err := h.Login(c); err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerLoginAccountLocked(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		loginFn: func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", nil, &apperrors.AccountLockedError{Until: time.Now().Add(90 * time.Second)}
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/login", `{"identifier":"cecep","password":"secret123"}`)
+
+	if err := h.Login(c); err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "90" {
+		t.Fatalf("Retry-After = %q, want 90", got)
+	}
+}
+
+func TestAuthHandlerLoginAccountSuspended(t *testing.T) {
+	until := time.Now().Add(72 * time.Hour)
+	h := NewAuthHandler(&mockAuthService{
+		loginFn: func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", nil, &apperrors.AccountSuspendedError{Until: &until, Reason: "spam"}
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/login", `{"identifier":"cecep","password":"secret123"}`)
+
+	if err := h.Login(c); err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"error":"account_suspended"`) || !strings.Contains(body, `"reason":"spam"`) {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestAuthHandlerLoginTwoFactorRequiredReturnsChallenge(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		loginFn: func(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", &model.User{ID: "user-1"}, apperrors.ErrTwoFactorRequired
+		},
+		createTwoFactorChallengeFn: func(ctx context.Context, user *model.User) (string, error) {
+			if user == nil || user.ID != "user-1" {
+				t.Fatalf("unexpected challenge user %+v", user)
+			}
+			return "challenge-token", nil
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/login", `{"identifier":"cecep","password":"secret123"}`)
+
+	if err := h.Login(c); err != nil {
+		t.Fatalf("Login returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	out := decodeAuthResponse(t, rec)
+	data, ok := out.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("unexpected data: %#v", out.Data)
+	}
+	if data["two_factor_required"] != true || data["challenge_token"] != "challenge-token" {
+		t.Fatalf("unexpected challenge response: %+v", data)
+	}
+	if _, leaked := data["access_token"]; leaked {
+		t.Fatalf("access token must not be issued before 2FA: %+v", data)
+	}
+}
+
+func TestAuthHandlerVerifyTwoFactorInvalidCode(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		verifyTwoFactorLoginFn: func(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", nil, apperrors.ErrInvalidTwoFactorCode
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/2fa/verify", `{"challenge_token":"challenge-token","code":"000000"}`)
+
+	if err := h.VerifyTwoFactor(c); err != nil {
+		t.Fatalf("VerifyTwoFactor returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerVerifyEmailExpiredToken(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		verifyEmailFn: func(ctx context.Context, token, ipAddress, userAgent string) error {
+			if token != "ev_token" {
+				t.Fatalf("unexpected token %q", token)
+			}
+			return apperrors.ErrVerificationTokenExpired
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/verify-email", `{"token":"ev_token"}`)
+
+	if err := h.VerifyEmail(c); err != nil {
+		t.Fatalf("VerifyEmail returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	out := decodeAuthResponse(t, rec)
+	if out.Message != "Verification token has expired" {
+		t.Fatalf("unexpected response: %+v", out)
+	}
+}
+
+func TestAuthHandlerConsumeMagicLinkInvalidToken(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		consumeMagicLinkFn: func(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", nil, apperrors.ErrInvalidToken
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/magic-link/consume", `{"token":"ml_used"}`)
+
+	if err := h.ConsumeMagicLink(c); err != nil {
+		t.Fatalf("ConsumeMagicLink returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerConsumeMagicLinkTwoFactorRequired(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		consumeMagicLinkFn: func(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error) {
+			return "", "", &model.User{ID: "user-1"}, apperrors.ErrTwoFactorRequired
+		},
+		createTwoFactorChallengeFn: func(ctx context.Context, user *model.User) (string, error) {
+			return "challenge-token", nil
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/magic-link/consume", `{"token":"ml_token"}`)
+
+	if err := h.ConsumeMagicLink(c); err != nil {
+		t.Fatalf("ConsumeMagicLink returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	data, ok := decodeAuthResponse(t, rec).Data.(map[string]any)
+	if !ok || data["challenge_token"] != "challenge-token" {
+		t.Fatalf("unexpected challenge response: %+v", data)
+	}
+}
+
 func TestAuthHandlerGetProfileRequiresUser(t *testing.T) {
 	h := NewAuthHandler(&mockAuthService{}, &mockAuthActivityService{}, config.FrontendConfig{})
 	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/profile", "")
@@ -192,19 +538,68 @@ func TestAuthHandlerGetProfileRequiresUser(t *testing.T) {
 	}
 }
 
-func TestAuthHandlerGithubOAuthRedirectSetsStateCookie(t *testing.T) {
+func TestAuthHandlerRevokeSessionNotFound(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		revokeSessionFn: func(ctx context.Context, userID, sessionID, ipAddress, userAgent string) error {
+			if userID != "user-1" || sessionID != "0190a6f4-7b1c-7c3e-9a2b-3c4d5e6f7a8b" {
+				t.Fatalf("unexpected revoke args %q/%q", userID, sessionID)
+			}
+			return apperrors.ErrSessionNotFound
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodDelete, "/api/auth/sessions/0190a6f4-7b1c-7c3e-9a2b-3c4d5e6f7a8b", "")
+	c.Set("user", jwt.MapClaims{"user_id": "user-1"})
+	c.SetPathValues(echo.PathValues{{Name: "id", Value: "0190a6f4-7b1c-7c3e-9a2b-3c4d5e6f7a8b"}})
+
+	if err := h.RevokeSession(c); err != nil {
+		t.Fatalf("RevokeSession returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerRevokeOtherSessionsKeepsCurrent(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		revokeOtherSessionsFn: func(ctx context.Context, userID, currentSessionID, ipAddress, userAgent string) (int64, error) {
+			if currentSessionID != "sess-1" {
+				t.Fatalf("current session = %q, want sess-1", currentSessionID)
+			}
+			return 2, nil
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodDelete, "/api/auth/sessions", "")
+	c.Set("user", jwt.MapClaims{"user_id": "user-1", "sid": "sess-1"})
+
+	if err := h.RevokeOtherSessions(c); err != nil {
+		t.Fatalf("RevokeOtherSessions returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerOAuthRedirectSetsStateCookie(t *testing.T) {
 	var stateFromService string
 	h := NewAuthHandler(&mockAuthService{
-		getGithubOAuthURLFn: func(state string) string {
+		getOAuthURLFn: func(_ context.Context, provider, state string) (string, error) {
+			if provider != "github" {
+				t.Fatalf("provider = %q, want github", provider)
+			}
 			stateFromService = state
-			return "https://github.com/login/oauth/authorize?state=" + state
+			return "https://github.com/login/oauth/authorize?state=" + state, nil
 		},
 	}, &mockAuthActivityService{}, config.FrontendConfig{URL: "https://pilput.net"})
 
 	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/oauth/github", "")
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "github"}})
 
-	if err := h.GithubOAuthRedirect(c); err != nil {
-		t.Fatalf("GithubOAuthRedirect returned error: %v", err)
+	if err := h.OAuthRedirect(c); err != nil {
+		t.Fatalf("OAuthRedirect returned error: %v", err)
 	}
 
 	if rec.Code != http.StatusTemporaryRedirect {
@@ -216,11 +611,145 @@ func TestAuthHandlerGithubOAuthRedirectSetsStateCookie(t *testing.T) {
 	if location := rec.Header().Get(echo.HeaderLocation); !strings.Contains(location, stateFromService) {
 		t.Fatalf("redirect location %q does not contain state %q", location, stateFromService)
 	}
-	if cookie := rec.Result().Cookies()[0]; cookie.Name != "github_oauth_state" || cookie.Value != stateFromService || !cookie.HttpOnly || !cookie.Secure {
+	cookie := rec.Result().Cookies()[0]
+	if cookie.Name != "oauth_state" || cookie.Value != stateFromService || cookie.Path != "/api/auth/oauth/github" || !cookie.HttpOnly || !cookie.Secure {
 		t.Fatalf("unexpected oauth cookie: %+v", cookie)
 	}
 }
 
+func TestAuthHandlerOAuthRedirectIgnoresLinkToken(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{}, &mockAuthActivityService{}, config.FrontendConfig{URL: "https://pilput.net"})
+
+	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/oauth/github?link_token=attacker", "")
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "github"}})
+
+	if err := h.OAuthRedirect(c); err != nil {
+		t.Fatalf("OAuthRedirect returned error: %v", err)
+	}
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "oauth_link" && cookie.Value != "" {
+			t.Fatalf("link token from the URL must not be stored: %+v", cookie)
+		}
+	}
+}
+
+func TestAuthHandlerCreateOAuthLinkSetsCookies(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{}, &mockAuthActivityService{}, config.FrontendConfig{URL: "https://pilput.net"})
+
+	c, rec := newAuthTestContext(t, http.MethodPost, "/api/auth/oauth/github/link", "")
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "github"}})
+	c.Set("user", jwt.MapClaims{"user_id": "user-1", "sid": "sess-1"})
+
+	if err := h.CreateOAuthLink(c); err != nil {
+		t.Fatalf("CreateOAuthLink returned error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	state, link := cookies["oauth_state"], cookies["oauth_link"]
+	if state == nil || link == nil || link.Value != "link-token-for-user-1" || link.Path != "/api/auth/oauth/github" || !link.HttpOnly {
+		t.Fatalf("unexpected oauth cookies: %+v", cookies)
+	}
+	if !strings.Contains(rec.Body.String(), "https://github.com/login/oauth/authorize?state="+state.Value) {
+		t.Fatalf("expected the provider URL with the cookie state, got %s", rec.Body.String())
+	}
+}
+
+func TestAuthHandlerOAuthRedirectUnknownProvider(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		getOAuthURLFn: func(context.Context, string, string) (string, error) {
+			return "", apperrors.ErrOAuthProviderNotFound
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{})
+
+	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/oauth/myspace", "")
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "myspace"}})
+
+	if err := h.OAuthRedirect(c); err != nil {
+		t.Fatalf("OAuthRedirect returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthHandlerOAuthCallbackRejectsExistingEmail(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{
+		signInWithOAuthFn: func(context.Context, string, string, string, string, string) (string, string, *model.User, error) {
+			return "", "", nil, apperrors.ErrOAuthEmailInUse
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{OAuthCallbackURL: "https://pilput.net/auth/callback"})
+
+	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/oauth/google/callback?code=abc&state=s1", "")
+	c.Request().AddCookie(&http.Cookie{Name: "oauth_state", Value: "s1"})
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "google"}})
+
+	if err := h.OAuthCallback(c); err != nil {
+		t.Fatalf("OAuthCallback returned error: %v", err)
+	}
+
+	if location := rec.Header().Get(echo.HeaderLocation); location != "https://pilput.net/auth/callback?error=account_exists" {
+		t.Fatalf("unexpected redirect %q", location)
+	}
+}
+
+func TestAuthHandlerOAuthCallbackLinksIdentity(t *testing.T) {
+	var gotLinkToken string
+	h := NewAuthHandler(&mockAuthService{
+		linkOAuthIdentityFn: func(_ context.Context, linkToken, provider, code, state, _, _ string) error {
+			gotLinkToken = linkToken
+			return nil
+		},
+		signInWithOAuthFn: func(context.Context, string, string, string, string, string) (string, string, *model.User, error) {
+			t.Fatal("link flow must not sign in")
+			return "", "", nil, nil
+		},
+	}, &mockAuthActivityService{}, config.FrontendConfig{OAuthCallbackURL: "https://pilput.net/auth/callback"})
+
+	c, rec := newAuthTestContext(t, http.MethodGet, "/api/auth/oauth/gitlab/callback?code=abc&state=s1", "")
+	c.Request().AddCookie(&http.Cookie{Name: "oauth_state", Value: "s1"})
+	c.Request().AddCookie(&http.Cookie{Name: "oauth_link", Value: "link-token"})
+	c.SetPathValues(echo.PathValues{{Name: "provider", Value: "gitlab"}})
+
+	if err := h.OAuthCallback(c); err != nil {
+		t.Fatalf("OAuthCallback returned error: %v", err)
+	}
+
+	if gotLinkToken != "link-token" {
+		t.Fatalf("link token = %q", gotLinkToken)
+	}
+	if location := rec.Header().Get(echo.HeaderLocation); location != "https://pilput.net/auth/callback?linked=gitlab" {
+		t.Fatalf("unexpected redirect %q", location)
+	}
+}
+
+func TestAuthHandlerJWKSServesKeySetWithoutEnvelope(t *testing.T) {
+	h := NewAuthHandler(&mockAuthService{}, &mockAuthActivityService{}, config.FrontendConfig{})
+	c, rec := newAuthTestContext(t, http.MethodGet, "/.well-known/jwks.json", "")
+
+	if err := h.JWKS(c); err != nil {
+		t.Fatalf("JWKS returned error: %v", err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var doc jwtkeys.JWKS
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode JWKS: %v", err)
+	}
+	if len(doc.Keys) != 1 || doc.Keys[0].Kid != "key-1" {
+		t.Fatalf("unexpected JWKS: %s", rec.Body.String())
+	}
+}
+
 func TestAppendQueryParamPreservesExistingQuery(t *testing.T) {
 	got := appendQueryParam("https://pilput.net/auth/callback?from=github", "code", "oc_123")
 
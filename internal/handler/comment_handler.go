@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
 	"echobackend/internal/service"
 	"echobackend/pkg/response"
@@ -40,6 +43,9 @@ func (h *CommentHandler) CreateComment(c *echo.Context) error {
 
 	comment, err := h.commentService.CreateComment(c.Request().Context(), postID, &commentDTO, userID)
 	if err != nil {
+		if errors.Is(err, apperrors.ErrUserBlocked) {
+			return response.Forbidden(c, "You cannot comment on this post")
+		}
 		return response.InternalServerError(c, "Failed to create comment", err)
 	}
 
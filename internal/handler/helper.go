@@ -2,6 +2,7 @@ package handler
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v5"
@@ -52,6 +53,47 @@ func GetUserIDFromClaims(c *echo.Context) (string, bool) {
 	return "", false
 }
 
+// GetSessionIDFromClaims returns the "sid" claim of the access token, which
+// identifies the refresh-token session it was issued for. Tokens issued before
+// sessions had IDs do not carry it.
+func GetSessionIDFromClaims(c *echo.Context) (string, bool) {
+	claims, ok := c.Get("user").(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return "", false
+	}
+	return sessionID, true
+}
+
+// GetAccessTokenIDFromClaims returns the "jti" and expiry of the access token,
+// used to denylist it on logout. Tokens issued before access tokens had IDs
+// do not carry it.
+func GetAccessTokenIDFromClaims(c *echo.Context) (string, time.Time, bool) {
+	claims, ok := c.Get("user").(jwt.MapClaims)
+	if !ok {
+		return "", time.Time{}, false
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return "", time.Time{}, false
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return "", time.Time{}, false
+	}
+	return tokenID, exp.Time, true
+}
+
+// GetImpersonatorID returns the ID of the admin acting as the authenticated
+// user, set by the auth middleware for impersonation tokens.
+func GetImpersonatorID(c *echo.Context) (string, bool) {
+	actorID, ok := c.Get("impersonator_id").(string)
+	return actorID, ok && actorID != ""
+}
+
 func ParsePaginationParams(c *echo.Context, defaultLimit int) (limit, offset int) {
 	limit = defaultLimit
 	offset = 0
@@ -73,3 +115,21 @@ func ParsePaginationParams(c *echo.Context, defaultLimit int) (limit, offset int
 
 	return limit, offset
 }
+
+// Context keys read by the audit middleware.
+const (
+	auditBeforeKey = "audit_before"
+	auditAfterKey  = "audit_after"
+)
+
+// SetAuditChanges attaches the target's state before and after a privileged
+// action to the request, for the audit middleware to diff once the handler
+// succeeds. Either side may be nil.
+func SetAuditChanges(c *echo.Context, before, after any) {
+	if before != nil {
+		c.Set(auditBeforeKey, before)
+	}
+	if after != nil {
+		c.Set(auditAfterKey, after)
+	}
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"errors"
+	"net/url"
+	"path"
 	"strconv"
 	"strings"
 
@@ -15,35 +17,80 @@ import (
 )
 
 type PostHandler struct {
-	postService     service.PostService
-	postViewService service.PostViewService
+	postService         service.PostService
+	postViewService     service.PostViewService
+	usernameService     service.UsernameService
+	postRevisionService service.PostRevisionService
 }
 
 func (h *PostHandler) respondPostError(c *echo.Context, message string, err error) error {
 	switch {
-	case errors.Is(err, apperrors.ErrPostNotFound):
+	case errors.Is(err, apperrors.ErrPostNotFound), errors.Is(err, apperrors.ErrRevisionNotFound):
 		return response.NotFound(c, message, err)
 	case errors.Is(err, apperrors.ErrNotAuthor), errors.Is(err, apperrors.ErrPostNotOwned):
 		return response.Forbidden(c, message)
-	case errors.Is(err, apperrors.ErrFileNil), errors.Is(err, apperrors.ErrFileTooLarge), errors.Is(err, apperrors.ErrInvalidFileType), errors.Is(err, apperrors.ErrStorageUnavailable):
+	case errors.Is(err, apperrors.ErrFileNil), errors.Is(err, apperrors.ErrFileTooLarge), errors.Is(err, apperrors.ErrInvalidFileType), errors.Is(err, apperrors.ErrStorageUnavailable), errors.Is(err, apperrors.ErrPublishAtNotFuture):
 		return response.BadRequest(c, message, err)
+	case errors.Is(err, apperrors.ErrPostAlreadyPublished):
+		return response.Conflict(c, message, err.Error())
+	case errors.Is(err, apperrors.ErrSchedulingDisabled):
+		return response.ServiceUnavailable(c, err.Error())
 	default:
 		return response.InternalServerError(c, message, err)
 	}
 }
 
-func NewPostHandler(postService service.PostService, postViewService service.PostViewService) *PostHandler {
+func NewPostHandler(postService service.PostService, postViewService service.PostViewService, usernameService service.UsernameService, postRevisionService service.PostRevisionService) *PostHandler {
 	return &PostHandler{
-		postService:     postService,
-		postViewService: postViewService,
+		postService:         postService,
+		postViewService:     postViewService,
+		usernameService:     usernameService,
+		postRevisionService: postRevisionService,
 	}
 }
 
 func (h *PostHandler) GetPosts(c *echo.Context) error {
+	filter := postQueryFilter(c, "search")
+
+	posts, total, err := h.postService.GetPostsFiltered(c.Request().Context(), filter)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get posts", err)
+	}
+
+	dto.TruncatePostBodies(posts, 250)
+
+	return response.SuccessWithMeta(c, "Successfully retrieved posts", posts,
+		response.CalculatePaginationMeta(total, filter.Offset, filter.Limit))
+}
+
+func (h *PostHandler) SearchPosts(c *echo.Context) error {
+	filter := postQueryFilter(c, "q")
+
+	results, total, err := h.postService.SearchPosts(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrEmptySearch) {
+			return response.BadRequest(c, "Failed to search posts", err)
+		}
+		return response.InternalServerError(c, "Failed to search posts", err)
+	}
+
+	posts := make([]*dto.PostResponse, len(results))
+	for i, result := range results {
+		posts[i] = result.PostResponse
+	}
+	dto.TruncatePostBodies(posts, 250)
+
+	return response.SuccessWithMeta(c, "Successfully searched posts", results,
+		response.CalculatePaginationMeta(total, filter.Offset, filter.Limit))
+}
+
+// postQueryFilter reads the list filters shared by GetPosts and SearchPosts;
+// searchParam names the query parameter holding the search text.
+func postQueryFilter(c *echo.Context, searchParam string) *dto.PostQueryFilter {
 	filter := &dto.PostQueryFilter{
 		Limit:     10,
 		Offset:    0,
-		Search:    c.QueryParam("search"),
+		Search:    c.QueryParam(searchParam),
 		SortBy:    c.QueryParam("sort_by"),
 		SortOrder: c.QueryParam("sort_order"),
 		StartDate: c.QueryParam("start_date"),
@@ -76,15 +123,7 @@ func (h *PostHandler) GetPosts(c *echo.Context) error {
 		}
 	}
 
-	posts, total, err := h.postService.GetPostsFiltered(c.Request().Context(), filter)
-	if err != nil {
-		return response.InternalServerError(c, "Failed to get posts", err)
-	}
-
-	dto.TruncatePostBodies(posts, 250)
-
-	return response.SuccessWithMeta(c, "Successfully retrieved posts", posts,
-		response.CalculatePaginationMeta(total, filter.Offset, filter.Limit))
+	return filter
 }
 
 func (h *PostHandler) CreatePost(c *echo.Context) error {
@@ -126,11 +165,19 @@ func (h *PostHandler) UpdatePost(c *echo.Context) error {
 		return response.FromValidateError(c, err)
 	}
 
-	updatedPost, err := h.postService.UpdatePost(c.Request().Context(), id, &updateDTO)
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	before, _ := h.postService.GetPostByID(c.Request().Context(), id)
+
+	updatedPost, err := h.postService.UpdatePost(c.Request().Context(), id, &updateDTO, userID)
 	if err != nil {
 		return h.respondPostError(c, "Failed to update post", err)
 	}
 
+	SetAuditChanges(c, before, updatedPost)
 	return response.Success(c, "Post updated successfully", updatedPost)
 }
 
@@ -159,7 +206,7 @@ func (h *PostHandler) UpdateMyPost(c *echo.Context) error {
 		return h.respondPostError(c, "Failed to check post ownership", err)
 	}
 
-	updatedPost, err := h.postService.UpdatePost(c.Request().Context(), id, &updateDTO)
+	updatedPost, err := h.postService.UpdatePost(c.Request().Context(), id, &updateDTO, userID)
 	if err != nil {
 		return h.respondPostError(c, "Failed to update post", err)
 	}
@@ -170,7 +217,13 @@ func (h *PostHandler) UpdateMyPost(c *echo.Context) error {
 func (h *PostHandler) GetPostBySlugAndUsername(c *echo.Context) error {
 	slug := c.Param("slug")
 	username := c.Param("username")
-	post, err := h.postService.GetPostBySlugAndUsername(c.Request().Context(), slug, username)
+	viewerID, _ := GetUserIDFromClaims(c)
+	post, err := h.postService.GetPostBySlugAndUsername(c.Request().Context(), slug, username, viewerID)
+	if errors.Is(err, apperrors.ErrPostNotFound) {
+		return respondMovedUsername(c, h.usernameService, username, "Failed to get post", err, func(canonical string) string {
+			return path.Join(path.Dir(path.Dir(c.Request().URL.Path)), url.PathEscape(canonical), url.PathEscape(slug))
+		})
+	}
 	if err != nil {
 		return h.respondPostError(c, "Failed to get post", err)
 	}
@@ -221,11 +274,14 @@ func (h *PostHandler) DeletePost(c *echo.Context) error {
 		return response.BadRequest(c, "Invalid post ID", nil)
 	}
 
+	before, _ := h.postService.GetPostByID(c.Request().Context(), id)
+
 	err := h.postService.DeletePostByID(c.Request().Context(), id)
 	if err != nil {
 		return h.respondPostError(c, "Failed to delete post", err)
 	}
 
+	SetAuditChanges(c, before, nil)
 	return response.Success(c, "Successfully deleted post", nil)
 }
 
@@ -299,6 +355,39 @@ func (h *PostHandler) GetMyPosts(c *echo.Context) error {
 		response.CalculatePaginationMeta(total, offset, limit))
 }
 
+func (h *PostHandler) GetMyScheduledPosts(c *echo.Context) error {
+	limit, offset := ParsePaginationParams(c, 10)
+
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	posts, total, err := h.postService.GetScheduledPosts(c.Request().Context(), userID, offset, limit)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get scheduled posts", err)
+	}
+
+	dto.TruncatePostBodies(posts, 250)
+
+	return response.SuccessWithMeta(c, "Successfully retrieved scheduled posts", posts,
+		response.CalculatePaginationMeta(total, offset, limit))
+}
+
+func (h *PostHandler) CancelMyPostSchedule(c *echo.Context) error {
+	postID, userID, ok, err := h.authorizeMyPost(c)
+	if !ok {
+		return err
+	}
+
+	post, err := h.postService.CancelSchedule(c.Request().Context(), postID, userID)
+	if err != nil {
+		return h.respondPostError(c, "Failed to cancel post schedule", err)
+	}
+
+	return response.Success(c, "Post schedule cancelled", post)
+}
+
 func (h *PostHandler) GetMyPostsAnalytics(c *echo.Context) error {
 	userID, ok := GetUserIDFromClaims(c)
 	if !ok {
@@ -363,8 +452,9 @@ func (h *PostHandler) GetPostsForYou(c *echo.Context) error {
 func (h *PostHandler) GetPostsByUsername(c *echo.Context) error {
 	username := c.Param("username")
 	limit, offset := ParsePaginationParams(c, 10)
+	viewerID, _ := GetUserIDFromClaims(c)
 
-	posts, total, err := h.postService.GetPostsByUsername(c.Request().Context(), username, offset, limit)
+	posts, total, err := h.postService.GetPostsByUsername(c.Request().Context(), username, viewerID, offset, limit)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to get posts", err)
 	}
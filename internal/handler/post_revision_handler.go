@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"strconv"
+
+	"echobackend/pkg/response"
+	"echobackend/pkg/validator"
+
+	"github.com/labstack/echo/v5"
+)
+
+// authorizeMyPost checks that the caller wrote the post in the :id param and
+// returns the post and caller IDs. Otherwise it writes the error response,
+// whose result is err, and ok is false.
+func (h *PostHandler) authorizeMyPost(c *echo.Context) (postID, userID string, ok bool, err error) {
+	postID = c.Param("id")
+	if !validator.IsValidUUID(postID) {
+		return "", "", false, response.BadRequest(c, "Invalid post ID", nil)
+	}
+
+	userID, ok = GetUserIDFromClaims(c)
+	if !ok {
+		return "", "", false, response.Unauthorized(c, "User not authenticated")
+	}
+
+	if err := h.postService.IsAuthor(c.Request().Context(), postID, userID); err != nil {
+		return "", "", false, h.respondPostError(c, "Failed to check post ownership", err)
+	}
+	return postID, userID, true, nil
+}
+
+// revisionNumber parses a positive revision number.
+func revisionNumber(value string) (int, bool) {
+	number, err := strconv.Atoi(value)
+	return number, err == nil && number > 0
+}
+
+func (h *PostHandler) GetMyPostRevisions(c *echo.Context) error {
+	postID, _, ok, err := h.authorizeMyPost(c)
+	if !ok {
+		return err
+	}
+
+	limit, offset := ParsePaginationParams(c, 20)
+	revisions, total, err := h.postRevisionService.ListRevisions(c.Request().Context(), postID, limit, offset)
+	if err != nil {
+		return h.respondPostError(c, "Failed to get post revisions", err)
+	}
+
+	return response.SuccessWithMeta(c, "Successfully retrieved post revisions", revisions,
+		response.CalculatePaginationMeta(total, offset, limit))
+}
+
+func (h *PostHandler) GetMyPostRevision(c *echo.Context) error {
+	postID, _, ok, err := h.authorizeMyPost(c)
+	if !ok {
+		return err
+	}
+
+	number, valid := revisionNumber(c.Param("number"))
+	if !valid {
+		return response.BadRequest(c, "Invalid revision number", nil)
+	}
+
+	revision, err := h.postRevisionService.GetRevision(c.Request().Context(), postID, number)
+	if err != nil {
+		return h.respondPostError(c, "Failed to get post revision", err)
+	}
+
+	return response.Success(c, "Successfully retrieved post revision", revision)
+}
+
+func (h *PostHandler) DiffMyPostRevisions(c *echo.Context) error {
+	postID, _, ok, err := h.authorizeMyPost(c)
+	if !ok {
+		return err
+	}
+
+	from, validFrom := revisionNumber(c.QueryParam("from"))
+	to, validTo := revisionNumber(c.QueryParam("to"))
+	if !validFrom || !validTo {
+		return response.BadRequest(c, "from and to must be revision numbers", nil)
+	}
+
+	diff, err := h.postRevisionService.DiffRevisions(c.Request().Context(), postID, from, to)
+	if err != nil {
+		return h.respondPostError(c, "Failed to diff post revisions", err)
+	}
+
+	return response.Success(c, "Successfully diffed post revisions", diff)
+}
+
+func (h *PostHandler) RestoreMyPostRevision(c *echo.Context) error {
+	postID, userID, ok, err := h.authorizeMyPost(c)
+	if !ok {
+		return err
+	}
+
+	number, valid := revisionNumber(c.Param("number"))
+	if !valid {
+		return response.BadRequest(c, "Invalid revision number", nil)
+	}
+
+	post, err := h.postRevisionService.RestoreRevision(c.Request().Context(), postID, number, userID)
+	if err != nil {
+		return h.respondPostError(c, "Failed to restore post revision", err)
+	}
+
+	return response.Success(c, "Post revision restored successfully", post)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/service"
+	"echobackend/pkg/response"
+	"echobackend/pkg/validator"
+
+	"github.com/labstack/echo/v5"
+)
+
+type RoleHandler struct {
+	roleService service.RoleService
+}
+
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+func (h *RoleHandler) GetRoles(c *echo.Context) error {
+	roles, err := h.roleService.ListRoles(c.Request().Context())
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get roles", err)
+	}
+
+	return response.Success(c, "Roles retrieved successfully", roles)
+}
+
+func (h *RoleHandler) GetMyAccess(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	access, err := h.roleService.GetUserAccess(c.Request().Context(), userID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get permissions", err)
+	}
+
+	return response.Success(c, "Permissions retrieved successfully", access)
+}
+
+func (h *RoleHandler) GetUserRoles(c *echo.Context) error {
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
+	}
+
+	access, err := h.roleService.GetUserAccess(c.Request().Context(), userID)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get user roles", err)
+	}
+
+	return response.Success(c, "User roles retrieved successfully", access)
+}
+
+func (h *RoleHandler) AssignRole(c *echo.Context) error {
+	actorID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
+	}
+
+	before, _ := h.roleService.GetUserAccess(c.Request().Context(), userID)
+
+	err := h.roleService.AssignRole(c.Request().Context(), actorID, userID, c.Param("role"))
+	if errors.Is(err, apperrors.ErrRoleNotFound) {
+		return response.NotFound(c, "Role not found", err)
+	}
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, "User not found", err)
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to assign role", err)
+	}
+
+	after, _ := h.roleService.GetUserAccess(c.Request().Context(), userID)
+	SetAuditChanges(c, before, after)
+	return response.Success(c, "Role assigned successfully", nil)
+}
+
+func (h *RoleHandler) RemoveRole(c *echo.Context) error {
+	actorID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	userID := c.Param("id")
+	if !validator.IsValidUUID(userID) {
+		return response.BadRequest(c, "Invalid user ID", nil)
+	}
+
+	before, _ := h.roleService.GetUserAccess(c.Request().Context(), userID)
+
+	err := h.roleService.RemoveRole(c.Request().Context(), actorID, userID, c.Param("role"))
+	if errors.Is(err, apperrors.ErrRoleNotFound) {
+		return response.NotFound(c, "Role not found", err)
+	}
+	if errors.Is(err, apperrors.ErrLastAdmin) {
+		return response.Conflict(c, "Failed to remove role", "Cannot remove the last admin")
+	}
+	if err != nil {
+		return response.InternalServerError(c, "Failed to remove role", err)
+	}
+
+	after, _ := h.roleService.GetUserAccess(c.Request().Context(), userID)
+	SetAuditChanges(c, before, after)
+	return response.Success(c, "Role removed successfully", nil)
+}
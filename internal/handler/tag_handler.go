@@ -103,6 +103,8 @@ func (h *TagHandler) UpdateTag(c *echo.Context) error {
 		return response.FromValidateError(c, err)
 	}
 
+	before, _ := h.service.GetTagByID(c.Request().Context(), uint(id))
+
 	tag, err := h.service.UpdateTag(c.Request().Context(), uint(id), &req)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrTagNameRequired) {
@@ -114,6 +116,7 @@ func (h *TagHandler) UpdateTag(c *echo.Context) error {
 		return response.InternalServerError(c, "Failed to update tag", err)
 	}
 
+	SetAuditChanges(c, dto.TagToResponse(before), dto.TagToResponse(tag))
 	return response.Success(c, "Tag updated successfully", dto.TagToResponse(tag))
 }
 
@@ -123,6 +126,8 @@ func (h *TagHandler) DeleteTag(c *echo.Context) error {
 		return response.BadRequest(c, "Invalid tag ID", err)
 	}
 
+	before, _ := h.service.GetTagByID(c.Request().Context(), uint(id))
+
 	if err := h.service.DeleteTag(c.Request().Context(), uint(id)); err != nil {
 		if errors.Is(err, apperrors.ErrTagNotFound) {
 			return response.NotFound(c, "Tag not found", err)
@@ -130,5 +135,6 @@ func (h *TagHandler) DeleteTag(c *echo.Context) error {
 		return response.InternalServerError(c, "Failed to delete tag", err)
 	}
 
+	SetAuditChanges(c, dto.TagToResponse(before), nil)
 	return response.Success(c, "Tag deleted successfully", nil)
 }
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/service"
+	"echobackend/pkg/response"
+
+	"github.com/labstack/echo/v5"
+)
+
+type UserBlockHandler struct {
+	userBlockService service.UserBlockService
+}
+
+func NewUserBlockHandler(userBlockService service.UserBlockService) *UserBlockHandler {
+	return &UserBlockHandler{userBlockService: userBlockService}
+}
+
+func (h *UserBlockHandler) BlockUser(c *echo.Context) error {
+	return h.change(c, h.userBlockService.BlockUser, "User blocked", "Failed to block user")
+}
+
+func (h *UserBlockHandler) UnblockUser(c *echo.Context) error {
+	return h.change(c, h.userBlockService.UnblockUser, "User unblocked", "Failed to unblock user")
+}
+
+func (h *UserBlockHandler) MuteUser(c *echo.Context) error {
+	return h.change(c, h.userBlockService.MuteUser, "User muted", "Failed to mute user")
+}
+
+func (h *UserBlockHandler) UnmuteUser(c *echo.Context) error {
+	return h.change(c, h.userBlockService.UnmuteUser, "User unmuted", "Failed to unmute user")
+}
+
+func (h *UserBlockHandler) GetBlockedUsers(c *echo.Context) error {
+	return h.list(c, h.userBlockService.GetBlockedUsers, "Successfully retrieved blocked users", "Failed to get blocked users")
+}
+
+func (h *UserBlockHandler) GetMutedUsers(c *echo.Context) error {
+	return h.list(c, h.userBlockService.GetMutedUsers, "Successfully retrieved muted users", "Failed to get muted users")
+}
+
+func (h *UserBlockHandler) change(c *echo.Context, apply func(ctx context.Context, userID, targetID string) error, successMessage, failureMessage string) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	targetID := c.Param("id")
+	if targetID == "" {
+		return response.BadRequest(c, "User ID is required", nil)
+	}
+
+	err := apply(c.Request().Context(), userID, targetID)
+	switch {
+	case errors.Is(err, apperrors.ErrCannotBlockSelf):
+		return response.BadRequest(c, failureMessage, err)
+	case errors.Is(err, apperrors.ErrUserNotFound):
+		return response.NotFound(c, "User not found", err)
+	case err != nil:
+		return response.InternalServerError(c, failureMessage, err)
+	}
+
+	return response.Success(c, successMessage, nil)
+}
+
+func (h *UserBlockHandler) list(c *echo.Context, fetch func(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error), successMessage, failureMessage string) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	limit, offset := ParsePaginationParams(c, 10)
+
+	users, total, err := fetch(c.Request().Context(), userID, limit, offset)
+	if err != nil {
+		return response.InternalServerError(c, failureMessage, err)
+	}
+
+	return response.SuccessWithMeta(c, successMessage, users, response.CalculatePaginationMeta(total, offset, limit))
+}
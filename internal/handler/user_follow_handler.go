@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
+	"echobackend/internal/model"
 	"echobackend/internal/service"
 	"echobackend/pkg/response"
 
@@ -33,6 +37,12 @@ func (h *UserFollowHandler) FollowUser(c *echo.Context) error {
 
 	followResponse, err := h.userFollowService.FollowUser(c.Request().Context(), userID, followReq.UserID)
 	if err != nil {
+		if errors.Is(err, apperrors.ErrUserBlocked) {
+			return response.Forbidden(c, "You cannot follow this user")
+		}
+		if errors.Is(err, apperrors.ErrFollowRequested) {
+			return response.Conflict(c, "Follow request already sent", err.Error())
+		}
 		return response.InternalServerError(c, "Failed to follow user", err)
 	}
 
@@ -65,10 +75,11 @@ func (h *UserFollowHandler) GetFollowers(c *echo.Context) error {
 	}
 
 	limit, offset := ParsePaginationParams(c, 10)
+	viewerID, _ := GetUserIDFromClaims(c)
 
-	followers, total, err := h.userFollowService.GetFollowers(c.Request().Context(), userID, limit, offset)
+	followers, total, err := h.userFollowService.GetFollowers(c.Request().Context(), userID, viewerID, limit, offset)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get followers", err)
+		return respondFollowListError(c, "Failed to get followers", err)
 	}
 
 	meta := response.CalculatePaginationMeta(total, offset, limit)
@@ -83,10 +94,11 @@ func (h *UserFollowHandler) GetFollowing(c *echo.Context) error {
 	}
 
 	limit, offset := ParsePaginationParams(c, 10)
+	viewerID, _ := GetUserIDFromClaims(c)
 
-	following, total, err := h.userFollowService.GetFollowing(c.Request().Context(), userID, limit, offset)
+	following, total, err := h.userFollowService.GetFollowing(c.Request().Context(), userID, viewerID, limit, offset)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get following", err)
+		return respondFollowListError(c, "Failed to get following", err)
 	}
 
 	meta := response.CalculatePaginationMeta(total, offset, limit)
@@ -119,13 +131,14 @@ func (h *UserFollowHandler) CheckFollowStatus(c *echo.Context) error {
 		return response.BadRequest(c, "User ID is required", nil)
 	}
 
-	isFollowing, err := h.userFollowService.IsFollowing(c.Request().Context(), userID, targetUserID)
+	status, err := h.userFollowService.GetFollowStatus(c.Request().Context(), userID, targetUserID)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to check follow status", err)
 	}
 
 	return response.Success(c, "Successfully checked follow status", map[string]bool{
-		"is_following": isFollowing,
+		"is_following": status == model.FollowStatusApproved,
+		"is_requested": status == model.FollowStatusPending,
 	})
 }
 
@@ -142,8 +155,97 @@ func (h *UserFollowHandler) GetMutualFollows(c *echo.Context) error {
 
 	mutualFollows, err := h.userFollowService.GetMutualFollows(c.Request().Context(), userID, otherUserID)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get mutual follows", err)
+		return respondFollowListError(c, "Failed to get mutual follows", err)
 	}
 
 	return response.Success(c, "Successfully retrieved mutual follows", mutualFollows)
 }
+
+func (h *UserFollowHandler) GetSuggestions(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	limit, _ := ParsePaginationParams(c, 10)
+
+	suggestions, err := h.userFollowService.GetSuggestions(c.Request().Context(), userID, limit)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get follow suggestions", err)
+	}
+
+	return response.Success(c, "Successfully retrieved follow suggestions", suggestions)
+}
+
+func (h *UserFollowHandler) GetFollowRequests(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	limit, offset := ParsePaginationParams(c, 10)
+
+	requests, total, err := h.userFollowService.GetFollowRequests(c.Request().Context(), userID, limit, offset)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to get follow requests", err)
+	}
+
+	meta := response.CalculatePaginationMeta(total, offset, limit)
+
+	return response.SuccessWithMeta(c, "Successfully retrieved follow requests", requests, meta)
+}
+
+func (h *UserFollowHandler) ApproveFollowRequest(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	followerID := c.Param("id")
+	if followerID == "" {
+		return response.BadRequest(c, "User ID is required", nil)
+	}
+
+	if err := h.userFollowService.ApproveFollowRequest(c.Request().Context(), userID, followerID); err != nil {
+		if errors.Is(err, apperrors.ErrNoFollowRequest) {
+			return response.NotFound(c, "Follow request not found", err)
+		}
+		return response.InternalServerError(c, "Failed to approve follow request", err)
+	}
+
+	return response.Success(c, "Follow request approved", nil)
+}
+
+func (h *UserFollowHandler) RejectFollowRequest(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "Authentication required")
+	}
+
+	followerID := c.Param("id")
+	if followerID == "" {
+		return response.BadRequest(c, "User ID is required", nil)
+	}
+
+	if err := h.userFollowService.RejectFollowRequest(c.Request().Context(), userID, followerID); err != nil {
+		if errors.Is(err, apperrors.ErrNoFollowRequest) {
+			return response.NotFound(c, "Follow request not found", err)
+		}
+		return response.InternalServerError(c, "Failed to reject follow request", err)
+	}
+
+	return response.Success(c, "Follow request rejected", nil)
+}
+
+// respondFollowListError maps the errors of follower, following and mutual
+// follow lists.
+func respondFollowListError(c *echo.Context, message string, err error) error {
+	switch {
+	case errors.Is(err, apperrors.ErrPrivateAccount):
+		return response.Forbidden(c, "This account is private")
+	case errors.Is(err, apperrors.ErrUserNotFound):
+		return response.NotFound(c, "User not found", err)
+	default:
+		return response.InternalServerError(c, message, err)
+	}
+}
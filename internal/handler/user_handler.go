@@ -2,6 +2,13 @@ package handler
 
 import (
 	"errors"
+	"math"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
@@ -14,12 +21,16 @@ import (
 type UserHandler struct {
 	userService       service.UserService
 	userFollowService service.UserFollowService
+	usernameService   service.UsernameService
+	profileService    service.ProfileService
 }
 
-func NewUserHandler(userService service.UserService, userFollowService service.UserFollowService) *UserHandler {
+func NewUserHandler(userService service.UserService, userFollowService service.UserFollowService, usernameService service.UsernameService, profileService service.ProfileService) *UserHandler {
 	return &UserHandler{
 		userService:       userService,
 		userFollowService: userFollowService,
+		usernameService:   usernameService,
+		profileService:    profileService,
 	}
 }
 
@@ -56,6 +67,11 @@ func (h *UserHandler) GetByUsername(c *echo.Context) error {
 	}
 
 	user, err := h.userService.GetByUsername(c.Request().Context(), username)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return respondMovedUsername(c, h.usernameService, username, "User not found", err, func(canonical string) string {
+			return path.Join(path.Dir(c.Request().URL.Path), url.PathEscape(canonical))
+		})
+	}
 	if err != nil {
 		return response.InternalServerError(c, "Failed to retrieve user", err)
 	}
@@ -87,16 +103,20 @@ func (h *UserHandler) GetUsers(c *echo.Context) error {
 
 func (h *UserHandler) DeleteUser(c *echo.Context) error {
 	id := c.Param("id")
+	before, _ := h.userService.GetAdminByID(c.Request().Context(), id, false)
+
 	err := h.userService.Delete(c.Request().Context(), id)
 	if err != nil {
 		return response.InternalServerError(c, "Failed to delete user", err)
 	}
 
+	SetAuditChanges(c, before, nil)
 	return response.Success(c, "Successfully deleted user", nil)
 }
 
 func (h *UserHandler) RestoreUser(c *echo.Context) error {
 	id := c.Param("id")
+	before, _ := h.userService.GetAdminByID(c.Request().Context(), id, true)
 
 	userResponse, err := h.userService.Restore(c.Request().Context(), id)
 	if err != nil {
@@ -109,9 +129,33 @@ func (h *UserHandler) RestoreUser(c *echo.Context) error {
 		return response.InternalServerError(c, "Failed to restore user", err)
 	}
 
+	SetAuditChanges(c, before, userResponse)
 	return response.Success(c, "Successfully restored user", userResponse)
 }
 
+func (h *UserHandler) SearchUsers(c *echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if n := utf8.RuneCountInString(query); n < 2 || n > 100 {
+		return response.BadRequest(c, "Search query must be between 2 and 100 characters", nil)
+	}
+
+	limit, offset := ParsePaginationParams(c, 10)
+
+	users, total, err := h.userService.SearchUsers(c.Request().Context(), query, offset, limit)
+	if err != nil {
+		return response.InternalServerError(c, "Failed to search users", err)
+	}
+
+	if currentUserID, ok := GetUserIDFromClaims(c); ok {
+		if err := h.userFollowService.SetFollowStatus(c.Request().Context(), currentUserID, users); err != nil {
+			return response.InternalServerError(c, "Failed to search users", err)
+		}
+	}
+
+	return response.SuccessWithMeta(c, "Successfully searched users", users,
+		response.CalculatePaginationMeta(total, offset, limit))
+}
+
 func (h *UserHandler) GetMe(c *echo.Context) error {
 	userID, ok := GetUserIDFromClaims(c)
 	if !ok {
@@ -125,3 +169,112 @@ func (h *UserHandler) GetMe(c *echo.Context) error {
 
 	return response.Success(c, "Successfully retrieved current user", userResponse)
 }
+
+func (h *UserHandler) ChangeUsername(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.ChangeUsernameRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	user, err := h.usernameService.ChangeUsername(c.Request().Context(), userID, req.Username, c.RealIP(), c.Request().UserAgent())
+	var cooldown *apperrors.UsernameCooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		seconds := max(int(math.Ceil(time.Until(cooldown.Until).Seconds())), 1)
+		c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+		return response.TooManyRequests(c, "Username was changed too recently")
+	case errors.Is(err, apperrors.ErrUsernameUnchanged):
+		return response.BadRequest(c, "New username must be different from the current one", err)
+	case errors.Is(err, apperrors.ErrUsernameUnavailable):
+		return response.Conflict(c, "Username is not available", err.Error())
+	case errors.Is(err, apperrors.ErrUserNotFound):
+		return response.NotFound(c, "User not found", err)
+	case err != nil:
+		return response.InternalServerError(c, "Failed to change username", err)
+	}
+
+	return response.Success(c, "Username changed successfully", user)
+}
+
+func (h *UserHandler) UpdateProfile(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest(c, "Invalid request format", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return response.FromValidateError(c, err)
+	}
+
+	user, err := h.profileService.UpdateProfile(c.Request().Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return response.NotFound(c, "User not found", err)
+		}
+		return response.InternalServerError(c, "Failed to update profile", err)
+	}
+
+	return response.Success(c, "Profile updated successfully", user)
+}
+
+func (h *UserHandler) UploadAvatar(c *echo.Context) error {
+	userID, ok := GetUserIDFromClaims(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return response.BadRequest(c, "Failed to upload avatar", err)
+	}
+
+	user, err := h.profileService.UploadAvatar(c.Request().Context(), userID, file)
+	switch {
+	case errors.Is(err, apperrors.ErrFileNil), errors.Is(err, apperrors.ErrFileTooLarge), errors.Is(err, apperrors.ErrInvalidFileType):
+		return response.BadRequest(c, "Failed to upload avatar", err)
+	case errors.Is(err, apperrors.ErrStorageUnavailable):
+		return response.ServiceUnavailable(c, "Avatar upload is not available")
+	case errors.Is(err, apperrors.ErrUserNotFound):
+		return response.NotFound(c, "User not found", err)
+	case err != nil:
+		return response.InternalServerError(c, "Failed to upload avatar", err)
+	}
+
+	return response.Success(c, "Avatar uploaded successfully", user)
+}
+
+// respondMovedUsername answers a lookup by a username nobody has: a 301 to
+// location(canonical) when the account that had it was renamed, 404
+// otherwise.
+func respondMovedUsername(c *echo.Context, usernames service.UsernameService, username, notFoundMessage string, notFoundErr error, location func(canonical string) string) error {
+	canonical, err := usernames.ResolveMoved(c.Request().Context(), username)
+	if errors.Is(err, apperrors.ErrUserNotFound) {
+		return response.NotFound(c, notFoundMessage, notFoundErr)
+	}
+	if err != nil {
+		return response.InternalServerError(c, notFoundMessage, err)
+	}
+
+	target := location(canonical)
+	if c.Request().URL.RawQuery != "" {
+		target += "?" + c.Request().URL.RawQuery
+	}
+	return response.MovedPermanently(c, "Username has changed", target, dto.UsernameRedirectResponse{
+		Username: canonical,
+		Location: target,
+	})
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"echobackend/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// AuditMiddleware records privileged actions in the admin audit log.
+type AuditMiddleware struct {
+	audit service.AdminAuditService
+}
+
+// NewAuditMiddleware creates a new instance of AuditMiddleware
+func NewAuditMiddleware(audit service.AdminAuditService) *AuditMiddleware {
+	return &AuditMiddleware{audit: audit}
+}
+
+// Record logs action against the target named by the targetParam path
+// parameter once the handler has succeeded. Handlers may attach the target's
+// state with handler.SetAuditChanges so the entry carries a before/after diff.
+// An empty targetParam records the action without a target ID. It must run
+// after Auth.
+func (m *AuditMiddleware) Record(action, targetType, targetParam string) echo.MiddlewareFunc {
+	return m.record(action, targetType, func(c *echo.Context) string {
+		if targetParam == "" {
+			return ""
+		}
+		return c.Param(targetParam)
+	})
+}
+
+// RecordTarget is Record for routes whose target is fixed, such as a report.
+func (m *AuditMiddleware) RecordTarget(action, targetType, targetID string) echo.MiddlewareFunc {
+	return m.record(action, targetType, func(*echo.Context) string { return targetID })
+}
+
+func (m *AuditMiddleware) record(action, targetType string, targetID func(*echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			if err := next(c); err != nil {
+				return err
+			}
+
+			resp, err := echo.UnwrapResponse(c.Response())
+			if err != nil || resp.Status >= 400 {
+				return nil
+			}
+
+			entry := service.AdminAuditEntry{
+				Action:     action,
+				TargetType: targetType,
+				TargetID:   targetID(c),
+				Before:     c.Get("audit_before"),
+				After:      c.Get("audit_after"),
+				IPAddress:  c.RealIP(),
+				UserAgent:  c.Request().UserAgent(),
+			}
+			if claims, ok := c.Get("user").(jwt.MapClaims); ok {
+				entry.ActorID, _ = getUserIDFromClaims(claims)
+			}
+
+			m.audit.Record(c.Request().Context(), entry)
+			return nil
+		}
+	}
+}
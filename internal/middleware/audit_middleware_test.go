@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+	"echobackend/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v5"
+)
+
+type mockAdminAuditService struct {
+	entries []service.AdminAuditEntry
+}
+
+func (m *mockAdminAuditService) Record(ctx context.Context, entry service.AdminAuditEntry) {
+	m.entries = append(m.entries, entry)
+}
+
+func (m *mockAdminAuditService) List(ctx context.Context, filter repository.AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error) {
+	return nil, 0, nil
+}
+
+func runAudited(t *testing.T, mw *AuditMiddleware, status int) {
+	t.Helper()
+	e := echo.New()
+	handler := mw.Record(model.AuditPostDelete, "post", "id")(func(c *echo.Context) error {
+		c.Set("audit_before", map[string]any{"title": "Hello"})
+		return c.NoContent(status)
+	})
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodDelete, "/api/posts/post-1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", jwt.MapClaims{"user_id": "admin-1"})
+	c.SetPathValues(echo.PathValues{{Name: "id", Value: "post-1"}})
+
+	if err := handler(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+}
+
+func TestAuditRecord_LogsSuccessfulAction(t *testing.T) {
+	audit := &mockAdminAuditService{}
+	runAudited(t, NewAuditMiddleware(audit), http.StatusOK)
+
+	if len(audit.entries) != 1 {
+		t.Fatalf("recorded %d entries, want 1", len(audit.entries))
+	}
+	entry := audit.entries[0]
+	if entry.ActorID != "admin-1" || entry.Action != model.AuditPostDelete || entry.TargetType != "post" || entry.TargetID != "post-1" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if entry.Before == nil || entry.After != nil {
+		t.Fatalf("before/after = %v/%v, want before only", entry.Before, entry.After)
+	}
+	if entry.UserAgent != "test-agent" {
+		t.Fatalf("user agent = %q", entry.UserAgent)
+	}
+}
+
+func TestAuditRecord_SkipsFailedAction(t *testing.T) {
+	audit := &mockAdminAuditService{}
+	runAudited(t, NewAuditMiddleware(audit), http.StatusNotFound)
+
+	if len(audit.entries) != 0 {
+		t.Fatalf("recorded %d entries for a failed action, want 0", len(audit.entries))
+	}
+}
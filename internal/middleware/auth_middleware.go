@@ -1,13 +1,16 @@
 package middleware
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"echobackend/config"
+	"echobackend/internal/model"
 	"echobackend/internal/service"
+	"echobackend/pkg/jwtkeys"
 	"echobackend/pkg/response"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,20 +19,36 @@ import (
 
 // AuthMiddleware provides authentication middleware for Echo
 type AuthMiddleware struct {
-	conf        *config.Config
-	userService service.UserService
+	conf         *config.Config
+	userService  service.UserService
+	accessTokens service.AccessTokenAuthenticator
+	permissions  service.PermissionChecker
+	tokenKeys    *jwtkeys.KeySet
+	audit        service.AdminAuditService
+	revocations  service.AccessTokenRevocationChecker
 }
 
 // NewAuthMiddleware creates a new instance of AuthMiddleware
-func NewAuthMiddleware(conf *config.Config, userService service.UserService) *AuthMiddleware {
+func NewAuthMiddleware(conf *config.Config, userService service.UserService, accessTokens service.AccessTokenAuthenticator, permissions service.PermissionChecker, tokenKeys *jwtkeys.KeySet, audit service.AdminAuditService, revocations service.AccessTokenRevocationChecker) *AuthMiddleware {
 	return &AuthMiddleware{
-		conf:        conf,
-		userService: userService,
+		conf:         conf,
+		userService:  userService,
+		accessTokens: accessTokens,
+		permissions:  permissions,
+		tokenKeys:    tokenKeys,
+		audit:        audit,
+		revocations:  revocations,
 	}
 }
 
-// Auth validates JWT tokens and sets user claims in the context
-func (a *AuthMiddleware) Auth() echo.MiddlewareFunc {
+// Auth validates JWT tokens or personal access tokens and sets user claims in
+// the context. Access token claims carry "pat_id" and "scopes" instead of "sid".
+// Impersonation tokens carry an "act" claim; the impersonated user is the
+// "user" and the admin's ID is set as "impersonator_id".
+//
+// Personal access tokens are refused unless the route passes scopes, and then
+// must hold all of them. Login sessions are not scoped.
+func (a *AuthMiddleware) Auth(scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c *echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -44,21 +63,26 @@ func (a *AuthMiddleware) Auth() echo.MiddlewareFunc {
 				return response.Unauthorized(c, "Invalid authorization header")
 			}
 
-			claims, err := validateToken(tokenString, a.conf.Auth.JWTSecret)
+			claims, err := a.authenticate(c, tokenString)
 			if err != nil {
 				// Log the real parse/validation error server-side only; never expose it to clients.
 				log.Warn("auth: invalid token", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "error", err)
 				return response.Unauthorized(c, "Invalid or expired token")
 			}
 
-			c.Set("user", claims)
-			return next(c)
+			if refusal := accessTokenRefusal(claims, scopes); refusal != "" {
+				return response.Forbidden(c, refusal)
+			}
+
+			return a.serve(c, claims, next)
 		}
 	}
 }
 
-// OptionalAuth validates JWT tokens if present but does not require them
-func (a *AuthMiddleware) OptionalAuth() echo.MiddlewareFunc {
+// OptionalAuth validates JWT tokens if present but does not require them.
+// Personal access tokens are checked against scopes as in Auth; a token that
+// does not pass is ignored like an invalid one.
+func (a *AuthMiddleware) OptionalAuth(scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c *echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -71,45 +95,45 @@ func (a *AuthMiddleware) OptionalAuth() echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			claims, err := validateToken(tokenString, a.conf.Auth.JWTSecret)
-			if err != nil {
+			claims, err := a.authenticate(c, tokenString)
+			if err != nil || accessTokenRefusal(claims, scopes) != "" {
 				return next(c)
 			}
 
-			c.Set("user", claims)
-			return next(c)
+			return a.serve(c, claims, next)
 		}
 	}
 }
 
-// AuthAdmin validates that the user has admin privileges
-func (a *AuthMiddleware) AuthAdmin() echo.MiddlewareFunc {
+// RequirePermission allows users whose roles grant permission. Personal
+// access tokens are refused: privileged routes need a login session. It must
+// run after Auth.
+func (a *AuthMiddleware) RequirePermission(permission string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c *echo.Context) error {
-			userClaims := c.Get("user")
-			if userClaims == nil {
+			claims, ok := c.Get("user").(jwt.MapClaims)
+			if !ok {
 				return response.Unauthorized(c, "Authentication required")
 			}
 
-			claims, ok := userClaims.(jwt.MapClaims)
-			if !ok {
-				return response.Unauthorized(c, "Authentication required")
+			if isAccessTokenClaims(claims) || isImpersonationClaims(claims) {
+				return response.Forbidden(c, "Admin routes require a login session")
 			}
 
 			userID, err := getUserIDFromClaims(claims)
 			if err != nil {
-				log.Warn("auth: admin check failed to resolve user id", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "error", err)
+				log.Warn("auth: permission check failed to resolve user id", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "error", err)
 				return response.Unauthorized(c, "Authentication required")
 			}
 
-			isSuperAdmin, err := a.isSuperAdminFromDB(c.Request().Context(), userID)
+			allowed, err := a.permissions.HasPermission(c.Request().Context(), userID, permission)
 			if err != nil {
-				log.Warn("auth: failed to validate admin privileges", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "user_id", userID, "error", err)
+				log.Warn("auth: failed to validate permissions", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "user_id", userID, "permission", permission, "error", err)
 				return response.Unauthorized(c, "Failed to validate privileges")
 			}
 
-			if !isSuperAdmin {
-				log.Warn("auth: insufficient privileges", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "user_id", userID)
+			if !allowed {
+				log.Warn("auth: insufficient privileges", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "user_id", userID, "permission", permission)
 				return response.Forbidden(c, "Insufficient privileges")
 			}
 
@@ -118,37 +142,225 @@ func (a *AuthMiddleware) AuthAdmin() echo.MiddlewareFunc {
 	}
 }
 
-// extractBearerToken extracts the token from the Authorization header
-func extractBearerToken(authHeader string) (string, error) {
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", errors.New("invalid token format, expected 'Bearer <token>'")
+// RequireVerifiedEmail blocks users whose email address is not verified when
+// EMAIL_VERIFICATION_MODE is "writes". It must run after Auth.
+func (a *AuthMiddleware) RequireVerifiedEmail() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if a.conf.Auth.EmailVerification != config.EmailVerificationWrites {
+			return next
+		}
+		return func(c *echo.Context) error {
+			claims, ok := c.Get("user").(jwt.MapClaims)
+			if !ok {
+				return response.Unauthorized(c, "Authentication required")
+			}
+
+			userID, err := getUserIDFromClaims(claims)
+			if err != nil {
+				return response.Unauthorized(c, "Authentication required")
+			}
+
+			user, err := a.userService.GetMe(c.Request().Context(), userID)
+			if err != nil {
+				log.Warn("auth: failed to check email verification", "path", c.Request().URL.Path, "remote_ip", c.RealIP(), "user_id", userID, "error", err)
+				return response.Unauthorized(c, "Failed to validate account")
+			}
+
+			if user.EmailVerifiedAt == nil {
+				return response.Forbidden(c, "Email address is not verified")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireSession rejects personal access tokens and impersonation tokens.
+// Account security endpoints such as password, 2FA, sessions and token
+// management use it so a leaked token cannot be used to take over the account
+// and an impersonating admin cannot change its credentials. It must run after
+// Auth.
+func (a *AuthMiddleware) RequireSession() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			claims, ok := c.Get("user").(jwt.MapClaims)
+			if !ok {
+				return response.Unauthorized(c, "Authentication required")
+			}
+
+			if isAccessTokenClaims(claims) {
+				return response.Forbidden(c, "This endpoint requires a login session")
+			}
+			if isImpersonationClaims(claims) {
+				return response.Forbidden(c, "This endpoint is not available while impersonating")
+			}
+
+			return next(c)
+		}
 	}
-	return parts[1], nil
 }
 
-// validateToken validates the JWT token and returns the claims
-func validateToken(tokenString, jwtSecret string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// authenticate resolves a bearer token: personal access tokens by their
+// prefix, everything else as a JWT. JWTs revoked before they expired are
+// rejected.
+func (a *AuthMiddleware) authenticate(c *echo.Context, tokenString string) (jwt.MapClaims, error) {
+	if !strings.HasPrefix(tokenString, service.PersonalAccessTokenPrefix) {
+		claims, err := validateToken(tokenString, a.tokenKeys)
+		if err != nil {
+			return nil, err
 		}
-		return []byte(jwtSecret), nil
-	})
+		if err := a.checkRevoked(c, claims); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
 
+	if a.accessTokens == nil {
+		return nil, errors.New("personal access tokens are not supported")
+	}
+	token, err := a.accessTokens.AuthenticateAccessToken(c.Request().Context(), tokenString, c.RealIP())
 	if err != nil {
-		return nil, fmt.Errorf("token parsing failed: %w", err)
+		return nil, fmt.Errorf("access token rejected: %w", err)
+	}
+
+	claims := jwt.MapClaims{
+		"user_id": token.UserID,
+		"pat_id":  token.ID,
+		"scopes":  token.ScopeList(),
+	}
+	if token.User != nil {
+		claims["username"] = token.User.Username
+		claims["email"] = token.User.Email
+	}
+	return claims, nil
+}
+
+// checkRevoked fails for access tokens denylisted on logout or issued before
+// the user's last password change or reset.
+func (a *AuthMiddleware) checkRevoked(c *echo.Context, claims jwt.MapClaims) error {
+	if a.revocations == nil {
+		return nil
+	}
+
+	userID, err := getUserIDFromClaims(claims)
+	if err != nil {
+		return err
+	}
+	tokenID, _ := claims["jti"].(string)
+	var issuedAt time.Time
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	revoked, err := a.revocations.IsAccessTokenRevoked(c.Request().Context(), userID, tokenID, issuedAt)
+	if err != nil {
+		return fmt.Errorf("revocation check failed: %w", err)
+	}
+	if revoked {
+		return errors.New("token has been revoked")
 	}
+	return nil
+}
+
+// serve stores claims for handlers and runs next. Requests made with an
+// impersonation token are recorded in the admin audit log under the admin's
+// ID, whatever their outcome.
+func (a *AuthMiddleware) serve(c *echo.Context, claims jwt.MapClaims, next echo.HandlerFunc) error {
+	c.Set("user", claims)
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	actorID, ok := impersonatorID(claims)
 	if !ok {
-		return nil, errors.New("invalid token claims")
+		return next(c)
 	}
+	c.Set("impersonator_id", actorID)
+
+	err := next(c)
 
+	userID, _ := getUserIDFromClaims(claims)
+	status := 0
+	if resp, unwrapErr := echo.UnwrapResponse(c.Response()); unwrapErr == nil {
+		status = resp.Status
+	}
+	log.Info("auth: impersonated request", "impersonator_id", actorID, "user_id", userID, "method", c.Request().Method, "path", c.Request().URL.Path, "status", status)
+	if a.audit != nil {
+		a.audit.Record(c.Request().Context(), service.AdminAuditEntry{
+			ActorID:    actorID,
+			Action:     model.AuditImpersonatedRequest,
+			TargetType: "user",
+			TargetID:   userID,
+			After: map[string]any{
+				"method": c.Request().Method,
+				"path":   c.Request().URL.Path,
+				"status": status,
+			},
+			IPAddress: c.RealIP(),
+			UserAgent: c.Request().UserAgent(),
+		})
+	}
+	return err
+}
+
+func isImpersonationClaims(claims jwt.MapClaims) bool {
+	_, ok := impersonatorID(claims)
+	return ok
+}
+
+// impersonatorID returns the admin named by the "act" claim.
+func impersonatorID(claims jwt.MapClaims) (string, bool) {
+	act, ok := claims[service.ImpersonationActorClaim].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	sub, ok := act["sub"].(string)
+	return sub, ok && sub != ""
+}
+
+func isAccessTokenClaims(claims jwt.MapClaims) bool {
+	_, ok := claims["pat_id"]
+	return ok
+}
+
+func accessTokenScopes(claims jwt.MapClaims) []string {
+	scopes, _ := claims["scopes"].([]string)
+	return scopes
+}
+
+// accessTokenRefusal returns why claims of a personal access token may not
+// be used on a route that declares scopes, or "" if they may. Tokens are
+// denied by default: a route that declares no scope refuses them all.
+func accessTokenRefusal(claims jwt.MapClaims, scopes []string) string {
+	if !isAccessTokenClaims(claims) {
+		return ""
+	}
+	if len(scopes) == 0 {
+		return "This endpoint does not accept personal access tokens"
+	}
+	granted := accessTokenScopes(claims)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return fmt.Sprintf("Access token is missing the %q scope", scope)
+		}
+	}
+	return ""
+}
+
+// extractBearerToken extracts the token from the Authorization header
+func extractBearerToken(authHeader string) (string, error) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", errors.New("invalid token format, expected 'Bearer <token>'")
+	}
+	return parts[1], nil
+}
+
+// validateToken validates the JWT token and returns the claims. RS256 and
+// EdDSA tokens are verified with the key named by their "kid"; HS256 tokens
+// with the shared secret while JWT_ACCEPT_HS256 is on.
+func validateToken(tokenString string, tokenKeys *jwtkeys.KeySet) (jwt.MapClaims, error) {
+	claims, err := tokenKeys.Parse(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("token parsing failed: %w", err)
+	}
 	return claims, nil
 }
 
@@ -168,16 +380,3 @@ func getUserIDFromClaims(claims jwt.MapClaims) (string, error) {
 		return "", errors.New("unauthorized: invalid user ID format in token")
 	}
 }
-
-func (a *AuthMiddleware) isSuperAdminFromDB(ctx context.Context, userID string) (bool, error) {
-	user, err := a.userService.GetAdminByID(ctx, userID, false)
-	if err != nil {
-		return false, err
-	}
-
-	if user.IsSuperAdmin == nil {
-		return false, nil
-	}
-
-	return *user.IsSuperAdmin, nil
-}
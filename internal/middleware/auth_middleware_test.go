@@ -2,15 +2,21 @@ package middleware
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"echobackend/config"
+	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/pkg/jwtkeys"
 	"echobackend/pkg/response"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,6 +25,7 @@ import (
 
 type mockUserService struct {
 	getAdminByIDFn func(ctx context.Context, id string, deletedOnly bool) (*dto.UserResponse, error)
+	getMeFn        func(ctx context.Context, id string) (*dto.CurrentUserResponse, error)
 }
 
 func (m *mockUserService) GetByID(ctx context.Context, id string) (*dto.UserResponse, error) {
@@ -33,6 +40,9 @@ func (m *mockUserService) GetAdminByID(ctx context.Context, id string, deletedOn
 }
 
 func (m *mockUserService) GetMe(ctx context.Context, id string) (*dto.CurrentUserResponse, error) {
+	if m.getMeFn != nil {
+		return m.getMeFn(ctx, id)
+	}
 	return nil, nil
 }
 
@@ -44,16 +54,39 @@ func (m *mockUserService) GetUsers(ctx context.Context, offset int, limit int, d
 	return nil, 0, nil
 }
 
+func (m *mockUserService) SearchUsers(ctx context.Context, query string, offset, limit int) ([]*dto.UserResponse, int64, error) {
+	return nil, 0, nil
+}
+
 func (m *mockUserService) Delete(ctx context.Context, id string) error { return nil }
 
 func (m *mockUserService) Restore(ctx context.Context, id string) (*dto.UserResponse, error) {
 	return nil, nil
 }
 
+type mockAccessTokenAuthenticator struct {
+	tokens map[string]*model.PersonalAccessToken
+}
+
+func (m *mockAccessTokenAuthenticator) AuthenticateAccessToken(ctx context.Context, token, ipAddress string) (*model.PersonalAccessToken, error) {
+	if pat, ok := m.tokens[token]; ok {
+		return pat, nil
+	}
+	return nil, apperrors.ErrInvalidToken
+}
+
+type mockPermissionChecker struct {
+	permissions map[string][]string
+}
+
+func (m *mockPermissionChecker) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	return slices.Contains(m.permissions[userID], permission), nil
+}
+
 func newAuthMiddlewareForTest(secret string, users *mockUserService) *AuthMiddleware {
 	return NewAuthMiddleware(&config.Config{
 		Auth: config.AuthConfig{JWTSecret: secret},
-	}, users)
+	}, users, nil, nil, jwtkeys.NewHMAC([]byte(secret)), nil, nil)
 }
 
 func signTestToken(t *testing.T, secret string, claims jwt.MapClaims) string {
@@ -203,18 +236,62 @@ func TestAuth_ValidToken(t *testing.T) {
 	}
 }
 
-func TestAuthAdmin_ForbiddenUsesStandardResponse(t *testing.T) {
+func TestAuth_SelectsKeyByKidAndAcceptsHS256DuringMigration(t *testing.T) {
 	const secret = "test-secret"
-	falseVal := false
-	users := &mockUserService{
-		getAdminByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*dto.UserResponse, error) {
-			return &dto.UserResponse{ID: id, IsSuperAdmin: &falseVal}, nil
-		},
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	retired, err := jwtkeys.NewKey(priv)
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
 	}
-	mw := newAuthMiddlewareForTest(secret, users)
+	_, priv, _ = ed25519.GenerateKey(rand.Reader)
+	active, _ := jwtkeys.NewKey(priv)
+
+	oldKeys, _ := jwtkeys.New(retired, nil, []byte(secret), true)
+	keys, err := jwtkeys.New(active, []*jwtkeys.Key{retired}, []byte(secret), true)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	mw := NewAuthMiddleware(&config.Config{Auth: config.AuthConfig{JWTSecret: secret}}, &mockUserService{}, nil, nil, keys, nil, nil)
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+	activeToken, _ := keys.Sign(claims())
+	retiredToken, _ := oldKeys.Sign(claims())
+
+	e := echo.New()
+	for name, token := range map[string]string{
+		"active key":  activeToken,
+		"retired key": retiredToken,
+		"HS256":       signTestToken(t, secret, claims()),
+	} {
+		handler := mw.Auth()(func(c *echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		})
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: handler error: %v", name, err)
+		}
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: status = %d body=%s", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestRequirePermission_ForbiddenUsesStandardResponse(t *testing.T) {
+	permissions := &mockPermissionChecker{permissions: map[string][]string{
+		"user-1": {model.PermissionPostsUpdate},
+	}}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, nil, permissions, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
 
 	e := echo.New()
-	handler := mw.AuthAdmin()(func(c *echo.Context) error {
+	handler := mw.RequirePermission(model.PermissionPostsDelete)(func(c *echo.Context) error {
 		t.Fatal("next should not run")
 		return nil
 	})
@@ -240,18 +317,15 @@ func TestAuthAdmin_ForbiddenUsesStandardResponse(t *testing.T) {
 	}
 }
 
-func TestAuthAdmin_AllowsSuperAdmin(t *testing.T) {
-	trueVal := true
-	users := &mockUserService{
-		getAdminByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*dto.UserResponse, error) {
-			return &dto.UserResponse{ID: id, IsSuperAdmin: &trueVal}, nil
-		},
-	}
-	mw := newAuthMiddlewareForTest("test-secret", users)
+func TestRequirePermission_AllowsGrantedPermission(t *testing.T) {
+	permissions := &mockPermissionChecker{permissions: map[string][]string{
+		"moderator-1": {model.PermissionPostsDelete},
+	}}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, nil, permissions, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
 
 	e := echo.New()
 	called := false
-	handler := mw.AuthAdmin()(func(c *echo.Context) error {
+	handler := mw.RequirePermission(model.PermissionPostsDelete)(func(c *echo.Context) error {
 		called = true
 		return c.NoContent(http.StatusNoContent)
 	})
@@ -259,7 +333,7 @@ func TestAuthAdmin_AllowsSuperAdmin(t *testing.T) {
 	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/admin", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	c.Set("user", jwt.MapClaims{"user_id": "admin-1"})
+	c.Set("user", jwt.MapClaims{"user_id": "moderator-1"})
 
 	if err := handler(c); err != nil {
 		t.Fatalf("handler error: %v", err)
@@ -271,3 +345,276 @@ func TestAuthAdmin_AllowsSuperAdmin(t *testing.T) {
 		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
 	}
 }
+
+func TestRequirePermission_RejectsPersonalAccessTokens(t *testing.T) {
+	permissions := &mockPermissionChecker{permissions: map[string][]string{
+		"user-1": {model.PermissionPostsDelete},
+	}}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, nil, permissions, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
+
+	e := echo.New()
+	handler := mw.RequirePermission(model.PermissionPostsDelete)(func(c *echo.Context) error {
+		t.Fatal("next should not run")
+		return nil
+	})
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodDelete, "/posts/1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", jwt.MapClaims{"user_id": "user-1", "pat_id": "pat-1", "scopes": []string{model.ScopePostsWrite}})
+
+	if err := handler(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireVerifiedEmail_BlocksUnverifiedInWritesMode(t *testing.T) {
+	users := &mockUserService{
+		getMeFn: func(ctx context.Context, id string) (*dto.CurrentUserResponse, error) {
+			return &dto.CurrentUserResponse{ID: id}, nil
+		},
+	}
+	mw := NewAuthMiddleware(&config.Config{
+		Auth: config.AuthConfig{JWTSecret: "test-secret", EmailVerification: config.EmailVerificationWrites},
+	}, users, nil, nil, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
+
+	e := echo.New()
+	handler := mw.RequireVerifiedEmail()(func(c *echo.Context) error {
+		t.Fatal("next should not run")
+		return nil
+	})
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/posts", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", jwt.MapClaims{"user_id": "user-1"})
+
+	if err := handler(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusForbidden, rec.Body.String())
+	}
+}
+
+func TestRequireVerifiedEmail_PassesThroughWhenNotEnforced(t *testing.T) {
+	users := &mockUserService{
+		getMeFn: func(ctx context.Context, id string) (*dto.CurrentUserResponse, error) {
+			t.Fatal("user lookup should be skipped")
+			return nil, nil
+		},
+	}
+	mw := NewAuthMiddleware(&config.Config{
+		Auth: config.AuthConfig{JWTSecret: "test-secret", EmailVerification: config.EmailVerificationLogin},
+	}, users, nil, nil, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
+
+	e := echo.New()
+	called := false
+	handler := mw.RequireVerifiedEmail()(func(c *echo.Context) error {
+		called = true
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/posts", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", jwt.MapClaims{"user_id": "user-1"})
+
+	if err := handler(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if !called {
+		t.Fatal("next was not called")
+	}
+}
+
+func TestAuth_AcceptsPersonalAccessTokenAndEnforcesScope(t *testing.T) {
+	accessTokens := &mockAccessTokenAuthenticator{tokens: map[string]*model.PersonalAccessToken{
+		"pat_valid": {ID: "pat-1", UserID: "user-1", Scopes: "posts:write holdings:read", User: &model.User{ID: "user-1"}},
+	}}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, accessTokens, nil, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
+
+	e := echo.New()
+	serve := func(token string, middlewares ...echo.MiddlewareFunc) *httptest.ResponseRecorder {
+		var handler echo.HandlerFunc = func(c *echo.Context) error {
+			if userID, _ := c.Get("user").(jwt.MapClaims)["user_id"].(string); userID != "user-1" {
+				t.Fatalf("user_id claim = %q", userID)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](handler)
+		}
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("handler error: %v", err)
+		}
+		return rec
+	}
+
+	if rec := serve("pat_valid", mw.Auth(model.ScopePostsWrite)); rec.Code != http.StatusNoContent {
+		t.Fatalf("granted scope: status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := serve("pat_valid", mw.Auth(model.ScopeChat)); rec.Code != http.StatusForbidden {
+		t.Fatalf("missing scope: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := serve("pat_valid", mw.Auth(model.ScopePostsWrite, model.ScopeChat)); rec.Code != http.StatusForbidden {
+		t.Fatalf("one of two scopes: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	// Routes that declare no scope refuse every token.
+	if rec := serve("pat_valid", mw.Auth()); rec.Code != http.StatusForbidden {
+		t.Fatalf("unscoped route: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := serve("pat_valid", mw.Auth(), mw.RequireSession()); rec.Code != http.StatusForbidden {
+		t.Fatalf("session-only route: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := serve("pat_unknown", mw.Auth(model.ScopePostsWrite)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// Login sessions are not scoped.
+	sessionToken := signTestToken(t, "test-secret", jwt.MapClaims{"user_id": "user-1", "sid": "sess-1"})
+	if rec := serve(sessionToken, mw.Auth(model.ScopeChat), mw.RequireSession()); rec.Code != http.StatusNoContent {
+		t.Fatalf("session token: status = %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOptionalAuth_IgnoresAccessTokenWithoutScope(t *testing.T) {
+	accessTokens := &mockAccessTokenAuthenticator{tokens: map[string]*model.PersonalAccessToken{
+		"pat_valid": {ID: "pat-1", UserID: "user-1", Scopes: "posts:read", User: &model.User{ID: "user-1"}},
+	}}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, accessTokens, nil, jwtkeys.NewHMAC([]byte("test-secret")), nil, nil)
+
+	e := echo.New()
+	for _, tc := range []struct {
+		name   string
+		scopes []string
+		want   bool
+	}{
+		{"granted scope", []string{model.ScopePostsRead}, true},
+		{"missing scope", []string{model.ScopeChat}, false},
+		{"no scope", nil, false},
+	} {
+		authenticated := false
+		handler := mw.OptionalAuth(tc.scopes...)(func(c *echo.Context) error {
+			_, authenticated = c.Get("user").(jwt.MapClaims)
+			return c.NoContent(http.StatusNoContent)
+		})
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/public", nil)
+		req.Header.Set("Authorization", "Bearer pat_valid")
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: handler error: %v", tc.name, err)
+		}
+		if rec.Code != http.StatusNoContent || authenticated != tc.want {
+			t.Fatalf("%s: status = %d authenticated = %v, want 204 and %v", tc.name, rec.Code, authenticated, tc.want)
+		}
+	}
+}
+
+func TestAuth_ImpersonationTokenExposesBothIdentitiesAndIsAudited(t *testing.T) {
+	permissions := &mockPermissionChecker{permissions: map[string][]string{
+		"user-1": {model.PermissionPostsDelete},
+	}}
+	audit := &mockAdminAuditService{}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, nil, permissions, jwtkeys.NewHMAC([]byte("test-secret")), audit, nil)
+	token := signTestToken(t, "test-secret", jwt.MapClaims{"user_id": "user-1", "act": map[string]any{"sub": "admin-1"}})
+
+	e := echo.New()
+	serve := func(middlewares ...echo.MiddlewareFunc) *httptest.ResponseRecorder {
+		var handler echo.HandlerFunc = func(c *echo.Context) error {
+			if userID, _ := c.Get("user").(jwt.MapClaims)["user_id"].(string); userID != "user-1" {
+				t.Fatalf("user_id claim = %q", userID)
+			}
+			if actorID, _ := c.Get("impersonator_id").(string); actorID != "admin-1" {
+				t.Fatalf("impersonator_id = %q", actorID)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](handler)
+		}
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("handler error: %v", err)
+		}
+		return rec
+	}
+
+	if rec := serve(mw.Auth()); rec.Code != http.StatusNoContent {
+		t.Fatalf("impersonated request: status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(mw.Auth(), mw.RequireSession()); rec.Code != http.StatusForbidden {
+		t.Fatalf("session-only route: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := serve(mw.Auth(), mw.RequirePermission(model.PermissionPostsDelete)); rec.Code != http.StatusForbidden {
+		t.Fatalf("admin route: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// Refused requests are recorded too.
+	if len(audit.entries) != 3 {
+		t.Fatalf("audit entries = %d, want 3", len(audit.entries))
+	}
+	entry := audit.entries[0]
+	if entry.ActorID != "admin-1" || entry.TargetID != "user-1" || entry.Action != model.AuditImpersonatedRequest {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+type mockRevocationChecker struct {
+	deniedIDs  map[string]bool
+	validAfter map[string]time.Time
+}
+
+func (m *mockRevocationChecker) IsAccessTokenRevoked(ctx context.Context, userID, tokenID string, issuedAt time.Time) (bool, error) {
+	if m.deniedIDs[tokenID] {
+		return true, nil
+	}
+	validAfter, ok := m.validAfter[userID]
+	return ok && issuedAt.Before(validAfter), nil
+}
+
+func TestAuth_RejectsRevokedAccessTokens(t *testing.T) {
+	now := time.Now()
+	revocations := &mockRevocationChecker{
+		deniedIDs:  map[string]bool{"jti-logged-out": true},
+		validAfter: map[string]time.Time{"user-2": now.Add(-time.Minute)},
+	}
+	mw := NewAuthMiddleware(&config.Config{}, &mockUserService{}, nil, nil, jwtkeys.NewHMAC([]byte("test-secret")), nil, revocations)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"valid", jwt.MapClaims{"user_id": "user-1", "jti": "jti-1", "iat": now.Unix()}, http.StatusNoContent},
+		{"logged out", jwt.MapClaims{"user_id": "user-1", "jti": "jti-logged-out", "iat": now.Unix()}, http.StatusUnauthorized},
+		{"issued before password change", jwt.MapClaims{"user_id": "user-2", "jti": "jti-2", "iat": now.Add(-time.Hour).Unix()}, http.StatusUnauthorized},
+		{"issued after password change", jwt.MapClaims{"user_id": "user-2", "jti": "jti-3", "iat": now.Unix()}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			handler := mw.Auth()(func(c *echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, "test-secret", tt.claims))
+			rec := httptest.NewRecorder()
+			if err := handler(e.NewContext(req, rec)); err != nil {
+				t.Fatalf("handler error: %v", err)
+			}
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d body=%s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"slices"
 	"time"
 
 	"echobackend/config"
@@ -68,5 +69,10 @@ func InitMiddleware(e *echo.Echo, config *config.Config) {
 
 	e.Use(RecoverWithLog())
 
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{AllowOrigins: config.HTTP.AllowOrigins}))
+	// Credentials (the OAuth link cookies) are only allowed for an explicit
+	// origin list; echo refuses them with "*".
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     config.HTTP.AllowOrigins,
+		AllowCredentials: !slices.Contains(config.HTTP.AllowOrigins, "*"),
+	}))
 }
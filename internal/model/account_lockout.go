@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// AccountLockout tracks consecutive failed logins for a user. Sign-in is
+// refused while LockedUntil is in the future.
+type AccountLockout struct {
+	UserID         string     `json:"user_id" gorm:"type:uuid;primaryKey"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LastFailedAt   time.Time  `json:"last_failed_at" gorm:"not null;default:now()"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+	User           *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (AccountLockout) TableName() string {
+	return "account_lockouts"
+}
+
+// IsLocked reports whether the account is locked at now.
+func (l *AccountLockout) IsLocked(now time.Time) bool {
+	return l != nil && l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+)
+
+// AdminAuditLog records one privileged action. Changes holds the fields that
+// differ between the target's state before and after the action, as
+// {"before": {...}, "after": {...}}.
+type AdminAuditLog struct {
+	ID         string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	ActorID    *string   `json:"actor_id" gorm:"type:uuid;index"`
+	Action     string    `json:"action" gorm:"type:varchar(100);not null;index"`
+	TargetType string    `json:"target_type" gorm:"type:varchar(50);not null"`
+	TargetID   *string   `json:"target_id" gorm:"type:text"`
+	Changes    *string   `json:"changes" gorm:"type:jsonb"`
+	IPAddress  *string   `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent  *string   `json:"user_agent" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;default:now()"`
+	Actor      *User     `json:"-" gorm:"foreignKey:ActorID"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+// Audited admin actions.
+const (
+	AuditUserDelete      = "user.delete"
+	AuditUserRestore     = "user.restore"
+	AuditPostUpdate      = "post.update"
+	AuditPostDelete      = "post.delete"
+	AuditTagUpdate       = "tag.update"
+	AuditTagDelete       = "tag.delete"
+	AuditRoleAssign      = "role.assign"
+	AuditRoleRemove      = "role.remove"
+	AuditAccountUnlock   = "account.unlock"
+	AuditReportView      = "report.view"
+	AuditUserImpersonate = "user.impersonate"
+	AuditUserSuspend     = "user.suspend"
+	AuditUserUnsuspend   = "user.unsuspend"
+	// AuditImpersonatedRequest is recorded for every request made with an
+	// impersonation token; the actor is the admin, the target the user.
+	AuditImpersonatedRequest = "impersonation.request"
+)
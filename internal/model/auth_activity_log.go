@@ -22,16 +22,42 @@ func (AuthActivityLog) TableName() string {
 }
 
 const (
-	ActivityLogin            = "login"
-	ActivityLoginFailed      = "login_failed"
-	ActivityLogout           = "logout"
-	ActivityRegister         = "register"
-	ActivityPasswordChange   = "password_change"
-	ActivityPasswordResetReq = "password_reset_request"
-	ActivityPasswordReset    = "password_reset"
-	ActivityTokenRefresh     = "token_refresh"
-	ActivityOAuthLogin       = "oauth_login"
-	ActivityOAuthLoginFailed = "oauth_login_failed"
+	ActivityLogin              = "login"
+	ActivityLoginFailed        = "login_failed"
+	ActivityLogout             = "logout"
+	ActivityRegister           = "register"
+	ActivityPasswordChange     = "password_change"
+	ActivityPasswordResetReq   = "password_reset_request"
+	ActivityPasswordReset      = "password_reset"
+	ActivityTokenRefresh       = "token_refresh"
+	ActivitySessionRevoked     = "session_revoked"
+	ActivityTokenReuseDetected = "token_reuse_detected"
+	ActivityOAuthLogin         = "oauth_login"
+	ActivityOAuthLoginFailed   = "oauth_login_failed"
+	ActivityTwoFactorEnroll    = "two_factor_enroll"
+	ActivityTwoFactorEnable    = "two_factor_enable"
+	ActivityTwoFactorDisable   = "two_factor_disable"
+	ActivityTwoFactorSuccess   = "two_factor_success"
+	ActivityTwoFactorFailed    = "two_factor_failed"
+	ActivityEmailVerifyReq     = "email_verification_request"
+	ActivityEmailVerified      = "email_verified"
+	ActivityEmailChangeReq     = "email_change_request"
+	ActivityEmailChange        = "email_change"
+	ActivityMagicLinkReq       = "magic_link_request"
+	ActivityIdentityLinked     = "identity_linked"
+	ActivityIdentityUnlinked   = "identity_unlinked"
+	ActivityAccountLocked      = "account_locked"
+	ActivityAccountUnlocked    = "account_unlocked"
+	ActivityAccessTokenCreated = "access_token_created"
+	ActivityAccessTokenRevoked = "access_token_revoked"
+	ActivityDataExportReq      = "data_export_request"
+	ActivityDeletionScheduled  = "account_deletion_scheduled"
+	ActivityDeletionCancelled  = "account_deletion_cancelled"
+	ActivityImpersonated       = "impersonated"
+	ActivityNewDeviceLogin     = "new_device_login"
+	ActivityUsernameChange     = "username_change"
+	ActivityAccountSuspended   = "account_suspended"
+	ActivityAccountReinstated  = "account_reinstated"
 
 	StatusSuccess = "success"
 	StatusFailure = "failure"
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// Data export statuses.
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
+
+// DataExport is a requested archive of everything stored about a user. The
+// archive is built by a background job and kept in object storage until
+// ExpiresAt.
+type DataExport struct {
+	ID           string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID       string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	FilePath     *string    `json:"-" gorm:"type:text"`
+	FileSize     *int64     `json:"file_size"`
+	ErrorMessage *string    `json:"-" gorm:"type:text"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now()"`
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// IsExpired reports whether the archive can no longer be downloaded.
+func (e *DataExport) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
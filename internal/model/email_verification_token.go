@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// EmailVerificationToken confirms ownership of Email for UserID. For a new
+// account Email is the address the user registered with; for an email change
+// it is the new address, which only replaces the current one once confirmed.
+type EmailVerificationToken struct {
+	ID        string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID    string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null"`
+	Token     string     `json:"token" gorm:"type:text;not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// KnownDevice is a user agent and IP network a user has signed in from. A
+// sign-in with an unknown fingerprint triggers a new-device alert.
+type KnownDevice struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID      string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_known_devices_user_fingerprint"`
+	Fingerprint string    `json:"-" gorm:"type:text;not null;uniqueIndex:idx_known_devices_user_fingerprint"`
+	IPAddress   *string   `json:"ip_address" gorm:"type:text"`
+	UserAgent   *string   `json:"user_agent" gorm:"type:text"`
+	FirstSeenAt time.Time `json:"first_seen_at" gorm:"not null;default:now()"`
+	LastSeenAt  time.Time `json:"last_seen_at" gorm:"not null;default:now()"`
+}
+
+func (KnownDevice) TableName() string {
+	return "known_devices"
+}
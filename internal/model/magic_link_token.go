@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+type MagicLinkToken struct {
+	ID        string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID    string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Token     string     `json:"token" gorm:"type:text;not null;uniqueIndex"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (MagicLinkToken) TableName() string {
+	return "magic_link_tokens"
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scopes a personal access token can be granted.
+const (
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeHoldingsRead  = "holdings:read"
+	ScopeHoldingsWrite = "holdings:write"
+	ScopeChat          = "chat"
+)
+
+// PersonalAccessTokenScopes lists every valid scope.
+var PersonalAccessTokenScopes = []string{
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeHoldingsRead,
+	ScopeHoldingsWrite,
+	ScopeChat,
+}
+
+// PersonalAccessToken is a long-lived, user-managed API token. Only the hash
+// of the token is stored; TokenPrefix is kept so users can tell tokens apart.
+// Scopes is a space-separated list.
+type PersonalAccessToken struct {
+	ID          string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID      string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name        string     `json:"name" gorm:"type:varchar(100);not null"`
+	TokenHash   string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	TokenPrefix string     `json:"token_prefix" gorm:"type:varchar(16);not null"`
+	Scopes      string     `json:"scopes" gorm:"type:text;not null;default:''"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip" gorm:"type:varchar(45)"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	User        *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList returns the granted scopes.
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// HasScope reports whether the token was granted scope.
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.ScopeList(), scope)
+}
+
+// IsExpired reports whether the token has expired at now.
+func (t *PersonalAccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
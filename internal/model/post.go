@@ -18,6 +18,7 @@ type Post struct {
 	PhotoURL      *string        `json:"photo_url"`
 	Published     *bool          `json:"published" gorm:"default:true"`
 	PublishedAt   *time.Time     `json:"published_at"`
+	PublishAt     *time.Time     `json:"publish_at"`
 	ViewCount     int64          `json:"view_count" gorm:"type:bigint;default:0"`
 	LikeCount     int64          `json:"like_count" gorm:"type:bigint;default:0"`
 	BookmarkCount int64          `json:"bookmark_count" gorm:"type:bigint;default:0;check:chk_posts_counts_positive,view_count >= 0 AND like_count >= 0 AND bookmark_count >= 0"`
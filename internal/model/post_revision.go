@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// PostRevision is the title, body and tags of a post after one edit. Number
+// counts the revisions of a post from 1. Revisions are never changed;
+// restoring one adds a new revision with RestoredFrom set to its number.
+type PostRevision struct {
+	ID           string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	PostID       string    `json:"post_id" gorm:"type:uuid;not null;uniqueIndex:uq_post_revisions_post_number"`
+	Number       int       `json:"number" gorm:"not null;uniqueIndex:uq_post_revisions_post_number"`
+	Title        string    `json:"title" gorm:"type:varchar(255);not null"`
+	Body         string    `json:"body" gorm:"type:text;not null;default:''"`
+	Tags         []string  `json:"tags" gorm:"type:jsonb;serializer:json;not null"`
+	EditorID     *string   `json:"editor_id" gorm:"type:uuid"`
+	RestoredFrom *int      `json:"restored_from"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+	Editor       *User     `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
+}
+
+func (PostRevision) TableName() string {
+	return "post_revisions"
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+)
+
+// Permissions checked by RequirePermission. They are seeded by migrations.
+const (
+	PermissionPostsRead        = "posts.read"
+	PermissionPostsUpdate      = "posts.update"
+	PermissionPostsDelete      = "posts.delete"
+	PermissionTagsUpdate       = "tags.update"
+	PermissionTagsDelete       = "tags.delete"
+	PermissionUsersRead        = "users.read"
+	PermissionUsersDelete      = "users.delete"
+	PermissionUsersRestore     = "users.restore"
+	PermissionReportsRead      = "reports.read"
+	PermissionAuthAudit        = "auth.audit"
+	PermissionAccountsUnlock   = "accounts.unlock"
+	PermissionRolesManage      = "roles.manage"
+	PermissionAuditRead        = "audit.read"
+	PermissionUsersImpersonate = "users.impersonate"
+	PermissionUsersSuspend     = "users.suspend"
+)
+
+// RoleAdmin holds every permission. Membership is mirrored to
+// users.is_super_admin for clients that still read that flag.
+const RoleAdmin = "admin"
+
+type Role struct {
+	ID          string       `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	Name        string       `json:"name" gorm:"type:varchar(50);not null;uniqueIndex"`
+	Description string       `json:"description" gorm:"type:text;not null;default:''"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"not null;default:now()"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;joinForeignKey:RoleID;joinReferences:PermissionName"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+type Permission struct {
+	Name        string `json:"name" gorm:"type:varchar(100);primaryKey"`
+	Description string `json:"description" gorm:"type:text;not null;default:''"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+type UserRole struct {
+	UserID     string    `json:"user_id" gorm:"type:uuid;primaryKey"`
+	RoleID     string    `json:"role_id" gorm:"type:uuid;primaryKey"`
+	AssignedBy *string   `json:"assigned_by" gorm:"type:uuid"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;default:now()"`
+	Role       *Role     `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
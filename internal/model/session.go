@@ -4,13 +4,22 @@ import (
 	"time"
 )
 
+// Session is one refresh token in a token family. A login starts a family
+// (FamilyID == ID); each refresh adds a child row and marks the parent rotated.
+// FamilyID is the stable session identifier exposed to users.
 type Session struct {
-	RefreshToken string     `gorm:"primaryKey;type:text;not null"`
-	UserID       string     `gorm:"type:uuid;not null"`
+	ID           string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	RefreshToken string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	UserID       string     `json:"user_id" gorm:"type:uuid;not null"`
+	FamilyID     string     `json:"family_id" gorm:"type:uuid;not null;index"`
+	ParentID     *string    `json:"parent_id" gorm:"type:uuid"`
+	RotatedAt    *time.Time `json:"rotated_at"`
 	CreatedAt    *time.Time `json:"created_at"`
 	UserAgent    *string    `json:"user_agent"`
+	IPAddress    *string    `json:"ip_address" gorm:"type:varchar(45)"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
 	ExpiresAt    *time.Time `json:"expires_at"`
-	User         *User      `gorm:"foreignKey:UserID"`
+	User         *User      `json:"-" gorm:"foreignKey:UserID"`
 }
 
 func (Session) TableName() string {
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// SessionRevokeToken backs the "this wasn't me" link of a new sign-in alert.
+// Opening it signs the user out of every session and forgets the device the
+// alert was about, so a later sign-in from it alerts again.
+type SessionRevokeToken struct {
+	ID          string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID      string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Token       string     `json:"token" gorm:"type:text;not null;uniqueIndex"`
+	Fingerprint string     `json:"-" gorm:"type:text;not null"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt      *time.Time `json:"used_at"`
+	User        *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (SessionRevokeToken) TableName() string {
+	return "session_revoke_tokens"
+}
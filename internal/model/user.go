@@ -7,21 +7,27 @@ import (
 )
 
 type User struct {
-	ID             string         `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
-	CreatedAt      *time.Time     `json:"created_at"`
-	UpdatedAt      *time.Time     `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
-	FirstName      *string        `json:"first_name" gorm:"type:varchar(255)"`
-	LastName       *string        `json:"last_name" gorm:"type:varchar(255)"`
-	Email          string         `json:"email" gorm:"uniqueIndex;not null;type:varchar(255)"`
-	Password       *string        `json:"-" gorm:"type:varchar(255)"`
-	Image          *string        `json:"image"`
-	IsSuperAdmin   *bool          `json:"-" gorm:"default:false"`
-	Username       *string        `json:"username" gorm:"uniqueIndex;type:varchar(255)"`
-	GithubID       *int64         `json:"github_id" gorm:"uniqueIndex:users_github_id_unique"`
-	FollowersCount int64          `json:"followers_count" gorm:"type:bigint;default:0"`
-	FollowingCount int64          `json:"following_count" gorm:"type:bigint;default:0"`
-	LastLoggedAt   *time.Time     `json:"last_logged_at"`
+	ID                  string         `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	CreatedAt           *time.Time     `json:"created_at"`
+	UpdatedAt           *time.Time     `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	FirstName           *string        `json:"first_name" gorm:"type:varchar(255)"`
+	LastName            *string        `json:"last_name" gorm:"type:varchar(255)"`
+	Email               string         `json:"email" gorm:"uniqueIndex;not null;type:varchar(255)"`
+	Password            *string        `json:"-" gorm:"type:varchar(255)"`
+	Image               *string        `json:"image"`
+	IsSuperAdmin        *bool          `json:"-" gorm:"default:false"`
+	Username            *string        `json:"username" gorm:"uniqueIndex;type:varchar(255)"`
+	FollowersCount      int64          `json:"followers_count" gorm:"type:bigint;default:0"`
+	FollowingCount      int64          `json:"following_count" gorm:"type:bigint;default:0"`
+	IsPrivate           bool           `json:"is_private" gorm:"not null;default:false"`
+	LastLoggedAt        *time.Time     `json:"last_logged_at"`
+	EmailVerifiedAt     *time.Time     `json:"email_verified_at"`
+	DeletionScheduledAt *time.Time     `json:"deletion_scheduled_at"`
+	TokensValidAfter    *time.Time     `json:"-"`
+	SuspendedAt         *time.Time     `json:"-"`
+	SuspendedUntil      *time.Time     `json:"-"`
+	SuspensionReason    *string        `json:"-" gorm:"type:varchar(500)"`
 
 	Files           []File           `gorm:"foreignKey:CreatedBy"`
 	PostComments    []PostComment    `gorm:"foreignKey:CreatedBy"`
@@ -39,3 +45,9 @@ type User struct {
 func (User) TableName() string {
 	return "users"
 }
+
+// IsSuspended reports whether the account is suspended at now. A suspension
+// without an end date lasts until an admin lifts it.
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedAt != nil && (u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil))
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// UserBlock stops two users from following, commenting on or notifying each
+// other, and hides their posts from each other's feed.
+type UserBlock struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	BlockerID string    `json:"blocker_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_blocks_pair"`
+	BlockedID string    `json:"blocked_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_blocks_pair;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserBlock) TableName() string {
+	return "user_blocks"
+}
+
+// UserMute hides the muted user's posts and notifications from the muter
+// only; the muted user is not affected.
+type UserMute struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	MuterID   string    `json:"muter_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_mutes_pair"`
+	MutedID   string    `json:"muted_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_mutes_pair"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserMute) TableName() string {
+	return "user_mutes"
+}
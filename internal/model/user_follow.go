@@ -6,10 +6,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// Follow statuses. A follow of a private account stays pending until the
+// account approves it; only approved follows count and grant access.
+const (
+	FollowStatusPending  = "pending"
+	FollowStatusApproved = "approved"
+)
+
 type UserFollow struct {
 	ID          string         `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
 	FollowerID  string         `json:"follower_id" gorm:"type:uuid;not null;index"`
 	FollowingID string         `json:"following_id" gorm:"type:uuid;not null;index"`
+	Status      string         `json:"status" gorm:"type:varchar(20);not null;default:approved"`
 	CreatedAt   *time.Time     `json:"created_at" gorm:"index"`
 	UpdatedAt   *time.Time     `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
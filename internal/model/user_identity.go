@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to an account at an external sign-in provider.
+type UserIdentity struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_identities_user_provider"`
+	Provider  string    `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:idx_user_identities_provider_subject;uniqueIndex:idx_user_identities_user_provider"`
+	Subject   string    `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     *string   `json:"email" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;default:now()"`
+	User      *User     `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// UserTwoFactor holds a user's TOTP secret. The factor is only enforced at
+// login once ConfirmedAt is set.
+type UserTwoFactor struct {
+	UserID       string     `json:"user_id" gorm:"type:uuid;primaryKey"`
+	Secret       string     `json:"-" gorm:"type:text;not null"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+	User         *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+// IsEnabled reports whether enrollment has been confirmed.
+func (t *UserTwoFactor) IsEnabled() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+type UserRecoveryCode struct {
+	ID        string     `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID    string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// UsernameHistory records a username change. OldUsername keeps resolving to
+// the user, and nobody else can take it before ReservedUntil.
+type UsernameHistory struct {
+	ID            string    `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	UserID        string    `json:"user_id" gorm:"type:uuid;not null"`
+	OldUsername   string    `json:"old_username" gorm:"type:varchar(255);not null"`
+	NewUsername   string    `json:"new_username" gorm:"type:varchar(255);not null"`
+	ChangedAt     time.Time `json:"changed_at" gorm:"not null;default:now()"`
+	ReservedUntil time.Time `json:"reserved_until" gorm:"not null"`
+}
+
+func (UsernameHistory) TableName() string {
+	return "username_history"
+}
@@ -150,6 +150,50 @@ func (c *RedisCache) SetJSONWithTTL(ctx context.Context, key string, value any,
 	return nil
 }
 
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if c == nil || c.client == nil || len(keys) == 0 {
+		return nil
+	}
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		log.Warn("cache: Delete error", "keys", keys, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteByPrefix removes every key that starts with prefix. Keys are found
+// with SCAN, so it is meant for small key families such as per-limit list
+// caches.
+func (c *RedisCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if c == nil || c.client == nil || prefix == "" {
+		return nil
+	}
+
+	iter := c.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Warn("cache: DeleteByPrefix scan error", "prefix", prefix, "error", err)
+		return err
+	}
+	return c.Delete(ctx, keys...)
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func (c *RedisCache) IncrementFixedWindow(ctx context.Context, key string, window time.Duration) (int, time.Duration, error) {
 	if c == nil || c.client == nil || key == "" || window <= 0 {
 		return 0, 0, nil
@@ -20,7 +20,16 @@ import (
 	"echobackend/internal/platform/queue"
 )
 
-const taskTypePasswordReset = "email:password_reset"
+const (
+	taskTypePasswordReset = "email:password_reset"
+	taskTypeEmailVerify   = "email:verify"
+	taskTypeMagicLink     = "email:magic_link"
+	taskTypeAccountLocked = "email:account_locked"
+	taskTypeDataExport    = "email:data_export"
+	taskTypeNewLogin      = "email:new_login"
+	taskTypeSuspended     = "email:account_suspended"
+	taskTypeReinstated    = "email:account_reinstated"
+)
 
 // Service sends application emails through SMTP.
 type Service struct {
@@ -40,6 +49,47 @@ type passwordResetPayload struct {
 	ResetLink string `json:"reset_link"`
 }
 
+type emailVerifyPayload struct {
+	To         string `json:"to"`
+	VerifyLink string `json:"verify_link"`
+}
+
+type magicLinkPayload struct {
+	To        string `json:"to"`
+	LoginLink string `json:"login_link"`
+}
+
+type accountLockedPayload struct {
+	To          string    `json:"to"`
+	LockedUntil time.Time `json:"locked_until"`
+	ResetLink   string    `json:"reset_link"`
+}
+
+type dataExportPayload struct {
+	To           string    `json:"to"`
+	DownloadLink string    `json:"download_link"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type newLoginPayload struct {
+	To         string    `json:"to"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	RevokeLink string    `json:"revoke_link"`
+}
+
+type accountSuspendedPayload struct {
+	To             string     `json:"to"`
+	Reason         string     `json:"reason"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+type accountReinstatedPayload struct {
+	To        string `json:"to"`
+	LoginLink string `json:"login_link"`
+}
+
 // NewService creates an SMTP-backed email service and registers its background tasks.
 func NewService(cfg config.EmailConfig, taskQueue *queue.Service) *Service {
 	service := &Service{
@@ -62,6 +112,13 @@ func (s *Service) registerQueueHandlers() {
 		return
 	}
 	s.queue.Handle(taskTypePasswordReset, s.handlePasswordResetTask)
+	s.queue.Handle(taskTypeEmailVerify, s.handleEmailVerifyTask)
+	s.queue.Handle(taskTypeMagicLink, s.handleMagicLinkTask)
+	s.queue.Handle(taskTypeAccountLocked, s.handleAccountLockedTask)
+	s.queue.Handle(taskTypeDataExport, s.handleDataExportTask)
+	s.queue.Handle(taskTypeNewLogin, s.handleNewLoginTask)
+	s.queue.Handle(taskTypeSuspended, s.handleAccountSuspendedTask)
+	s.queue.Handle(taskTypeReinstated, s.handleAccountReinstatedTask)
 }
 
 // IsConfigured reports whether queued email delivery is enabled.
@@ -111,6 +168,246 @@ func (s *Service) SendPasswordResetEmail(ctx context.Context, to, resetLink stri
 	return s.send(ctx, to, "Reset your password", text, htmlBody)
 }
 
+// EnqueueVerificationEmail queues an email address confirmation email for Asynq delivery.
+func (s *Service) EnqueueVerificationEmail(to, verifyLink string) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := emailVerifyPayload{To: to, VerifyLink: verifyLink}
+	return s.queue.EnqueueJSON(taskTypeEmailVerify, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleEmailVerifyTask(ctx context.Context, payloadBytes []byte) error {
+	var payload emailVerifyPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.VerifyLink == "" {
+		return fmt.Errorf("invalid email verification payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendVerificationEmail(ctx, payload.To, payload.VerifyLink)
+}
+
+// SendVerificationEmail sends the email address confirmation link.
+func (s *Service) SendVerificationEmail(ctx context.Context, to, verifyLink string) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := emailVerifyTemplate(verifyLink, "24 hours")
+	return s.send(ctx, to, "Confirm your email address", text, htmlBody)
+}
+
+// EnqueueMagicLinkEmail queues a passwordless login email for Asynq delivery.
+func (s *Service) EnqueueMagicLinkEmail(to, loginLink string) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := magicLinkPayload{To: to, LoginLink: loginLink}
+	return s.queue.EnqueueJSON(taskTypeMagicLink, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleMagicLinkTask(ctx context.Context, payloadBytes []byte) error {
+	var payload magicLinkPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.LoginLink == "" {
+		return fmt.Errorf("invalid magic link payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendMagicLinkEmail(ctx, payload.To, payload.LoginLink)
+}
+
+// SendMagicLinkEmail sends the single-use login link email.
+func (s *Service) SendMagicLinkEmail(ctx context.Context, to, loginLink string) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := magicLinkTemplate(loginLink, "15 minutes")
+	return s.send(ctx, to, "Your sign-in link", text, htmlBody)
+}
+
+// EnqueueAccountLockedEmail queues a notice that sign-in to the account was
+// locked after repeated failed logins.
+func (s *Service) EnqueueAccountLockedEmail(to string, lockedUntil time.Time, resetLink string) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := accountLockedPayload{To: to, LockedUntil: lockedUntil, ResetLink: resetLink}
+	return s.queue.EnqueueJSON(taskTypeAccountLocked, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleAccountLockedTask(ctx context.Context, payloadBytes []byte) error {
+	var payload accountLockedPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.ResetLink == "" {
+		return fmt.Errorf("invalid account locked payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendAccountLockedEmail(ctx, payload.To, payload.LockedUntil, payload.ResetLink)
+}
+
+// SendAccountLockedEmail sends the account lockout notice.
+func (s *Service) SendAccountLockedEmail(ctx context.Context, to string, lockedUntil time.Time, resetLink string) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := accountLockedTemplate(lockedUntil.UTC().Format("2006-01-02 15:04 MST"), resetLink)
+	return s.send(ctx, to, "Sign-in to your account was locked", text, htmlBody)
+}
+
+// EnqueueDataExportEmail queues the download link of a finished personal data
+// export.
+func (s *Service) EnqueueDataExportEmail(to, downloadLink string, expiresAt time.Time) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := dataExportPayload{To: to, DownloadLink: downloadLink, ExpiresAt: expiresAt}
+	return s.queue.EnqueueJSON(taskTypeDataExport, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleDataExportTask(ctx context.Context, payloadBytes []byte) error {
+	var payload dataExportPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.DownloadLink == "" {
+		return fmt.Errorf("invalid data export payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendDataExportEmail(ctx, payload.To, payload.DownloadLink, payload.ExpiresAt)
+}
+
+// SendDataExportEmail sends the data export download link.
+func (s *Service) SendDataExportEmail(ctx context.Context, to, downloadLink string, expiresAt time.Time) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := dataExportTemplate(downloadLink, expiresAt.UTC().Format("2006-01-02 15:04 MST"))
+	return s.send(ctx, to, "Your data export is ready", text, htmlBody)
+}
+
+// EnqueueNewLoginEmail queues a notice of a sign-in from a device or network
+// the account has not used before. revokeLink signs out every session.
+func (s *Service) EnqueueNewLoginEmail(to, ipAddress, userAgent string, signedInAt time.Time, revokeLink string) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := newLoginPayload{To: to, IPAddress: ipAddress, UserAgent: userAgent, SignedInAt: signedInAt, RevokeLink: revokeLink}
+	return s.queue.EnqueueJSON(taskTypeNewLogin, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleNewLoginTask(ctx context.Context, payloadBytes []byte) error {
+	var payload newLoginPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.RevokeLink == "" {
+		return fmt.Errorf("invalid new login payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendNewLoginEmail(ctx, payload.To, payload.IPAddress, payload.UserAgent, payload.SignedInAt, payload.RevokeLink)
+}
+
+// SendNewLoginEmail sends the new sign-in notice.
+func (s *Service) SendNewLoginEmail(ctx context.Context, to, ipAddress, userAgent string, signedInAt time.Time, revokeLink string) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := newLoginTemplate(ipAddress, userAgent, signedInAt.UTC().Format("2006-01-02 15:04 MST"), revokeLink)
+	return s.send(ctx, to, "New sign-in from "+loginDevice(userAgent), text, htmlBody)
+}
+
+// EnqueueAccountSuspendedEmail queues a notice that an admin suspended the
+// account. A nil suspendedUntil means the suspension has no end date.
+func (s *Service) EnqueueAccountSuspendedEmail(to, reason string, suspendedUntil *time.Time) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := accountSuspendedPayload{To: to, Reason: reason, SuspendedUntil: suspendedUntil}
+	return s.queue.EnqueueJSON(taskTypeSuspended, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleAccountSuspendedTask(ctx context.Context, payloadBytes []byte) error {
+	var payload accountSuspendedPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.Reason == "" {
+		return fmt.Errorf("invalid account suspended payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendAccountSuspendedEmail(ctx, payload.To, payload.Reason, payload.SuspendedUntil)
+}
+
+// SendAccountSuspendedEmail sends the account suspension notice.
+func (s *Service) SendAccountSuspendedEmail(ctx context.Context, to, reason string, suspendedUntil *time.Time) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	until := ""
+	if suspendedUntil != nil {
+		until = suspendedUntil.UTC().Format("2006-01-02 15:04 MST")
+	}
+	text, htmlBody := accountSuspendedTemplate(reason, until)
+	return s.send(ctx, to, "Your account has been suspended", text, htmlBody)
+}
+
+// EnqueueAccountReinstatedEmail queues a notice that the account's suspension
+// was lifted.
+func (s *Service) EnqueueAccountReinstatedEmail(to, loginLink string) error {
+	if !s.IsConfigured() {
+		return errors.New("email service not configured")
+	}
+
+	payload := accountReinstatedPayload{To: to, LoginLink: loginLink}
+	return s.queue.EnqueueJSON(taskTypeReinstated, payload, queue.TaskOptions{Timeout: s.taskTTL})
+}
+
+func (s *Service) handleAccountReinstatedTask(ctx context.Context, payloadBytes []byte) error {
+	var payload accountReinstatedPayload
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+
+	if payload.To == "" || payload.LoginLink == "" {
+		return fmt.Errorf("invalid account reinstated payload: %w", queue.SkipRetry)
+	}
+
+	return s.SendAccountReinstatedEmail(ctx, payload.To, payload.LoginLink)
+}
+
+// SendAccountReinstatedEmail sends the notice that a suspension was lifted.
+func (s *Service) SendAccountReinstatedEmail(ctx context.Context, to, loginLink string) error {
+	if !s.hasSMTPConfig() {
+		return errors.New("email service not configured")
+	}
+
+	text, htmlBody := accountReinstatedTemplate(loginLink)
+	return s.send(ctx, to, "Your account has been reinstated", text, htmlBody)
+}
+
 func (s *Service) send(ctx context.Context, to, subject, textBody, htmlBody string) error {
 	message, err := buildMessage(s.from, to, subject, textBody, htmlBody)
 	if err != nil {
@@ -269,21 +566,202 @@ func sanitizeHeader(value string) string {
 }
 
 func passwordResetTemplate(resetLink, expiresIn string) (string, string) {
-	escapedLink := html.EscapeString(resetLink)
-	escapedExpiresIn := html.EscapeString(expiresIn)
-
 	textBody := fmt.Sprintf(
 		"We received a request to reset your password.\n\nReset your password here:\n%s\n\nThis link expires in %s. If you did not request a password reset, you can safely ignore this email.",
 		resetLink,
 		expiresIn,
 	)
 
-	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Reset your password",
+		Intro:       "We received a request to reset the password for your account. Use the button below to choose a new password.",
+		ButtonLabel: "Reset password",
+		Link:        resetLink,
+		ExpiresIn:   expiresIn,
+		Warning:     "For your security, do not forward this email or share the reset link.",
+		Footer:      "If you did not request a password reset, you can safely ignore this email.",
+	})
+}
+
+func emailVerifyTemplate(verifyLink, expiresIn string) (string, string) {
+	textBody := fmt.Sprintf(
+		"Please confirm that this is your email address.\n\nConfirm your email here:\n%s\n\nThis link expires in %s. If you did not create an account or change your email, you can safely ignore this email.",
+		verifyLink,
+		expiresIn,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Confirm your email address",
+		Intro:       "Please confirm that this address belongs to you. Use the button below to finish verifying your email.",
+		ButtonLabel: "Confirm email",
+		Link:        verifyLink,
+		ExpiresIn:   expiresIn,
+		Warning:     "Do not forward this email; anyone with the link can confirm the address.",
+		Footer:      "If you did not create an account or change your email, you can safely ignore this email.",
+	})
+}
+
+func magicLinkTemplate(loginLink, expiresIn string) (string, string) {
+	textBody := fmt.Sprintf(
+		"We received a request to sign in to your account.\n\nSign in here:\n%s\n\nThis link expires in %s and can only be used once. If you did not request it, you can safely ignore this email.",
+		loginLink,
+		expiresIn,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Sign in to your account",
+		Intro:       "We received a request to sign in to your account without a password. Use the button below to sign in.",
+		ButtonLabel: "Sign in",
+		Link:        loginLink,
+		ExpiresIn:   expiresIn,
+		Warning:     "The link works once. Do not forward this email; anyone with the link can sign in as you.",
+		Footer:      "If you did not request a sign-in link, you can safely ignore this email.",
+	})
+}
+
+func accountLockedTemplate(lockedUntil, resetLink string) (string, string) {
+	textBody := fmt.Sprintf(
+		"We locked sign-in to your account after several failed login attempts. You can sign in again after %s.\n\nIf these attempts were not you, reset your password here:\n%s",
+		lockedUntil,
+		resetLink,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Sign-in to your account was locked",
+		Intro:       fmt.Sprintf("We locked sign-in to your account after several failed login attempts. You can sign in again after %s.", lockedUntil),
+		ButtonLabel: "Reset password",
+		Link:        resetLink,
+		Warning:     "If these attempts were not you, someone may know your email or username. Resetting your password is recommended.",
+		Footer:      "If you entered the wrong password yourself, no action is needed.",
+	})
+}
+
+func dataExportTemplate(downloadLink, expiresAt string) (string, string) {
+	textBody := fmt.Sprintf(
+		"The export of your account data you requested is ready.\n\nDownload it here:\n%s\n\nThe link works until %s. You can request a new export from your account settings at any time.",
+		downloadLink,
+		expiresAt,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Your data export is ready",
+		Intro:       "The export of your account data you requested is ready. It is a ZIP archive of JSON files.",
+		ButtonLabel: "Download export",
+		Link:        downloadLink,
+		Warning:     fmt.Sprintf("The link works until %s. Anyone with the link can download your data, so do not forward this email.", expiresAt),
+		Footer:      "If you did not request a data export, change your password.",
+	})
+}
+
+func newLoginTemplate(ipAddress, userAgent, signedInAt, revokeLink string) (string, string) {
+	device := loginDevice(userAgent)
+	textBody := fmt.Sprintf(
+		"Your account was just signed in to from a device or network it has not used before.\n\nDevice: %s\nIP address: %s\nTime: %s\n\nIf this was you, no action is needed. If it wasn't you, sign out everywhere here and then change your password:\n%s",
+		device,
+		ipAddress,
+		signedInAt,
+		revokeLink,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "New sign-in from " + device,
+		Intro:       fmt.Sprintf("Your account was just signed in to from %s (IP address %s) at %s. It has not been used from this device or network before.", device, ipAddress, signedInAt),
+		ButtonLabel: "This wasn't me",
+		Link:        revokeLink,
+		Warning:     "The button signs out every session on your account, including this one. Change your password afterwards.",
+		Footer:      "If this was you, no action is needed.",
+	})
+}
+
+func accountSuspendedTemplate(reason, suspendedUntil string) (string, string) {
+	duration := "until further notice"
+	if suspendedUntil != "" {
+		duration = "until " + suspendedUntil
+	}
+	textBody := fmt.Sprintf(
+		"Your account has been suspended %s.\n\nReason: %s\n\nWhile it is suspended you cannot sign in and your posts are hidden. If you think this is a mistake, reply to this email.",
+		duration,
+		reason,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:   "Your account has been suspended",
+		Intro:   fmt.Sprintf("Your account has been suspended %s. Reason: %s", duration, reason),
+		Warning: "While your account is suspended you cannot sign in and your posts are hidden from other people.",
+		Footer:  "If you think this is a mistake, reply to this email.",
+	})
+}
+
+func accountReinstatedTemplate(loginLink string) (string, string) {
+	textBody := fmt.Sprintf(
+		"The suspension of your account has been lifted. You can sign in again and your posts are visible again.\n\nSign in here:\n%s",
+		loginLink,
+	)
+
+	return textBody, actionEmailHTML(actionEmail{
+		Title:       "Your account has been reinstated",
+		Intro:       "The suspension of your account has been lifted. You can sign in again and your posts are visible again.",
+		ButtonLabel: "Sign in",
+		Link:        loginLink,
+		Warning:     "You were signed out everywhere when the account was suspended, so sign in again on each device.",
+		Footer:      "If you have questions about the suspension, reply to this email.",
+	})
+}
+
+// loginDevice shortens a user agent for display, falling back to a generic
+// label when the client sent none.
+func loginDevice(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return "an unknown device"
+	}
+	const maxLength = 80
+	if len(userAgent) > maxLength {
+		return userAgent[:maxLength] + "..."
+	}
+	return userAgent
+}
+
+// actionEmail describes a transactional email built around a single link.
+// All fields are plain text and escaped when rendered.
+type actionEmail struct {
+	Title       string
+	Intro       string
+	ButtonLabel string
+	Link        string
+	// ExpiresIn is omitted from the email when empty.
+	ExpiresIn string
+	Warning   string
+	Footer    string
+}
+
+func actionEmailHTML(e actionEmail) string {
+	escapedLink := html.EscapeString(e.Link)
+	meta := html.EscapeString(e.Warning)
+	if e.ExpiresIn != "" {
+		meta = "This link expires in <strong>" + html.EscapeString(e.ExpiresIn) + "</strong>. " + meta
+	}
+
+	// Notices without an action leave out the button and its fallback link.
+	button, fallback := "", ""
+	if e.Link != "" {
+		button = fmt.Sprintf(`
+        <div class="button-wrap">
+          <a href="%s" class="button">%s</a>
+        </div>`, escapedLink, html.EscapeString(e.ButtonLabel))
+		fallback = fmt.Sprintf(`
+        <div class="fallback">
+          <p>If the button does not work, copy and paste this link into your browser:</p>
+          <p><a href="%s" class="link">%s</a></p>
+        </div>`, escapedLink, escapedLink)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head>
   <meta charset="utf-8">
   <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Reset Your Password</title>
+  <title>%s</title>
   <style>
     body { margin: 0; padding: 0; background: #ffffff; color: #111111; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif; }
     .page { width: 100%%; padding: 32px 16px; background: #ffffff; }
@@ -314,26 +792,25 @@ func passwordResetTemplate(resetLink, expiresIn string) (string, string) {
     <div class="container">
       <div class="header">
         <p class="brand">Pilput</p>
-        <h1>Reset your password</h1>
+        <h1>%s</h1>
       </div>
       <div class="content">
-        <p>We received a request to reset the password for your account. Use the button below to choose a new password.</p>
-        <div class="button-wrap">
-          <a href="%s" class="button">Reset password</a>
-        </div>
-        <div class="meta">This link expires in <strong>%s</strong>. For your security, do not forward this email or share the reset link.</div>
-        <div class="fallback">
-          <p>If the button does not work, copy and paste this link into your browser:</p>
-          <p><a href="%s" class="link">%s</a></p>
-        </div>
+        <p>%s</p>%s
+        <div class="meta">%s</div>%s
       </div>
     </div>
     <div class="footer">
-      <p>If you did not request a password reset, you can safely ignore this email.</p>
+      <p>%s</p>
     </div>
   </div>
 </body>
-</html>`, escapedLink, escapedExpiresIn, escapedLink, escapedLink)
-
-	return textBody, htmlBody
+</html>`,
+		html.EscapeString(e.Title),
+		html.EscapeString(e.Title),
+		html.EscapeString(e.Intro),
+		button,
+		meta,
+		fallback,
+		html.EscapeString(e.Footer),
+	)
 }
@@ -25,12 +25,19 @@ type TaskOptions struct {
 	Queue    string
 	Timeout  time.Duration
 	MaxRetry int
+	// ProcessAt delays the task until then; the zero time runs it right away.
+	ProcessAt time.Time
 }
 
-// Service owns the shared Asynq client and worker server.
+// periodicUniqueTTL stops several instances from each enqueueing the same
+// periodic task.
+const periodicUniqueTTL = time.Minute
+
+// Service owns the shared Asynq client, worker server and periodic scheduler.
 type Service struct {
 	client       *asynq.Client
 	server       *asynq.Server
+	scheduler    *asynq.Scheduler
 	mux          *asynq.ServeMux
 	defaultQueue string
 	maxRetry     int
@@ -80,6 +87,8 @@ func NewService(cfg config.QueueConfig) *Service {
 		}),
 	})
 
+	service.scheduler = asynq.NewScheduler(redisOpt, nil)
+
 	log.Info("server configured", "queue", cfg.DefaultQueue, "concurrency", cfg.Concurrency)
 	return service
 }
@@ -100,7 +109,21 @@ func (s *Service) Handle(taskType string, handler HandlerFunc) {
 	})
 }
 
-// Start begins processing registered task handlers.
+// Periodic enqueues an empty taskType task on the cron schedule cronspec
+// (e.g. "@hourly" or "*/5 * * * *"). Every instance runs the scheduler, so the
+// task is made unique for a short while to run it once per tick.
+func (s *Service) Periodic(cronspec, taskType string) {
+	if s == nil || s.scheduler == nil || cronspec == "" || taskType == "" {
+		return
+	}
+
+	task := asynq.NewTask(taskType, nil)
+	if _, err := s.scheduler.Register(cronspec, task, asynq.Queue(s.defaultQueue), asynq.Unique(periodicUniqueTTL)); err != nil {
+		log.Error("failed to register periodic task", "error", err, "task_type", taskType, "cronspec", cronspec)
+	}
+}
+
+// Start begins processing registered task handlers and periodic tasks.
 func (s *Service) Start() {
 	if !s.IsConfigured() || s.started {
 		return
@@ -112,6 +135,10 @@ func (s *Service) Start() {
 			log.Error("Asynq server stopped", "error", err)
 		}
 	}()
+
+	if err := s.scheduler.Start(); err != nil {
+		log.Error("Asynq scheduler failed to start", "error", err)
+	}
 }
 
 // Close stops the worker and closes the client.
@@ -120,6 +147,10 @@ func (s *Service) Close() error {
 		return nil
 	}
 
+	if s.scheduler != nil && s.started {
+		s.scheduler.Shutdown()
+	}
+
 	if s.server != nil {
 		s.server.Shutdown()
 	}
@@ -159,6 +190,9 @@ func (s *Service) EnqueueJSON(taskType string, payload any, opts TaskOptions) er
 	if opts.Timeout > 0 {
 		taskOptions = append(taskOptions, asynq.Timeout(opts.Timeout))
 	}
+	if !opts.ProcessAt.IsZero() {
+		taskOptions = append(taskOptions, asynq.ProcessAt(opts.ProcessAt))
+	}
 
 	task := asynq.NewTask(taskType, body)
 	_, err = s.client.Enqueue(task, taskOptions...)
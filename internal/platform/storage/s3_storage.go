@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"strings"
 	"time"
 
 	"echobackend/config"
@@ -17,8 +20,9 @@ import (
 var log = applog.Component("storage")
 
 type S3Storage struct {
-	client *minio.Client
-	bucket string
+	client    *minio.Client
+	bucket    string
+	publicURL string
 }
 
 const (
@@ -42,9 +46,19 @@ func NewS3Storage(cfg *config.Config) *S3Storage {
 		return nil
 	}
 
+	publicURL := strings.TrimRight(cfg.S3.PublicURL, "/")
+	if publicURL == "" {
+		scheme := "http"
+		if cfg.S3.UseSSL {
+			scheme = "https"
+		}
+		publicURL = scheme + "://" + cfg.S3.Endpoint + "/" + cfg.S3.Bucket
+	}
+
 	return &S3Storage{
-		client: minioClient,
-		bucket: cfg.S3.Bucket,
+		client:    minioClient,
+		bucket:    cfg.S3.Bucket,
+		publicURL: publicURL,
 	}
 }
 
@@ -101,6 +115,72 @@ func (s *S3Storage) Delete(ctx context.Context, path string) error {
 	return s.client.RemoveObject(ctx, s.bucket, path, minio.RemoveObjectOptions{})
 }
 
+// DeletePrefix deletes every object whose path starts with prefix.
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	if s == nil || s.client == nil {
+		return errors.New("storage is not configured")
+	}
+	if prefix == "" {
+		return errors.New("prefix cannot be empty")
+	}
+
+	// Cancelling stops the listing if a removal fails part way.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := make(chan minio.ObjectInfo)
+	var listErr error
+	go func() {
+		defer close(objects)
+		for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+			select {
+			case objects <- object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for result := range s.client.RemoveObjects(ctx, s.bucket, objects, minio.RemoveObjectsOptions{}) {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return listErr
+}
+
+// PresignedURL returns a time-limited download URL for path. When filename is
+// set the browser saves the object under that name.
+func (s *S3Storage) PresignedURL(ctx context.Context, path, filename string, expiry time.Duration) (string, error) {
+	if s == nil || s.client == nil {
+		return "", errors.New("storage is not configured")
+	}
+
+	params := url.Values{}
+	if filename != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+
+	presigned, err := s.client.PresignedGetObject(ctx, s.bucket, path, expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return presigned.String(), nil
+}
+
+// ObjectURL returns the public URL of path. The object is only reachable if
+// the bucket or the CDN in front of it serves it without signing.
+func (s *S3Storage) ObjectURL(path string) string {
+	if s == nil {
+		return ""
+	}
+	return s.publicURL + "/" + strings.TrimLeft(path, "/")
+}
+
 type readCloserWithCancel struct {
 	io.ReadCloser
 	cancel context.CancelFunc
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AccountLockoutRepository persists failed login counters and account locks.
+// It is the source of truth for locks and counts failures when Redis is
+// unavailable.
+type AccountLockoutRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*model.AccountLockout, error)
+	RecordFailure(ctx context.Context, userID string, window time.Duration) (int, error)
+	Lock(ctx context.Context, userID string, failedAttempts int, until time.Time) error
+	DeleteByUserID(ctx context.Context, userID string) error
+	ListLocked(ctx context.Context, now time.Time, limit, offset int) ([]*model.AccountLockout, int64, error)
+}
+
+type accountLockoutRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountLockoutRepository(db *gorm.DB) AccountLockoutRepository {
+	return &accountLockoutRepository{db: db}
+}
+
+func (r *accountLockoutRepository) FindByUserID(ctx context.Context, userID string) (*model.AccountLockout, error) {
+	var lockout model.AccountLockout
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&lockout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+// RecordFailure counts a failed login and returns the number of failures in
+// the current window. The counter restarts when the previous failure is older
+// than window.
+func (r *accountLockoutRepository) RecordFailure(ctx context.Context, userID string, window time.Duration) (int, error) {
+	now := time.Now()
+	var failedAttempts int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO account_lockouts (user_id, failed_attempts, last_failed_at, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN account_lockouts.last_failed_at < ? THEN 1
+				ELSE account_lockouts.failed_attempts + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING failed_attempts`,
+		userID, now, now, now.Add(-window),
+	).Scan(&failedAttempts).Error
+	return failedAttempts, err
+}
+
+func (r *accountLockoutRepository) Lock(ctx context.Context, userID string, failedAttempts int, until time.Time) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO account_lockouts (user_id, failed_attempts, last_failed_at, locked_until, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			failed_attempts = GREATEST(account_lockouts.failed_attempts, EXCLUDED.failed_attempts),
+			last_failed_at = EXCLUDED.last_failed_at,
+			locked_until = EXCLUDED.locked_until,
+			updated_at = EXCLUDED.updated_at`,
+		userID, failedAttempts, now, until, now,
+	).Error
+}
+
+func (r *accountLockoutRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.AccountLockout{}).Error
+}
+
+// ListLocked returns accounts whose lock has not expired, most recently
+// failed first.
+func (r *accountLockoutRepository) ListLocked(ctx context.Context, now time.Time, limit, offset int) ([]*model.AccountLockout, int64, error) {
+	var lockouts []*model.AccountLockout
+	var totalCount int64
+
+	query := r.db.WithContext(ctx).Model(&model.AccountLockout{}).Where("locked_until > ?", now)
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "email", "username") }).
+		Order("last_failed_at DESC").Offset(offset).Limit(limit).
+		Find(&lockouts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return lockouts, totalCount, nil
+}
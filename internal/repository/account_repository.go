@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AccountRepository covers a user's own account lifecycle: exporting their
+// data and erasing the account.
+type AccountRepository interface {
+	// SetDeletionSchedule sets or, with nil, clears when the account is erased.
+	SetDeletionSchedule(ctx context.Context, userID string, at *time.Time) error
+	ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]string, error)
+	// ListFilePaths returns the storage paths of the files the user uploaded,
+	// including soft-deleted ones.
+	ListFilePaths(ctx context.Context, userID string) ([]string, error)
+	// Erase permanently deletes the user. Their content is removed by the
+	// ON DELETE CASCADE foreign keys; auth activity, which is kept for
+	// other deleted accounts, is deleted explicitly.
+	Erase(ctx context.Context, userID string) error
+	ExportData(ctx context.Context, userID string) (*dto.AccountData, error)
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) SetDeletionSchedule(ctx context.Context, userID string, at *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("deletion_scheduled_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *accountRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *accountRepository) ListFilePaths(ctx context.Context, userID string) ([]string, error) {
+	var paths []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.File{}).
+		Where("created_by = ? AND path IS NOT NULL AND path <> ''", userID).
+		Pluck("path", &paths).Error
+	return paths, err
+}
+
+func (r *accountRepository) Erase(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.AuthActivityLog{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", userID).Delete(&model.User{}).Error
+	})
+}
+
+func (r *accountRepository) ExportData(ctx context.Context, userID string) (*dto.AccountData, error) {
+	db := r.db.WithContext(ctx)
+	data := &dto.AccountData{}
+
+	var user model.User
+	if err := db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	data.User = &user
+
+	queries := []struct {
+		dest  any
+		query *gorm.DB
+	}{
+		{&data.Posts, db.Preload("Tags").Where("created_by = ?", userID).Order("created_at")},
+		{&data.Comments, db.Where("created_by = ?", userID).Order("created_at")},
+		{&data.Likes, db.Where("user_id = ?", userID).Order("created_at")},
+		{&data.BookmarkFolders, db.Where("user_id = ?", userID).Order("created_at")},
+		{&data.Bookmarks, db.Where("user_id = ?", userID).Order("created_at")},
+		{&data.Followers, db.Preload("Follower", preloadUserBrief).Where("following_id = ?", userID).Order("created_at")},
+		{&data.Following, db.Preload("Following", preloadUserBrief).Where("follower_id = ?", userID).Order("created_at")},
+		{&data.Notifications, db.Where("user_id = ?", userID).Order("created_at")},
+		{&data.Conversations, db.Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		}).Where("user_id = ?", userID).Order("created_at")},
+		{&data.Holdings, db.Preload("HoldingType").Where("user_id = ?", userID).Order("year, month, id")},
+		{&data.AuthActivity, db.Where("user_id = ?", userID).Order("created_at")},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AdminAuditLogFilter narrows an audit log listing. Zero values are ignored.
+type AdminAuditLogFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+type AdminAuditLogRepository interface {
+	Create(ctx context.Context, log *model.AdminAuditLog) error
+	List(ctx context.Context, filter AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error)
+}
+
+type adminAuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminAuditLogRepository(db *gorm.DB) AdminAuditLogRepository {
+	return &adminAuditLogRepository{db: db}
+}
+
+func (r *adminAuditLogRepository) Create(ctx context.Context, log *model.AdminAuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+func (r *adminAuditLogRepository) List(ctx context.Context, filter AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error) {
+	var logs []*model.AdminAuditLog
+	var totalCount int64
+
+	query := r.db.WithContext(ctx).Model(&model.AdminAuditLog{})
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Preload("Actor", preloadUserBrief).
+		Order("created_at DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, totalCount, nil
+}
@@ -61,7 +61,7 @@ func (r *authActivityLogRepository) GetFailedLogins(ctx context.Context, since t
 	var totalCount int64
 
 	query := r.db.WithContext(ctx).Model(&model.AuthActivityLog{}).
-		Where("activity_type IN ? AND created_at >= ?", []string{model.ActivityLoginFailed}, since)
+		Where("activity_type IN ? AND created_at >= ?", []string{model.ActivityLoginFailed, model.ActivityAccountLocked}, since)
 
 	if err := query.Count(&totalCount).Error; err != nil {
 		return nil, 0, err
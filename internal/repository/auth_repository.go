@@ -14,7 +14,6 @@ import (
 type AuthRepository interface {
 	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
 	FindUserByIdentifier(ctx context.Context, identifier string) (*model.User, error)
-	FindUserByGithubID(ctx context.Context, githubID int64) (*model.User, error)
 	CreateUser(ctx context.Context, user *model.User) error
 }
 
@@ -62,15 +61,3 @@ func (r *authRepository) CreateUser(ctx context.Context, user *model.User) error
 	}
 	return nil
 }
-
-func (r *authRepository) FindUserByGithubID(ctx context.Context, githubID int64) (*model.User, error) {
-	var user model.User
-	err := r.db.WithContext(ctx).Where("github_id = ?", githubID).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, apperrors.ErrUserNotFound
-		}
-		return nil, err
-	}
-	return &user, nil
-}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *model.DataExport) error
+	FindByID(ctx context.Context, id string) (*model.DataExport, error)
+	FindLatestByUserID(ctx context.Context, userID string) (*model.DataExport, error)
+	Update(ctx context.Context, export *model.DataExport) error
+	// ListExpired returns exports whose archive is still stored past expiry.
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.DataExport, error)
+	ListStoredByUserID(ctx context.Context, userID string) ([]*model.DataExport, error)
+}
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *dataExportRepository) FindByID(ctx context.Context, id string) (*model.DataExport, error) {
+	var export model.DataExport
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) FindLatestByUserID(ctx context.Context, userID string) (*model.DataExport, error) {
+	var export model.DataExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *model.DataExport) error {
+	return r.db.WithContext(ctx).Model(export).
+		Select("Status", "FilePath", "FileSize", "ErrorMessage", "ExpiresAt", "CompletedAt").
+		Updates(export).Error
+}
+
+func (r *dataExportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	err := r.db.WithContext(ctx).
+		Where("file_path IS NOT NULL AND expires_at <= ?", now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *dataExportRepository) ListStoredByUserID(ctx context.Context, userID string) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	err := r.db.WithContext(ctx).Where("user_id = ? AND file_path IS NOT NULL", userID).Find(&exports).Error
+	return exports, err
+}
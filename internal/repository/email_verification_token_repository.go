@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTokenRepository interface {
+	Create(ctx context.Context, token *model.EmailVerificationToken) error
+	FindByToken(ctx context.Context, token string) (*model.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type emailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationTokenRepository(db *gorm.DB) EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+func (r *emailVerificationTokenRepository) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *emailVerificationTokenRepository) FindByToken(ctx context.Context, token string) (*model.EmailVerificationToken, error) {
+	var evt model.EmailVerificationToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&evt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &evt, nil
+}
+
+// MarkUsed consumes the token and reports whether this call was the one that
+// did so. Two concurrent requests with the same link cannot both succeed.
+func (r *emailVerificationTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *emailVerificationTokenRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.EmailVerificationToken{}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KnownDeviceRepository interface {
+	// Touch marks a known device as seen now and reports whether the user has
+	// signed in with this fingerprint before.
+	Touch(ctx context.Context, userID, fingerprint, ipAddress string) (bool, error)
+	// HasAny reports whether any device has been recorded for the user.
+	HasAny(ctx context.Context, userID string) (bool, error)
+	// Add records a new device and reports whether this call inserted it.
+	Add(ctx context.Context, device *model.KnownDevice) (bool, error)
+	Delete(ctx context.Context, userID, fingerprint string) error
+}
+
+type knownDeviceRepository struct {
+	db *gorm.DB
+}
+
+func NewKnownDeviceRepository(db *gorm.DB) KnownDeviceRepository {
+	return &knownDeviceRepository{db: db}
+}
+
+func (r *knownDeviceRepository) Touch(ctx context.Context, userID, fingerprint, ipAddress string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.KnownDevice{}).
+		Where("user_id = ? AND fingerprint = ?", userID, fingerprint).
+		Updates(map[string]any{"last_seen_at": time.Now(), "ip_address": ipAddress})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *knownDeviceRepository) HasAny(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.KnownDevice{}).
+		Where("user_id = ?", userID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Add ignores a device inserted concurrently by another sign-in, so only one
+// of them reports it as new.
+func (r *knownDeviceRepository) Add(ctx context.Context, device *model.KnownDevice) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(device)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *knownDeviceRepository) Delete(ctx context.Context, userID, fingerprint string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND fingerprint = ?", userID, fingerprint).
+		Delete(&model.KnownDevice{}).Error
+}
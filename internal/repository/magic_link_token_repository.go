@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type MagicLinkTokenRepository interface {
+	Create(ctx context.Context, token *model.MagicLinkToken) error
+	FindByToken(ctx context.Context, token string) (*model.MagicLinkToken, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type magicLinkTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkTokenRepository(db *gorm.DB) MagicLinkTokenRepository {
+	return &magicLinkTokenRepository{db: db}
+}
+
+func (r *magicLinkTokenRepository) Create(ctx context.Context, token *model.MagicLinkToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *magicLinkTokenRepository) FindByToken(ctx context.Context, token string) (*model.MagicLinkToken, error) {
+	var mlt model.MagicLinkToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&mlt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mlt, nil
+}
+
+// MarkUsed consumes the token and reports whether this call was the one that
+// did so. Two concurrent requests with the same link cannot both succeed.
+func (r *magicLinkTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *magicLinkTokenRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.MagicLinkToken{}).Error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	ListByUserID(ctx context.Context, userID string) ([]*model.PersonalAccessToken, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	Delete(ctx context.Context, userID, id string) (bool, error)
+	TouchLastUsed(ctx context.Context, id, ipAddress string, usedAt time.Time) error
+}
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByTokenHash loads the token with its owner. User is nil when the owner
+// has been soft-deleted.
+func (r *personalAccessTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "email", "username", "is_super_admin", "suspended_at", "suspended_until", "suspension_reason")
+		}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) ListByUserID(ctx context.Context, userID string) ([]*model.PersonalAccessToken, error) {
+	var tokens []*model.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *personalAccessTokenRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Delete revokes a token owned by userID and reports whether one was removed.
+func (r *personalAccessTokenRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&model.PersonalAccessToken{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *personalAccessTokenRepository) TouchLastUsed(ctx context.Context, id, ipAddress string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_used_at": usedAt, "last_used_ip": ipAddress}).Error
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
 	"echobackend/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository interface {
@@ -17,16 +19,36 @@ type PostRepository interface {
 	CreatePostWithTags(ctx context.Context, post *model.Post, tags []model.Tag) (*model.Post, error)
 	GetPosts(ctx context.Context, limit int, offset int) ([]*model.Post, int64, error)
 	GetPostsFiltered(ctx context.Context, filter *dto.PostQueryFilter) ([]*model.Post, int64, error)
-	GetPostByUsername(ctx context.Context, username string, offset int, limit int) ([]*model.Post, int64, error)
+	// GetPostByUsername and GetPostBySlugAndUsername only return posts of a
+	// private author to the author and their approved followers; viewerID may
+	// be empty.
+	GetPostByUsername(ctx context.Context, username string, viewerID string, offset int, limit int) ([]*model.Post, int64, error)
 	GetPostsRandom(ctx context.Context, limit int) ([]*model.Post, error)
 	GetPostsTrending(ctx context.Context, limit int) ([]*model.Post, error)
 	GetPostByID(ctx context.Context, id string) (*model.Post, error)
-	GetPostBySlugAndUsername(ctx context.Context, slug string, username string) (*model.Post, error)
+	GetPostBySlugAndUsername(ctx context.Context, slug string, username string, viewerID string) (*model.Post, error)
 	GetPostsByCreatedBy(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error)
+	// GetScheduledByCreatedBy returns the author's posts waiting to be
+	// published, soonest first.
+	GetScheduledByCreatedBy(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error)
+	// PublishScheduled publishes the post if it is still scheduled for
+	// publishAt, and reports whether it did. A post that was rescheduled,
+	// unscheduled or already published is left alone.
+	PublishScheduled(ctx context.Context, id string, publishAt time.Time) (bool, error)
+	// PublishDue publishes every post scheduled for now or earlier and
+	// returns how many there were.
+	PublishDue(ctx context.Context, now time.Time) (int64, error)
 	DeletePostByID(ctx context.Context, id string) error
-	UpdatePost(ctx context.Context, id string, updates map[string]any) (*model.Post, error)
+	// UpdatePost applies updates to the post and, unless tags is nil,
+	// replaces its tags. When the title, body or tags change, the new content
+	// is stored as the post's next revision; only the EditorID and
+	// RestoredFrom of revision are used.
+	UpdatePost(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error)
 	GetPostsForSitemap(ctx context.Context, limit int) ([]*dto.SitemapPost, error)
-	SearchPosts(ctx context.Context, keyword string, limit int, offset int) ([]*model.Post, int64, error)
+	// SearchPosts returns published posts matching the to_tsquery expression
+	// tsquery, best match first. The tag, author, date and pagination fields
+	// of filter apply; its search and sort fields are ignored.
+	SearchPosts(ctx context.Context, tsquery string, filter *dto.PostQueryFilter) ([]*dto.PostSearchHit, int64, error)
 	GetPostsByTag(ctx context.Context, tag string, limit int, offset int) ([]*model.Post, int64, error)
 	GetPostsForYou(ctx context.Context, userID string, offset int, limit int) ([]*model.Post, int64, error)
 	ExistsByID(ctx context.Context, id string) (bool, error)
@@ -49,9 +71,14 @@ func (r *postRepository) CreatePost(ctx context.Context, post *model.Post) error
 func (r *postRepository) CreatePostWithTags(ctx context.Context, post *model.Post, tags []model.Tag) (*model.Post, error) {
 	post.Tags = tags
 
-	err := r.db.WithContext(ctx).Create(post).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return fmt.Errorf("failed to create post with tags: %w", err)
+		}
+		return recordRevision(tx, post, &model.PostRevision{EditorID: post.CreatedBy})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create post with tags: %w", err)
+		return nil, err
 	}
 
 	err = r.db.WithContext(ctx).Preload("User", preloadUserBrief).Preload("Tags").First(post, "id = ?", post.ID).Error
@@ -62,50 +89,63 @@ func (r *postRepository) CreatePostWithTags(ctx context.Context, post *model.Pos
 	return post, nil
 }
 
-func (r *postRepository) UpdatePost(ctx context.Context, id string, updates map[string]any) (*model.Post, error) {
-	if len(updates) == 0 {
-		var currentPost model.Post
-		err := r.db.WithContext(ctx).Preload("User", preloadUserBrief).Preload("Tags").First(&currentPost, "id = ?", id).Error
+func (r *postRepository) UpdatePost(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+	var updatedPost model.Post
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the post orders concurrent edits, so each gets its own
+		// revision number.
+		var current model.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&current, "id = ?", id).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, apperrors.ErrPostNotFound
+				return apperrors.ErrPostNotFound
 			}
-			return nil, fmt.Errorf("failed to fetch post (no updates provided): %w", err)
+			return fmt.Errorf("failed to lock post: %w", err)
 		}
-		return &currentPost, nil
-	}
 
-	result := r.db.WithContext(ctx).Model(&model.Post{}).Where("id = ?", id).Updates(updates)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to update post: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return nil, apperrors.ErrPostNotFound
-	}
+		if len(updates) > 0 {
+			if err := tx.Model(&model.Post{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update post: %w", err)
+			}
+		}
+		if tags != nil {
+			association := tx.Model(&model.Post{ID: id}).Association("Tags")
+			if len(tags) == 0 {
+				err = association.Clear()
+			} else {
+				err = association.Replace(tags)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update post tags: %w", err)
+			}
+		}
 
-	var updatedPost model.Post
-	err := r.db.WithContext(ctx).Preload("User", preloadUserBrief).Preload("Tags").First(&updatedPost, "id = ?", id).Error
+		if err := tx.Preload("User", preloadUserBrief).Preload("Tags").First(&updatedPost, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("post updated, but failed to retrieve updated record: %w", err)
+		}
+		return recordRevision(tx, &updatedPost, revision)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("post updated, but failed to retrieve updated record: %w", err)
+		return nil, err
 	}
 
 	return &updatedPost, nil
 }
 
-func (r *postRepository) GetPostByUsername(ctx context.Context, username string, offset int, limit int) ([]*model.Post, int64, error) {
+func (r *postRepository) GetPostByUsername(ctx context.Context, username string, viewerID string, offset int, limit int) ([]*model.Post, int64, error) {
 	var posts []*model.Post
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&model.Post{}).
+	query := visibleAuthors(r.db.WithContext(ctx).Model(&model.Post{}).
 		Joins("JOIN users ON users.id = posts.created_by").
-		Where("users.username = ? AND users.deleted_at IS NULL", username)
+		Where("users.username = ? AND users.deleted_at IS NULL", username), viewerID)
 
 	err := query.Count(&count).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count posts for username %s: %w", username, err)
 	}
 
-	err = r.db.WithContext(ctx).Model(&model.Post{}).
+	err = visibleAuthors(r.db.WithContext(ctx).Model(&model.Post{}), viewerID).
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Joins("JOIN users ON users.id = posts.created_by").
@@ -137,14 +177,14 @@ func (r *postRepository) GetPosts(ctx context.Context, limit int, offset int) ([
 	var posts []*model.Post
 	var count int64
 
-	err := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	err := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
 		Where("posts.published = ?", true).
 		Count(&count).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
 	}
 
-	err = activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	err = visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Where("posts.published = ?", true).
@@ -159,9 +199,9 @@ func (r *postRepository) GetPosts(ctx context.Context, limit int, offset int) ([
 	return posts, count, nil
 }
 
-func (r *postRepository) GetPostBySlugAndUsername(ctx context.Context, slug string, username string) (*model.Post, error) {
+func (r *postRepository) GetPostBySlugAndUsername(ctx context.Context, slug string, username string, viewerID string) (*model.Post, error) {
 	var post model.Post
-	err := r.db.WithContext(ctx).
+	err := visibleAuthors(r.db.WithContext(ctx), viewerID).
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Joins("JOIN users ON users.id = posts.created_by").
@@ -196,7 +236,7 @@ func (r *postRepository) GetPostByID(ctx context.Context, id string) (*model.Pos
 
 func (r *postRepository) GetPostsRandom(ctx context.Context, limit int) ([]*model.Post, error) {
 	var randomPosts []*model.Post
-	err := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	err := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Where("posts.published = ?", true).
@@ -213,7 +253,7 @@ func (r *postRepository) GetPostsRandom(ctx context.Context, limit int) ([]*mode
 func (r *postRepository) GetPostsTrending(ctx context.Context, limit int) ([]*model.Post, error) {
 	var posts []*model.Post
 
-	err := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	err := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Where("posts.published = ?", true).
@@ -252,6 +292,59 @@ func (r *postRepository) GetPostsByCreatedBy(ctx context.Context, createdBy stri
 	return posts, count, nil
 }
 
+func (r *postRepository) GetScheduledByCreatedBy(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error) {
+	var posts []*model.Post
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("created_by = ? AND publish_at IS NOT NULL AND published = ?", createdBy, false)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count scheduled posts: %w", err)
+	}
+
+	err := query.
+		Preload("User", preloadUserBrief).
+		Preload("Tags").
+		Order("publish_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get scheduled posts: %w", err)
+	}
+	return posts, count, nil
+}
+
+// publishScheduledColumns publishes a scheduled post as of its schedule.
+// Postgres reads publish_at before the row is changed.
+func publishScheduledColumns() map[string]any {
+	return map[string]any{
+		"published":    true,
+		"published_at": gorm.Expr("publish_at"),
+		"publish_at":   nil,
+	}
+}
+
+func (r *postRepository) PublishScheduled(ctx context.Context, id string, publishAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("id = ? AND publish_at = ? AND published = ?", id, publishAt, false).
+		UpdateColumns(publishScheduledColumns())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to publish scheduled post: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("publish_at <= ? AND published = ?", now, false).
+		UpdateColumns(publishScheduledColumns())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to publish due posts: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 func (r *postRepository) GetPostsForYou(ctx context.Context, userID string, offset int, limit int) ([]*model.Post, int64, error) {
 	var posts []*model.Post
 	var count int64
@@ -262,21 +355,21 @@ func (r *postRepository) GetPostsForYou(ctx context.Context, userID string, offs
 
 	followingIDs := r.db.WithContext(ctx).Model(&model.UserFollow{}).
 		Select("following_id").
-		Where("follower_id = ?", userID)
+		Where("follower_id = ? AND status = ?", userID, model.FollowStatusApproved)
 
-	base := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	base := excludeHiddenAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), userID).
 		Where("posts.published = ?", true).
-		Where("posts.created_by = ? OR posts.created_by IN (?)", userID, followingIDs)
+		Where("(posts.created_by = ? OR posts.created_by IN (?))", userID, followingIDs)
 
 	if err := base.Count(&count).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count for-you posts: %w", err)
 	}
 
-	err := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	err := excludeHiddenAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), userID).
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Where("posts.published = ?", true).
-		Where("posts.created_by = ? OR posts.created_by IN (?)", userID, followingIDs).
+		Where("(posts.created_by = ? OR posts.created_by IN (?))", userID, followingIDs).
 		Order("posts.created_at DESC").
 		Offset(offset).
 		Limit(limit).
@@ -288,37 +381,92 @@ func (r *postRepository) GetPostsForYou(ctx context.Context, userID string, offs
 	return posts, count, nil
 }
 
-func (r *postRepository) SearchPosts(ctx context.Context, keyword string, limit int, offset int) ([]*model.Post, int64, error) {
-	var posts []*model.Post
+// postHeadlineOptions configures the ts_headline snippets of search results.
+// Matches are delimited with the dto.HeadlineStartSel and HeadlineStopSel
+// control characters, which are stripped from the body first, so the service
+// can escape the snippet before marking them up.
+const postHeadlineOptions = "StartSel=\"" + dto.HeadlineStartSel + "\", StopSel=\"" + dto.HeadlineStopSel + "\", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" ... \""
+
+func (r *postRepository) SearchPosts(ctx context.Context, tsquery string, filter *dto.PostQueryFilter) ([]*dto.PostSearchHit, int64, error) {
+	matching := func() *gorm.DB {
+		query := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
+			Where("posts.published = ? AND posts.search_vector @@ to_tsquery('simple', ?)", true, tsquery)
+		if filter.StartDate != "" {
+			query = query.Where("posts.created_at >= ?", filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			query = query.Where("posts.created_at <= ?", filter.EndDate)
+		}
+		if filter.CreatedBy != "" {
+			query = query.Where("posts.created_by = ?", filter.CreatedBy)
+		}
+		if len(filter.Tags) > 0 {
+			query = query.Where(`EXISTS (
+				SELECT 1 FROM posts_to_tags JOIN tags ON tags.id = posts_to_tags.tag_id
+				WHERE posts_to_tags.post_id = posts.id AND tags.name IN ?
+			)`, filter.Tags)
+		}
+		return query
+	}
+
 	var count int64
-	likePattern := "%" + keyword + "%"
+	if err := matching().Count(&count).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts for search: %w", err)
+	}
+	if count == 0 {
+		return []*dto.PostSearchHit{}, 0, nil
+	}
 
-	err := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
-		Where("(posts.title ILIKE ? OR posts.body ILIKE ?) AND posts.published = ?", likePattern, likePattern, true).
-		Count(&count).Error
+	var ranked []struct {
+		ID       string
+		Rank     float64
+		Headline string
+	}
+	err := matching().
+		Select("posts.id, ts_rank(posts.search_vector, to_tsquery('simple', ?)) AS rank, ts_headline('simple', translate(COALESCE(posts.body, ''), ?, ''), to_tsquery('simple', ?), ?) AS headline", tsquery, dto.HeadlineStartSel+dto.HeadlineStopSel, tsquery, postHeadlineOptions).
+		Order("rank DESC, posts.created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&ranked).Error
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count posts for search: %w", err)
+		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+	if len(ranked) == 0 {
+		return []*dto.PostSearchHit{}, count, nil
 	}
 
-	err = activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	ids := make([]string, len(ranked))
+	for i, row := range ranked {
+		ids[i] = row.ID
+	}
+	var posts []*model.Post
+	err = r.db.WithContext(ctx).
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
-		Where("(posts.title ILIKE ? OR posts.body ILIKE ?) AND posts.published = ?", likePattern, likePattern, true).
-		Order("posts.created_at DESC").
-		Limit(limit).
-		Offset(offset).
+		Where("id IN ?", ids).
 		Find(&posts).Error
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+		return nil, 0, fmt.Errorf("failed to load searched posts: %w", err)
 	}
-	return posts, count, nil
+	byID := make(map[string]*model.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	hits := make([]*dto.PostSearchHit, 0, len(ranked))
+	for _, row := range ranked {
+		if post, ok := byID[row.ID]; ok {
+			hits = append(hits, &dto.PostSearchHit{Post: post, Rank: row.Rank, Headline: row.Headline})
+		}
+	}
+	return hits, count, nil
 }
 
 func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, limit int, offset int) ([]*model.Post, int64, error) {
 	var posts []*model.Post
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&model.Post{}).
+	query := visibleAuthors(r.db.WithContext(ctx).Model(&model.Post{}), "").
 		Joins("JOIN users ON users.id = posts.created_by AND users.deleted_at IS NULL").
 		Joins("JOIN posts_to_tags ON posts_to_tags.post_id = posts.id").
 		Joins("JOIN tags ON tags.id = posts_to_tags.tag_id").
@@ -329,7 +477,7 @@ func (r *postRepository) GetPostsByTag(ctx context.Context, tag string, limit in
 		return nil, 0, fmt.Errorf("failed to count posts by tag: %w", err)
 	}
 
-	err = r.db.WithContext(ctx).Model(&model.Post{}).
+	err = visibleAuthors(r.db.WithContext(ctx).Model(&model.Post{}), "").
 		Preload("User", preloadUserBrief).
 		Preload("Tags").
 		Joins("JOIN users ON users.id = posts.created_by AND users.deleted_at IS NULL").
@@ -359,13 +507,12 @@ func (r *postRepository) GetPostsFiltered(ctx context.Context, filter *dto.PostQ
 	var posts []*model.Post
 	var count int64
 
-	query := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})).
+	query := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "").
 		Preload("User", preloadUserBrief).
 		Preload("Tags")
 
 	if filter.Search != "" {
-		likePattern := "%" + filter.Search + "%"
-		query = query.Where("posts.title ILIKE ? AND posts.published = ?", likePattern, true)
+		query = query.Where("posts.search_vector @@ websearch_to_tsquery('simple', ?) AND posts.published = ?", filter.Search, true)
 	} else {
 		query = query.Where("posts.published = ?", true)
 	}
@@ -391,11 +538,10 @@ func (r *postRepository) GetPostsFiltered(ctx context.Context, filter *dto.PostQ
 			Where("tags.name IN ?", filter.Tags)
 	}
 
-	countQuery := activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{}))
+	countQuery := visibleAuthors(activePostUserJoin(r.db.WithContext(ctx).Model(&model.Post{})), "")
 
 	if filter.Search != "" {
-		likePattern := "%" + filter.Search + "%"
-		countQuery = countQuery.Where("posts.title ILIKE ? AND posts.published = ?", likePattern, true)
+		countQuery = countQuery.Where("posts.search_vector @@ websearch_to_tsquery('simple', ?) AND posts.published = ?", filter.Search, true)
 	} else {
 		countQuery = countQuery.Where("posts.published = ?", true)
 	}
@@ -444,7 +590,7 @@ func (r *postRepository) GetPostsForSitemap(ctx context.Context, limit int) ([]*
 		Table("posts").
 		Select("users.username, posts.slug, posts.created_at, posts.updated_at").
 		Joins("JOIN users ON users.id = posts.created_by").
-		Where("posts.published = ? AND users.deleted_at IS NULL", true).
+		Where("posts.published = ? AND users.deleted_at IS NULL AND users.suspended_at IS NULL AND users.is_private = ?", true, false).
 		Order("posts.created_at DESC").
 		Limit(limit).
 		Find(&sitemapPosts).Error
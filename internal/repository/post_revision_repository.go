@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PostRevisionRepository reads the history of posts. Revisions are written
+// by PostRepository in the same transaction as the edit they record.
+type PostRevisionRepository interface {
+	// List returns the revisions of a post, newest first, without bodies.
+	List(ctx context.Context, postID string, limit, offset int) ([]*model.PostRevision, int64, error)
+	GetByNumber(ctx context.Context, postID string, number int) (*model.PostRevision, error)
+}
+
+type postRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostRevisionRepository(db *gorm.DB) PostRevisionRepository {
+	return &postRevisionRepository{db: db}
+}
+
+func (r *postRevisionRepository) List(ctx context.Context, postID string, limit, offset int) ([]*model.PostRevision, int64, error) {
+	var revisions []*model.PostRevision
+	var count int64
+
+	query := r.db.WithContext(ctx).Model(&model.PostRevision{}).Where("post_id = ?", postID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count post revisions: %w", err)
+	}
+
+	err := query.
+		Select("id", "post_id", "number", "title", "tags", "editor_id", "restored_from", "created_at").
+		Preload("Editor", preloadUserBrief).
+		Order("number DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get post revisions: %w", err)
+	}
+	return revisions, count, nil
+}
+
+func (r *postRevisionRepository) GetByNumber(ctx context.Context, postID string, number int) (*model.PostRevision, error) {
+	var revision model.PostRevision
+	err := r.db.WithContext(ctx).
+		Preload("Editor", preloadUserBrief).
+		Where("post_id = ? AND number = ?", postID, number).
+		First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to get post revision: %w", err)
+	}
+	return &revision, nil
+}
+
+// recordRevision stores the title, body and tags of post as its next
+// revision, unless they match the latest one. The post must be locked by tx
+// or new.
+func recordRevision(tx *gorm.DB, post *model.Post, revision *model.PostRevision) error {
+	revision.PostID = post.ID
+	revision.Title, revision.Body = "", ""
+	if post.Title != nil {
+		revision.Title = *post.Title
+	}
+	if post.Body != nil {
+		revision.Body = *post.Body
+	}
+	revision.Tags = make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		revision.Tags = append(revision.Tags, tag.Name)
+	}
+	slices.Sort(revision.Tags)
+
+	var latest model.PostRevision
+	err := tx.Where("post_id = ?", post.ID).Order("number DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return fmt.Errorf("failed to get latest post revision: %w", err)
+	}
+	if latest.ID != "" && latest.Title == revision.Title && latest.Body == revision.Body && slices.Equal(latest.Tags, revision.Tags) {
+		return nil
+	}
+
+	revision.Number = latest.Number + 1
+	if err := tx.Create(revision).Error; err != nil {
+		return fmt.Errorf("failed to record post revision: %w", err)
+	}
+	return nil
+}
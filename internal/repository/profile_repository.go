@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProfileRepository interface {
+	// Update sets userColumns on the user and profileColumns on their
+	// profile in one transaction, creating the profile if there is none.
+	// Making the account public approves its pending follow requests.
+	Update(ctx context.Context, userID string, userColumns, profileColumns map[string]any) error
+	// SetImage replaces the user's image and returns the previous one.
+	SetImage(ctx context.Context, userID, image string) (*string, error)
+}
+
+type profileRepository struct {
+	db *gorm.DB
+}
+
+func NewProfileRepository(db *gorm.DB) ProfileRepository {
+	return &profileRepository{db: db}
+}
+
+func (r *profileRepository) Update(ctx context.Context, userID string, userColumns, profileColumns map[string]any) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(userColumns) > 0 {
+			result := tx.Model(&model.User{}).Where("id = ?", userID).Updates(userColumns)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return apperrors.ErrUserNotFound
+			}
+		}
+		if isPrivate, ok := userColumns["is_private"]; ok && isPrivate == false {
+			err := tx.Model(&model.UserFollow{}).
+				Where("following_id = ? AND status = ?", userID, model.FollowStatusPending).
+				Update("status", model.FollowStatusApproved).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(profileColumns) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		updateColumns := append(slices.Sorted(maps.Keys(profileColumns)), "updated_at")
+		row := maps.Clone(profileColumns)
+		row["user_id"] = userID
+		row["created_at"] = now
+		row["updated_at"] = now
+		return tx.Model(&model.Profile{}).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns(updateColumns),
+		}).Create(row).Error
+	})
+}
+
+func (r *profileRepository) SetImage(ctx context.Context, userID, image string) (*string, error) {
+	var previous *string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "image").Where("id = ?", userID).First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return err
+		}
+		previous = user.Image
+		return tx.Model(&user).Update("image", image).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
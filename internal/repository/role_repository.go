@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	FindByName(ctx context.Context, name string) (*model.Role, error)
+	ListUserRoles(ctx context.Context, userID string) ([]*model.Role, error)
+	ListUserPermissions(ctx context.Context, userID string) ([]string, error)
+	CountUsersWithRole(ctx context.Context, roleID string) (int64, error)
+	AssignRole(ctx context.Context, userID string, role *model.Role, assignedBy string) (bool, error)
+	RemoveRole(ctx context.Context, userID string, role *model.Role) (bool, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := r.db.WithContext(ctx).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("permissions.name")
+		}).
+		Order("name").
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) ListUserRoles(ctx context.Context, userID string) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := r.db.WithContext(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// ListUserPermissions returns the union of the permissions of every role the
+// user holds.
+func (r *roleRepository) ListUserPermissions(ctx context.Context, userID string) ([]string, error) {
+	var permissions []string
+	err := r.db.WithContext(ctx).
+		Table("role_permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Distinct().
+		Order("role_permissions.permission_name").
+		Pluck("role_permissions.permission_name", &permissions).Error
+	return permissions, err
+}
+
+// CountUsersWithRole counts active (not soft-deleted) holders of a role.
+func (r *roleRepository) CountUsersWithRole(ctx context.Context, roleID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserRole{}).
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Where("user_roles.role_id = ?", roleID).
+		Count(&count).Error
+	return count, err
+}
+
+// AssignRole grants role to the user and reports whether it was newly granted.
+// Granting the admin role also sets users.is_super_admin.
+func (r *roleRepository) AssignRole(ctx context.Context, userID string, role *model.Role, assignedBy string) (bool, error) {
+	var assigned bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRole := &model.UserRole{UserID: userID, RoleID: role.ID}
+		if assignedBy != "" {
+			userRole.AssignedBy = &assignedBy
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(userRole)
+		if result.Error != nil {
+			return result.Error
+		}
+		assigned = result.RowsAffected > 0
+
+		if role.Name == model.RoleAdmin {
+			return tx.Model(&model.User{}).Where("id = ?", userID).Update("is_super_admin", true).Error
+		}
+		return nil
+	})
+	return assigned, err
+}
+
+// RemoveRole revokes role from the user and reports whether it was held.
+// Removing the admin role also clears users.is_super_admin.
+func (r *roleRepository) RemoveRole(ctx context.Context, userID string, role *model.Role) (bool, error) {
+	var removed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND role_id = ?", userID, role.ID).Delete(&model.UserRole{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+
+		if role.Name == model.RoleAdmin {
+			return tx.Model(&model.User{}).Where("id = ?", userID).Update("is_super_admin", false).Error
+		}
+		return nil
+	})
+	return removed, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"echobackend/internal/model"
 
@@ -12,7 +13,12 @@ import (
 type SessionRepository interface {
 	CreateSession(ctx context.Context, s *model.Session) error
 	GetSessionByRefreshToken(ctx context.Context, token string) (*model.Session, error)
+	ListActiveByUserID(ctx context.Context, userID string) ([]*model.Session, error)
+	RotateSession(ctx context.Context, oldToken string, next *model.Session) (bool, error)
 	DeleteSession(ctx context.Context, token string) error
+	DeleteFamily(ctx context.Context, familyID string) error
+	DeleteFamilyForUser(ctx context.Context, familyID, userID string) (bool, error)
+	DeleteOthersByUserID(ctx context.Context, userID, keepFamilyID string) (int64, error)
 	DeleteByUserID(ctx context.Context, userID string) error
 	UpdateSession(ctx context.Context, s *model.Session) error
 }
@@ -25,8 +31,17 @@ func NewSessionRepository(db *gorm.DB) SessionRepository {
 	return &sessionRepository{db: db}
 }
 
+// CreateSession inserts a session. When FamilyID is empty the session starts a
+// new family whose ID is the session's own ID.
 func (r *sessionRepository) CreateSession(ctx context.Context, s *model.Session) error {
-	return r.db.WithContext(ctx).Create(s).Error
+	db := r.db.WithContext(ctx)
+	if s.FamilyID == "" {
+		if err := db.Raw("SELECT uuidv7()").Scan(&s.ID).Error; err != nil {
+			return err
+		}
+		s.FamilyID = s.ID
+	}
+	return db.Create(s).Error
 }
 
 func (r *sessionRepository) GetSessionByRefreshToken(ctx context.Context, token string) (*model.Session, error) {
@@ -37,10 +52,74 @@ func (r *sessionRepository) GetSessionByRefreshToken(ctx context.Context, token
 	return &sess, nil
 }
 
+// ListActiveByUserID returns the current (unrotated, unexpired) token of each
+// of the user's session families, most recently used first.
+func (r *sessionRepository) ListActiveByUserID(ctx context.Context, userID string) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND rotated_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("COALESCE(last_used_at, created_at) DESC NULLS LAST").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RotateSession marks the session holding oldToken as rotated and inserts next
+// as its child in one transaction. It returns false without inserting when
+// oldToken was already rotated, which callers must treat as token reuse.
+func (r *sessionRepository) RotateSession(ctx context.Context, oldToken string, next *model.Session) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Session{}).
+			Where("refresh_token = ? AND rotated_at IS NULL", oldToken).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
 func (r *sessionRepository) DeleteSession(ctx context.Context, token string) error {
 	return r.db.WithContext(ctx).Where("refresh_token = ?", token).Delete(&model.Session{}).Error
 }
 
+func (r *sessionRepository) DeleteFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Where("family_id = ?", familyID).Delete(&model.Session{}).Error
+}
+
+func (r *sessionRepository) DeleteFamilyForUser(ctx context.Context, familyID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("family_id = ? AND user_id = ?", familyID, userID).Delete(&model.Session{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteOthersByUserID revokes every session family of the user except
+// keepFamilyID and returns how many families were revoked.
+func (r *sessionRepository) DeleteOthersByUserID(ctx context.Context, userID, keepFamilyID string) (int64, error) {
+	var revoked int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Session{}).
+			Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).
+			Distinct("family_id").
+			Count(&revoked).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).Delete(&model.Session{}).Error
+	})
+	return revoked, err
+}
+
 func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID string) error {
 	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Session{}).Error
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SessionRevokeTokenRepository interface {
+	Create(ctx context.Context, token *model.SessionRevokeToken) error
+	FindByToken(ctx context.Context, token string) (*model.SessionRevokeToken, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+}
+
+type sessionRevokeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRevokeTokenRepository(db *gorm.DB) SessionRevokeTokenRepository {
+	return &sessionRevokeTokenRepository{db: db}
+}
+
+func (r *sessionRevokeTokenRepository) Create(ctx context.Context, token *model.SessionRevokeToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *sessionRevokeTokenRepository) FindByToken(ctx context.Context, token string) (*model.SessionRevokeToken, error) {
+	var srt model.SessionRevokeToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&srt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &srt, nil
+}
+
+// MarkUsed consumes the token and reports whether this call was the one that
+// did so.
+func (r *sessionRevokeTokenRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.SessionRevokeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwoFactorRepository stores TOTP secrets and hashed recovery codes.
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*model.UserTwoFactor, error)
+	UpsertPending(ctx context.Context, tf *model.UserTwoFactor) error
+	Confirm(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	AdvanceLastUsedStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) FindByUserID(ctx context.Context, userID string) (*model.UserTwoFactor, error) {
+	var tf model.UserTwoFactor
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// UpsertPending stores a new unconfirmed secret, replacing any earlier
+// enrollment attempt that was never confirmed.
+func (r *twoFactorRepository) UpsertPending(ctx context.Context, tf *model.UserTwoFactor) error {
+	tf.ConfirmedAt = nil
+	tf.LastUsedStep = 0
+	tf.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "confirmed_at", "updated_at"}),
+	}).Create(tf).Error
+}
+
+// Confirm marks the pending secret as active and replaces any previous
+// recovery codes in a single transaction.
+func (r *twoFactorRepository) Confirm(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&model.UserTwoFactor{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]any{"confirmed_at": now, "last_used_step": step, "updated_at": now}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.UserRecoveryCode, 0, len(recoveryCodeHashes))
+		for _, hash := range recoveryCodeHashes {
+			codes = append(codes, model.UserRecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// AdvanceLastUsedStep records the TOTP time step that was just accepted. It
+// returns false when the step (or a later one) was already used, so the same
+// code cannot be replayed within its validity window.
+func (r *twoFactorRepository) AdvanceLastUsedStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]any{"last_used_step": step, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as consumed. It returns false
+// when no matching unused code exists.
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *twoFactorRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserTwoFactor{}).Error
+	})
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserBlockRepository stores blocks and mutes. Both are idempotent: blocking
+// twice or unmuting someone who is not muted is not an error.
+type UserBlockRepository interface {
+	Block(ctx context.Context, blockerID, blockedID string) error
+	Unblock(ctx context.Context, blockerID, blockedID string) error
+	// IsBlockedBetween reports whether either user has blocked the other.
+	IsBlockedBetween(ctx context.Context, userID1, userID2 string) (bool, error)
+	GetBlocked(ctx context.Context, blockerID string, limit, offset int) ([]*model.User, int64, error)
+
+	Mute(ctx context.Context, muterID, mutedID string) error
+	Unmute(ctx context.Context, muterID, mutedID string) error
+	IsMuted(ctx context.Context, muterID, mutedID string) (bool, error)
+	GetMuted(ctx context.Context, muterID string, limit, offset int) ([]*model.User, int64, error)
+}
+
+type userBlockRepository struct {
+	db *gorm.DB
+}
+
+func NewUserBlockRepository(db *gorm.DB) UserBlockRepository {
+	return &userBlockRepository{db: db}
+}
+
+func (r *userBlockRepository) Block(ctx context.Context, blockerID, blockedID string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserBlock{BlockerID: blockerID, BlockedID: blockedID}).Error
+}
+
+func (r *userBlockRepository) Unblock(ctx context.Context, blockerID, blockedID string) error {
+	return r.db.WithContext(ctx).Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Delete(&model.UserBlock{}).Error
+}
+
+func (r *userBlockRepository) IsBlockedBetween(ctx context.Context, userID1, userID2 string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID1, userID2, userID2, userID1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userBlockRepository) GetBlocked(ctx context.Context, blockerID string, limit, offset int) ([]*model.User, int64, error) {
+	return r.listUsers(ctx, "user_blocks", "blocked_id", "blocker_id", blockerID, limit, offset)
+}
+
+func (r *userBlockRepository) Mute(ctx context.Context, muterID, mutedID string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserMute{MuterID: muterID, MutedID: mutedID}).Error
+}
+
+func (r *userBlockRepository) Unmute(ctx context.Context, muterID, mutedID string) error {
+	return r.db.WithContext(ctx).Where("muter_id = ? AND muted_id = ?", muterID, mutedID).
+		Delete(&model.UserMute{}).Error
+}
+
+func (r *userBlockRepository) IsMuted(ctx context.Context, muterID, mutedID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserMute{}).
+		Where("muter_id = ? AND muted_id = ?", muterID, mutedID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userBlockRepository) GetMuted(ctx context.Context, muterID string, limit, offset int) ([]*model.User, int64, error) {
+	return r.listUsers(ctx, "user_mutes", "muted_id", "muter_id", muterID, limit, offset)
+}
+
+// listUsers returns the users in targetColumn of table for rows whose
+// ownerColumn is ownerID, most recent first.
+func (r *userBlockRepository) listUsers(ctx context.Context, table, targetColumn, ownerColumn, ownerID string, limit, offset int) ([]*model.User, int64, error) {
+	var users []*model.User
+	var total int64
+
+	if err := r.db.WithContext(ctx).Table(table).
+		Where(ownerColumn+" = ?", ownerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Joins("JOIN "+table+" ON users.id = "+table+"."+targetColumn).
+		Where(table+"."+ownerColumn+" = ?", ownerID).
+		Order(table + ".created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+// excludeHiddenAuthors drops posts by users that userID blocked, was blocked
+// by or muted.
+func excludeHiddenAuthors(query *gorm.DB, userID string) *gorm.DB {
+	return query.Where(`posts.created_by NOT IN (
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = ?
+		UNION SELECT blocker_id FROM user_blocks WHERE blocked_id = ?
+		UNION SELECT muted_id FROM user_mutes WHERE muter_id = ?
+	)`, userID, userID, userID)
+}
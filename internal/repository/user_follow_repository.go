@@ -12,13 +12,35 @@ import (
 
 type UserFollowRepository interface {
 	Follow(ctx context.Context, followerID, followingID string) error
+	// RequestFollow records a pending follow of a private account.
+	RequestFollow(ctx context.Context, followerID, followingID string) error
+	// Unfollow deletes the follow or pending request of followerID.
 	Unfollow(ctx context.Context, followerID, followingID string) error
+	// IsFollowing reports whether followerID has an approved follow.
 	IsFollowing(ctx context.Context, followerID, followingID string) (bool, error)
+	// GetFollowStatus returns model.FollowStatusPending or
+	// model.FollowStatusApproved, or "" when followerID has neither.
+	GetFollowStatus(ctx context.Context, followerID, followingID string) (string, error)
+	// GetFollowRequests lists the users waiting for userID to approve them,
+	// oldest first.
+	GetFollowRequests(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error)
+	// ApproveFollowRequest and DeleteFollowRequest fail with
+	// ErrNoFollowRequest if followerID has no pending request.
+	ApproveFollowRequest(ctx context.Context, followerID, followingID string) error
+	DeleteFollowRequest(ctx context.Context, followerID, followingID string) error
 	GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error)
 	GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error)
 	GetFollowStats(ctx context.Context, userID string) (*dto.UserFollowStats, error)
 	UpdateFollowCounts(ctx context.Context, userID string) error
 	GetMutualFollows(ctx context.Context, userID1, userID2 string) ([]*model.User, error)
+	// FilterFollowing returns the IDs in userIDs that followerID follows.
+	FilterFollowing(ctx context.Context, followerID string, userIDs []string) ([]string, error)
+	// GetSuggestions ranks accounts userID may want to follow by follows of
+	// the people they follow and by tags of posts they liked. Followed,
+	// requested, blocked, muted and deleted users are left out.
+	GetSuggestions(ctx context.Context, userID string, limit int) ([]*dto.FollowSuggestionCandidate, error)
+	// RemoveBetween deletes follows in both directions between the two users.
+	RemoveBetween(ctx context.Context, userID1, userID2 string) error
 }
 
 type userFollowRepository struct {
@@ -30,12 +52,23 @@ func NewUserFollowRepository(db *gorm.DB) UserFollowRepository {
 }
 
 func (r *userFollowRepository) Follow(ctx context.Context, followerID, followingID string) error {
-	isFollowing, err := r.IsFollowing(ctx, followerID, followingID)
+	return r.create(ctx, followerID, followingID, model.FollowStatusApproved)
+}
+
+func (r *userFollowRepository) RequestFollow(ctx context.Context, followerID, followingID string) error {
+	return r.create(ctx, followerID, followingID, model.FollowStatusPending)
+}
+
+func (r *userFollowRepository) create(ctx context.Context, followerID, followingID, status string) error {
+	current, err := r.GetFollowStatus(ctx, followerID, followingID)
 	if err != nil {
 		return err
 	}
-	if isFollowing {
+	switch current {
+	case model.FollowStatusApproved:
 		return apperrors.ErrAlreadyFollowing
+	case model.FollowStatusPending:
+		return apperrors.ErrFollowRequested
 	}
 
 	if followerID == followingID {
@@ -45,22 +78,23 @@ func (r *userFollowRepository) Follow(ctx context.Context, followerID, following
 	follow := &model.UserFollow{
 		FollowerID:  followerID,
 		FollowingID: followingID,
+		Status:      status,
 	}
 
 	// followers_count / following_count are maintained automatically by the
-	// database triggers (trigger_update_follow_counts_insert/delete on
-	// user_follows), so only the follow row is created here. The previous
+	// database triggers (trigger_update_follow_counts_* on user_follows, which
+	// skip pending requests), so only the follow row is created here. The previous
 	// app-level gorm.Expr increments ran *in addition* to the triggers and
 	// double-counted every follow.
 	return r.db.WithContext(ctx).Create(follow).Error
 }
 
 func (r *userFollowRepository) Unfollow(ctx context.Context, followerID, followingID string) error {
-	isFollowing, err := r.IsFollowing(ctx, followerID, followingID)
+	status, err := r.GetFollowStatus(ctx, followerID, followingID)
 	if err != nil {
 		return err
 	}
-	if !isFollowing {
+	if status == "" {
 		return apperrors.ErrNotFollowing
 	}
 
@@ -72,25 +106,85 @@ func (r *userFollowRepository) Unfollow(ctx context.Context, followerID, followi
 func (r *userFollowRepository) IsFollowing(ctx context.Context, followerID, followingID string) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
-		Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Where("follower_id = ? AND following_id = ? AND status = ?", followerID, followingID, model.FollowStatusApproved).
 		Count(&count).Error
 	return count > 0, err
 }
 
+func (r *userFollowRepository) GetFollowStatus(ctx context.Context, followerID, followingID string) (string, error) {
+	var statuses []string
+	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Limit(1).
+		Pluck("status", &statuses).Error
+	if err != nil || len(statuses) == 0 {
+		return "", err
+	}
+	return statuses[0], nil
+}
+
+func (r *userFollowRepository) GetFollowRequests(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error) {
+	var users []*model.User
+	var total int64
+
+	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Where("following_id = ? AND status = ?", userID, model.FollowStatusPending).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("users").
+		Select("users.*").
+		Joins("JOIN user_follows ON users.id = user_follows.follower_id AND user_follows.deleted_at IS NULL").
+		Where("user_follows.following_id = ? AND user_follows.status = ?", userID, model.FollowStatusPending).
+		Order("user_follows.created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+func (r *userFollowRepository) ApproveFollowRequest(ctx context.Context, followerID, followingID string) error {
+	result := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Where("follower_id = ? AND following_id = ? AND status = ?", followerID, followingID, model.FollowStatusPending).
+		Update("status", model.FollowStatusApproved)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrNoFollowRequest
+	}
+	return nil
+}
+
+func (r *userFollowRepository) DeleteFollowRequest(ctx context.Context, followerID, followingID string) error {
+	result := r.db.WithContext(ctx).
+		Where("follower_id = ? AND following_id = ? AND status = ?", followerID, followingID, model.FollowStatusPending).
+		Delete(&model.UserFollow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrNoFollowRequest
+	}
+	return nil
+}
+
 func (r *userFollowRepository) GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error) {
 	var users []*model.User
 	var total int64
 
 	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
-		Where("following_id = ?", userID).Count(&total).Error; err != nil {
+		Where("following_id = ? AND status = ?", userID, model.FollowStatusApproved).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	err := r.db.WithContext(ctx).
 		Table("users").
 		Select("users.*").
-		Joins("JOIN user_follows ON users.id = user_follows.follower_id").
-		Where("user_follows.following_id = ?", userID).
+		Joins("JOIN user_follows ON users.id = user_follows.follower_id AND user_follows.deleted_at IS NULL").
+		Where("user_follows.following_id = ? AND user_follows.status = ?", userID, model.FollowStatusApproved).
 		Order("user_follows.created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -104,15 +198,15 @@ func (r *userFollowRepository) GetFollowing(ctx context.Context, userID string,
 	var total int64
 
 	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
-		Where("follower_id = ?", userID).Count(&total).Error; err != nil {
+		Where("follower_id = ? AND status = ?", userID, model.FollowStatusApproved).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	err := r.db.WithContext(ctx).
 		Table("users").
 		Select("users.*").
-		Joins("JOIN user_follows ON users.id = user_follows.following_id").
-		Where("user_follows.follower_id = ?", userID).
+		Joins("JOIN user_follows ON users.id = user_follows.following_id AND user_follows.deleted_at IS NULL").
+		Where("user_follows.follower_id = ? AND user_follows.status = ?", userID, model.FollowStatusApproved).
 		Order("user_follows.created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -125,12 +219,12 @@ func (r *userFollowRepository) GetFollowStats(ctx context.Context, userID string
 	stats := &dto.UserFollowStats{UserID: userID}
 
 	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
-		Where("following_id = ?", userID).Count(&stats.FollowersCount).Error; err != nil {
+		Where("following_id = ? AND status = ?", userID, model.FollowStatusApproved).Count(&stats.FollowersCount).Error; err != nil {
 		return nil, err
 	}
 
 	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
-		Where("follower_id = ?", userID).Count(&stats.FollowingCount).Error; err != nil {
+		Where("follower_id = ? AND status = ?", userID, model.FollowStatusApproved).Count(&stats.FollowingCount).Error; err != nil {
 		return nil, err
 	}
 
@@ -156,10 +250,92 @@ func (r *userFollowRepository) GetMutualFollows(ctx context.Context, userID1, us
 	err := r.db.WithContext(ctx).
 		Table("users").
 		Select("users.*").
-		Joins("JOIN user_follows uf1 ON users.id = uf1.following_id").
-		Joins("JOIN user_follows uf2 ON users.id = uf2.following_id").
+		Joins("JOIN user_follows uf1 ON users.id = uf1.following_id AND uf1.deleted_at IS NULL AND uf1.status = ?", model.FollowStatusApproved).
+		Joins("JOIN user_follows uf2 ON users.id = uf2.following_id AND uf2.deleted_at IS NULL AND uf2.status = ?", model.FollowStatusApproved).
 		Where("uf1.follower_id = ? AND uf2.follower_id = ?", userID1, userID2).
 		Find(&users).Error
 
 	return users, err
 }
+
+func (r *userFollowRepository) FilterFollowing(ctx context.Context, followerID string, userIDs []string) ([]string, error) {
+	var followingIDs []string
+	if len(userIDs) == 0 {
+		return followingIDs, nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Where("follower_id = ? AND following_id IN ? AND status = ?", followerID, userIDs, model.FollowStatusApproved).
+		Pluck("following_id", &followingIDs).Error
+	return followingIDs, err
+}
+
+// followSuggestionsSQL scores a follow by someone the user follows twice as
+// much as a shared tag, and breaks ties by popularity.
+const followSuggestionsSQL = `
+WITH following AS (
+	SELECT following_id FROM user_follows
+	WHERE follower_id = @user AND status = 'approved' AND deleted_at IS NULL
+), friends_of_friends AS (
+	SELECT uf.following_id AS user_id, COUNT(*) AS mutual_follows
+	FROM user_follows uf
+	JOIN following f ON f.following_id = uf.follower_id
+	WHERE uf.status = 'approved' AND uf.deleted_at IS NULL
+	GROUP BY uf.following_id
+), liked_tags AS (
+	SELECT DISTINCT ptt.tag_id
+	FROM post_likes pl
+	JOIN posts_to_tags ptt ON ptt.post_id = pl.post_id
+	WHERE pl.user_id = @user
+), shared_interests AS (
+	SELECT p.created_by AS user_id, COUNT(DISTINCT ptt.tag_id) AS shared_tags
+	FROM posts p
+	JOIN posts_to_tags ptt ON ptt.post_id = p.id
+	JOIN liked_tags lt ON lt.tag_id = ptt.tag_id
+	WHERE p.published = TRUE AND p.deleted_at IS NULL
+	GROUP BY p.created_by
+)
+SELECT u.id AS user_id,
+	COALESCE(fof.mutual_follows, 0) AS mutual_follows,
+	COALESCE(si.shared_tags, 0) AS shared_tags
+FROM friends_of_friends fof
+FULL OUTER JOIN shared_interests si ON si.user_id = fof.user_id
+JOIN users u ON u.id = COALESCE(fof.user_id, si.user_id) AND u.deleted_at IS NULL AND u.suspended_at IS NULL
+WHERE u.id <> @user
+	AND u.id NOT IN (
+		SELECT following_id FROM user_follows WHERE follower_id = @user AND deleted_at IS NULL
+	)
+	AND u.id NOT IN (
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = @user
+		UNION SELECT blocker_id FROM user_blocks WHERE blocked_id = @user
+		UNION SELECT muted_id FROM user_mutes WHERE muter_id = @user
+	)
+ORDER BY 2 * COALESCE(fof.mutual_follows, 0) + COALESCE(si.shared_tags, 0) DESC, u.followers_count DESC, u.id
+LIMIT @limit`
+
+func (r *userFollowRepository) GetSuggestions(ctx context.Context, userID string, limit int) ([]*dto.FollowSuggestionCandidate, error) {
+	var candidates []*dto.FollowSuggestionCandidate
+	err := r.db.WithContext(ctx).
+		Raw(followSuggestionsSQL, map[string]any{"user": userID, "limit": limit}).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+func (r *userFollowRepository) RemoveBetween(ctx context.Context, userID1, userID2 string) error {
+	return r.db.WithContext(ctx).
+		Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)", userID1, userID2, userID2, userID1).
+		Delete(&model.UserFollow{}).Error
+}
+
+// visibleAuthors limits a posts query joined with users to authors that are
+// not suspended and are public, are viewerID, or have approved viewerID's
+// follow. An empty viewerID sees public authors only.
+func visibleAuthors(query *gorm.DB, viewerID string) *gorm.DB {
+	query = query.Where("users.suspended_at IS NULL")
+	if viewerID == "" {
+		return query.Where("users.is_private = ?", false)
+	}
+	return query.Where(`(users.is_private = FALSE OR users.id = ? OR users.id IN (
+		SELECT following_id FROM user_follows WHERE follower_id = ? AND status = ? AND deleted_at IS NULL
+	))`, viewerID, viewerID, model.FollowStatusApproved)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	ListByUserID(ctx context.Context, userID string) ([]model.UserIdentity, error)
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+	DeleteByUserAndProvider(ctx context.Context, userID, provider string) (bool, error)
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) ListByUserID(ctx context.Context, userID string) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+// Create links identity to its user. Both the provider account and the
+// user's slot for that provider are unique, so either being taken returns
+// ErrIdentityAlreadyLinked.
+func (r *userIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	if err := r.db.WithContext(ctx).Create(identity).Error; err != nil {
+		if isUniqueViolation(err) {
+			return apperrors.ErrIdentityAlreadyLinked
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateUserWithIdentity registers a new user signing in through a provider,
+// so an account never exists without the identity that created it.
+func (r *userIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			if isUniqueViolation(err) {
+				return apperrors.ErrUserExists
+			}
+			return err
+		}
+
+		identity.UserID = user.ID
+		if err := tx.Create(identity).Error; err != nil {
+			if isUniqueViolation(err) {
+				return apperrors.ErrIdentityAlreadyLinked
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *userIdentityRepository) DeleteByUserAndProvider(ctx context.Context, userID, provider string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&model.UserIdentity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
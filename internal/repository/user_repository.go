@@ -4,18 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
 	"echobackend/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id string, deletedOnly bool) (*model.User, error)
+	// GetByIDs returns the active users among ids, in no particular order.
+	GetByIDs(ctx context.Context, ids []string) ([]*model.User, error)
 	GetUsers(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error)
+	// Search returns active, unsuspended users whose username or display
+	// name is similar to query, best match first.
+	Search(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error)
 	GetUsersByEmail(ctx context.Context, email string) ([]*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
@@ -24,6 +31,10 @@ type UserRepository interface {
 	RestoreByID(ctx context.Context, id string) error
 	Exists(ctx context.Context, email string) (bool, error)
 	CheckUserByUsername(ctx context.Context, username string) error
+	// GetTokensValidAfter returns the time before which the user's access
+	// tokens are rejected, or nil if it was never set.
+	GetTokensValidAfter(ctx context.Context, id string) (*time.Time, error)
+	SetTokensValidAfter(ctx context.Context, id string, at time.Time) error
 }
 
 type userRepository struct {
@@ -47,6 +58,20 @@ func (r *userRepository) CheckUserByUsername(ctx context.Context, username strin
 	if exists {
 		return apperrors.ErrUserExists
 	}
+
+	// A released username stays reserved for its previous owner for a while.
+	var reserved bool
+	err = r.db.WithContext(ctx).Model(&model.UsernameHistory{}).
+		Select("1").
+		Where("old_username = ? AND reserved_until > ?", username, time.Now()).
+		Limit(1).
+		Scan(&reserved).Error
+	if err != nil {
+		return err
+	}
+	if reserved {
+		return apperrors.ErrUserExists
+	}
 	return nil
 }
 
@@ -65,7 +90,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	result := r.db.WithContext(ctx).Model(user).
-		Select("Email", "FirstName", "LastName", "Username", "IsSuperAdmin", "Password", "LastLoggedAt").
+		Select("Email", "FirstName", "LastName", "Username", "IsSuperAdmin", "Password", "LastLoggedAt", "EmailVerifiedAt").
 		Where("id = ?", user.ID).
 		Updates(user)
 
@@ -94,6 +119,17 @@ func (r *userRepository) GetByID(ctx context.Context, id string, deletedOnly boo
 	return &user, nil
 }
 
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	var users []*model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return users, nil
+}
+
 func (r *userRepository) GetUsers(ctx context.Context, offset, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error) {
 	var users []*model.User
 	var totalCount int64
@@ -130,6 +166,45 @@ func (r *userRepository) GetUsers(ctx context.Context, offset, limit int, delete
 	return users, totalCount, nil
 }
 
+// userSearchThreshold is the pg_trgm word similarity a username or display
+// name needs to match a search. The default of 0.6 misses small typos.
+const userSearchThreshold = 0.3
+
+// userDisplayNameSQL must match the expression of idx_users_display_name_trgm.
+const userDisplayNameSQL = "(COALESCE(first_name, '') || ' ' || COALESCE(last_name, ''))"
+
+func (r *userRepository) Search(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error) {
+	var users []*model.User
+	var totalCount int64
+
+	// Similarity decides the order; followers add a small boost, about 0.05
+	// per tenfold, so popular accounts win among similar matches.
+	matchSQL := "(? <% username OR ? <% " + userDisplayNameSQL + ") AND suspended_at IS NULL"
+	scoreSQL := "GREATEST(word_similarity(?, COALESCE(username, '')), word_similarity(?, " + userDisplayNameSQL + "))" +
+		" + 0.05 * LOG(GREATEST(followers_count, 0) + 1) DESC, id"
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", userSearchThreshold)).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.User{}).Where(matchSQL, query, query).Count(&totalCount).Error; err != nil {
+			return fmt.Errorf("failed to count users for search: %w", err)
+		}
+
+		return tx.Where(matchSQL, query, query).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: scoreSQL, Vars: []any{query, query}, WithoutParentheses: true}}).
+			Offset(offset).
+			Limit(limit).
+			Find(&users).Error
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	return users, totalCount, nil
+}
+
 func (r *userRepository) GetUsersByEmail(ctx context.Context, email string) ([]*model.User, error) {
 	var users []*model.User
 	err := r.db.WithContext(ctx).Where("email = ?", email).Find(&users).Error
@@ -150,6 +225,34 @@ func (r *userRepository) SoftDeleteByID(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *userRepository) GetTokensValidAfter(ctx context.Context, id string) (*time.Time, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("id", "tokens_valid_after").
+		Where("id = ?", id).
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get tokens valid after: %w", err)
+	}
+	return user.TokensValidAfter, nil
+}
+
+func (r *userRepository) SetTokensValidAfter(ctx context.Context, id string, at time.Time) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("id = ?", id).
+		UpdateColumn("tokens_valid_after", at)
+	if result.Error != nil {
+		return fmt.Errorf("failed to set tokens valid after: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *userRepository) RestoreByID(ctx context.Context, id string) error {
 	var user model.User
 	err := r.db.WithContext(ctx).Unscoped().
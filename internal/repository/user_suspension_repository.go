@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UserSuspensionRepository suspends and reinstates accounts. The suspension
+// is stored on the user row so listings can filter on it without a join.
+type UserSuspensionRepository interface {
+	// Suspend suspends the user from at until until, or indefinitely when
+	// until is nil. Suspending a suspended user replaces the suspension.
+	Suspend(ctx context.Context, userID, reason string, at time.Time, until *time.Time) error
+	// Lift reinstates the user and reports whether they were suspended.
+	Lift(ctx context.Context, userID string) (bool, error)
+	// LiftExpired reinstates every user whose suspension ended before now
+	// and returns them.
+	LiftExpired(ctx context.Context, now time.Time) ([]*model.User, error)
+}
+
+type userSuspensionRepository struct {
+	db *gorm.DB
+}
+
+func NewUserSuspensionRepository(db *gorm.DB) UserSuspensionRepository {
+	return &userSuspensionRepository{db: db}
+}
+
+func (r *userSuspensionRepository) Suspend(ctx context.Context, userID, reason string, at time.Time, until *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]any{
+			"suspended_at":      at,
+			"suspended_until":   until,
+			"suspension_reason": reason,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to suspend user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userSuspensionRepository) Lift(ctx context.Context, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND suspended_at IS NOT NULL", userID).
+		UpdateColumns(map[string]any{
+			"suspended_at":      nil,
+			"suspended_until":   nil,
+			"suspension_reason": nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to lift suspension: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userSuspensionRepository) LiftExpired(ctx context.Context, now time.Time) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE users SET suspended_at = NULL, suspended_until = NULL, suspension_reason = NULL
+		WHERE suspended_at IS NOT NULL AND suspended_until <= ? AND deleted_at IS NULL
+		RETURNING id, email`,
+		now,
+	).Scan(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lift expired suspensions: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type UsernameHistoryRepository interface {
+	// FindLatestByOldUsername returns the most recent change away from
+	// username, or nil if it was never released.
+	FindLatestByOldUsername(ctx context.Context, username string) (*model.UsernameHistory, error)
+	FindLatestByUserID(ctx context.Context, userID string) (*model.UsernameHistory, error)
+	// ChangeUsername renames entry.UserID from entry.OldUsername to
+	// entry.NewUsername and records the change. An empty OldUsername sets a
+	// first username and records nothing. It fails with
+	// ErrUsernameUnavailable if another account holds the new username and
+	// with ErrUserNotFound if the user no longer has the old one.
+	ChangeUsername(ctx context.Context, entry *model.UsernameHistory) error
+}
+
+type usernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewUsernameHistoryRepository(db *gorm.DB) UsernameHistoryRepository {
+	return &usernameHistoryRepository{db: db}
+}
+
+func (r *usernameHistoryRepository) FindLatestByOldUsername(ctx context.Context, username string) (*model.UsernameHistory, error) {
+	return r.findLatest(ctx, "old_username = ?", username)
+}
+
+func (r *usernameHistoryRepository) FindLatestByUserID(ctx context.Context, userID string) (*model.UsernameHistory, error) {
+	return r.findLatest(ctx, "user_id = ?", userID)
+}
+
+func (r *usernameHistoryRepository) findLatest(ctx context.Context, query string, arg any) (*model.UsernameHistory, error) {
+	var entry model.UsernameHistory
+	err := r.db.WithContext(ctx).Where(query, arg).Order("changed_at DESC").First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *usernameHistoryRepository) ChangeUsername(ctx context.Context, entry *model.UsernameHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&model.User{}).Where("id = ?", entry.UserID)
+		if entry.OldUsername == "" {
+			query = query.Where("(username IS NULL OR username = '')")
+		} else {
+			query = query.Where("username = ?", entry.OldUsername)
+		}
+		result := query.Update("username", entry.NewUsername)
+		if result.Error != nil {
+			if isUniqueViolation(result.Error) {
+				return apperrors.ErrUsernameUnavailable
+			}
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrUserNotFound
+		}
+
+		if entry.OldUsername == "" {
+			return nil
+		}
+		return tx.Create(entry).Error
+	})
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+)
+
+func (r *Routes) setupAdminRoutes(api *echo.Group) {
+	admin := api.Group("/admin", r.authMiddleware.Auth())
+	{
+		admin.GET("/audit-logs", r.adminAuditHandler.GetAuditLogs, r.authMiddleware.RequirePermission(model.PermissionAuditRead))
+		admin.POST("/users/:id/impersonate", r.authHandler.ImpersonateUser, r.authMiddleware.RequirePermission(model.PermissionUsersImpersonate), r.auditMiddleware.Record(model.AuditUserImpersonate, "user", "id"))
+		admin.POST("/users/:id/suspend", r.authHandler.SuspendUser, r.authMiddleware.RequirePermission(model.PermissionUsersSuspend), r.auditMiddleware.Record(model.AuditUserSuspend, "user", "id"))
+		admin.DELETE("/users/:id/suspend", r.authHandler.UnsuspendUser, r.authMiddleware.RequirePermission(model.PermissionUsersSuspend), r.auditMiddleware.Record(model.AuditUserUnsuspend, "user", "id"))
+	}
+}
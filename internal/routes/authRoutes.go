@@ -2,6 +2,7 @@ package routes
 
 import (
 	appmiddleware "echobackend/internal/middleware"
+	"echobackend/internal/model"
 	"time"
 
 	"github.com/labstack/echo/v5"
@@ -15,20 +16,46 @@ func (r *Routes) setupAuthRoutes(api *echo.Group) {
 	resetPasswordRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:reset-password", 5, 5*time.Minute)
 	refreshRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:refresh", 30, time.Minute)
 	oauthExchangeRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:oauth-exchange", 10, time.Minute)
+	twoFactorRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:2fa", 5, 5*time.Minute)
+	verifyEmailRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:verify-email", 10, 5*time.Minute)
+	sendVerificationRateLimit := appmiddleware.FixedWindowRateLimiterWithCache(r.cache, "auth:send-verification", 3, 5*time.Minute)
 	{
 		auth.POST("/register", r.authHandler.Register, registerRateLimit)
 		auth.POST("/login", r.authHandler.Login, loginRateLimit)
 		auth.POST("/forgot-password", r.authHandler.ForgotPassword, forgotPasswordRateLimit)
 		auth.POST("/reset-password", r.authHandler.ResetPassword, resetPasswordRateLimit)
+		auth.POST("/magic-link", r.authHandler.RequestMagicLink, forgotPasswordRateLimit)
+		auth.POST("/magic-link/consume", r.authHandler.ConsumeMagicLink, loginRateLimit)
+		auth.POST("/verify-email", r.authHandler.VerifyEmail, verifyEmailRateLimit)
+		auth.POST("/verify-email/resend", r.authHandler.ResendVerificationEmail, r.authMiddleware.Auth(), sendVerificationRateLimit)
+		auth.POST("/verify-email/request", r.authHandler.RequestVerificationEmail, sendVerificationRateLimit)
 		auth.POST("/refresh", r.authHandler.RefreshToken, refreshRateLimit)
 		auth.POST("/logout", r.authHandler.Logout, r.authMiddleware.Auth())
 		auth.GET("/profile", r.authHandler.GetProfile, r.authMiddleware.Auth())
-		auth.PATCH("/password", r.authHandler.ChangePassword, r.authMiddleware.Auth())
+		auth.GET("/sessions", r.authHandler.GetSessions, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.DELETE("/sessions", r.authHandler.RevokeOtherSessions, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.DELETE("/sessions/:id", r.authHandler.RevokeSession, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.POST("/sessions/revoke-all", r.authHandler.RevokeSessionsWithToken, verifyEmailRateLimit)
+		auth.GET("/tokens", r.authHandler.GetAccessTokens, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.POST("/tokens", r.authHandler.CreateAccessToken, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.DELETE("/tokens/:id", r.authHandler.RevokeAccessToken, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.PATCH("/password", r.authHandler.ChangePassword, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.PATCH("/email", r.authHandler.ChangeEmail, r.authMiddleware.Auth(), r.authMiddleware.RequireSession(), sendVerificationRateLimit)
 		auth.GET("/activity-logs", r.authHandler.GetActivityLogs, r.authMiddleware.Auth())
 		auth.GET("/activity-logs/recent", r.authHandler.GetRecentActivity, r.authMiddleware.Auth())
-		auth.GET("/activity-logs/failed-logins", r.authHandler.GetFailedLogins, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
-		auth.GET("/oauth/github", r.authHandler.GithubOAuthRedirect)
-		auth.GET("/oauth/github/callback", r.authHandler.GithubOAuthCallback)
+		auth.GET("/activity-logs/failed-logins", r.authHandler.GetFailedLogins, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionAuthAudit), r.auditMiddleware.RecordTarget(model.AuditReportView, "report", "failed-logins"))
+		auth.GET("/locked-accounts", r.authHandler.GetLockedAccounts, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionAccountsUnlock))
+		auth.POST("/locked-accounts/:id/unlock", r.authHandler.UnlockAccount, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionAccountsUnlock), r.auditMiddleware.Record(model.AuditAccountUnlock, "user", "id"))
+		auth.GET("/oauth/providers", r.authHandler.GetOAuthProviders)
+		auth.GET("/oauth/:provider", r.authHandler.OAuthRedirect)
+		auth.GET("/oauth/:provider/callback", r.authHandler.OAuthCallback)
+		auth.POST("/oauth/:provider/link", r.authHandler.CreateOAuthLink, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
 		auth.POST("/oauth/exchange", r.authHandler.ExchangeOAuthCode, oauthExchangeRateLimit)
+		auth.GET("/identities", r.authHandler.GetIdentities, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.DELETE("/identities/:provider", r.authHandler.UnlinkIdentity, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.POST("/2fa/verify", r.authHandler.VerifyTwoFactor, twoFactorRateLimit)
+		auth.POST("/2fa/enroll", r.authHandler.EnrollTwoFactor, r.authMiddleware.Auth(), r.authMiddleware.RequireSession())
+		auth.POST("/2fa/confirm", r.authHandler.ConfirmTwoFactor, r.authMiddleware.Auth(), r.authMiddleware.RequireSession(), twoFactorRateLimit)
+		auth.POST("/2fa/disable", r.authHandler.DisableTwoFactor, r.authMiddleware.Auth(), r.authMiddleware.RequireSession(), twoFactorRateLimit)
 	}
 }
@@ -1,26 +1,30 @@
 package routes
 
-import "github.com/labstack/echo/v5"
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+)
 
 func (r *Routes) setupHoldingRoutes(api *echo.Group) {
-	holdings := api.Group("/holdings", r.authMiddleware.Auth())
+	holdings := api.Group("/holdings")
 	{
-		holdings.GET("", r.holdingHandler.GetHoldings)
-		holdings.GET("/summary", r.holdingHandler.GetSummary)
-		holdings.GET("/trends", r.holdingHandler.GetTrends)
-		holdings.GET("/compare", r.holdingHandler.CompareMonths)
-		holdings.GET("/monthly", r.holdingHandler.GetMonthlyData)
-		holdings.GET("/calendar", r.corporateActionHandler.GetCalendar)
-		holdings.POST("", r.holdingHandler.CreateHolding)
-		holdings.POST("/duplicate", r.holdingHandler.DuplicateHoldings)
-		holdings.POST("/sync", r.holdingHandler.SyncPrices)
-		holdings.GET("/:id", r.holdingHandler.GetHoldingByID)
-		holdings.PUT("/:id", r.holdingHandler.UpdateHolding)
-		holdings.DELETE("/:id", r.holdingHandler.DeleteHolding)
+		holdings.GET("", r.holdingHandler.GetHoldings, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.GET("/summary", r.holdingHandler.GetSummary, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.GET("/trends", r.holdingHandler.GetTrends, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.GET("/compare", r.holdingHandler.CompareMonths, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.GET("/monthly", r.holdingHandler.GetMonthlyData, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.GET("/calendar", r.corporateActionHandler.GetCalendar, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.POST("", r.holdingHandler.CreateHolding, r.authMiddleware.Auth(model.ScopeHoldingsWrite))
+		holdings.POST("/duplicate", r.holdingHandler.DuplicateHoldings, r.authMiddleware.Auth(model.ScopeHoldingsWrite))
+		holdings.POST("/sync", r.holdingHandler.SyncPrices, r.authMiddleware.Auth(model.ScopeHoldingsWrite))
+		holdings.GET("/:id", r.holdingHandler.GetHoldingByID, r.authMiddleware.Auth(model.ScopeHoldingsRead))
+		holdings.PUT("/:id", r.holdingHandler.UpdateHolding, r.authMiddleware.Auth(model.ScopeHoldingsWrite))
+		holdings.DELETE("/:id", r.holdingHandler.DeleteHolding, r.authMiddleware.Auth(model.ScopeHoldingsWrite))
 	}
 
-	holdingTypes := api.Group("/holding-types", r.authMiddleware.Auth())
+	holdingTypes := api.Group("/holding-types")
 	{
-		holdingTypes.GET("", r.holdingHandler.GetHoldingTypes)
+		holdingTypes.GET("", r.holdingHandler.GetHoldingTypes, r.authMiddleware.Auth(model.ScopeHoldingsRead))
 	}
 }
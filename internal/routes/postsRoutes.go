@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"echobackend/internal/model"
+
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
 )
@@ -8,43 +10,50 @@ import (
 func (r *Routes) setupPostRoutes(api *echo.Group) {
 	posts := api.Group("/posts")
 	{
-		posts.POST("", r.postHandler.CreatePost, r.authMiddleware.Auth())
+		posts.POST("", r.postHandler.CreatePost, r.authMiddleware.Auth(model.ScopePostsWrite), r.authMiddleware.RequireVerifiedEmail())
 		posts.GET("/random", r.postHandler.GetPostsRandom)
 		posts.GET("/trending", r.postHandler.GetPostsTrending)
-		posts.GET("/me", r.postHandler.GetMyPosts, r.authMiddleware.Auth())
-		posts.GET("/me/analytics", r.postHandler.GetMyPostsAnalytics, r.authMiddleware.Auth())
-		posts.GET("/me/analytics/likes-by-month", r.postHandler.GetMyPostsLikesByMonth, r.authMiddleware.Auth())
-		posts.GET("/me/:id", r.postHandler.GetMyPost, r.authMiddleware.Auth())
-		posts.PUT("/me/:id", r.postHandler.UpdateMyPost, r.authMiddleware.Auth())
-		posts.DELETE("/me/:id", r.postHandler.DeleteMyPost, r.authMiddleware.Auth())
-		posts.GET("/feed/for-you", r.postHandler.GetPostsForYou, r.authMiddleware.Auth())
-		posts.POST("/image", r.postHandler.UploadImagePosts, r.authMiddleware.Auth(), middleware.BodyLimit(1*1024*1024))
+		posts.GET("/search", r.postHandler.SearchPosts)
+		posts.GET("/me", r.postHandler.GetMyPosts, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/scheduled", r.postHandler.GetMyScheduledPosts, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/analytics", r.postHandler.GetMyPostsAnalytics, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/analytics/likes-by-month", r.postHandler.GetMyPostsLikesByMonth, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/:id", r.postHandler.GetMyPost, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.PUT("/me/:id", r.postHandler.UpdateMyPost, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.DELETE("/me/:id", r.postHandler.DeleteMyPost, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.DELETE("/me/:id/schedule", r.postHandler.CancelMyPostSchedule, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.GET("/me/:id/revisions", r.postHandler.GetMyPostRevisions, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/:id/revisions/diff", r.postHandler.DiffMyPostRevisions, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.GET("/me/:id/revisions/:number", r.postHandler.GetMyPostRevision, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.POST("/me/:id/revisions/:number/restore", r.postHandler.RestoreMyPostRevision, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.GET("/feed/for-you", r.postHandler.GetPostsForYou, r.authMiddleware.Auth(model.ScopePostsRead))
+		posts.POST("/image", r.postHandler.UploadImagePosts, r.authMiddleware.Auth(model.ScopePostsWrite), middleware.BodyLimit(1*1024*1024))
 		posts.GET("/sitemap", r.postHandler.GetPostsForSitemap)
-		posts.GET("/username/:username", r.postHandler.GetPostsByUsername)
-		posts.GET("/u/:username/:slug", r.postHandler.GetPostBySlugAndUsername)
+		posts.GET("/username/:username", r.postHandler.GetPostsByUsername, r.authMiddleware.OptionalAuth(model.ScopePostsRead))
+		posts.GET("/u/:username/:slug", r.postHandler.GetPostBySlugAndUsername, r.authMiddleware.OptionalAuth(model.ScopePostsRead))
 		posts.GET("/tag/:tag", r.postHandler.GetPostsByTag)
 		posts.GET("", r.postHandler.GetPosts)
-		posts.PUT("/:id", r.postHandler.UpdatePost, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
-		posts.DELETE("/:id", r.postHandler.DeletePost, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
-		posts.GET("/:id", r.postHandler.GetPost, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
+		posts.PUT("/:id", r.postHandler.UpdatePost, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionPostsUpdate), r.auditMiddleware.Record(model.AuditPostUpdate, "post", "id"))
+		posts.DELETE("/:id", r.postHandler.DeletePost, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionPostsDelete), r.auditMiddleware.Record(model.AuditPostDelete, "post", "id"))
+		posts.GET("/:id", r.postHandler.GetPost, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionPostsRead))
 
 		// Comment routes
 		posts.GET("/:id/comments", r.commentHandler.GetCommentsByPostID)
-		posts.POST("/:id/comments", r.commentHandler.CreateComment, r.authMiddleware.Auth())
-		posts.PUT("/:id/comments/:comment_id", r.commentHandler.UpdateComment, r.authMiddleware.Auth())
-		posts.DELETE("/:id/comments/:comment_id", r.commentHandler.DeleteComment, r.authMiddleware.Auth())
+		posts.POST("/:id/comments", r.commentHandler.CreateComment, r.authMiddleware.Auth(model.ScopePostsWrite), r.authMiddleware.RequireVerifiedEmail())
+		posts.PUT("/:id/comments/:comment_id", r.commentHandler.UpdateComment, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.DELETE("/:id/comments/:comment_id", r.commentHandler.DeleteComment, r.authMiddleware.Auth(model.ScopePostsWrite))
 
 		// View routes
-		posts.POST("/:id/view", r.postViewHandler.RecordView, r.authMiddleware.Auth()) // Only authenticated users
-		posts.GET("/:id/views", r.postViewHandler.GetPostViews, r.authMiddleware.Auth())
+		posts.POST("/:id/view", r.postViewHandler.RecordView, r.authMiddleware.Auth(model.ScopePostsRead)) // Only authenticated users
+		posts.GET("/:id/views", r.postViewHandler.GetPostViews, r.authMiddleware.Auth(model.ScopePostsRead))
 		posts.GET("/:id/view-stats", r.postViewHandler.GetPostViewStats)
-		posts.GET("/:id/viewed", r.postViewHandler.CheckUserViewed, r.authMiddleware.Auth())
+		posts.GET("/:id/viewed", r.postViewHandler.CheckUserViewed, r.authMiddleware.Auth(model.ScopePostsRead))
 
 		// Like routes
-		posts.POST("/:id/like", r.postLikeHandler.LikePost, r.authMiddleware.Auth())
-		posts.DELETE("/:id/like", r.postLikeHandler.UnlikePost, r.authMiddleware.Auth())
+		posts.POST("/:id/like", r.postLikeHandler.LikePost, r.authMiddleware.Auth(model.ScopePostsWrite))
+		posts.DELETE("/:id/like", r.postLikeHandler.UnlikePost, r.authMiddleware.Auth(model.ScopePostsWrite))
 		posts.GET("/:id/likes", r.postLikeHandler.GetPostLikes)
 		posts.GET("/:id/like-stats", r.postLikeHandler.GetPostLikeStats)
-		posts.GET("/:id/liked", r.postLikeHandler.CheckUserLiked, r.authMiddleware.Auth())
+		posts.GET("/:id/liked", r.postLikeHandler.CheckUserLiked, r.authMiddleware.Auth(model.ScopePostsRead))
 	}
 }
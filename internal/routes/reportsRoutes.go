@@ -1,13 +1,17 @@
 package routes
 
-import "github.com/labstack/echo/v5"
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+)
 
 func (r *Routes) setupReportRoutes(api *echo.Group) {
-	reports := api.Group("/reports", r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
+	reports := api.Group("/reports", r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionReportsRead))
 	{
-		reports.GET("/overview", r.reportHandler.GetOverview)
-		reports.GET("/users", r.reportHandler.GetUsers)
-		reports.GET("/posts", r.reportHandler.GetPosts)
-		reports.GET("/engagement", r.reportHandler.GetEngagement)
+		reports.GET("/overview", r.reportHandler.GetOverview, r.auditMiddleware.RecordTarget(model.AuditReportView, "report", "overview"))
+		reports.GET("/users", r.reportHandler.GetUsers, r.auditMiddleware.RecordTarget(model.AuditReportView, "report", "users"))
+		reports.GET("/posts", r.reportHandler.GetPosts, r.auditMiddleware.RecordTarget(model.AuditReportView, "report", "posts"))
+		reports.GET("/engagement", r.reportHandler.GetEngagement, r.auditMiddleware.RecordTarget(model.AuditReportView, "report", "engagement"))
 	}
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+)
+
+func (r *Routes) setupRoleRoutes(api *echo.Group) {
+	roles := api.Group("/roles", r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionRolesManage))
+	{
+		roles.GET("", r.roleHandler.GetRoles)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"echobackend/config"
 	"echobackend/internal/handler"
 	"echobackend/internal/middleware"
+	"echobackend/internal/model"
 	"echobackend/internal/platform/cache"
 
 	"github.com/labstack/echo/v5"
@@ -16,6 +17,7 @@ type Routes struct {
 	postHandler             *handler.PostHandler
 	authHandler             *handler.AuthHandler
 	authMiddleware          *middleware.AuthMiddleware
+	auditMiddleware         *middleware.AuditMiddleware
 	tagHandler              *handler.TagHandler
 	commentHandler          *handler.CommentHandler
 	postViewHandler         *handler.PostViewHandler
@@ -28,6 +30,10 @@ type Routes struct {
 	notificationHandler     *handler.NotificationHandler
 	reportHandler           *handler.ReportHandler
 	corporateActionHandler  *handler.CorporateActionHandler
+	roleHandler             *handler.RoleHandler
+	adminAuditHandler       *handler.AdminAuditHandler
+	accountHandler          *handler.AccountHandler
+	userBlockHandler        *handler.UserBlockHandler
 }
 
 func NewRoutes(
@@ -37,6 +43,7 @@ func NewRoutes(
 	postHandler *handler.PostHandler,
 	authHandler *handler.AuthHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	auditMiddleware *middleware.AuditMiddleware,
 	tagHandler *handler.TagHandler,
 	commentHandler *handler.CommentHandler,
 	postViewHandler *handler.PostViewHandler,
@@ -49,6 +56,10 @@ func NewRoutes(
 	notificationHandler *handler.NotificationHandler,
 	reportHandler *handler.ReportHandler,
 	corporateActionHandler *handler.CorporateActionHandler,
+	roleHandler *handler.RoleHandler,
+	adminAuditHandler *handler.AdminAuditHandler,
+	accountHandler *handler.AccountHandler,
+	userBlockHandler *handler.UserBlockHandler,
 ) *Routes {
 	return &Routes{
 		config:                  config,
@@ -57,6 +68,7 @@ func NewRoutes(
 		postHandler:             postHandler,
 		authHandler:             authHandler,
 		authMiddleware:          authMiddleware,
+		auditMiddleware:         auditMiddleware,
 		tagHandler:              tagHandler,
 		commentHandler:          commentHandler,
 		postViewHandler:         postViewHandler,
@@ -69,10 +81,16 @@ func NewRoutes(
 		notificationHandler:     notificationHandler,
 		reportHandler:           reportHandler,
 		corporateActionHandler:  corporateActionHandler,
+		roleHandler:             roleHandler,
+		adminAuditHandler:       adminAuditHandler,
+		accountHandler:          accountHandler,
+		userBlockHandler:        userBlockHandler,
 	}
 }
 
 func (r *Routes) Setup(e *echo.Echo) {
+	e.GET("/.well-known/jwks.json", r.authHandler.JWKS)
+
 	// API Group
 	api := e.Group("/api")
 	r.setupAPIRoutes(api)
@@ -89,25 +107,27 @@ func (r *Routes) setupAPIRoutes(api *echo.Group) {
 	r.setupBookmarkRoutes(api)
 	r.setupNotificationRoutes(api)
 	r.setupReportRoutes(api)
+	r.setupRoleRoutes(api)
+	r.setupAdminRoutes(api)
 }
 
 func (r *Routes) setupChatConversationRoutes(api *echo.Group) {
 	conversations := api.Group("/chat/conversations")
 	{
-		conversations.POST("", r.chatConversationHandler.CreateConversation, r.authMiddleware.Auth())
-		conversations.POST("/stream", r.chatConversationHandler.CreateConversationStream, r.authMiddleware.Auth())
-		conversations.GET("", r.chatConversationHandler.GetConversations, r.authMiddleware.Auth())
-		conversations.GET("/:id", r.chatConversationHandler.GetConversation, r.authMiddleware.Auth())
-		conversations.PUT("/:id", r.chatConversationHandler.UpdateConversation, r.authMiddleware.Auth())
-		conversations.DELETE("/:id", r.chatConversationHandler.DeleteConversation, r.authMiddleware.Auth())
-		conversations.POST("/:conversationId/messages", r.chatConversationHandler.CreateMessage, r.authMiddleware.Auth())
-		conversations.POST("/:conversationId/messages/stream", r.chatConversationHandler.CreateMessageStream, r.authMiddleware.Auth())
-		conversations.GET("/:conversationId/messages", r.chatConversationHandler.GetMessages, r.authMiddleware.Auth())
+		conversations.POST("", r.chatConversationHandler.CreateConversation, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.POST("/stream", r.chatConversationHandler.CreateConversationStream, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.GET("", r.chatConversationHandler.GetConversations, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.GET("/:id", r.chatConversationHandler.GetConversation, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.PUT("/:id", r.chatConversationHandler.UpdateConversation, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.DELETE("/:id", r.chatConversationHandler.DeleteConversation, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.POST("/:conversationId/messages", r.chatConversationHandler.CreateMessage, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.POST("/:conversationId/messages/stream", r.chatConversationHandler.CreateMessageStream, r.authMiddleware.Auth(model.ScopeChat))
+		conversations.GET("/:conversationId/messages", r.chatConversationHandler.GetMessages, r.authMiddleware.Auth(model.ScopeChat))
 	}
 
 	messages := api.Group("/chat/messages")
 	{
-		messages.GET("/:messageId", r.chatConversationHandler.GetMessage, r.authMiddleware.Auth())
-		messages.DELETE("/:messageId", r.chatConversationHandler.DeleteMessage, r.authMiddleware.Auth())
+		messages.GET("/:messageId", r.chatConversationHandler.GetMessage, r.authMiddleware.Auth(model.ScopeChat))
+		messages.DELETE("/:messageId", r.chatConversationHandler.DeleteMessage, r.authMiddleware.Auth(model.ScopeChat))
 	}
 }
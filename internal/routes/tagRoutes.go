@@ -1,6 +1,10 @@
 package routes
 
-import "github.com/labstack/echo/v5"
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+)
 
 func (r *Routes) setupTagRoutes(api *echo.Group) {
 	tags := api.Group("/tags")
@@ -10,7 +14,7 @@ func (r *Routes) setupTagRoutes(api *echo.Group) {
 		tags.GET("/trending", r.tagHandler.GetTrendingTags)
 		tags.GET("/sitemap", r.tagHandler.GetTagsForSitemap)
 		tags.GET("/:id", r.tagHandler.GetTagByID)
-		tags.PUT("/:id", r.tagHandler.UpdateTag, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
-		tags.DELETE("/:id", r.tagHandler.DeleteTag, r.authMiddleware.Auth(), r.authMiddleware.AuthAdmin())
+		tags.PUT("/:id", r.tagHandler.UpdateTag, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionTagsUpdate), r.auditMiddleware.Record(model.AuditTagUpdate, "tag", "id"))
+		tags.DELETE("/:id", r.tagHandler.DeleteTag, r.authMiddleware.Auth(), r.authMiddleware.RequirePermission(model.PermissionTagsDelete), r.auditMiddleware.Record(model.AuditTagDelete, "tag", "id"))
 	}
 }
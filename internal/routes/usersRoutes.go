@@ -1,32 +1,63 @@
 package routes
 
-import "github.com/labstack/echo/v5"
+import (
+	"echobackend/internal/model"
+
+	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
+)
 
 func (r *Routes) setupUserRoutes(api *echo.Group) {
 	users := api.Group("/users")
 	{
 		// Public routes
 		users.GET("/username/:username", r.userHandler.GetByUsername)
+		users.GET("/search", r.userHandler.SearchUsers, r.authMiddleware.OptionalAuth())
 
 		// Authenticated routes
 		authUsers := users.Group("", r.authMiddleware.Auth())
 		{
 			authUsers.GET("/me", r.userHandler.GetMe)
-			authUsers.GET("", r.userHandler.GetUsers, r.authMiddleware.AuthAdmin())
-			authUsers.GET("/:id", r.userHandler.GetByID, r.authMiddleware.AuthAdmin())
-			authUsers.DELETE("/:id", r.userHandler.DeleteUser, r.authMiddleware.AuthAdmin())
-			authUsers.POST("/:id/restore", r.userHandler.RestoreUser, r.authMiddleware.AuthAdmin())
+			authUsers.GET("/me/permissions", r.roleHandler.GetMyAccess)
+			authUsers.PATCH("/me", r.userHandler.UpdateProfile, r.authMiddleware.RequireSession())
+			authUsers.POST("/me/avatar", r.userHandler.UploadAvatar, r.authMiddleware.RequireSession(), middleware.BodyLimit(1*1024*1024))
+			authUsers.PATCH("/me/username", r.userHandler.ChangeUsername, r.authMiddleware.RequireSession())
+			authUsers.DELETE("/me", r.accountHandler.DeleteAccount, r.authMiddleware.RequireSession())
+			authUsers.POST("/me/cancel-deletion", r.accountHandler.CancelDeletion, r.authMiddleware.RequireSession())
+			authUsers.GET("/me/export", r.accountHandler.GetDataExport, r.authMiddleware.RequireSession())
+			authUsers.POST("/me/export", r.accountHandler.RequestDataExport, r.authMiddleware.RequireSession())
+			authUsers.GET("", r.userHandler.GetUsers, r.authMiddleware.RequirePermission(model.PermissionUsersRead))
+			authUsers.GET("/:id", r.userHandler.GetByID, r.authMiddleware.RequirePermission(model.PermissionUsersRead))
+			authUsers.DELETE("/:id", r.userHandler.DeleteUser, r.authMiddleware.RequirePermission(model.PermissionUsersDelete), r.auditMiddleware.Record(model.AuditUserDelete, "user", "id"))
+			authUsers.POST("/:id/restore", r.userHandler.RestoreUser, r.authMiddleware.RequirePermission(model.PermissionUsersRestore), r.auditMiddleware.Record(model.AuditUserRestore, "user", "id"))
+
+			// Role routes
+			authUsers.GET("/:id/roles", r.roleHandler.GetUserRoles, r.authMiddleware.RequirePermission(model.PermissionRolesManage))
+			authUsers.PUT("/:id/roles/:role", r.roleHandler.AssignRole, r.authMiddleware.RequirePermission(model.PermissionRolesManage), r.auditMiddleware.Record(model.AuditRoleAssign, "user", "id"))
+			authUsers.DELETE("/:id/roles/:role", r.roleHandler.RemoveRole, r.authMiddleware.RequirePermission(model.PermissionRolesManage), r.auditMiddleware.Record(model.AuditRoleRemove, "user", "id"))
 
 			// Follow routes
-			authUsers.POST("/follow", r.userFollowHandler.FollowUser)
-			authUsers.DELETE("/:id/follow", r.userFollowHandler.UnfollowUser)
+			authUsers.GET("/suggestions", r.userFollowHandler.GetSuggestions, r.authMiddleware.RequireSession())
+			authUsers.GET("/me/follow-requests", r.userFollowHandler.GetFollowRequests, r.authMiddleware.RequireSession())
+			authUsers.POST("/me/follow-requests/:id/approve", r.userFollowHandler.ApproveFollowRequest, r.authMiddleware.RequireSession())
+			authUsers.POST("/me/follow-requests/:id/reject", r.userFollowHandler.RejectFollowRequest, r.authMiddleware.RequireSession())
+			authUsers.POST("/follow", r.userFollowHandler.FollowUser, r.authMiddleware.RequireSession())
+			authUsers.DELETE("/:id/follow", r.userFollowHandler.UnfollowUser, r.authMiddleware.RequireSession())
 			authUsers.GET("/:id/follow-status", r.userFollowHandler.CheckFollowStatus)
 			authUsers.GET("/:id/mutual-follows", r.userFollowHandler.GetMutualFollows)
+
+			// Block and mute routes
+			authUsers.GET("/me/blocks", r.userBlockHandler.GetBlockedUsers, r.authMiddleware.RequireSession())
+			authUsers.GET("/me/mutes", r.userBlockHandler.GetMutedUsers, r.authMiddleware.RequireSession())
+			authUsers.POST("/:id/block", r.userBlockHandler.BlockUser, r.authMiddleware.RequireSession())
+			authUsers.DELETE("/:id/block", r.userBlockHandler.UnblockUser, r.authMiddleware.RequireSession())
+			authUsers.POST("/:id/mute", r.userBlockHandler.MuteUser, r.authMiddleware.RequireSession())
+			authUsers.DELETE("/:id/mute", r.userBlockHandler.UnmuteUser, r.authMiddleware.RequireSession())
 		}
 
 		// Follow-related public routes
-		users.GET("/:id/followers", r.userFollowHandler.GetFollowers)
-		users.GET("/:id/following", r.userFollowHandler.GetFollowing)
+		users.GET("/:id/followers", r.userFollowHandler.GetFollowers, r.authMiddleware.OptionalAuth())
+		users.GET("/:id/following", r.userFollowHandler.GetFollowing, r.authMiddleware.OptionalAuth())
 		users.GET("/:id/follow-stats", r.userFollowHandler.GetFollowStats)
 	}
 }
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/platform/queue"
+	"echobackend/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Background tasks run by the account service. They are registered with the
+// queue in the DI container.
+const (
+	TaskDataExport    = "account:data_export"
+	TaskPurgeAccounts = "account:purge"
+)
+
+const (
+	dataExportTaskTimeout = 10 * time.Minute
+	// dataExportStaleAfter lets a new export replace one whose job was lost.
+	dataExportStaleAfter = time.Hour
+	accountPurgeBatch    = 100
+)
+
+// TaskEnqueuer queues background jobs.
+type TaskEnqueuer interface {
+	EnqueueJSON(taskType string, payload any, opts queue.TaskOptions) error
+	IsConfigured() bool
+}
+
+// ExportStorage keeps data export archives and removes the objects of erased
+// accounts.
+type ExportStorage interface {
+	Save(ctx context.Context, path string, file io.Reader, contentType string) error
+	Delete(ctx context.Context, path string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+	PresignedURL(ctx context.Context, path, filename string, expiry time.Duration) (string, error)
+}
+
+// DataExportMailer sends the download link of a finished export.
+type DataExportMailer interface {
+	EnqueueDataExportEmail(to, downloadLink string, expiresAt time.Time) error
+}
+
+// AccountService lets users export their data and delete their own account.
+type AccountService interface {
+	// RequestDataExport queues a new export unless one is already being
+	// built, in which case that one is returned with created false.
+	RequestDataExport(ctx context.Context, userID, ipAddress, userAgent string) (export *dto.DataExportResponse, created bool, err error)
+	GetDataExport(ctx context.Context, userID string) (*dto.DataExportResponse, error)
+	ProcessDataExport(ctx context.Context, exportID string) error
+	ScheduleDeletion(ctx context.Context, userID, password, ipAddress, userAgent string) (time.Time, error)
+	CancelDeletion(ctx context.Context, userID, ipAddress, userAgent string) error
+	// PurgeAccounts erases accounts whose deletion grace period is over and
+	// removes expired export archives.
+	PurgeAccounts(ctx context.Context) error
+	HandleDataExportTask(ctx context.Context, payload []byte) error
+	HandlePurgeTask(ctx context.Context, payload []byte) error
+}
+
+type dataExportTask struct {
+	ExportID string `json:"export_id"`
+}
+
+type accountService struct {
+	accountRepo     repository.AccountRepository
+	exportRepo      repository.DataExportRepository
+	userRepo        repository.UserRepository
+	activityService AuthActivityService
+	storage         ExportStorage
+	tasks           TaskEnqueuer
+	mailer          DataExportMailer
+	deletionGrace   time.Duration
+	exportTTL       time.Duration
+}
+
+func NewAccountService(
+	accountRepo repository.AccountRepository,
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	activityService AuthActivityService,
+	storage ExportStorage,
+	tasks TaskEnqueuer,
+	mailer DataExportMailer,
+	deletionGrace, exportTTL time.Duration,
+) AccountService {
+	return &accountService{
+		accountRepo:     accountRepo,
+		exportRepo:      exportRepo,
+		userRepo:        userRepo,
+		activityService: activityService,
+		storage:         storage,
+		tasks:           tasks,
+		mailer:          mailer,
+		deletionGrace:   deletionGrace,
+		exportTTL:       exportTTL,
+	}
+}
+
+func (s *accountService) RequestDataExport(ctx context.Context, userID, ipAddress, userAgent string) (*dto.DataExportResponse, bool, error) {
+	if s.storage == nil || s.tasks == nil || !s.tasks.IsConfigured() {
+		return nil, false, apperrors.ErrDataExportDisabled
+	}
+
+	latest, err := s.exportRepo.FindLatestByUserID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest != nil && isExportInProgress(latest) && time.Since(latest.CreatedAt) < dataExportStaleAfter {
+		return dto.DataExportToResponse(latest, nil), false, nil
+	}
+
+	export := &model.DataExport{UserID: userID, Status: model.DataExportPending}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, false, err
+	}
+
+	task := dataExportTask{ExportID: export.ID}
+	if err := s.tasks.EnqueueJSON(TaskDataExport, task, queue.TaskOptions{Timeout: dataExportTaskTimeout}); err != nil {
+		s.failExport(ctx, export, err)
+		return nil, false, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityDataExportReq, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"exportId": export.ID})
+	return dto.DataExportToResponse(export, nil), true, nil
+}
+
+func (s *accountService) GetDataExport(ctx context.Context, userID string) (*dto.DataExportResponse, error) {
+	export, err := s.exportRepo.FindLatestByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, apperrors.ErrDataExportNotFound
+	}
+
+	if export.Status != model.DataExportReady || export.FilePath == nil {
+		return dto.DataExportToResponse(export, nil), nil
+	}
+	if export.IsExpired(time.Now()) {
+		export.Status = model.DataExportExpired
+		return dto.DataExportToResponse(export, nil), nil
+	}
+
+	link, err := s.downloadURL(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+	return dto.DataExportToResponse(export, &link), nil
+}
+
+func (s *accountService) ProcessDataExport(ctx context.Context, exportID string) error {
+	export, err := s.exportRepo.FindByID(ctx, exportID)
+	if err != nil {
+		return err
+	}
+	// The account may have been erased meanwhile, or a retry may find the
+	// export already done.
+	if export == nil || export.Status == model.DataExportReady {
+		return nil
+	}
+
+	export.Status = model.DataExportProcessing
+	export.ErrorMessage = nil
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		return err
+	}
+
+	data, err := s.accountRepo.ExportData(ctx, export.UserID)
+	if err != nil {
+		s.failExport(ctx, export, err)
+		return err
+	}
+
+	archive, err := buildExportArchive(dto.AccountDataToExportFiles(data))
+	if err != nil {
+		s.failExport(ctx, export, err)
+		return err
+	}
+
+	path := fmt.Sprintf("exports/%s/%s.zip", export.UserID, export.ID)
+	if err := s.storage.Save(ctx, path, bytes.NewReader(archive), "application/zip"); err != nil {
+		s.failExport(ctx, export, err)
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.exportTTL)
+	size := int64(len(archive))
+	export.Status = model.DataExportReady
+	export.FilePath = &path
+	export.FileSize = &size
+	export.ExpiresAt = &expiresAt
+	export.CompletedAt = &now
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		return err
+	}
+
+	link, err := s.downloadURL(ctx, export)
+	if err != nil {
+		accountLog.Warn("failed to sign data export link", "error", err, "export_id", export.ID)
+		return nil
+	}
+	if err := s.mailer.EnqueueDataExportEmail(data.User.Email, link, expiresAt); err != nil {
+		accountLog.Warn("failed to queue data export email", "error", err, "export_id", export.ID)
+	}
+	return nil
+}
+
+func (s *accountService) ScheduleDeletion(ctx context.Context, userID, password, ipAddress, userAgent string) (time.Time, error) {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return time.Time{}, apperrors.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt != nil {
+		return time.Time{}, apperrors.ErrDeletionAlreadyScheduled
+	}
+
+	// Accounts created through OAuth have no password to confirm with.
+	if user.Password != nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)); err != nil {
+			s.activityService.LogActivity(ctx, &userID, model.ActivityDeletionScheduled, model.StatusFailure, ipAddress, userAgent, nil, nil)
+			return time.Time{}, apperrors.ErrInvalidCredentials
+		}
+	}
+
+	at := time.Now().Add(s.deletionGrace)
+	if err := s.accountRepo.SetDeletionSchedule(ctx, userID, &at); err != nil {
+		return time.Time{}, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityDeletionScheduled, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"deletionScheduledAt": at})
+	return at, nil
+}
+
+func (s *accountService) CancelDeletion(ctx context.Context, userID, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt == nil {
+		return apperrors.ErrDeletionNotScheduled
+	}
+
+	if err := s.accountRepo.SetDeletionSchedule(ctx, userID, nil); err != nil {
+		return err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityDeletionCancelled, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+	return nil
+}
+
+func (s *accountService) PurgeAccounts(ctx context.Context) error {
+	now := time.Now()
+	var errs []error
+
+	userIDs, err := s.accountRepo.ListDueForDeletion(ctx, now, accountPurgeBatch)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if err := s.eraseAccount(ctx, userID); err != nil {
+			accountLog.Error("failed to erase account", "error", err, "user_id", userID)
+			errs = append(errs, err)
+			continue
+		}
+		accountLog.Info("account erased after deletion grace period", "user_id", userID)
+	}
+
+	// Archives are only ever stored when storage is configured.
+	if s.storage == nil {
+		return errors.Join(errs...)
+	}
+	expired, err := s.exportRepo.ListExpired(ctx, now, accountPurgeBatch)
+	if err != nil {
+		return err
+	}
+	for _, export := range expired {
+		if err := s.storage.Delete(ctx, *export.FilePath); err != nil {
+			accountLog.Error("failed to delete expired data export", "error", err, "export_id", export.ID)
+			errs = append(errs, err)
+			continue
+		}
+		export.Status = model.DataExportExpired
+		export.FilePath = nil
+		if err := s.exportRepo.Update(ctx, export); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *accountService) HandleDataExportTask(ctx context.Context, payload []byte) error {
+	var task dataExportTask
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+	if task.ExportID == "" {
+		return fmt.Errorf("invalid data export payload: %w", queue.SkipRetry)
+	}
+	return s.ProcessDataExport(ctx, task.ExportID)
+}
+
+func (s *accountService) HandlePurgeTask(ctx context.Context, _ []byte) error {
+	return s.PurgeAccounts(ctx)
+}
+
+// eraseAccount deletes the user's stored objects, then the user. Without
+// storage there are no objects to delete.
+func (s *accountService) eraseAccount(ctx context.Context, userID string) error {
+	if s.storage != nil {
+		if err := s.deleteStoredObjects(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return s.accountRepo.Erase(ctx, userID)
+}
+
+// deleteStoredObjects deletes the user's export archives, avatars and uploaded
+// files. It runs before the erase: once the rows are gone nothing records
+// where the objects were.
+func (s *accountService) deleteStoredObjects(ctx context.Context, userID string) error {
+	exports, err := s.exportRepo.ListStoredByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := s.storage.Delete(ctx, *export.FilePath); err != nil {
+			return err
+		}
+	}
+
+	if err := s.storage.DeletePrefix(ctx, avatarUploadPrefix+"/"+userID+"/"); err != nil {
+		return err
+	}
+
+	paths, err := s.accountRepo.ListFilePaths(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := s.storage.Delete(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *accountService) failExport(ctx context.Context, export *model.DataExport, cause error) {
+	msg := cause.Error()
+	export.Status = model.DataExportFailed
+	export.ErrorMessage = &msg
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		accountLog.Error("failed to mark data export as failed", "error", err, "export_id", export.ID)
+	}
+	accountLog.Error("data export failed", "error", cause, "export_id", export.ID)
+}
+
+// downloadURL signs a link that works until the archive expires.
+func (s *accountService) downloadURL(ctx context.Context, export *model.DataExport) (string, error) {
+	filename := fmt.Sprintf("data-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02"))
+	return s.storage.PresignedURL(ctx, *export.FilePath, filename, time.Until(*export.ExpiresAt))
+}
+
+func isExportInProgress(export *model.DataExport) bool {
+	return export.Status == model.DataExportPending || export.Status == model.DataExportProcessing
+}
+
+// buildExportArchive writes each file as indented JSON into a ZIP archive.
+func buildExportArchive(files []dto.ExportFile) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.Content); err != nil {
+			return nil, fmt.Errorf("encode %s: %w", file.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
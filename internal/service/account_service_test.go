@@ -0,0 +1,328 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/platform/queue"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type mockAccountRepo struct {
+	scheduled map[string]*time.Time
+	due       []string
+	erased    []string
+	files     map[string][]string
+	data      *dto.AccountData
+}
+
+func (m *mockAccountRepo) SetDeletionSchedule(ctx context.Context, userID string, at *time.Time) error {
+	if m.scheduled == nil {
+		m.scheduled = map[string]*time.Time{}
+	}
+	m.scheduled[userID] = at
+	return nil
+}
+
+func (m *mockAccountRepo) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	return m.due, nil
+}
+
+func (m *mockAccountRepo) ListFilePaths(ctx context.Context, userID string) ([]string, error) {
+	return m.files[userID], nil
+}
+
+func (m *mockAccountRepo) Erase(ctx context.Context, userID string) error {
+	m.erased = append(m.erased, userID)
+	return nil
+}
+
+func (m *mockAccountRepo) ExportData(ctx context.Context, userID string) (*dto.AccountData, error) {
+	return m.data, nil
+}
+
+type mockDataExportRepo struct {
+	exports map[string]*model.DataExport
+	latest  *model.DataExport
+	expired []*model.DataExport
+}
+
+func (m *mockDataExportRepo) Create(ctx context.Context, export *model.DataExport) error {
+	export.ID = "export-1"
+	export.CreatedAt = time.Now()
+	m.exports = map[string]*model.DataExport{export.ID: export}
+	m.latest = export
+	return nil
+}
+
+func (m *mockDataExportRepo) FindByID(ctx context.Context, id string) (*model.DataExport, error) {
+	return m.exports[id], nil
+}
+
+func (m *mockDataExportRepo) FindLatestByUserID(ctx context.Context, userID string) (*model.DataExport, error) {
+	return m.latest, nil
+}
+
+func (m *mockDataExportRepo) Update(ctx context.Context, export *model.DataExport) error {
+	return nil
+}
+
+func (m *mockDataExportRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.DataExport, error) {
+	return m.expired, nil
+}
+
+func (m *mockDataExportRepo) ListStoredByUserID(ctx context.Context, userID string) ([]*model.DataExport, error) {
+	var stored []*model.DataExport
+	for _, e := range m.exports {
+		if e.UserID == userID && e.FilePath != nil {
+			stored = append(stored, e)
+		}
+	}
+	return stored, nil
+}
+
+type mockExportStorage struct {
+	files           map[string][]byte
+	deleted         []string
+	deletedPrefixes []string
+}
+
+func (m *mockExportStorage) Save(ctx context.Context, path string, file io.Reader, contentType string) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if m.files == nil {
+		m.files = map[string][]byte{}
+	}
+	m.files[path] = data
+	return nil
+}
+
+func (m *mockExportStorage) Delete(ctx context.Context, path string) error {
+	m.deleted = append(m.deleted, path)
+	return nil
+}
+
+func (m *mockExportStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	m.deletedPrefixes = append(m.deletedPrefixes, prefix)
+	return nil
+}
+
+func (m *mockExportStorage) PresignedURL(ctx context.Context, path, filename string, expiry time.Duration) (string, error) {
+	return "https://s3.example.com/" + path, nil
+}
+
+type mockTaskEnqueuer struct {
+	configured bool
+	tasks      []string
+	payloads   []any
+	options    []queue.TaskOptions
+}
+
+func (m *mockTaskEnqueuer) EnqueueJSON(taskType string, payload any, opts queue.TaskOptions) error {
+	m.tasks = append(m.tasks, taskType)
+	m.payloads = append(m.payloads, payload)
+	m.options = append(m.options, opts)
+	return nil
+}
+
+func (m *mockTaskEnqueuer) IsConfigured() bool {
+	return m.configured
+}
+
+type mockDataExportMailer struct {
+	links []string
+}
+
+func (m *mockDataExportMailer) EnqueueDataExportEmail(to, downloadLink string, expiresAt time.Time) error {
+	m.links = append(m.links, downloadLink)
+	return nil
+}
+
+func TestRequestDataExport_RequiresQueue(t *testing.T) {
+	svc := NewAccountService(&mockAccountRepo{}, &mockDataExportRepo{}, &mockUserRepo{}, &mockActivityRecorder{}, &mockExportStorage{}, &mockTaskEnqueuer{}, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	_, _, err := svc.RequestDataExport(context.Background(), "user-1", "127.0.0.1", "test")
+	if !errors.Is(err, apperrors.ErrDataExportDisabled) {
+		t.Fatalf("err = %v, want ErrDataExportDisabled", err)
+	}
+}
+
+func TestRequestDataExport_ReusesExportInProgress(t *testing.T) {
+	exports := &mockDataExportRepo{latest: &model.DataExport{ID: "export-0", UserID: "user-1", Status: model.DataExportProcessing, CreatedAt: time.Now()}}
+	tasks := &mockTaskEnqueuer{configured: true}
+	svc := NewAccountService(&mockAccountRepo{}, exports, &mockUserRepo{}, &mockActivityRecorder{}, &mockExportStorage{}, tasks, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	export, created, err := svc.RequestDataExport(context.Background(), "user-1", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("RequestDataExport: %v", err)
+	}
+	if created || export.ID != "export-0" || len(tasks.tasks) != 0 {
+		t.Fatalf("created = %v, export = %+v, tasks = %v", created, export, tasks.tasks)
+	}
+}
+
+func TestRequestDataExport_QueuesNewExport(t *testing.T) {
+	tasks := &mockTaskEnqueuer{configured: true}
+	activity := &mockActivityRecorder{}
+	svc := NewAccountService(&mockAccountRepo{}, &mockDataExportRepo{}, &mockUserRepo{}, activity, &mockExportStorage{}, tasks, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	export, created, err := svc.RequestDataExport(context.Background(), "user-1", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("RequestDataExport: %v", err)
+	}
+	if !created || export.Status != model.DataExportPending {
+		t.Fatalf("created = %v, export = %+v", created, export)
+	}
+	if len(tasks.tasks) != 1 || tasks.tasks[0] != TaskDataExport {
+		t.Fatalf("tasks = %v", tasks.tasks)
+	}
+	if !activity.has(model.ActivityDataExportReq) {
+		t.Fatal("expected data export request to be logged")
+	}
+}
+
+func TestProcessDataExport_BuildsArchiveAndEmailsLink(t *testing.T) {
+	title := "Hello"
+	export := &model.DataExport{ID: "export-1", UserID: "user-1", Status: model.DataExportPending, CreatedAt: time.Now()}
+	exports := &mockDataExportRepo{exports: map[string]*model.DataExport{export.ID: export}}
+	accounts := &mockAccountRepo{data: &dto.AccountData{
+		User:  &model.User{ID: "user-1", Email: "a@example.com"},
+		Posts: []*model.Post{{ID: "post-1", Title: &title, Tags: []model.Tag{{ID: 1, Name: "go"}}}},
+	}}
+	storage := &mockExportStorage{}
+	mailer := &mockDataExportMailer{}
+	svc := NewAccountService(accounts, exports, &mockUserRepo{}, &mockActivityRecorder{}, storage, &mockTaskEnqueuer{configured: true}, mailer, 30*24*time.Hour, 24*time.Hour)
+
+	if err := svc.ProcessDataExport(context.Background(), export.ID); err != nil {
+		t.Fatalf("ProcessDataExport: %v", err)
+	}
+
+	if export.Status != model.DataExportReady || export.ExpiresAt == nil || export.FilePath == nil {
+		t.Fatalf("export = %+v", export)
+	}
+	if *export.FilePath != "exports/user-1/export-1.zip" {
+		t.Fatalf("file path = %q", *export.FilePath)
+	}
+	if len(mailer.links) != 1 {
+		t.Fatalf("emails = %v", mailer.links)
+	}
+
+	archive := storage.files[*export.FilePath]
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	names := map[string]*zip.File{}
+	for _, f := range zr.File {
+		names[f.Name] = f
+	}
+	for _, name := range []string{"profile.json", "posts.json", "comments.json", "likes.json", "bookmarks.json", "follows.json", "notifications.json", "chat_conversations.json", "holdings.json", "auth_activity.json"} {
+		if names[name] == nil {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	rc, err := names["posts.json"].Open()
+	if err != nil {
+		t.Fatalf("open posts.json: %v", err)
+	}
+	defer rc.Close()
+	var posts []map[string]any
+	if err := json.NewDecoder(rc).Decode(&posts); err != nil {
+		t.Fatalf("decode posts.json: %v", err)
+	}
+	if len(posts) != 1 || posts[0]["title"] != "Hello" {
+		t.Fatalf("posts = %v", posts)
+	}
+}
+
+func TestScheduleDeletion_ChecksPassword(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	password := string(hash)
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id, Password: &password}, nil
+	}}
+	accounts := &mockAccountRepo{}
+	svc := NewAccountService(accounts, &mockDataExportRepo{}, users, &mockActivityRecorder{}, &mockExportStorage{}, &mockTaskEnqueuer{}, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	if _, err := svc.ScheduleDeletion(context.Background(), "user-1", "wrong", "127.0.0.1", "test"); !errors.Is(err, apperrors.ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+	}
+
+	at, err := svc.ScheduleDeletion(context.Background(), "user-1", "secret", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("ScheduleDeletion: %v", err)
+	}
+	if d := time.Until(at); d < 29*24*time.Hour || d > 30*24*time.Hour {
+		t.Fatalf("deletion scheduled %v from now, want the 30 day grace period", d)
+	}
+	if accounts.scheduled["user-1"] == nil {
+		t.Fatal("expected schedule to be stored")
+	}
+}
+
+func TestCancelDeletion_RequiresSchedule(t *testing.T) {
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id}, nil
+	}}
+	svc := NewAccountService(&mockAccountRepo{}, &mockDataExportRepo{}, users, &mockActivityRecorder{}, &mockExportStorage{}, &mockTaskEnqueuer{}, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	if err := svc.CancelDeletion(context.Background(), "user-1", "127.0.0.1", "test"); !errors.Is(err, apperrors.ErrDeletionNotScheduled) {
+		t.Fatalf("err = %v, want ErrDeletionNotScheduled", err)
+	}
+}
+
+func TestPurgeAccounts_ErasesDueAccountsAndExpiredArchives(t *testing.T) {
+	stored := "exports/user-1/export-1.zip"
+	expiredPath := "exports/user-2/export-2.zip"
+	expired := &model.DataExport{ID: "export-2", UserID: "user-2", Status: model.DataExportReady, FilePath: &expiredPath}
+	exports := &mockDataExportRepo{
+		exports: map[string]*model.DataExport{"export-1": {ID: "export-1", UserID: "user-1", FilePath: &stored}},
+		expired: []*model.DataExport{expired},
+	}
+	uploaded := "files/user-1/report.pdf"
+	accounts := &mockAccountRepo{due: []string{"user-1"}, files: map[string][]string{"user-1": {uploaded}}}
+	storage := &mockExportStorage{}
+	svc := NewAccountService(accounts, exports, &mockUserRepo{}, &mockActivityRecorder{}, storage, &mockTaskEnqueuer{}, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	if err := svc.PurgeAccounts(context.Background()); err != nil {
+		t.Fatalf("PurgeAccounts: %v", err)
+	}
+
+	if len(accounts.erased) != 1 || accounts.erased[0] != "user-1" {
+		t.Fatalf("erased = %v", accounts.erased)
+	}
+	if len(storage.deleted) != 3 || storage.deleted[0] != stored || storage.deleted[1] != uploaded || storage.deleted[2] != expiredPath {
+		t.Fatalf("deleted = %v", storage.deleted)
+	}
+	if len(storage.deletedPrefixes) != 1 || storage.deletedPrefixes[0] != "avatars/user-1/" {
+		t.Fatalf("deleted prefixes = %v", storage.deletedPrefixes)
+	}
+	if expired.Status != model.DataExportExpired || expired.FilePath != nil {
+		t.Fatalf("expired export = %+v", expired)
+	}
+}
+
+func TestPurgeAccounts_ErasesWithoutStorage(t *testing.T) {
+	accounts := &mockAccountRepo{due: []string{"user-1"}, files: map[string][]string{"user-1": {"files/user-1/report.pdf"}}}
+	svc := NewAccountService(accounts, &mockDataExportRepo{}, &mockUserRepo{}, &mockActivityRecorder{}, nil, &mockTaskEnqueuer{}, &mockDataExportMailer{}, 30*24*time.Hour, 24*time.Hour)
+
+	if err := svc.PurgeAccounts(context.Background()); err != nil {
+		t.Fatalf("PurgeAccounts: %v", err)
+	}
+	if len(accounts.erased) != 1 || accounts.erased[0] != "user-1" {
+		t.Fatalf("erased = %v", accounts.erased)
+	}
+}
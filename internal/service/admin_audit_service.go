@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+// AdminAuditEntry describes a privileged action. Before and After are the
+// target's state around the action (nil when it did not exist before or no
+// longer exists after); only the top-level fields that differ are stored.
+type AdminAuditEntry struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+	IPAddress  string
+	UserAgent  string
+}
+
+type AdminAuditService interface {
+	// Record stores entry. Failures are logged rather than returned so that
+	// auditing never fails the action itself.
+	Record(ctx context.Context, entry AdminAuditEntry)
+	List(ctx context.Context, filter repository.AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error)
+}
+
+type adminAuditService struct {
+	repo repository.AdminAuditLogRepository
+}
+
+func NewAdminAuditService(repo repository.AdminAuditLogRepository) AdminAuditService {
+	return &adminAuditService{repo: repo}
+}
+
+func (s *adminAuditService) Record(ctx context.Context, entry AdminAuditEntry) {
+	log := &model.AdminAuditLog{
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		Changes:    auditChanges(entry.Before, entry.After),
+	}
+	if entry.ActorID != "" {
+		log.ActorID = &entry.ActorID
+	}
+	if entry.TargetID != "" {
+		log.TargetID = &entry.TargetID
+	}
+	if entry.IPAddress != "" {
+		log.IPAddress = &entry.IPAddress
+	}
+	if entry.UserAgent != "" {
+		log.UserAgent = &entry.UserAgent
+	}
+
+	if err := s.repo.Create(ctx, log); err != nil {
+		auditLog.Error("failed to record admin audit log", "error", err, "action", entry.Action, "target_id", entry.TargetID)
+	}
+}
+
+func (s *adminAuditService) List(ctx context.Context, filter repository.AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error) {
+	return s.repo.List(ctx, filter, limit, offset)
+}
+
+// auditChanges encodes the difference between before and after as
+// {"before": {...}, "after": {...}}. When both sides are objects only the keys
+// whose values differ are kept; otherwise the side that exists is stored whole.
+func auditChanges(before, after any) *string {
+	beforeValue, beforeOK := auditValue(before)
+	afterValue, afterOK := auditValue(after)
+	if !beforeOK && !afterOK {
+		return nil
+	}
+
+	changes := map[string]any{}
+	beforeFields, beforeIsObject := beforeValue.(map[string]any)
+	afterFields, afterIsObject := afterValue.(map[string]any)
+	if beforeIsObject && afterIsObject {
+		changedBefore := map[string]any{}
+		changedAfter := map[string]any{}
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+				changedBefore[key] = value
+			}
+		}
+		for key, value := range afterFields {
+			if other, ok := beforeFields[key]; !ok || !reflect.DeepEqual(value, other) {
+				changedAfter[key] = value
+			}
+		}
+		changes["before"] = changedBefore
+		changes["after"] = changedAfter
+	} else {
+		if beforeOK {
+			changes["before"] = beforeValue
+		}
+		if afterOK {
+			changes["after"] = afterValue
+		}
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+	str := string(data)
+	return &str
+}
+
+// auditValue round-trips v through JSON so that structs, DTOs and maps compare
+// by their serialized fields. It reports false for nil values.
+func auditValue(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+type mockAdminAuditLogRepo struct {
+	logs      []*model.AdminAuditLog
+	createErr error
+}
+
+func (m *mockAdminAuditLogRepo) Create(ctx context.Context, log *model.AdminAuditLog) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *mockAdminAuditLogRepo) List(ctx context.Context, filter repository.AdminAuditLogFilter, limit, offset int) ([]*model.AdminAuditLog, int64, error) {
+	return m.logs, int64(len(m.logs)), nil
+}
+
+func TestAdminAuditRecord_StoresOnlyChangedFields(t *testing.T) {
+	repo := &mockAdminAuditLogRepo{}
+	svc := NewAdminAuditService(repo)
+
+	svc.Record(context.Background(), AdminAuditEntry{
+		ActorID:    "admin-1",
+		Action:     model.AuditTagUpdate,
+		TargetType: "tag",
+		TargetID:   "7",
+		Before:     &dto.TagResponse{ID: 7, Name: "golang"},
+		After:      &dto.TagResponse{ID: 7, Name: "go"},
+		IPAddress:  "10.0.0.1",
+	})
+
+	if len(repo.logs) != 1 {
+		t.Fatalf("stored %d logs, want 1", len(repo.logs))
+	}
+	log := repo.logs[0]
+	if *log.ActorID != "admin-1" || *log.TargetID != "7" || log.UserAgent != nil {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+
+	var changes map[string]map[string]any
+	if err := json.Unmarshal([]byte(*log.Changes), &changes); err != nil {
+		t.Fatalf("changes are not JSON: %v", err)
+	}
+	if len(changes["before"]) != 1 || changes["before"]["name"] != "golang" {
+		t.Fatalf("before = %v, want only the old name", changes["before"])
+	}
+	if len(changes["after"]) != 1 || changes["after"]["name"] != "go" {
+		t.Fatalf("after = %v, want only the new name", changes["after"])
+	}
+}
+
+func TestAdminAuditRecord_DeleteKeepsFullBeforeState(t *testing.T) {
+	repo := &mockAdminAuditLogRepo{}
+	svc := NewAdminAuditService(repo)
+
+	var missing *dto.TagResponse
+	svc.Record(context.Background(), AdminAuditEntry{
+		Action:     model.AuditTagDelete,
+		TargetType: "tag",
+		Before:     &dto.TagResponse{ID: 7, Name: "go"},
+		After:      missing,
+	})
+
+	var changes map[string]any
+	if err := json.Unmarshal([]byte(*repo.logs[0].Changes), &changes); err != nil {
+		t.Fatalf("changes are not JSON: %v", err)
+	}
+	before, ok := changes["before"].(map[string]any)
+	if !ok || before["name"] != "go" || before["id"] != float64(7) {
+		t.Fatalf("before = %v, want the full tag", changes["before"])
+	}
+	if _, ok := changes["after"]; ok {
+		t.Fatal("after must be omitted for a deleted target")
+	}
+}
+
+func TestAdminAuditRecord_SwallowsRepositoryErrors(t *testing.T) {
+	svc := NewAdminAuditService(&mockAdminAuditLogRepo{createErr: errors.New("db down")})
+	svc.Record(context.Background(), AdminAuditEntry{Action: model.AuditUserDelete, TargetType: "user"})
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+// PersonalAccessTokenPrefix marks personal access tokens so the auth
+// middleware can tell them apart from JWTs without a database lookup.
+const PersonalAccessTokenPrefix = "pat_"
+
+const (
+	maxAccessTokensPerUser = 50
+	// accessTokenDisplayLength is how much of the token is kept in clear
+	// for users to recognise it.
+	accessTokenDisplayLength = 12
+	// accessTokenTouchInterval limits last-used writes for busy tokens.
+	accessTokenTouchInterval = time.Minute
+)
+
+// CreateAccessToken issues a personal access token. The plaintext token is
+// returned only here; the database keeps its hash.
+func (s *authService) CreateAccessToken(ctx context.Context, userID string, req *dto.CreateAccessTokenRequest, ipAddress, userAgent string) (*dto.CreatedAccessTokenResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.accessTokenRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, apperrors.ErrAccessTokenLimitReached
+	}
+
+	tokenBytes, err := generateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	token := &model.PersonalAccessToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenHash:   tokenHash(plaintext),
+		TokenPrefix: plaintext[:accessTokenDisplayLength],
+		Scopes:      strings.Join(scopes, " "),
+		CreatedAt:   time.Now(),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := token.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.accessTokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityAccessTokenCreated, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"tokenId": token.ID, "scopes": scopes})
+
+	return &dto.CreatedAccessTokenResponse{
+		AccessTokenResponse: *dto.AccessTokenToResponse(token),
+		Token:               plaintext,
+	}, nil
+}
+
+func (s *authService) ListAccessTokens(ctx context.Context, userID string) ([]*dto.AccessTokenResponse, error) {
+	tokens, err := s.accessTokenRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		responses = append(responses, dto.AccessTokenToResponse(token))
+	}
+	return responses, nil
+}
+
+func (s *authService) RevokeAccessToken(ctx context.Context, userID, tokenID, ipAddress, userAgent string) error {
+	deleted, err := s.accessTokenRepo.Delete(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperrors.ErrAccessTokenNotFound
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityAccessTokenRevoked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"tokenId": tokenID})
+
+	return nil
+}
+
+// AuthenticateAccessToken resolves a personal access token presented as a
+// bearer token. Expired tokens and tokens of deleted users are rejected.
+func (s *authService) AuthenticateAccessToken(ctx context.Context, plaintext, ipAddress string) (*model.PersonalAccessToken, error) {
+	token, err := s.accessTokenRepo.FindByTokenHash(ctx, tokenHash(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token == nil || token.User == nil || token.IsExpired(now) {
+		return nil, apperrors.ErrInvalidToken
+	}
+	if token.User.IsSuspended(now) {
+		return nil, suspensionError(token.User)
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.accessTokenRepo.TouchLastUsed(ctx, token.ID, ipAddress, now); err != nil {
+			authLog.Warn("failed to record access token use", "token_id", token.ID, "error", err)
+		}
+	}
+
+	return token, nil
+}
+
+// normalizeScopes rejects unknown scopes and drops duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(model.PersonalAccessTokenScopes, scope) {
+			return nil, apperrors.ErrInvalidAccessTokenScope
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, apperrors.ErrInvalidAccessTokenScope
+	}
+	return normalized, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"echobackend/config"
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// ResendVerificationEmail issues a fresh verification link for the user's
+// current email address.
+func (s *authService) ResendVerificationEmail(ctx context.Context, userID, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if user.EmailVerifiedAt != nil {
+		return apperrors.ErrEmailAlreadyVerified
+	}
+
+	return s.issueEmailVerification(ctx, user, user.Email, model.ActivityEmailVerifyReq, ipAddress, userAgent)
+}
+
+// RequestVerificationEmail is ResendVerificationEmail for users who cannot
+// sign in until they verify. It does nothing, without an error, for unknown
+// or already verified addresses so that callers cannot tell them apart.
+func (s *authService) RequestVerificationEmail(ctx context.Context, email, ipAddress, userAgent string) error {
+	user, err := s.authRepo.FindUserByEmail(ctx, email)
+	if err != nil {
+		return nil //nolint:nilerr // user-enumeration protection
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.issueEmailVerification(ctx, user, user.Email, model.ActivityEmailVerifyReq, ipAddress, userAgent)
+}
+
+// RequestEmailChange sends a verification link to newEmail. The account keeps
+// its current address until the link is confirmed through VerifyEmail.
+func (s *authService) RequestEmailChange(ctx context.Context, userID, newEmail, password, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return apperrors.ErrEmailUnchanged
+	}
+
+	// Accounts created through OAuth have no password to confirm with.
+	if user.Password != nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)); err != nil {
+			s.activityService.LogActivity(ctx, &userID, model.ActivityEmailChangeReq, model.StatusFailure, ipAddress, userAgent, nil, nil)
+			return apperrors.ErrInvalidCredentials
+		}
+	}
+
+	if err := s.ensureEmailAvailable(ctx, newEmail, userID); err != nil {
+		return err
+	}
+
+	return s.issueEmailVerification(ctx, user, newEmail, model.ActivityEmailChangeReq, ipAddress, userAgent)
+}
+
+// VerifyEmail consumes a verification token. When the token was issued for a
+// different address than the user's current one, the address is changed.
+func (s *authService) VerifyEmail(ctx context.Context, token, ipAddress, userAgent string) error {
+	tokenEntry, err := s.emailVerificationTokenRepo.FindByToken(ctx, tokenHash(token))
+	if err != nil {
+		return apperrors.ErrInvalidToken
+	}
+	if tokenEntry == nil {
+		return apperrors.ErrInvalidToken
+	}
+
+	if tokenEntry.UsedAt != nil {
+		return apperrors.ErrVerificationTokenUsed
+	}
+
+	if time.Now().After(tokenEntry.ExpiresAt) {
+		return apperrors.ErrVerificationTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, tokenEntry.UserID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	activityType := model.ActivityEmailVerified
+	if !strings.EqualFold(tokenEntry.Email, user.Email) {
+		// Someone may have registered the address since the change was requested.
+		if err := s.ensureEmailAvailable(ctx, tokenEntry.Email, user.ID); err != nil {
+			return err
+		}
+		user.Email = tokenEntry.Email
+		activityType = model.ActivityEmailChange
+	}
+
+	// The token is consumed before the user is changed, so a link can only
+	// be applied once.
+	consumed, err := s.emailVerificationTokenRepo.MarkUsed(ctx, tokenEntry.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return apperrors.ErrVerificationTokenUsed
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, activityType, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+
+	return nil
+}
+
+// issueEmailVerification replaces any pending verification token for user
+// with a new one for email and queues the link.
+func (s *authService) issueEmailVerification(ctx context.Context, user *model.User, email, activityType, ipAddress, userAgent string) error {
+	verifyBytes, err := generateRandomBytes(32)
+	if err != nil {
+		return err
+	}
+	verifyToken := "ev_" + base64.RawURLEncoding.EncodeToString(verifyBytes)
+
+	tokenEntry := &model.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     email,
+		Token:     tokenHash(verifyToken),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}
+
+	if err := s.emailVerificationTokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		authLog.Warn("failed to delete previous email verification tokens", "user_id", user.ID, "error", err)
+	}
+
+	if err := s.emailVerificationTokenRepo.Create(ctx, tokenEntry); err != nil {
+		return err
+	}
+
+	verifyLink := buildFrontendTokenLink(s.frontendConfig.VerifyEmailURL, "http://localhost:3000/verify-email", verifyToken)
+	if s.emailService != nil && s.emailService.IsConfigured() {
+		if err := s.emailService.EnqueueVerificationEmail(email, verifyLink); err != nil {
+			errMsg := "Failed to queue email"
+			s.activityService.LogActivity(ctx, &user.ID, activityType, model.StatusFailure, ipAddress, userAgent, &errMsg, nil)
+			authLog.Error("failed to queue verification email", "error", err, "user_id", user.ID)
+			return nil
+		}
+		s.activityService.LogActivity(ctx, &user.ID, activityType, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"emailQueued": true})
+		return nil
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, activityType, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"devMode": true})
+
+	return nil
+}
+
+func (s *authService) ensureEmailAvailable(ctx context.Context, email, userID string) error {
+	existing, err := s.authRepo.FindUserByEmail(ctx, email)
+	if err == nil && existing.ID != userID {
+		return apperrors.ErrUserExists
+	}
+	if err != nil && !errors.Is(err, apperrors.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+// requiresVerifiedEmailForLogin reports whether user must verify their email
+// before a password login is allowed.
+func (s *authService) requiresVerifiedEmailForLogin(user *model.User) bool {
+	return s.emailVerification == config.EmailVerificationLogin && user.EmailVerifiedAt == nil
+}
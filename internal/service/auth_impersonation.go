@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ImpersonationActorClaim is the RFC 8693 "act" claim. Its "sub" member names
+// the admin acting as the token's user.
+const ImpersonationActorClaim = "act"
+
+// Impersonate issues a short-lived access token for userID on behalf of
+// adminID. The token has no "sid" and no refresh token, so it cannot be
+// extended and is refused by session-only routes.
+func (s *authService) Impersonate(ctx context.Context, adminID, userID, ipAddress, userAgent string) (*dto.ImpersonationResponse, error) {
+	if adminID == userID {
+		return nil, apperrors.ErrCannotImpersonateSelf
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil || user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	tokenID, err := newAccessTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.impersonationTTL)
+	claims := jwt.MapClaims{
+		"jti":                   tokenID,
+		"user_id":               user.ID,
+		"username":              user.Username,
+		"email":                 user.Email,
+		ImpersonationActorClaim: map[string]any{"sub": adminID},
+		"iat":                   now.Unix(),
+		"exp":                   expiresAt.Unix(),
+	}
+	token, err := s.tokenKeys.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityImpersonated, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"adminId": adminID, "expiresAt": expiresAt})
+	return &dto.ImpersonationResponse{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		User:        dto.UserToBrief(user),
+	}, nil
+}
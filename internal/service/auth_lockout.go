@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+// lockoutPolicy locks an account after threshold failed logins within window.
+// The first lock lasts baseDuration and each further failure doubles it, up to
+// maxDuration.
+type lockoutPolicy struct {
+	threshold    int
+	baseDuration time.Duration
+	maxDuration  time.Duration
+	window       time.Duration
+}
+
+func (p lockoutPolicy) enabled() bool {
+	return p.threshold > 0
+}
+
+func (p lockoutPolicy) lockDuration(failures int) time.Duration {
+	d := p.baseDuration
+	for i := p.threshold; i < failures && d < p.maxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.maxDuration)
+}
+
+type accountLock struct {
+	Until time.Time `json:"until"`
+}
+
+// checkAccountLock refuses logins to a locked account. The lock is read from
+// Redis first; the database row is consulted when Redis has no entry.
+func (s *authService) checkAccountLock(ctx context.Context, userID string) error {
+	if !s.lockout.enabled() {
+		return nil
+	}
+
+	now := time.Now()
+	if s.cache != nil {
+		var lock accountLock
+		found, err := s.cache.GetJSON(ctx, s.cache.BuildKey("login_lock", userID), &lock)
+		if err == nil && found && now.Before(lock.Until) {
+			return &apperrors.AccountLockedError{Until: lock.Until}
+		}
+	}
+
+	lockout, err := s.accountLockoutRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if lockout.IsLocked(now) {
+		return &apperrors.AccountLockedError{Until: *lockout.LockedUntil}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a wrong password or two-factor code. Failures
+// are counted in Redis, or in the database when Redis is unavailable. It
+// returns an *apperrors.AccountLockedError when this failure locks the
+// account.
+func (s *authService) recordLoginFailure(ctx context.Context, user *model.User, ipAddress, userAgent string) error {
+	if !s.lockout.enabled() {
+		return nil
+	}
+
+	failures := 0
+	if s.cache != nil {
+		// A zero count means Redis is not configured or failed.
+		failures, _, _ = s.cache.IncrementFixedWindow(ctx, s.cache.BuildKey("login_failures", user.ID), s.lockout.window)
+	}
+	if failures == 0 {
+		var err error
+		failures, err = s.accountLockoutRepo.RecordFailure(ctx, user.ID, s.lockout.window)
+		if err != nil {
+			authLog.Warn("failed to record login failure", "user_id", user.ID, "error", err)
+			return nil
+		}
+	}
+
+	if failures < s.lockout.threshold {
+		return nil
+	}
+
+	now := time.Now()
+	duration := s.lockout.lockDuration(failures)
+	until := now.Add(duration)
+
+	// The database row is written even when Redis holds the counter so that
+	// admins can list and unlock the account.
+	if err := s.accountLockoutRepo.Lock(ctx, user.ID, failures, until); err != nil {
+		authLog.Error("failed to persist account lock", "user_id", user.ID, "error", err)
+	}
+	if s.cache != nil {
+		_ = s.cache.SetJSONWithTTL(ctx, s.cache.BuildKey("login_lock", user.ID), accountLock{Until: until}, duration)
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityAccountLocked, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{
+		"failedAttempts": failures,
+		"lockedUntil":    until,
+	})
+
+	// Only the first lock in a window is emailed; later ones just extend it.
+	if failures == s.lockout.threshold {
+		s.sendAccountLockedEmail(user, until)
+	}
+
+	return &apperrors.AccountLockedError{Until: until}
+}
+
+func (s *authService) sendAccountLockedEmail(user *model.User, until time.Time) {
+	if s.emailService == nil || !s.emailService.IsConfigured() {
+		return
+	}
+
+	resetLink := s.frontendConfig.ForgotPasswordURL
+	if resetLink == "" {
+		resetLink = "http://localhost:3000/forgot-password"
+	}
+	if err := s.emailService.EnqueueAccountLockedEmail(user.Email, until, resetLink); err != nil {
+		authLog.Error("failed to queue account locked email", "error", err, "user_id", user.ID)
+	}
+}
+
+// clearLoginFailures resets the failure counter and removes any lock.
+func (s *authService) clearLoginFailures(ctx context.Context, userID string) error {
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx, s.cache.BuildKey("login_failures", userID), s.cache.BuildKey("login_lock", userID))
+	}
+	return s.accountLockoutRepo.DeleteByUserID(ctx, userID)
+}
+
+// UnlockAccount lets an admin lift a lock before it expires.
+func (s *authService) UnlockAccount(ctx context.Context, adminID, userID, ipAddress, userAgent string) error {
+	if _, err := s.userRepo.GetByID(ctx, userID, false); err != nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	if err := s.clearLoginFailures(ctx, userID); err != nil {
+		return err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityAccountUnlocked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"unlockedBy": adminID})
+
+	return nil
+}
+
+func (s *authService) ListLockedAccounts(ctx context.Context, limit, offset int) ([]*dto.LockedAccountResponse, int64, error) {
+	lockouts, totalCount, err := s.accountLockoutRepo.ListLocked(ctx, time.Now(), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*dto.LockedAccountResponse, 0, len(lockouts))
+	for _, lockout := range lockouts {
+		responses = append(responses, dto.LockedAccountToResponse(lockout))
+	}
+	return responses, totalCount, nil
+}
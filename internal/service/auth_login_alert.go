@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+const (
+	sessionRevokeTokenTTL = 7 * 24 * time.Hour
+	// NotificationTypeNewLogin marks in-app alerts about a sign-in from a new
+	// device or network.
+	NotificationTypeNewLogin = "new_login"
+)
+
+// checkLoginDevice records the device of a successful sign-in and alerts the
+// user when it has not been seen before. The very first device of an account
+// is recorded silently. Failures are logged and never block the sign-in.
+func (s *authService) checkLoginDevice(ctx context.Context, user *model.User, ipAddress, userAgent string) {
+	if s.knownDeviceRepo == nil {
+		return
+	}
+
+	fingerprint := deviceFingerprint(ipAddress, userAgent)
+	known, err := s.knownDeviceRepo.Touch(ctx, user.ID, fingerprint, ipAddress)
+	if err != nil {
+		authLog.Warn("failed to look up login device", "user_id", user.ID, "error", err)
+		return
+	}
+	if known {
+		return
+	}
+
+	hadDevices, err := s.knownDeviceRepo.HasAny(ctx, user.ID)
+	if err != nil {
+		authLog.Warn("failed to look up login devices", "user_id", user.ID, "error", err)
+		return
+	}
+
+	added, err := s.knownDeviceRepo.Add(ctx, &model.KnownDevice{
+		UserID:      user.ID,
+		Fingerprint: fingerprint,
+		IPAddress:   &ipAddress,
+		UserAgent:   &userAgent,
+	})
+	if err != nil {
+		authLog.Warn("failed to record login device", "user_id", user.ID, "error", err)
+		return
+	}
+	if !added || !hadDevices {
+		return
+	}
+
+	s.sendLoginAlert(ctx, user, fingerprint, ipAddress, userAgent)
+}
+
+// sendLoginAlert notifies the user in-app and by email. The email carries a
+// one-click link that signs out every session.
+func (s *authService) sendLoginAlert(ctx context.Context, user *model.User, fingerprint, ipAddress, userAgent string) {
+	metadata := map[string]any{"notified": false, "emailQueued": false}
+	defer func() {
+		s.activityService.LogActivity(ctx, &user.ID, model.ActivityNewDeviceLogin, model.StatusSuccess, ipAddress, userAgent, nil, metadata)
+	}()
+
+	if s.notificationService != nil {
+		message := "Signed in from " + loginDeviceLabel(userAgent) + " (" + ipAddress + "). If this wasn't you, sign out of all sessions and change your password."
+		_, err := s.notificationService.CreateNotification(ctx, &dto.CreateNotificationRequest{
+			UserID:  user.ID,
+			Type:    NotificationTypeNewLogin,
+			Title:   "New sign-in to your account",
+			Message: &message,
+			Data: map[string]any{
+				"ip_address": ipAddress,
+				"user_agent": userAgent,
+			},
+		})
+		if err != nil {
+			authLog.Warn("failed to create new sign-in notification", "user_id", user.ID, "error", err)
+		} else {
+			metadata["notified"] = true
+		}
+	}
+
+	if s.emailService == nil || !s.emailService.IsConfigured() || s.sessionRevokeTokenRepo == nil {
+		return
+	}
+
+	tokenBytes, err := generateRandomBytes(32)
+	if err != nil {
+		authLog.Warn("failed to generate session revoke token", "user_id", user.ID, "error", err)
+		return
+	}
+	revokeToken := "sr_" + base64.RawURLEncoding.EncodeToString(tokenBytes)
+	if err := s.sessionRevokeTokenRepo.Create(ctx, &model.SessionRevokeToken{
+		UserID:      user.ID,
+		Token:       tokenHash(revokeToken),
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(sessionRevokeTokenTTL),
+	}); err != nil {
+		authLog.Warn("failed to store session revoke token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	revokeLink := buildFrontendTokenLink(s.frontendConfig.SecureAccountURL, "http://localhost:3000/secure-account", revokeToken)
+	if err := s.emailService.EnqueueNewLoginEmail(user.Email, ipAddress, userAgent, time.Now(), revokeLink); err != nil {
+		authLog.Warn("failed to queue new sign-in email", "user_id", user.ID, "error", err)
+		return
+	}
+	metadata["emailQueued"] = true
+}
+
+// RevokeSessionsWithToken handles the "this wasn't me" link of a new sign-in
+// alert: every session and access token of the user is revoked and the
+// alerted device is forgotten. The link works without being signed in.
+func (s *authService) RevokeSessionsWithToken(ctx context.Context, token, ipAddress, userAgent string) error {
+	tokenEntry, err := s.sessionRevokeTokenRepo.FindByToken(ctx, tokenHash(token))
+	if err != nil {
+		return err
+	}
+	if tokenEntry == nil || tokenEntry.UsedAt != nil || time.Now().After(tokenEntry.ExpiresAt) {
+		return apperrors.ErrInvalidToken
+	}
+
+	consumed, err := s.sessionRevokeTokenRepo.MarkUsed(ctx, tokenEntry.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return apperrors.ErrInvalidToken
+	}
+
+	if err := s.sessionRepo.DeleteByUserID(ctx, tokenEntry.UserID); err != nil {
+		return err
+	}
+	if err := s.revokeAllAccessTokens(ctx, tokenEntry.UserID); err != nil {
+		return err
+	}
+	if err := s.knownDeviceRepo.Delete(ctx, tokenEntry.UserID, tokenEntry.Fingerprint); err != nil {
+		authLog.Warn("failed to forget alerted device", "user_id", tokenEntry.UserID, "error", err)
+	}
+
+	s.activityService.LogActivity(ctx, &tokenEntry.UserID, model.ActivitySessionRevoked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"scope": "all", "source": "login_alert"})
+	return nil
+}
+
+// deviceFingerprint identifies a device by its user agent and IP network
+// (/24 for IPv4, /64 for IPv6), so an address change within the same network
+// does not count as a new device.
+func deviceFingerprint(ipAddress, userAgent string) string {
+	network := ipAddress
+	if ip := net.ParseIP(ipAddress); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			network = v4.Mask(net.CIDRMask(24, 32)).String()
+		} else {
+			network = ip.Mask(net.CIDRMask(64, 128)).String()
+		}
+	}
+	return tokenHash(network + "\n" + strings.TrimSpace(userAgent))
+}
+
+func loginDeviceLabel(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return "an unknown device"
+	}
+	return userAgent
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+var _ repository.KnownDeviceRepository = (*mockKnownDeviceRepo)(nil)
+
+type mockKnownDeviceRepo struct {
+	devices map[string]bool
+}
+
+func (m *mockKnownDeviceRepo) Touch(ctx context.Context, userID, fingerprint, ipAddress string) (bool, error) {
+	return m.devices[userID+"/"+fingerprint], nil
+}
+
+func (m *mockKnownDeviceRepo) HasAny(ctx context.Context, userID string) (bool, error) {
+	for key := range m.devices {
+		if strings.HasPrefix(key, userID+"/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockKnownDeviceRepo) Add(ctx context.Context, device *model.KnownDevice) (bool, error) {
+	key := device.UserID + "/" + device.Fingerprint
+	if m.devices[key] {
+		return false, nil
+	}
+	if m.devices == nil {
+		m.devices = map[string]bool{}
+	}
+	m.devices[key] = true
+	return true, nil
+}
+
+func (m *mockKnownDeviceRepo) Delete(ctx context.Context, userID, fingerprint string) error {
+	delete(m.devices, userID+"/"+fingerprint)
+	return nil
+}
+
+var _ repository.SessionRevokeTokenRepository = (*mockSessionRevokeTokenRepo)(nil)
+
+type mockSessionRevokeTokenRepo struct {
+	tokens map[string]*model.SessionRevokeToken
+}
+
+func (m *mockSessionRevokeTokenRepo) Create(ctx context.Context, token *model.SessionRevokeToken) error {
+	if m.tokens == nil {
+		m.tokens = map[string]*model.SessionRevokeToken{}
+	}
+	token.ID = "revoke-1"
+	m.tokens[token.Token] = token
+	return nil
+}
+
+func (m *mockSessionRevokeTokenRepo) FindByToken(ctx context.Context, token string) (*model.SessionRevokeToken, error) {
+	return m.tokens[token], nil
+}
+
+func (m *mockSessionRevokeTokenRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	for _, t := range m.tokens {
+		if t.ID == id && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type mockLoginAlertMailer struct {
+	revokeLinks []string
+}
+
+func (m *mockLoginAlertMailer) EnqueuePasswordResetEmail(to, resetLink string) error { return nil }
+func (m *mockLoginAlertMailer) EnqueueVerificationEmail(to, verifyLink string) error { return nil }
+func (m *mockLoginAlertMailer) EnqueueMagicLinkEmail(to, loginLink string) error     { return nil }
+func (m *mockLoginAlertMailer) IsConfigured() bool                                   { return true }
+func (m *mockLoginAlertMailer) EnqueueAccountLockedEmail(to string, lockedUntil time.Time, resetLink string) error {
+	return nil
+}
+func (m *mockLoginAlertMailer) EnqueueAccountSuspendedEmail(to, reason string, suspendedUntil *time.Time) error {
+	return nil
+}
+func (m *mockLoginAlertMailer) EnqueueAccountReinstatedEmail(to, loginLink string) error { return nil }
+func (m *mockLoginAlertMailer) EnqueueNewLoginEmail(to, ipAddress, userAgent string, signedInAt time.Time, revokeLink string) error {
+	m.revokeLinks = append(m.revokeLinks, revokeLink)
+	return nil
+}
+
+func TestCheckLoginDevice_AlertsOnlyForNewDevices(t *testing.T) {
+	var notified []string
+	notifications := &mockNotificationService{createNotificationFn: func(ctx context.Context, req *dto.CreateNotificationRequest) (*dto.NotificationResponse, error) {
+		notified = append(notified, req.Type)
+		return &dto.NotificationResponse{}, nil
+	}}
+	mailer := &mockLoginAlertMailer{}
+	svc := &authService{
+		sessionRepo:            &mockSessionRepo{},
+		activityService:        &mockActivityRecorder{},
+		knownDeviceRepo:        &mockKnownDeviceRepo{},
+		sessionRevokeTokenRepo: &mockSessionRevokeTokenRepo{},
+		notificationService:    notifications,
+		emailService:           mailer,
+	}
+	user := &model.User{ID: "user-1", Email: "a@example.com"}
+	ctx := context.Background()
+
+	svc.checkLoginDevice(ctx, user, "203.0.113.10", "Firefox")
+	if len(notified) != 0 || len(mailer.revokeLinks) != 0 {
+		t.Fatalf("first device should be recorded silently, notified = %v", notified)
+	}
+
+	svc.checkLoginDevice(ctx, user, "203.0.113.99", "Firefox")
+	if len(notified) != 0 {
+		t.Fatalf("same network and user agent should not alert, notified = %v", notified)
+	}
+
+	svc.checkLoginDevice(ctx, user, "198.51.100.7", "Firefox")
+	if len(notified) != 1 || notified[0] != NotificationTypeNewLogin {
+		t.Fatalf("notified = %v, want one %q alert", notified, NotificationTypeNewLogin)
+	}
+	if len(mailer.revokeLinks) != 1 || !strings.Contains(mailer.revokeLinks[0], "token=sr_") {
+		t.Fatalf("revoke links = %v", mailer.revokeLinks)
+	}
+}
+
+func TestRevokeSessionsWithToken_RevokesAllSessionsOnce(t *testing.T) {
+	devices := &mockKnownDeviceRepo{devices: map[string]bool{"user-1/old": true}}
+	sessions := &mockSessionRepo{}
+	mailer := &mockLoginAlertMailer{}
+	svc := &authService{
+		sessionRepo:            sessions,
+		userRepo:               &mockUserRepo{},
+		activityService:        &mockActivityRecorder{},
+		knownDeviceRepo:        devices,
+		sessionRevokeTokenRepo: &mockSessionRevokeTokenRepo{},
+		notificationService:    &mockNotificationService{},
+		emailService:           mailer,
+	}
+	user := &model.User{ID: "user-1", Email: "a@example.com"}
+	ctx := context.Background()
+
+	svc.checkLoginDevice(ctx, user, "198.51.100.7", "Firefox")
+	if len(mailer.revokeLinks) != 1 {
+		t.Fatalf("revoke links = %v", mailer.revokeLinks)
+	}
+	token := mailer.revokeLinks[0][strings.Index(mailer.revokeLinks[0], "token=")+len("token="):]
+
+	if err := svc.RevokeSessionsWithToken(ctx, token, "198.51.100.8", "Chrome"); err != nil {
+		t.Fatalf("RevokeSessionsWithToken: %v", err)
+	}
+	if len(sessions.deletedUsers) != 1 || sessions.deletedUsers[0] != "user-1" {
+		t.Fatalf("deleted sessions of %v", sessions.deletedUsers)
+	}
+	if devices.devices["user-1/"+deviceFingerprint("198.51.100.7", "Firefox")] {
+		t.Fatal("expected the alerted device to be forgotten")
+	}
+
+	if err := svc.RevokeSessionsWithToken(ctx, token, "198.51.100.8", "Chrome"); !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("second use err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+)
+
+const magicLinkTTL = 15 * time.Minute
+
+// RequestMagicLink emails a single-use login link. Like ForgotPassword it
+// never reveals whether an account exists for email.
+func (s *authService) RequestMagicLink(ctx context.Context, email, ipAddress, userAgent string) error {
+	user, err := s.authRepo.FindUserByEmail(ctx, email)
+	if err != nil {
+		return nil //nolint:nilerr // user-enumeration protection
+	}
+
+	linkBytes, err := generateRandomBytes(32)
+	if err != nil {
+		return err
+	}
+	linkToken := "ml_" + base64.RawURLEncoding.EncodeToString(linkBytes)
+
+	tokenEntry := &model.MagicLinkToken{
+		UserID:    user.ID,
+		Token:     tokenHash(linkToken),
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+	}
+
+	// Only the most recent link is valid.
+	if err := s.magicLinkTokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		authLog.Warn("failed to delete previous magic link tokens", "user_id", user.ID, "error", err)
+	}
+
+	if err := s.magicLinkTokenRepo.Create(ctx, tokenEntry); err != nil {
+		return err
+	}
+
+	loginLink := buildFrontendTokenLink(s.frontendConfig.MagicLinkURL, "http://localhost:3000/magic-link", linkToken)
+	if s.emailService != nil && s.emailService.IsConfigured() {
+		if err := s.emailService.EnqueueMagicLinkEmail(user.Email, loginLink); err != nil {
+			errMsg := "Failed to queue email"
+			s.activityService.LogActivity(ctx, &user.ID, model.ActivityMagicLinkReq, model.StatusFailure, ipAddress, userAgent, &errMsg, nil)
+			authLog.Error("failed to queue magic link email", "error", err, "user_id", user.ID)
+			return nil
+		}
+		s.activityService.LogActivity(ctx, &user.ID, model.ActivityMagicLinkReq, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"emailQueued": true})
+		return nil
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityMagicLinkReq, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"devMode": true})
+
+	return nil
+}
+
+// ConsumeMagicLink exchanges a login link for tokens. Opening the link proves
+// ownership of the address, so an unverified email becomes verified. Accounts
+// with two-factor authentication still have to pass the second factor.
+func (s *authService) ConsumeMagicLink(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error) {
+	metadata := map[string]any{"method": "magic_link"}
+
+	tokenEntry, err := s.magicLinkTokenRepo.FindByToken(ctx, tokenHash(token))
+	if err != nil {
+		return "", "", nil, err
+	}
+	if tokenEntry == nil {
+		s.activityService.LogActivity(ctx, nil, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+	if tokenEntry.UsedAt != nil || time.Now().After(tokenEntry.ExpiresAt) {
+		s.activityService.LogActivity(ctx, &tokenEntry.UserID, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	consumed, err := s.magicLinkTokenRepo.MarkUsed(ctx, tokenEntry.ID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if !consumed {
+		s.activityService.LogActivity(ctx, &tokenEntry.UserID, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, tokenEntry.UserID, false)
+	if err != nil {
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			authLog.Warn("failed to mark email verified after magic link login", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return s.beginLogin(ctx, user, ipAddress, userAgent, metadata)
+}
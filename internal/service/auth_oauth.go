@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/pkg/oauth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oauthLinkPurpose = "oauth_link"
+	// oauthLinkTTL covers the round trip through the provider's consent screen.
+	oauthLinkTTL = 10 * time.Minute
+
+	oauthUsernameMaxLen   = 30
+	oauthUsernameAttempts = 5
+)
+
+func (s *authService) OAuthProviders() []string {
+	return s.oauthProviders.Names()
+}
+
+func (s *authService) GetOAuthURL(ctx context.Context, provider, state string) (string, error) {
+	p, err := s.oauthProvider(provider)
+	if err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(ctx, state)
+}
+
+// SignInWithOAuth redeems a provider callback. A known identity signs in its
+// user; an unknown one creates a new account. An unknown identity whose email
+// already belongs to an account is refused rather than merged, since the
+// provider's claim on the address is not proof of owning that account; the
+// owner can sign in and link the provider instead.
+func (s *authService) SignInWithOAuth(ctx context.Context, provider, code, state, ipAddress, userAgent string) (string, string, *model.User, error) {
+	metadata := map[string]any{"provider": provider}
+
+	identity, err := s.exchangeOAuthCode(ctx, provider, code, state)
+	if err != nil {
+		s.activityService.LogActivity(ctx, nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return "", "", nil, err
+	}
+
+	user, err := s.findOrCreateOAuthUser(ctx, identity)
+	if err != nil {
+		errMsg := err.Error()
+		s.activityService.LogActivity(ctx, nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, &errMsg, metadata)
+		return "", "", nil, err
+	}
+
+	if err := s.refuseSuspendedLogin(ctx, user, model.ActivityOAuthLoginFailed, ipAddress, userAgent, metadata); err != nil {
+		return "", "", nil, err
+	}
+
+	tokenString, refreshToken, err := s.createTokenAndSession(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		s.activityService.LogActivity(ctx, &user.ID, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return "", "", nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityOAuthLogin, model.StatusSuccess, ipAddress, userAgent, nil, metadata)
+	s.checkLoginDevice(ctx, user, ipAddress, userAgent)
+
+	now := time.Now()
+	user.LastLoggedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		authLog.Warn("failed to update last_logged_at", "user_id", user.ID, "error", err)
+	}
+
+	return tokenString, refreshToken, user, nil
+}
+
+// CreateOAuthLinkToken returns a short-lived token that makes the callback
+// of provider link the identity to the user's account. The browser cannot
+// carry the access token through the provider redirect, so the token travels
+// in a cookie instead.
+func (s *authService) CreateOAuthLinkToken(_ context.Context, userID, provider string) (string, error) {
+	if _, err := s.oauthProvider(provider); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":      userID,
+		"purpose":  oauthLinkPurpose,
+		"provider": provider,
+		"iat":      now.Unix(),
+		"exp":      now.Add(oauthLinkTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.oauthLinkKey())
+}
+
+// LinkOAuthIdentity attaches the identity returned by a provider callback to
+// the user the link token was issued for.
+func (s *authService) LinkOAuthIdentity(ctx context.Context, linkToken, provider, code, state, ipAddress, userAgent string) error {
+	userID, err := s.parseOAuthLinkToken(linkToken, provider)
+	if err != nil {
+		return apperrors.ErrInvalidToken
+	}
+	metadata := map[string]any{"provider": provider}
+
+	identity, err := s.exchangeOAuthCode(ctx, provider, code, state)
+	if err != nil {
+		s.activityService.LogActivity(ctx, &userID, model.ActivityIdentityLinked, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return err
+	}
+
+	existing, err := s.userIdentityRepo.FindByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		s.activityService.LogActivity(ctx, &userID, model.ActivityIdentityLinked, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		return apperrors.ErrIdentityAlreadyLinked
+	}
+
+	if err := s.userIdentityRepo.Create(ctx, newUserIdentity(userID, identity)); err != nil {
+		if errors.Is(err, apperrors.ErrIdentityAlreadyLinked) {
+			s.activityService.LogActivity(ctx, &userID, model.ActivityIdentityLinked, model.StatusFailure, ipAddress, userAgent, nil, metadata)
+		}
+		return err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityIdentityLinked, model.StatusSuccess, ipAddress, userAgent, nil, metadata)
+
+	return nil
+}
+
+func (s *authService) ListIdentities(ctx context.Context, userID string) ([]*dto.IdentityResponse, error) {
+	identities, err := s.userIdentityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.IdentityResponse, 0, len(identities))
+	for i := range identities {
+		responses = append(responses, dto.IdentityToResponse(&identities[i]))
+	}
+	return responses, nil
+}
+
+// UnlinkIdentity removes a linked provider. The last identity of an account
+// without a password cannot be removed, as the user could no longer sign in.
+func (s *authService) UnlinkIdentity(ctx context.Context, userID, provider, ipAddress, userAgent string) error {
+	identities, err := s.userIdentityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return apperrors.ErrIdentityNotFound
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if user.Password == nil && len(identities) <= 1 {
+		return apperrors.ErrLastLoginMethod
+	}
+
+	deleted, err := s.userIdentityRepo.DeleteByUserAndProvider(ctx, userID, provider)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperrors.ErrIdentityNotFound
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityIdentityUnlinked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"provider": provider})
+
+	return nil
+}
+
+func (s *authService) oauthProvider(name string) (oauth.Provider, error) {
+	p, err := s.oauthProviders.Get(name)
+	if err != nil {
+		return nil, apperrors.ErrOAuthProviderNotFound
+	}
+	return p, nil
+}
+
+func (s *authService) exchangeOAuthCode(ctx context.Context, provider, code, state string) (*oauth.Identity, error) {
+	p, err := s.oauthProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := p.Exchange(ctx, code, state)
+	if err != nil {
+		authLog.Warn("oauth code exchange failed", "provider", provider, "error", err)
+		return nil, apperrors.ErrOAuthExchangeFailed
+	}
+	return identity, nil
+}
+
+func (s *authService) findOrCreateOAuthUser(ctx context.Context, identity *oauth.Identity) (*model.User, error) {
+	existing, err := s.userIdentityRepo.FindByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.userRepo.GetByID(ctx, existing.UserID, false)
+	}
+
+	email := identity.Email
+	if email == "" {
+		email = fmt.Sprintf("%s@%s.placeholder", placeholderLocalPart(identity.Subject), identity.Provider)
+	}
+	if err := s.ensureEmailAvailable(ctx, email, ""); err != nil {
+		if errors.Is(err, apperrors.ErrUserExists) {
+			return nil, apperrors.ErrOAuthEmailInUse
+		}
+		return nil, err
+	}
+
+	username, err := s.availableOAuthUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:    email,
+		Username: &username,
+	}
+	if identity.AvatarURL != "" {
+		user.Image = &identity.AvatarURL
+	}
+	if identity.Email != "" && identity.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userIdentityRepo.CreateUserWithIdentity(ctx, user, newUserIdentity("", identity)); err != nil {
+		if errors.Is(err, apperrors.ErrUserExists) {
+			return nil, apperrors.ErrOAuthEmailInUse
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableOAuthUsername derives a username from the provider profile,
+// adding a random suffix when the preferred one is taken.
+func (s *authService) availableOAuthUsername(ctx context.Context, identity *oauth.Identity) (string, error) {
+	base := sanitizeUsername(identity.Username)
+	if base == "" {
+		local, _, _ := strings.Cut(identity.Email, "@")
+		base = sanitizeUsername(local)
+	}
+	if len(base) < 3 {
+		base = identity.Provider + "-user"
+	}
+
+	candidate := base
+	for range oauthUsernameAttempts {
+		err := s.userRepo.CheckUserByUsername(ctx, candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, apperrors.ErrUserExists) {
+			return "", err
+		}
+
+		suffix, err := generateRandomBytes(3)
+		if err != nil {
+			return "", err
+		}
+		candidate = truncateUsername(base, oauthUsernameMaxLen-7) + "-" + hex.EncodeToString(suffix)
+	}
+	return "", apperrors.ErrUserExists
+}
+
+func (s *authService) oauthLinkKey() []byte {
+	sum := sha256.Sum256(append([]byte(oauthLinkPurpose+":"), s.jwtSecret...))
+	return sum[:]
+}
+
+func (s *authService) parseOAuthLinkToken(linkToken, provider string) (string, error) {
+	token, err := jwt.Parse(linkToken, func(*jwt.Token) (any, error) {
+		return s.oauthLinkKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != oauthLinkPurpose || claims["provider"] != provider {
+		return "", apperrors.ErrInvalidToken
+	}
+
+	userID, err := claims.GetSubject()
+	if err != nil || userID == "" {
+		return "", apperrors.ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func newUserIdentity(userID string, identity *oauth.Identity) *model.UserIdentity {
+	entry := &model.UserIdentity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+	}
+	if identity.Email != "" {
+		entry.Email = &identity.Email
+	}
+	return entry
+}
+
+func sanitizeUsername(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			b.WriteRune(r)
+		}
+	}
+	return truncateUsername(b.String(), oauthUsernameMaxLen)
+}
+
+// placeholderLocalPart keeps placeholder addresses valid for subjects that
+// contain characters such as "|" (e.g. "auth0|123").
+func placeholderLocalPart(subject string) string {
+	if clean := sanitizeUsername(subject); clean == subject {
+		return subject
+	}
+	sum := sha256.Sum256([]byte(subject))
+	return hex.EncodeToString(sum[:8])
+}
+
+func truncateUsername(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -7,75 +7,131 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
-	"fmt"
 	"io"
-	"net/http"
+	"maps"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 
 	"echobackend/config"
 	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
 	"echobackend/internal/model"
 	"echobackend/internal/repository"
+	"echobackend/pkg/jwtkeys"
+	"echobackend/pkg/oauth"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService interface {
-	Register(ctx context.Context, email, username, password string) (*model.User, error)
+	Register(ctx context.Context, email, username, password, ipAddress, userAgent string) (*model.User, error)
 	Login(ctx context.Context, identifier, password, ipAddress, userAgent string) (string, string, *model.User, error)
 	ForgotPassword(ctx context.Context, email, ipAddress, userAgent string) error
 	ResetPassword(ctx context.Context, token, password, ipAddress, userAgent string) error
 	RefreshToken(ctx context.Context, refreshToken, ipAddress, userAgent string) (string, string, *model.User, error)
 	ChangePassword(ctx context.Context, userID, currentPassword, newPassword, ipAddress, userAgent string) error
-	Logout(ctx context.Context, refreshToken string) error
+	Logout(ctx context.Context, refreshToken, accessTokenID string, accessTokenExpiresAt time.Time) error
 	GetProfile(ctx context.Context, userID string) (*model.User, error)
-	GetGithubOAuthURL(state string) string
-	GetGithubToken(ctx context.Context, code string) (string, error)
-	SignInWithGithub(ctx context.Context, githubUser *GithubUser, ipAddress, userAgent string) (string, string, *model.User, error)
+	OAuthProviders() []string
+	GetOAuthURL(ctx context.Context, provider, state string) (string, error)
+	SignInWithOAuth(ctx context.Context, provider, code, state, ipAddress, userAgent string) (string, string, *model.User, error)
+	CreateOAuthLinkToken(ctx context.Context, userID, provider string) (string, error)
+	LinkOAuthIdentity(ctx context.Context, linkToken, provider, code, state, ipAddress, userAgent string) error
+	ListIdentities(ctx context.Context, userID string) ([]*dto.IdentityResponse, error)
+	UnlinkIdentity(ctx context.Context, userID, provider, ipAddress, userAgent string) error
 	CreateOAuthExchangeCode(ctx context.Context, accessToken, refreshToken string, user *model.User) (string, error)
 	ExchangeOAuthCode(ctx context.Context, code string) (string, string, *model.User, error)
+	CreateTwoFactorChallenge(ctx context.Context, user *model.User) (string, error)
+	VerifyTwoFactorLogin(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (string, string, *model.User, error)
+	EnrollTwoFactor(ctx context.Context, userID, ipAddress, userAgent string) (*dto.TwoFactorEnrollResponse, error)
+	ConfirmTwoFactor(ctx context.Context, userID, code, ipAddress, userAgent string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID, password, code, ipAddress, userAgent string) error
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]*dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID, ipAddress, userAgent string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID, ipAddress, userAgent string) (int64, error)
+	VerifyEmail(ctx context.Context, token, ipAddress, userAgent string) error
+	ResendVerificationEmail(ctx context.Context, userID, ipAddress, userAgent string) error
+	RequestVerificationEmail(ctx context.Context, email, ipAddress, userAgent string) error
+	RequestEmailChange(ctx context.Context, userID, newEmail, password, ipAddress, userAgent string) error
+	RequestMagicLink(ctx context.Context, email, ipAddress, userAgent string) error
+	ConsumeMagicLink(ctx context.Context, token, ipAddress, userAgent string) (string, string, *model.User, error)
+	UnlockAccount(ctx context.Context, adminID, userID, ipAddress, userAgent string) error
+	ListLockedAccounts(ctx context.Context, limit, offset int) ([]*dto.LockedAccountResponse, int64, error)
+	CreateAccessToken(ctx context.Context, userID string, req *dto.CreateAccessTokenRequest, ipAddress, userAgent string) (*dto.CreatedAccessTokenResponse, error)
+	ListAccessTokens(ctx context.Context, userID string) ([]*dto.AccessTokenResponse, error)
+	RevokeAccessToken(ctx context.Context, userID, tokenID, ipAddress, userAgent string) error
+	Impersonate(ctx context.Context, adminID, userID, ipAddress, userAgent string) (*dto.ImpersonationResponse, error)
+	RevokeSessionsWithToken(ctx context.Context, token, ipAddress, userAgent string) error
+	SuspendUser(ctx context.Context, adminID, userID, reason string, until *time.Time, ipAddress, userAgent string) (*dto.Suspension, error)
+	UnsuspendUser(ctx context.Context, adminID, userID, ipAddress, userAgent string) error
+	LiftExpiredSuspensions(ctx context.Context) error
+	HandleLiftSuspensionsTask(ctx context.Context, payload []byte) error
+	AccessTokenAuthenticator
+	AccessTokenRevocationChecker
+	JWKS() jwtkeys.JWKS
 }
 
-type GithubUser struct {
-	Login     string  `json:"login"`
-	ID        int64   `json:"id"`
-	AvatarURL string  `json:"avatar_url"`
-	Email     *string `json:"email"`
-	Name      string  `json:"name"`
-	HTMLURL   string  `json:"html_url"`
+// AccessTokenAuthenticator resolves personal access tokens for the auth
+// middleware.
+type AccessTokenAuthenticator interface {
+	AuthenticateAccessToken(ctx context.Context, token, ipAddress string) (*model.PersonalAccessToken, error)
 }
 
 type EmailSender interface {
 	EnqueuePasswordResetEmail(to, resetLink string) error
+	EnqueueVerificationEmail(to, verifyLink string) error
+	EnqueueMagicLinkEmail(to, loginLink string) error
+	EnqueueAccountLockedEmail(to string, lockedUntil time.Time, resetLink string) error
+	EnqueueNewLoginEmail(to, ipAddress, userAgent string, signedInAt time.Time, revokeLink string) error
+	EnqueueAccountSuspendedEmail(to, reason string, suspendedUntil *time.Time) error
+	EnqueueAccountReinstatedEmail(to, loginLink string) error
 	IsConfigured() bool
 }
 
 type AuthCache interface {
 	BuildKey(parts ...string) string
+	GetJSON(ctx context.Context, key string, dest any) (bool, error)
 	GetJSONAndDelete(ctx context.Context, key string, dest any) (bool, error)
 	SetJSONWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error
+	IncrementFixedWindow(ctx context.Context, key string, window time.Duration) (int, time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+	DeleteByPrefix(ctx context.Context, prefix string) error
 }
 
 type authService struct {
-	authRepo               repository.AuthRepository
-	userRepo               repository.UserRepository
-	sessionRepo            repository.SessionRepository
-	passwordResetTokenRepo repository.PasswordResetTokenRepository
-	activityService        AuthActivityService
-	jwtSecret              []byte
-	jwtExpiry              time.Duration
-	refreshTokenExpiry     time.Duration
-	githubConfig           config.GitHubConfig
-	frontendConfig         config.FrontendConfig
-	emailService           EmailSender
-	httpClient             *http.Client
-	oauthExchangeCache     AuthCache
-	oauthExchangeCodes     map[string]oauthExchangeEntry
-	oauthExchangeMu        sync.Mutex
+	authRepo                   repository.AuthRepository
+	userRepo                   repository.UserRepository
+	sessionRepo                repository.SessionRepository
+	passwordResetTokenRepo     repository.PasswordResetTokenRepository
+	emailVerificationTokenRepo repository.EmailVerificationTokenRepository
+	magicLinkTokenRepo         repository.MagicLinkTokenRepository
+	userIdentityRepo           repository.UserIdentityRepository
+	twoFactorRepo              repository.TwoFactorRepository
+	accountLockoutRepo         repository.AccountLockoutRepository
+	accessTokenRepo            repository.PersonalAccessTokenRepository
+	knownDeviceRepo            repository.KnownDeviceRepository
+	sessionRevokeTokenRepo     repository.SessionRevokeTokenRepository
+	suspensionRepo             repository.UserSuspensionRepository
+	activityService            AuthActivityService
+	notificationService        NotificationService
+	jwtSecret                  []byte
+	tokenKeys                  *jwtkeys.KeySet
+	jwtExpiry                  time.Duration
+	refreshTokenExpiry         time.Duration
+	impersonationTTL           time.Duration
+	totpIssuer                 string
+	emailVerification          string
+	lockout                    lockoutPolicy
+	frontendConfig             config.FrontendConfig
+	emailService               EmailSender
+	oauthProviders             *oauth.Registry
+	cache                      AuthCache
+	oauthExchangeCodes         map[string]oauthExchangeEntry
+	oauthExchangeMu            sync.Mutex
+	deniedAccessTokens         map[string]time.Time
+	deniedAccessTokensMu       sync.Mutex
 }
 
 const oauthExchangeTTL = 2 * time.Minute
@@ -92,30 +148,62 @@ func NewAuthService(
 	userRepo repository.UserRepository,
 	sessionRepo repository.SessionRepository,
 	passwordResetTokenRepo repository.PasswordResetTokenRepository,
+	emailVerificationTokenRepo repository.EmailVerificationTokenRepository,
+	magicLinkTokenRepo repository.MagicLinkTokenRepository,
+	userIdentityRepo repository.UserIdentityRepository,
+	twoFactorRepo repository.TwoFactorRepository,
+	accountLockoutRepo repository.AccountLockoutRepository,
+	accessTokenRepo repository.PersonalAccessTokenRepository,
+	knownDeviceRepo repository.KnownDeviceRepository,
+	sessionRevokeTokenRepo repository.SessionRevokeTokenRepository,
+	suspensionRepo repository.UserSuspensionRepository,
 	activityService AuthActivityService,
+	notificationService NotificationService,
 	config *config.Config,
-	oauthExchangeCache AuthCache,
+	tokenKeys *jwtkeys.KeySet,
+	oauthProviders *oauth.Registry,
+	cache AuthCache,
 	emailService EmailSender,
 ) AuthService {
 	return &authService{
-		authRepo:               authRepo,
-		userRepo:               userRepo,
-		sessionRepo:            sessionRepo,
-		passwordResetTokenRepo: passwordResetTokenRepo,
-		activityService:        activityService,
-		jwtSecret:              []byte(config.Auth.JWTSecret),
-		jwtExpiry:              config.Auth.JWTExpiry,
-		refreshTokenExpiry:     config.Auth.RefreshTokenExpiry,
-		githubConfig:           config.GitHub,
-		frontendConfig:         config.Frontend,
-		emailService:           emailService,
-		httpClient:             &http.Client{Timeout: 10 * time.Second},
-		oauthExchangeCache:     oauthExchangeCache,
-		oauthExchangeCodes:     make(map[string]oauthExchangeEntry),
+		authRepo:                   authRepo,
+		userRepo:                   userRepo,
+		sessionRepo:                sessionRepo,
+		passwordResetTokenRepo:     passwordResetTokenRepo,
+		emailVerificationTokenRepo: emailVerificationTokenRepo,
+		magicLinkTokenRepo:         magicLinkTokenRepo,
+		userIdentityRepo:           userIdentityRepo,
+		twoFactorRepo:              twoFactorRepo,
+		accountLockoutRepo:         accountLockoutRepo,
+		accessTokenRepo:            accessTokenRepo,
+		knownDeviceRepo:            knownDeviceRepo,
+		sessionRevokeTokenRepo:     sessionRevokeTokenRepo,
+		suspensionRepo:             suspensionRepo,
+		activityService:            activityService,
+		notificationService:        notificationService,
+		jwtSecret:                  []byte(config.Auth.JWTSecret),
+		tokenKeys:                  tokenKeys,
+		jwtExpiry:                  config.Auth.JWTExpiry,
+		refreshTokenExpiry:         config.Auth.RefreshTokenExpiry,
+		impersonationTTL:           config.Auth.ImpersonationTTL,
+		totpIssuer:                 config.Auth.TOTPIssuer,
+		emailVerification:          config.Auth.EmailVerification,
+		lockout: lockoutPolicy{
+			threshold:    config.Auth.LockoutThreshold,
+			baseDuration: config.Auth.LockoutBaseDuration,
+			maxDuration:  config.Auth.LockoutMaxDuration,
+			window:       config.Auth.LockoutWindow,
+		},
+		frontendConfig:     config.Frontend,
+		emailService:       emailService,
+		oauthProviders:     oauthProviders,
+		cache:              cache,
+		oauthExchangeCodes: make(map[string]oauthExchangeEntry),
+		deniedAccessTokens: make(map[string]time.Time),
 	}
 }
 
-func (s *authService) Register(ctx context.Context, email, username, password string) (*model.User, error) {
+func (s *authService) Register(ctx context.Context, email, username, password, ipAddress, userAgent string) (*model.User, error) {
 	_, err := s.authRepo.FindUserByEmail(ctx, email)
 	if err == nil {
 		return nil, apperrors.ErrUserExists
@@ -146,6 +234,12 @@ func (s *authService) Register(ctx context.Context, email, username, password st
 		return nil, err
 	}
 
+	// The account exists at this point; a failure to issue the link is
+	// recoverable through the resend endpoint.
+	if err := s.issueEmailVerification(ctx, newUser, newUser.Email, model.ActivityEmailVerifyReq, ipAddress, userAgent); err != nil {
+		authLog.Warn("failed to issue email verification", "user_id", newUser.ID, "error", err)
+	}
+
 	return newUser, nil
 }
 
@@ -161,17 +255,71 @@ func (s *authService) Login(ctx context.Context, identifier, password, ipAddress
 		return "", "", nil, apperrors.ErrInvalidCredentials
 	}
 
+	// A locked account is refused before the password is checked, so guesses
+	// made during the lock neither succeed nor extend it.
+	if err := s.checkAccountLock(ctx, user.ID); err != nil {
+		if errors.Is(err, apperrors.ErrAccountLocked) {
+			errMsg := "Account locked"
+			s.activityService.LogActivity(ctx, &user.ID, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, &errMsg, nil)
+		}
+		return "", "", nil, err
+	}
+
 	if compareErr := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)); compareErr != nil {
 		s.activityService.LogActivity(ctx, &user.ID, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, nil)
+		if err := s.recordLoginFailure(ctx, user, ipAddress, userAgent); err != nil {
+			return "", "", nil, err
+		}
 		return "", "", nil, apperrors.ErrInvalidCredentials
 	}
 
-	tokenString, refreshToken, err := s.createTokenAndSession(ctx, user)
+	if s.requiresVerifiedEmailForLogin(user) {
+		errMsg := "Email not verified"
+		s.activityService.LogActivity(ctx, &user.ID, model.ActivityLoginFailed, model.StatusFailure, ipAddress, userAgent, &errMsg, nil)
+		return "", "", nil, apperrors.ErrEmailNotVerified
+	}
+
+	return s.beginLogin(ctx, user, ipAddress, userAgent, nil)
+}
+
+// beginLogin finishes a login whose first factor has been checked. When the
+// account has two-factor authentication enabled no tokens are issued; the
+// caller exchanges the returned user for a challenge instead.
+func (s *authService) beginLogin(ctx context.Context, user *model.User, ipAddress, userAgent string, metadata map[string]any) (string, string, *model.User, error) {
+	// Suspension is only revealed once the first factor is right.
+	if err := s.refuseSuspendedLogin(ctx, user, model.ActivityLoginFailed, ipAddress, userAgent, metadata); err != nil {
+		return "", "", nil, err
+	}
+
+	twoFactor, err := s.twoFactorRepo.FindByUserID(ctx, user.ID)
 	if err != nil {
 		return "", "", nil, err
 	}
+	if twoFactor.IsEnabled() {
+		pending := map[string]any{"twoFactor": true}
+		maps.Copy(pending, metadata)
+		s.activityService.LogActivity(ctx, &user.ID, model.ActivityLogin, model.StatusPending, ipAddress, userAgent, nil, pending)
+		return "", "", user, apperrors.ErrTwoFactorRequired
+	}
 
-	s.activityService.LogActivity(ctx, &user.ID, model.ActivityLogin, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+	return s.completeLogin(ctx, user, ipAddress, userAgent, metadata)
+}
+
+// completeLogin issues tokens for a fully authenticated login.
+func (s *authService) completeLogin(ctx context.Context, user *model.User, ipAddress, userAgent string, metadata map[string]any) (string, string, *model.User, error) {
+	tokenString, refreshToken, err := s.createTokenAndSession(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityLogin, model.StatusSuccess, ipAddress, userAgent, nil, metadata)
+	s.checkLoginDevice(ctx, user, ipAddress, userAgent)
+
+	if s.lockout.enabled() {
+		if err := s.clearLoginFailures(ctx, user.ID); err != nil {
+			authLog.Warn("failed to clear login failures", "user_id", user.ID, "error", err)
+		}
+	}
 
 	now := time.Now()
 	user.LastLoggedAt = &now
@@ -213,7 +361,7 @@ func (s *authService) ForgotPassword(ctx context.Context, email, ipAddress, user
 		return err
 	}
 
-	resetLink := buildPasswordResetLink(s.frontendConfig.ResetPasswordURL, resetToken)
+	resetLink := buildFrontendTokenLink(s.frontendConfig.ResetPasswordURL, "http://localhost:3000/reset-password", resetToken)
 	if s.emailService != nil && s.emailService.IsConfigured() {
 		if err := s.emailService.EnqueuePasswordResetEmail(email, resetLink); err != nil {
 			errMsg := "Failed to queue email"
@@ -263,6 +411,10 @@ func (s *authService) ResetPassword(ctx context.Context, token, password, ipAddr
 		return err
 	}
 
+	if err := s.revokeAllAccessTokens(ctx, user.ID); err != nil {
+		authLog.Warn("failed to revoke access tokens after password reset", "user_id", user.ID, "error", err)
+	}
+
 	if err := s.passwordResetTokenRepo.MarkUsed(ctx, tokenEntry.ID); err != nil {
 		// The password was already changed; a failure to mark the token used is
 		// logged so a potentially reusable token is observable. Sessions are
@@ -274,6 +426,13 @@ func (s *authService) ResetPassword(ctx context.Context, token, password, ipAddr
 		authLog.Warn("failed to invalidate sessions after password reset", "user_id", user.ID, "error", err)
 	}
 
+	// The lock email points here, so a reset also lifts the lock.
+	if s.lockout.enabled() {
+		if err := s.clearLoginFailures(ctx, user.ID); err != nil {
+			authLog.Warn("failed to clear login failures after password reset", "user_id", user.ID, "error", err)
+		}
+	}
+
 	s.activityService.LogActivity(ctx, &user.ID, model.ActivityPasswordReset, model.StatusSuccess, ipAddress, userAgent, nil, nil)
 
 	return nil
@@ -286,8 +445,13 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken, ipAddress,
 		return "", "", nil, apperrors.ErrInvalidToken
 	}
 
+	if session.RotatedAt != nil {
+		s.revokeReusedFamily(ctx, session, ipAddress, userAgent)
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
 	if session.ExpiresAt != nil && time.Now().After(*session.ExpiresAt) {
-		if err := s.sessionRepo.DeleteSession(ctx, refreshTokenHash); err != nil {
+		if err := s.sessionRepo.DeleteFamily(ctx, session.FamilyID); err != nil {
 			authLog.Warn("failed to delete expired session", "user_id", session.UserID, "error", err)
 		}
 		return "", "", nil, apperrors.ErrTokenExpired
@@ -297,157 +461,157 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken, ipAddress,
 	if err != nil {
 		return "", "", nil, err
 	}
+	if err := suspensionError(user); err != nil {
+		if err := s.sessionRepo.DeleteFamily(ctx, session.FamilyID); err != nil {
+			authLog.Warn("failed to delete session of suspended user", "user_id", user.ID, "error", err)
+		}
+		return "", "", nil, err
+	}
+
+	newRefreshTokenValue, err := newRefreshToken()
+	if err != nil {
+		return "", "", nil, err
+	}
 
-	tokenString, newRefreshToken, err := s.createTokenAndSession(ctx, user)
+	now := time.Now()
+	next := &model.Session{
+		RefreshToken: tokenHash(newRefreshTokenValue),
+		UserID:       session.UserID,
+		FamilyID:     session.FamilyID,
+		ParentID:     &session.ID,
+		CreatedAt:    session.CreatedAt,
+		UserAgent:    &userAgent,
+		IPAddress:    &ipAddress,
+		LastUsedAt:   &now,
+		ExpiresAt:    new(now.Add(s.refreshTokenExpiry)),
+	}
+
+	rotated, err := s.sessionRepo.RotateSession(ctx, refreshTokenHash, next)
 	if err != nil {
 		return "", "", nil, err
 	}
+	if !rotated {
+		// Another request rotated this token between our read and write.
+		s.revokeReusedFamily(ctx, session, ipAddress, userAgent)
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
 
-	if err := s.sessionRepo.DeleteSession(ctx, refreshTokenHash); err != nil {
-		authLog.Warn("failed to delete old session after refresh", "user_id", user.ID, "error", err)
+	tokenString, err := s.signAccessToken(user, next.FamilyID)
+	if err != nil {
+		return "", "", nil, err
 	}
 
 	s.activityService.LogActivity(ctx, &user.ID, model.ActivityTokenRefresh, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+	s.checkLoginDevice(ctx, user, ipAddress, userAgent)
 
-	return tokenString, newRefreshToken, user, nil
+	return tokenString, newRefreshTokenValue, user, nil
 }
 
-func (s *authService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword, ipAddress, userAgent string) error {
-	user, err := s.userRepo.GetByID(ctx, userID, false)
-	if err != nil {
-		return apperrors.ErrUserNotFound
+// revokeReusedFamily handles a refresh token that was presented after it had
+// already been rotated. Either the legitimate client or an attacker holds a
+// stale copy, and we cannot tell which, so the whole family is revoked.
+func (s *authService) revokeReusedFamily(ctx context.Context, session *model.Session, ipAddress, userAgent string) {
+	if err := s.sessionRepo.DeleteFamily(ctx, session.FamilyID); err != nil {
+		authLog.Error("failed to revoke session family after token reuse", "user_id", session.UserID, "family_id", session.FamilyID, "error", err)
 	}
 
-	if user.Password == nil {
-		return apperrors.ErrInvalidCredentials
+	authLog.Warn("refresh token reuse detected", "user_id", session.UserID, "family_id", session.FamilyID)
+	s.activityService.LogActivity(ctx, &session.UserID, model.ActivityTokenReuseDetected, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"sessionId": session.FamilyID})
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]*dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(currentPassword)); err != nil {
-		s.activityService.LogActivity(ctx, &userID, model.ActivityPasswordChange, model.StatusFailure, ipAddress, userAgent, nil, nil)
-		return apperrors.ErrInvalidCredentials
+	responses := make([]*dto.SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		responses = append(responses, dto.SessionToResponse(sess, currentSessionID))
 	}
+	return responses, nil
+}
 
-	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID, ipAddress, userAgent string) error {
+	deleted, err := s.sessionRepo.DeleteFamilyForUser(ctx, sessionID, userID)
 	if err != nil {
 		return err
 	}
-	hashedPassword := string(hashedPasswordBytes)
-
-	user.Password = &hashedPassword
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return err
+	if !deleted {
+		return apperrors.ErrSessionNotFound
 	}
 
-	s.activityService.LogActivity(ctx, &userID, model.ActivityPasswordChange, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+	s.activityService.LogActivity(ctx, &userID, model.ActivitySessionRevoked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"sessionId": sessionID})
 
 	return nil
 }
 
-func (s *authService) Logout(ctx context.Context, refreshToken string) error {
-	return s.sessionRepo.DeleteSession(ctx, tokenHash(refreshToken))
-}
+func (s *authService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID, ipAddress, userAgent string) (int64, error) {
+	if currentSessionID == "" {
+		return 0, apperrors.ErrSessionNotFound
+	}
 
-func (s *authService) GetProfile(ctx context.Context, userID string) (*model.User, error) {
-	return s.userRepo.GetByID(ctx, userID, false)
-}
+	revoked, err := s.sessionRepo.DeleteOthersByUserID(ctx, userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
 
-func (s *authService) GetGithubOAuthURL(state string) string {
-	authURL, _ := url.Parse("https://github.com/login/oauth/authorize")
-	q := authURL.Query()
-	q.Set("client_id", s.githubConfig.ClientID)
-	q.Set("redirect_uri", s.githubConfig.RedirectURI)
-	q.Set("scope", "user:email")
-	q.Set("state", state)
-	authURL.RawQuery = q.Encode()
-	return authURL.String()
-}
+	s.activityService.LogActivity(ctx, &userID, model.ActivitySessionRevoked, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"scope": "others", "revoked": revoked})
 
-func (s *authService) GetGithubToken(ctx context.Context, code string) (string, error) {
-	data := url.Values{}
-	data.Set("client_id", s.githubConfig.ClientID)
-	data.Set("client_secret", s.githubConfig.ClientSecret)
-	data.Set("code", code)
-	data.Set("redirect_uri", s.githubConfig.RedirectURI)
+	return revoked, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://github.com/login/oauth/access_token", strings.NewReader(data.Encode()))
+func (s *authService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to build token request: %w", err)
+		return apperrors.ErrUserNotFound
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/x-www-form-urlencoded")
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to exchange code for token: %w", err)
+	if user.Password == nil {
+		return apperrors.ErrInvalidCredentials
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read token response: %w", err)
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(currentPassword)); err != nil {
+		s.activityService.LogActivity(ctx, &userID, model.ActivityPasswordChange, model.StatusFailure, ipAddress, userAgent, nil, nil)
+		return apperrors.ErrInvalidCredentials
 	}
 
-	values, err := url.ParseQuery(string(body))
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse token response: %w", err)
+		return err
 	}
+	hashedPassword := string(hashedPasswordBytes)
 
-	accessToken := values.Get("access_token")
-	if accessToken == "" {
-		return "", errors.New("no access_token in GitHub response")
+	user.Password = &hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
 	}
 
-	return accessToken, nil
-}
-
-func (s *authService) SignInWithGithub(ctx context.Context, githubUser *GithubUser, ipAddress, userAgent string) (string, string, *model.User, error) {
-	var user *model.User
-
-	githubID := githubUser.ID
-	user, err := s.authRepo.FindUserByGithubID(ctx, githubID)
-	if err != nil && !errors.Is(err, apperrors.ErrUserNotFound) {
-		s.activityService.LogActivity(ctx, nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"provider": "github"})
-		return "", "", nil, err
+	// Sessions survive a password change, so clients get a fresh access
+	// token by refreshing.
+	if err := s.revokeAllAccessTokens(ctx, userID); err != nil {
+		authLog.Warn("failed to revoke access tokens after password change", "user_id", userID, "error", err)
 	}
 
-	if user == nil || errors.Is(err, apperrors.ErrUserNotFound) {
-		email := ""
-		if githubUser.Email != nil {
-			email = *githubUser.Email
-		} else {
-			email = fmt.Sprintf("%d@github.placeholder", githubUser.ID)
-		}
+	s.activityService.LogActivity(ctx, &userID, model.ActivityPasswordChange, model.StatusSuccess, ipAddress, userAgent, nil, nil)
 
-		username := githubUser.Login
-		newUser := &model.User{
-			Email:    email,
-			Username: &username,
-			GithubID: &githubID,
-			Image:    &githubUser.AvatarURL,
-		}
+	return nil
+}
 
-		if err := s.authRepo.CreateUser(ctx, newUser); err != nil {
-			s.activityService.LogActivity(ctx, nil, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"provider": "github", "error": err.Error()})
-			return "", "", nil, err
-		}
-		user = newUser
-	}
+// Logout revokes the session of refreshToken and denylists the caller's
+// access token so it stops working before it expires.
+func (s *authService) Logout(ctx context.Context, refreshToken, accessTokenID string, accessTokenExpiresAt time.Time) error {
+	s.denyAccessToken(ctx, accessTokenID, accessTokenExpiresAt)
 
-	tokenString, refreshToken, err := s.createTokenAndSession(ctx, user)
+	session, err := s.sessionRepo.GetSessionByRefreshToken(ctx, tokenHash(refreshToken))
 	if err != nil {
-		s.activityService.LogActivity(ctx, &user.ID, model.ActivityOAuthLoginFailed, model.StatusFailure, ipAddress, userAgent, nil, map[string]any{"provider": "github"})
-		return "", "", nil, err
-	}
-
-	s.activityService.LogActivity(ctx, &user.ID, model.ActivityOAuthLogin, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"provider": "github"})
-
-	now := time.Now()
-	user.LastLoggedAt = &now
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		authLog.Warn("failed to update last_logged_at", "user_id", user.ID, "error", err)
+		return err
 	}
+	return s.sessionRepo.DeleteFamily(ctx, session.FamilyID)
+}
 
-	return tokenString, refreshToken, user, nil
+func (s *authService) GetProfile(ctx context.Context, userID string) (*model.User, error) {
+	return s.userRepo.GetByID(ctx, userID, false)
 }
 
 func (s *authService) CreateOAuthExchangeCode(ctx context.Context, accessToken, refreshToken string, user *model.User) (string, error) {
@@ -467,9 +631,9 @@ func (s *authService) CreateOAuthExchangeCode(ctx context.Context, accessToken,
 		ExpiresAt:    time.Now().Add(oauthExchangeTTL),
 	}
 
-	if s.oauthExchangeCache != nil {
-		key := s.oauthExchangeCache.BuildKey("oauth_exchange", code)
-		if err := s.oauthExchangeCache.SetJSONWithTTL(ctx, key, entry, oauthExchangeTTL); err != nil {
+	if s.cache != nil {
+		key := s.cache.BuildKey("oauth_exchange", code)
+		if err := s.cache.SetJSONWithTTL(ctx, key, entry, oauthExchangeTTL); err != nil {
 			return "", err
 		}
 		return code, nil
@@ -484,10 +648,10 @@ func (s *authService) CreateOAuthExchangeCode(ctx context.Context, accessToken,
 }
 
 func (s *authService) ExchangeOAuthCode(ctx context.Context, code string) (string, string, *model.User, error) {
-	if s.oauthExchangeCache != nil {
-		key := s.oauthExchangeCache.BuildKey("oauth_exchange", code)
+	if s.cache != nil {
+		key := s.cache.BuildKey("oauth_exchange", code)
 		var entry oauthExchangeEntry
-		found, err := s.oauthExchangeCache.GetJSONAndDelete(ctx, key, &entry)
+		found, err := s.cache.GetJSONAndDelete(ctx, key, &entry)
 		if err != nil {
 			return "", "", nil, err
 		}
@@ -521,40 +685,68 @@ func (s *authService) cleanupExpiredOAuthExchangeCodesLocked(now time.Time) {
 	}
 }
 
-func (s *authService) createTokenAndSession(ctx context.Context, user *model.User) (string, string, error) {
-	claims := jwt.MapClaims{
-		"user_id":        user.ID,
-		"username":       user.Username,
-		"email":          user.Email,
-		"is_super_admin": user.IsSuperAdmin,
-		"iat":            time.Now().Unix(),
-		"exp":            time.Now().Add(s.jwtExpiry).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString(s.jwtSecret)
+func (s *authService) createTokenAndSession(ctx context.Context, user *model.User, ipAddress, userAgent string) (string, string, error) {
+	refreshToken, err := newRefreshToken()
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshBytes := make([]byte, 64)
-	if _, err := rand.Read(refreshBytes); err != nil {
-		return "", "", err
-	}
-	refreshToken := "pl_" + base64.RawURLEncoding.EncodeToString(refreshBytes)
-
+	now := time.Now()
 	sess := &model.Session{
 		RefreshToken: tokenHash(refreshToken),
 		UserID:       user.ID,
-		ExpiresAt:    new(time.Now().Add(s.refreshTokenExpiry)),
+		UserAgent:    &userAgent,
+		IPAddress:    &ipAddress,
+		LastUsedAt:   &now,
+		ExpiresAt:    new(now.Add(s.refreshTokenExpiry)),
 	}
 	if err := s.sessionRepo.CreateSession(ctx, sess); err != nil {
 		return "", "", err
 	}
 
+	tokenString, err := s.signAccessToken(user, sess.FamilyID)
+	if err != nil {
+		return "", "", err
+	}
+
 	return tokenString, refreshToken, nil
 }
 
+// signAccessToken issues a JWT bound to a session family through the "sid"
+// claim so session endpoints can tell which session the caller is using. The
+// "jti" claim lets a single token be denylisted on logout.
+func (s *authService) signAccessToken(user *model.User, sessionID string) (string, error) {
+	tokenID, err := newAccessTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"jti":            tokenID,
+		"user_id":        user.ID,
+		"sid":            sessionID,
+		"username":       user.Username,
+		"email":          user.Email,
+		"is_super_admin": user.IsSuperAdmin,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(s.jwtExpiry).Unix(),
+	}
+	return s.tokenKeys.Sign(claims)
+}
+
+// JWKS returns the public keys that verify access tokens.
+func (s *authService) JWKS() jwtkeys.JWKS {
+	return s.tokenKeys.JWKS()
+}
+
+func newRefreshToken() (string, error) {
+	refreshBytes, err := generateRandomBytes(64)
+	if err != nil {
+		return "", err
+	}
+	return "pl_" + base64.RawURLEncoding.EncodeToString(refreshBytes), nil
+}
+
 func generateRandomBytes(n int) ([]byte, error) {
 	b := make([]byte, n)
 	if _, err := io.ReadFull(rand.Reader, b); err != nil {
@@ -568,9 +760,11 @@ func tokenHash(token string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func buildPasswordResetLink(baseURL, token string) string {
+// buildFrontendTokenLink appends token as a query parameter to the frontend
+// page at baseURL, falling back to defaultURL when none is configured.
+func buildFrontendTokenLink(baseURL, defaultURL, token string) string {
 	if baseURL == "" {
-		baseURL = "http://localhost:3000/reset-password"
+		baseURL = defaultURL
 	}
 
 	parsed, err := url.Parse(baseURL)
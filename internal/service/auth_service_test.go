@@ -0,0 +1,1163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"echobackend/config"
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+	"echobackend/pkg/jwtkeys"
+	"echobackend/pkg/oauth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ---- Inlined Auth Service Mocks -----------------------------------------------
+
+var _ repository.SessionRepository = (*mockSessionRepo)(nil)
+
+type mockSessionRepo struct {
+	getByRefreshTokenFn func(ctx context.Context, token string) (*model.Session, error)
+	rotateSessionFn     func(ctx context.Context, oldToken string, next *model.Session) (bool, error)
+	deletedFamilies     []string
+	deletedUsers        []string
+}
+
+func (m *mockSessionRepo) CreateSession(ctx context.Context, s *model.Session) error { return nil }
+func (m *mockSessionRepo) GetSessionByRefreshToken(ctx context.Context, token string) (*model.Session, error) {
+	if m.getByRefreshTokenFn != nil {
+		return m.getByRefreshTokenFn(ctx, token)
+	}
+	return nil, errors.New("not found")
+}
+func (m *mockSessionRepo) ListActiveByUserID(ctx context.Context, userID string) ([]*model.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionRepo) RotateSession(ctx context.Context, oldToken string, next *model.Session) (bool, error) {
+	if m.rotateSessionFn != nil {
+		return m.rotateSessionFn(ctx, oldToken, next)
+	}
+	return true, nil
+}
+func (m *mockSessionRepo) DeleteSession(ctx context.Context, token string) error { return nil }
+func (m *mockSessionRepo) DeleteFamily(ctx context.Context, familyID string) error {
+	m.deletedFamilies = append(m.deletedFamilies, familyID)
+	return nil
+}
+func (m *mockSessionRepo) DeleteFamilyForUser(ctx context.Context, familyID, userID string) (bool, error) {
+	return true, nil
+}
+func (m *mockSessionRepo) DeleteOthersByUserID(ctx context.Context, userID, keepFamilyID string) (int64, error) {
+	return 0, nil
+}
+func (m *mockSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	m.deletedUsers = append(m.deletedUsers, userID)
+	return nil
+}
+func (m *mockSessionRepo) UpdateSession(ctx context.Context, s *model.Session) error { return nil }
+
+var _ repository.AuthRepository = (*mockAuthRepo)(nil)
+
+type mockAuthRepo struct {
+	findUserByEmailFn      func(ctx context.Context, email string) (*model.User, error)
+	findUserByIdentifierFn func(ctx context.Context, identifier string) (*model.User, error)
+}
+
+func (m *mockAuthRepo) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	if m.findUserByEmailFn != nil {
+		return m.findUserByEmailFn(ctx, email)
+	}
+	return nil, apperrors.ErrUserNotFound
+}
+func (m *mockAuthRepo) FindUserByIdentifier(ctx context.Context, identifier string) (*model.User, error) {
+	if m.findUserByIdentifierFn != nil {
+		return m.findUserByIdentifierFn(ctx, identifier)
+	}
+	return nil, apperrors.ErrUserNotFound
+}
+func (m *mockAuthRepo) CreateUser(ctx context.Context, user *model.User) error { return nil }
+
+var _ repository.EmailVerificationTokenRepository = (*mockEmailVerificationTokenRepo)(nil)
+
+type mockEmailVerificationTokenRepo struct {
+	findByTokenFn func(ctx context.Context, token string) (*model.EmailVerificationToken, error)
+	markUsedFn    func(ctx context.Context, id string) (bool, error)
+	markedUsed    []string
+}
+
+func (m *mockEmailVerificationTokenRepo) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	return nil
+}
+func (m *mockEmailVerificationTokenRepo) FindByToken(ctx context.Context, token string) (*model.EmailVerificationToken, error) {
+	if m.findByTokenFn != nil {
+		return m.findByTokenFn(ctx, token)
+	}
+	return nil, nil
+}
+func (m *mockEmailVerificationTokenRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	if m.markUsedFn != nil {
+		return m.markUsedFn(ctx, id)
+	}
+	m.markedUsed = append(m.markedUsed, id)
+	return true, nil
+}
+func (m *mockEmailVerificationTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+var _ repository.MagicLinkTokenRepository = (*mockMagicLinkTokenRepo)(nil)
+
+type mockMagicLinkTokenRepo struct {
+	findByTokenFn func(ctx context.Context, token string) (*model.MagicLinkToken, error)
+	markUsedFn    func(ctx context.Context, id string) (bool, error)
+}
+
+func (m *mockMagicLinkTokenRepo) Create(ctx context.Context, token *model.MagicLinkToken) error {
+	return nil
+}
+func (m *mockMagicLinkTokenRepo) FindByToken(ctx context.Context, token string) (*model.MagicLinkToken, error) {
+	if m.findByTokenFn != nil {
+		return m.findByTokenFn(ctx, token)
+	}
+	return nil, nil
+}
+func (m *mockMagicLinkTokenRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	if m.markUsedFn != nil {
+		return m.markUsedFn(ctx, id)
+	}
+	return true, nil
+}
+func (m *mockMagicLinkTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+var _ repository.TwoFactorRepository = (*mockTwoFactorRepo)(nil)
+
+type mockTwoFactorRepo struct {
+	twoFactor *model.UserTwoFactor
+}
+
+func (m *mockTwoFactorRepo) FindByUserID(ctx context.Context, userID string) (*model.UserTwoFactor, error) {
+	return m.twoFactor, nil
+}
+func (m *mockTwoFactorRepo) UpsertPending(ctx context.Context, tf *model.UserTwoFactor) error {
+	return nil
+}
+func (m *mockTwoFactorRepo) Confirm(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	return nil
+}
+func (m *mockTwoFactorRepo) AdvanceLastUsedStep(ctx context.Context, userID string, step int64) (bool, error) {
+	return true, nil
+}
+func (m *mockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	return false, nil
+}
+func (m *mockTwoFactorRepo) DeleteByUserID(ctx context.Context, userID string) error { return nil }
+
+var _ repository.AccountLockoutRepository = (*mockAccountLockoutRepo)(nil)
+
+// mockAccountLockoutRepo keeps lockouts in memory, standing in for the
+// database fallback used when Redis is unavailable.
+type mockAccountLockoutRepo struct {
+	lockouts map[string]*model.AccountLockout
+}
+
+func (m *mockAccountLockoutRepo) FindByUserID(ctx context.Context, userID string) (*model.AccountLockout, error) {
+	return m.lockouts[userID], nil
+}
+func (m *mockAccountLockoutRepo) RecordFailure(ctx context.Context, userID string, window time.Duration) (int, error) {
+	if m.lockouts == nil {
+		m.lockouts = make(map[string]*model.AccountLockout)
+	}
+	lockout, ok := m.lockouts[userID]
+	if !ok {
+		lockout = &model.AccountLockout{UserID: userID}
+		m.lockouts[userID] = lockout
+	}
+	lockout.FailedAttempts++
+	lockout.LastFailedAt = time.Now()
+	return lockout.FailedAttempts, nil
+}
+func (m *mockAccountLockoutRepo) Lock(ctx context.Context, userID string, failedAttempts int, until time.Time) error {
+	m.lockouts[userID].LockedUntil = &until
+	return nil
+}
+func (m *mockAccountLockoutRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	delete(m.lockouts, userID)
+	return nil
+}
+func (m *mockAccountLockoutRepo) ListLocked(ctx context.Context, now time.Time, limit, offset int) ([]*model.AccountLockout, int64, error) {
+	return nil, 0, nil
+}
+
+var _ repository.PersonalAccessTokenRepository = (*mockAccessTokenRepo)(nil)
+
+type mockAccessTokenRepo struct {
+	tokens  []*model.PersonalAccessToken
+	touched int
+}
+
+func (m *mockAccessTokenRepo) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	token.ID = "pat-" + strconv.Itoa(len(m.tokens)+1)
+	token.User = &model.User{ID: token.UserID}
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+func (m *mockAccessTokenRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+func (m *mockAccessTokenRepo) ListByUserID(ctx context.Context, userID string) ([]*model.PersonalAccessToken, error) {
+	return m.tokens, nil
+}
+func (m *mockAccessTokenRepo) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	return int64(len(m.tokens)), nil
+}
+func (m *mockAccessTokenRepo) Delete(ctx context.Context, userID, id string) (bool, error) {
+	return false, nil
+}
+func (m *mockAccessTokenRepo) TouchLastUsed(ctx context.Context, id, ipAddress string, usedAt time.Time) error {
+	m.touched++
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+var _ repository.UserIdentityRepository = (*mockUserIdentityRepo)(nil)
+
+type mockUserIdentityRepo struct {
+	identities []model.UserIdentity
+	created    *model.User
+}
+
+func (m *mockUserIdentityRepo) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	for i := range m.identities {
+		if m.identities[i].Provider == provider && m.identities[i].Subject == subject {
+			return &m.identities[i], nil
+		}
+	}
+	return nil, nil
+}
+func (m *mockUserIdentityRepo) ListByUserID(ctx context.Context, userID string) ([]model.UserIdentity, error) {
+	var out []model.UserIdentity
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			out = append(out, identity)
+		}
+	}
+	return out, nil
+}
+func (m *mockUserIdentityRepo) Create(ctx context.Context, identity *model.UserIdentity) error {
+	for _, existing := range m.identities {
+		if existing.UserID == identity.UserID && existing.Provider == identity.Provider {
+			return apperrors.ErrIdentityAlreadyLinked
+		}
+	}
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+func (m *mockUserIdentityRepo) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	user.ID = "new-user"
+	m.created = user
+	identity.UserID = user.ID
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+func (m *mockUserIdentityRepo) DeleteByUserAndProvider(ctx context.Context, userID, provider string) (bool, error) {
+	for i, identity := range m.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			m.identities = append(m.identities[:i], m.identities[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeOAuthProvider returns a fixed identity for any code.
+type fakeOAuthProvider struct {
+	identity oauth.Identity
+}
+
+func (p *fakeOAuthProvider) Name() string { return p.identity.Provider }
+func (p *fakeOAuthProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return "https://idp.example.com/authorize?state=" + state, nil
+}
+func (p *fakeOAuthProvider) Exchange(ctx context.Context, code, state string) (*oauth.Identity, error) {
+	identity := p.identity
+	return &identity, nil
+}
+
+type recordedActivity struct {
+	activityType string
+	status       string
+}
+
+type mockActivityRecorder struct {
+	activities []recordedActivity
+}
+
+func (m *mockActivityRecorder) LogActivity(ctx context.Context, userID *string, activityType, status, ipAddress, userAgent string, errorMessage *string, metadata map[string]any) {
+	m.activities = append(m.activities, recordedActivity{activityType: activityType, status: status})
+}
+func (m *mockActivityRecorder) GetActivityLogs(ctx context.Context, userID, activityType string, limit, offset int) ([]*model.AuthActivityLog, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockActivityRecorder) GetRecentActivity(ctx context.Context, userID string, limit int) ([]*model.AuthActivityLog, error) {
+	return nil, nil
+}
+func (m *mockActivityRecorder) GetFailedLogins(ctx context.Context, since time.Time, limit, offset int) ([]*model.AuthActivityLog, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockActivityRecorder) has(activityType string) bool {
+	for _, a := range m.activities {
+		if a.activityType == activityType {
+			return true
+		}
+	}
+	return false
+}
+
+// ---- Test Cases ---------------------------------------------------------------
+
+func TestRefreshToken_RotatesWithinFamily(t *testing.T) {
+	var rotatedFrom string
+	var next *model.Session
+	sessions := &mockSessionRepo{
+		getByRefreshTokenFn: func(ctx context.Context, token string) (*model.Session, error) {
+			return &model.Session{ID: "sess-2", FamilyID: "family-1", UserID: "user-1", RefreshToken: token}, nil
+		},
+		rotateSessionFn: func(ctx context.Context, oldToken string, n *model.Session) (bool, error) {
+			rotatedFrom, next = oldToken, n
+			return true, nil
+		},
+	}
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id}, nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        sessions,
+		userRepo:           users,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+
+	access, refresh, _, err := svc.RefreshToken(context.Background(), "pl_old", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if access == "" || refresh == "" || refresh == "pl_old" {
+		t.Fatalf("expected new tokens, got access=%q refresh=%q", access, refresh)
+	}
+	if rotatedFrom != tokenHash("pl_old") {
+		t.Fatalf("rotated from %q, want hash of presented token", rotatedFrom)
+	}
+	if next.FamilyID != "family-1" || next.ParentID == nil || *next.ParentID != "sess-2" {
+		t.Fatalf("next session not chained to parent: %+v", next)
+	}
+	if next.RefreshToken != tokenHash(refresh) {
+		t.Fatal("next session must store the hash of the returned refresh token")
+	}
+	if !activity.has(model.ActivityTokenRefresh) {
+		t.Fatalf("expected token_refresh activity, got %+v", activity.activities)
+	}
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	rotatedAt := time.Now().Add(-time.Minute)
+	sessions := &mockSessionRepo{
+		getByRefreshTokenFn: func(ctx context.Context, token string) (*model.Session, error) {
+			return &model.Session{ID: "sess-1", FamilyID: "family-1", UserID: "user-1", RotatedAt: &rotatedAt}, nil
+		},
+		rotateSessionFn: func(ctx context.Context, oldToken string, n *model.Session) (bool, error) {
+			t.Fatal("a rotated token must not be rotated again")
+			return false, nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        sessions,
+		userRepo:           &mockUserRepo{},
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+
+	_, _, _, err := svc.RefreshToken(context.Background(), "pl_stolen", "10.0.0.1", "attacker")
+	if !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if len(sessions.deletedFamilies) != 1 || sessions.deletedFamilies[0] != "family-1" {
+		t.Fatalf("expected family-1 to be revoked, got %v", sessions.deletedFamilies)
+	}
+	if !activity.has(model.ActivityTokenReuseDetected) {
+		t.Fatalf("expected token_reuse_detected activity, got %+v", activity.activities)
+	}
+}
+
+func TestRefreshToken_LostRotationRaceRevokesFamily(t *testing.T) {
+	sessions := &mockSessionRepo{
+		getByRefreshTokenFn: func(ctx context.Context, token string) (*model.Session, error) {
+			return &model.Session{ID: "sess-1", FamilyID: "family-1", UserID: "user-1"}, nil
+		},
+		rotateSessionFn: func(ctx context.Context, oldToken string, n *model.Session) (bool, error) {
+			return false, nil
+		},
+	}
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id}, nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        sessions,
+		userRepo:           users,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+
+	_, _, _, err := svc.RefreshToken(context.Background(), "pl_raced", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if len(sessions.deletedFamilies) != 1 {
+		t.Fatalf("expected family to be revoked, got %v", sessions.deletedFamilies)
+	}
+}
+
+func TestLogin_RequiresVerifiedEmailInLoginMode(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	password := string(hashed)
+
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		emailVerification:  config.EmailVerificationLogin,
+		authRepo: &mockAuthRepo{
+			findUserByIdentifierFn: func(ctx context.Context, identifier string) (*model.User, error) {
+				return &model.User{ID: "user-1", Email: "a@example.com", Password: &password}, nil
+			},
+		},
+	}
+
+	_, _, _, err = svc.Login(context.Background(), "a@example.com", "secret123", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrEmailNotVerified) {
+		t.Fatalf("expected ErrEmailNotVerified, got %v", err)
+	}
+	if !activity.has(model.ActivityLoginFailed) {
+		t.Fatalf("expected login_failed activity, got %+v", activity.activities)
+	}
+}
+
+func TestLogin_LocksAccountAfterRepeatedFailures(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	password := string(hashed)
+
+	activity := &mockActivityRecorder{}
+	lockouts := &mockAccountLockoutRepo{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		accountLockoutRepo: lockouts,
+		lockout:            lockoutPolicy{threshold: 3, baseDuration: time.Minute, maxDuration: time.Hour, window: 24 * time.Hour},
+		authRepo: &mockAuthRepo{
+			findUserByIdentifierFn: func(ctx context.Context, identifier string) (*model.User, error) {
+				return &model.User{ID: "user-1", Email: "a@example.com", Password: &password}, nil
+			},
+		},
+	}
+
+	for i := range 2 {
+		_, _, _, err := svc.Login(context.Background(), "a@example.com", "wrong", "127.0.0.1", "test-agent")
+		if !errors.Is(err, apperrors.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: expected ErrInvalidCredentials, got %v", i+1, err)
+		}
+	}
+
+	_, _, _, err = svc.Login(context.Background(), "a@example.com", "wrong", "127.0.0.1", "test-agent")
+	var lockErr *apperrors.AccountLockedError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected AccountLockedError on the threshold attempt, got %v", err)
+	}
+	if d := time.Until(lockErr.Until); d <= 0 || d > time.Minute {
+		t.Fatalf("first lock should last the base duration, got %v", d)
+	}
+	if !activity.has(model.ActivityAccountLocked) {
+		t.Fatalf("expected account_locked activity, got %+v", activity.activities)
+	}
+
+	// The correct password is refused while locked and does not add a failure.
+	_, _, _, err = svc.Login(context.Background(), "a@example.com", "secret123", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrAccountLocked) {
+		t.Fatalf("expected ErrAccountLocked, got %v", err)
+	}
+	if got := lockouts.lockouts["user-1"].FailedAttempts; got != 3 {
+		t.Fatalf("failed attempts = %d, want 3", got)
+	}
+}
+
+func TestLockoutPolicy_LockDurationDoublesUpToMax(t *testing.T) {
+	p := lockoutPolicy{threshold: 5, baseDuration: time.Minute, maxDuration: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.lockDuration(tt.failures); got != tt.want {
+			t.Errorf("lockDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyEmail_AppliesPendingEmailChange(t *testing.T) {
+	tokens := &mockEmailVerificationTokenRepo{
+		findByTokenFn: func(ctx context.Context, token string) (*model.EmailVerificationToken, error) {
+			if token != tokenHash("ev_token") {
+				t.Fatalf("lookup by %q, want hash of presented token", token)
+			}
+			return &model.EmailVerificationToken{
+				ID:        "token-1",
+				UserID:    "user-1",
+				Email:     "new@example.com",
+				ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}
+	var updated *model.User
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id, Email: "old@example.com"}, nil
+		},
+		updateFn: func(ctx context.Context, user *model.User) error {
+			updated = user
+			return nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:                &mockSessionRepo{},
+		userRepo:                   users,
+		activityService:            activity,
+		jwtSecret:                  []byte("test-secret"),
+		tokenKeys:                  jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:                  time.Hour,
+		refreshTokenExpiry:         24 * time.Hour,
+		deniedAccessTokens:         make(map[string]time.Time),
+		authRepo:                   &mockAuthRepo{},
+		emailVerificationTokenRepo: tokens,
+	}
+
+	if err := svc.VerifyEmail(context.Background(), "ev_token", "127.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated == nil || updated.Email != "new@example.com" || updated.EmailVerifiedAt == nil {
+		t.Fatalf("expected verified email change, got %+v", updated)
+	}
+	if len(tokens.markedUsed) != 1 || tokens.markedUsed[0] != "token-1" {
+		t.Fatalf("expected token to be marked used, got %v", tokens.markedUsed)
+	}
+	if !activity.has(model.ActivityEmailChange) {
+		t.Fatalf("expected email_change activity, got %+v", activity.activities)
+	}
+}
+
+func TestVerifyEmail_RejectsTakenAddress(t *testing.T) {
+	tokens := &mockEmailVerificationTokenRepo{
+		findByTokenFn: func(ctx context.Context, token string) (*model.EmailVerificationToken, error) {
+			return &model.EmailVerificationToken{ID: "token-1", UserID: "user-1", Email: "taken@example.com", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id, Email: "old@example.com"}, nil
+		},
+		updateFn: func(ctx context.Context, user *model.User) error {
+			t.Fatal("user must not be updated")
+			return nil
+		},
+	}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		authRepo: &mockAuthRepo{
+			findUserByEmailFn: func(ctx context.Context, email string) (*model.User, error) {
+				return &model.User{ID: "user-2", Email: email}, nil
+			},
+		},
+		emailVerificationTokenRepo: tokens,
+	}
+
+	err := svc.VerifyEmail(context.Background(), "ev_token", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrUserExists) {
+		t.Fatalf("expected ErrUserExists, got %v", err)
+	}
+}
+
+func TestVerifyEmail_RejectsTokenConsumedConcurrently(t *testing.T) {
+	tokens := &mockEmailVerificationTokenRepo{
+		findByTokenFn: func(ctx context.Context, token string) (*model.EmailVerificationToken, error) {
+			return &model.EmailVerificationToken{ID: "token-1", UserID: "user-1", Email: "a@example.com", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		// Another request consumed the token after it was looked up.
+		markUsedFn: func(ctx context.Context, id string) (bool, error) {
+			return false, nil
+		},
+	}
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id, Email: "a@example.com"}, nil
+		},
+		updateFn: func(ctx context.Context, user *model.User) error {
+			t.Fatal("user must not be updated")
+			return nil
+		},
+	}
+	svc := &authService{
+		sessionRepo:                &mockSessionRepo{},
+		userRepo:                   users,
+		activityService:            &mockActivityRecorder{},
+		jwtSecret:                  []byte("test-secret"),
+		tokenKeys:                  jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:                  time.Hour,
+		refreshTokenExpiry:         24 * time.Hour,
+		deniedAccessTokens:         make(map[string]time.Time),
+		authRepo:                   &mockAuthRepo{},
+		emailVerificationTokenRepo: tokens,
+	}
+
+	err := svc.VerifyEmail(context.Background(), "ev_token", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrVerificationTokenUsed) {
+		t.Fatalf("expected ErrVerificationTokenUsed, got %v", err)
+	}
+}
+
+func TestRequestVerificationEmail_OnlySendsToUnverifiedUsers(t *testing.T) {
+	users := map[string]*model.User{
+		"new@example.com":      {ID: "user-1", Email: "new@example.com"},
+		"verified@example.com": {ID: "user-2", Email: "verified@example.com", EmailVerifiedAt: new(time.Now())},
+	}
+	for _, email := range []string{"unknown@example.com", "verified@example.com", "new@example.com"} {
+		activity := &mockActivityRecorder{}
+		svc := &authService{
+			sessionRepo:                &mockSessionRepo{},
+			userRepo:                   &mockUserRepo{},
+			activityService:            activity,
+			jwtSecret:                  []byte("test-secret"),
+			tokenKeys:                  jwtkeys.NewHMAC([]byte("test-secret")),
+			jwtExpiry:                  time.Hour,
+			refreshTokenExpiry:         24 * time.Hour,
+			deniedAccessTokens:         make(map[string]time.Time),
+			emailVerificationTokenRepo: &mockEmailVerificationTokenRepo{},
+			authRepo: &mockAuthRepo{findUserByEmailFn: func(ctx context.Context, email string) (*model.User, error) {
+				if user, ok := users[email]; ok {
+					return user, nil
+				}
+				return nil, apperrors.ErrUserNotFound
+			}},
+		}
+
+		if err := svc.RequestVerificationEmail(context.Background(), email, "127.0.0.1", "test-agent"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", email, err)
+		}
+		if sent, want := activity.has(model.ActivityEmailVerifyReq), email == "new@example.com"; sent != want {
+			t.Fatalf("%s: link sent = %v, want %v", email, sent, want)
+		}
+	}
+}
+
+func TestConsumeMagicLink_IssuesTokensAndVerifiesEmail(t *testing.T) {
+	links := &mockMagicLinkTokenRepo{
+		findByTokenFn: func(ctx context.Context, token string) (*model.MagicLinkToken, error) {
+			if token != tokenHash("ml_token") {
+				t.Fatalf("lookup by %q, want hash of presented token", token)
+			}
+			return &model.MagicLinkToken{ID: "link-1", UserID: "user-1", ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+	}
+	var updated *model.User
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id, Email: "a@example.com"}, nil
+		},
+		updateFn: func(ctx context.Context, user *model.User) error {
+			updated = user
+			return nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		magicLinkTokenRepo: links,
+		twoFactorRepo:      &mockTwoFactorRepo{},
+	}
+
+	access, refresh, user, err := svc.ConsumeMagicLink(context.Background(), "ml_token", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if access == "" || refresh == "" || user == nil {
+		t.Fatalf("expected tokens, got access=%q refresh=%q user=%v", access, refresh, user)
+	}
+	if updated == nil || updated.EmailVerifiedAt == nil {
+		t.Fatal("expected email to be marked verified")
+	}
+	if !activity.has(model.ActivityLogin) {
+		t.Fatalf("expected login activity, got %+v", activity.activities)
+	}
+}
+
+func TestConsumeMagicLink_SingleUse(t *testing.T) {
+	links := &mockMagicLinkTokenRepo{
+		findByTokenFn: func(ctx context.Context, token string) (*model.MagicLinkToken, error) {
+			return &model.MagicLinkToken{ID: "link-1", UserID: "user-1", ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+		markUsedFn: func(ctx context.Context, id string) (bool, error) {
+			return false, nil
+		},
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		magicLinkTokenRepo: links,
+	}
+
+	_, _, _, err := svc.ConsumeMagicLink(context.Background(), "ml_token", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if !activity.has(model.ActivityLoginFailed) {
+		t.Fatalf("expected login_failed activity, got %+v", activity.activities)
+	}
+}
+
+func TestSignInWithOAuth_CreatesUserWithIdentity(t *testing.T) {
+	identities := &mockUserIdentityRepo{}
+	users := &mockUserRepo{
+		checkUsernameFn: func(ctx context.Context, username string) error {
+			if username == "jane" {
+				return apperrors.ErrUserExists
+			}
+			return nil
+		},
+		updateFn: func(ctx context.Context, user *model.User) error { return nil },
+	}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		authRepo:           &mockAuthRepo{},
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		userIdentityRepo:   identities,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		oauthProviders: oauth.NewRegistry(&fakeOAuthProvider{identity: oauth.Identity{
+			Provider:      "google",
+			Subject:       "g-123",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Username:      "jane",
+		}}),
+	}
+
+	access, refresh, user, err := svc.SignInWithOAuth(context.Background(), "google", "code", "state", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if access == "" || refresh == "" || user == nil {
+		t.Fatalf("expected tokens, got access=%q refresh=%q user=%v", access, refresh, user)
+	}
+	if identities.created == nil || identities.created.EmailVerifiedAt == nil {
+		t.Fatal("expected a new user with a verified email")
+	}
+	if username := *identities.created.Username; username == "jane" || len(username) <= len("jane-") {
+		t.Fatalf("expected a suffixed username for a taken name, got %q", username)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != "new-user" {
+		t.Fatalf("expected identity linked to the new user, got %+v", identities.identities)
+	}
+	if !activity.has(model.ActivityOAuthLogin) {
+		t.Fatalf("expected oauth_login activity, got %+v", activity.activities)
+	}
+}
+
+func TestSignInWithOAuth_RefusesExistingEmail(t *testing.T) {
+	identities := &mockUserIdentityRepo{}
+	authRepo := &mockAuthRepo{
+		findUserByEmailFn: func(ctx context.Context, email string) (*model.User, error) {
+			return &model.User{ID: "someone-else", Email: email}, nil
+		},
+	}
+	svc := &authService{
+		authRepo:           authRepo,
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		userIdentityRepo:   identities,
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		oauthProviders: oauth.NewRegistry(&fakeOAuthProvider{identity: oauth.Identity{
+			Provider:      "google",
+			Subject:       "g-123",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Username:      "jane",
+		}}),
+	}
+
+	_, _, _, err := svc.SignInWithOAuth(context.Background(), "google", "code", "state", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrOAuthEmailInUse) {
+		t.Fatalf("expected ErrOAuthEmailInUse, got %v", err)
+	}
+	if identities.created != nil {
+		t.Fatal("no account should be created")
+	}
+}
+
+func TestLinkOAuthIdentity_UsesLinkTokenUser(t *testing.T) {
+	identities := &mockUserIdentityRepo{}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		authRepo:           &mockAuthRepo{},
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		userIdentityRepo:   identities,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		oauthProviders: oauth.NewRegistry(&fakeOAuthProvider{identity: oauth.Identity{
+			Provider:      "google",
+			Subject:       "g-123",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Username:      "jane",
+		}}),
+	}
+
+	linkToken, err := svc.CreateOAuthLinkToken(context.Background(), "user-1", "google")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := svc.LinkOAuthIdentity(context.Background(), linkToken, "gitlab", "code", "state", "127.0.0.1", "test-agent"); !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("expected link token to be bound to its provider, got %v", err)
+	}
+
+	if err := svc.LinkOAuthIdentity(context.Background(), linkToken, "google", "code", "state", "127.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != "user-1" {
+		t.Fatalf("expected identity linked to user-1, got %+v", identities.identities)
+	}
+	if !activity.has(model.ActivityIdentityLinked) {
+		t.Fatalf("expected identity_linked activity, got %+v", activity.activities)
+	}
+}
+
+func TestUnlinkIdentity_RefusesLastLoginMethod(t *testing.T) {
+	identities := &mockUserIdentityRepo{identities: []model.UserIdentity{
+		{UserID: "user-1", Provider: "google", Subject: "g-123"},
+	}}
+	users := &mockUserRepo{
+		getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+			return &model.User{ID: id}, nil
+		},
+	}
+	svc := &authService{
+		authRepo:           &mockAuthRepo{},
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		userIdentityRepo:   identities,
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		oauthProviders: oauth.NewRegistry(&fakeOAuthProvider{identity: oauth.Identity{
+			Provider:      "google",
+			Subject:       "g-123",
+			Email:         "jane@example.com",
+			EmailVerified: true,
+			Username:      "jane",
+		}}),
+	}
+
+	err := svc.UnlinkIdentity(context.Background(), "user-1", "google", "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrLastLoginMethod) {
+		t.Fatalf("expected ErrLastLoginMethod, got %v", err)
+	}
+	if len(identities.identities) != 1 {
+		t.Fatal("identity must not be removed")
+	}
+}
+
+func TestAccessToken_StoresHashAndAuthenticates(t *testing.T) {
+	tokens := &mockAccessTokenRepo{}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		accessTokenRepo:    tokens,
+	}
+
+	created, err := svc.CreateAccessToken(context.Background(), "user-1", &dto.CreateAccessTokenRequest{
+		Name:          "deploy script",
+		Scopes:        []string{"posts:write", "holdings:read", "posts:write"},
+		ExpiresInDays: 30,
+	}, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, PersonalAccessTokenPrefix) || !strings.HasPrefix(created.Token, created.TokenPrefix) {
+		t.Fatalf("unexpected token %q with prefix %q", created.Token, created.TokenPrefix)
+	}
+	if stored := tokens.tokens[0]; stored.TokenHash == created.Token || stored.Scopes != "posts:write holdings:read" {
+		t.Fatalf("unexpected stored token: %+v", stored)
+	}
+	if !activity.has(model.ActivityAccessTokenCreated) {
+		t.Fatalf("expected access_token_created activity, got %+v", activity.activities)
+	}
+
+	for range 2 {
+		pat, err := svc.AuthenticateAccessToken(context.Background(), created.Token, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("AuthenticateAccessToken: %v", err)
+		}
+		if pat.UserID != "user-1" || !pat.HasScope(model.ScopeHoldingsRead) {
+			t.Fatalf("unexpected token: %+v", pat)
+		}
+	}
+	if tokens.touched != 1 {
+		t.Fatalf("last used should be recorded once per interval, got %d writes", tokens.touched)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	tokens.tokens[0].ExpiresAt = &expired
+	if _, err := svc.AuthenticateAccessToken(context.Background(), created.Token, "127.0.0.1"); !errors.Is(err, apperrors.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for an expired token, got %v", err)
+	}
+}
+
+func TestCreateAccessToken_RejectsUnknownScope(t *testing.T) {
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{},
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		accessTokenRepo:    &mockAccessTokenRepo{},
+	}
+
+	_, err := svc.CreateAccessToken(context.Background(), "user-1", &dto.CreateAccessTokenRequest{
+		Name:   "bad",
+		Scopes: []string{"admin"},
+	}, "127.0.0.1", "test-agent")
+	if !errors.Is(err, apperrors.ErrInvalidAccessTokenScope) {
+		t.Fatalf("expected ErrInvalidAccessTokenScope, got %v", err)
+	}
+}
+
+func TestImpersonate_IssuesShortLivedTokenWithActorClaim(t *testing.T) {
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id, Email: "user@example.com"}, nil
+	}}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		activityService:    activity,
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+		impersonationTTL:   15 * time.Minute,
+	}
+
+	if _, err := svc.Impersonate(context.Background(), "admin-1", "admin-1", "127.0.0.1", "test"); !errors.Is(err, apperrors.ErrCannotImpersonateSelf) {
+		t.Fatalf("err = %v, want ErrCannotImpersonateSelf", err)
+	}
+
+	result, err := svc.Impersonate(context.Background(), "admin-1", "user-1", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	if d := time.Until(result.ExpiresAt); d <= 0 || d > 15*time.Minute {
+		t.Fatalf("token expires in %v, want at most 15m", d)
+	}
+
+	claims, err := svc.tokenKeys.Parse(result.AccessToken)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if claims["user_id"] != "user-1" {
+		t.Fatalf("user_id = %v", claims["user_id"])
+	}
+	if act, _ := claims[ImpersonationActorClaim].(map[string]any); act["sub"] != "admin-1" {
+		t.Fatalf("act = %v", claims[ImpersonationActorClaim])
+	}
+	if _, ok := claims["sid"]; ok {
+		t.Fatal("impersonation token must not be bound to a session")
+	}
+	if !activity.has(model.ActivityImpersonated) {
+		t.Fatal("expected impersonation to be logged for the user")
+	}
+}
+
+func TestLogout_DenylistsAccessToken(t *testing.T) {
+	sessions := &mockSessionRepo{
+		getByRefreshTokenFn: func(ctx context.Context, token string) (*model.Session, error) {
+			return &model.Session{ID: "sess-1", FamilyID: "family-1", UserID: "user-1"}, nil
+		},
+	}
+	svc := &authService{
+		sessionRepo:        sessions,
+		userRepo:           &mockUserRepo{},
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := svc.Logout(ctx, "pl_refresh", "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if revoked, err := svc.IsAccessTokenRevoked(ctx, "user-1", "jti-1", now); err != nil || !revoked {
+		t.Fatalf("logged out token: revoked = %v, err = %v", revoked, err)
+	}
+	if revoked, err := svc.IsAccessTokenRevoked(ctx, "user-1", "jti-2", now); err != nil || revoked {
+		t.Fatalf("other token: revoked = %v, err = %v", revoked, err)
+	}
+	if len(sessions.deletedFamilies) != 1 || sessions.deletedFamilies[0] != "family-1" {
+		t.Fatalf("deleted families = %v", sessions.deletedFamilies)
+	}
+}
+
+func TestChangePassword_RevokesEarlierAccessTokens(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-secret"), bcrypt.MinCost)
+	password := string(hash)
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id, Password: &password}, nil
+	}}
+	svc := &authService{
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           users,
+		activityService:    &mockActivityRecorder{},
+		jwtSecret:          []byte("test-secret"),
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+	ctx := context.Background()
+	issuedBefore := time.Now().Add(-time.Minute)
+
+	if err := svc.ChangePassword(ctx, "user-1", "old-secret", "new-secret", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if revoked, err := svc.IsAccessTokenRevoked(ctx, "user-1", "jti-1", issuedBefore); err != nil || !revoked {
+		t.Fatalf("token issued before the change: revoked = %v, err = %v", revoked, err)
+	}
+	if revoked, err := svc.IsAccessTokenRevoked(ctx, "user-1", "jti-2", time.Now()); err != nil || revoked {
+		t.Fatalf("token issued after the change: revoked = %v, err = %v", revoked, err)
+	}
+}
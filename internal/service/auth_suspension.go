@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+// TaskLiftSuspensions lifts suspensions whose end date has passed. It is
+// registered with the queue in the DI container.
+const TaskLiftSuspensions = "auth:lift_suspensions"
+
+// suspensionError returns an *apperrors.AccountSuspendedError if the user is
+// suspended.
+func suspensionError(user *model.User) error {
+	if !user.IsSuspended(time.Now()) {
+		return nil
+	}
+	suspended := &apperrors.AccountSuspendedError{Until: user.SuspendedUntil}
+	if user.SuspensionReason != nil {
+		suspended.Reason = *user.SuspensionReason
+	}
+	return suspended
+}
+
+// SuspendUser suspends the user until until, or until an admin lifts it when
+// until is nil. Every session and access token of the user is revoked and
+// their posts disappear from public listings.
+func (s *authService) SuspendUser(ctx context.Context, adminID, userID, reason string, until *time.Time, ipAddress, userAgent string) (*dto.Suspension, error) {
+	if adminID == userID {
+		return nil, apperrors.ErrCannotSuspendSelf
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil || user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	now := time.Now()
+	if err := s.suspensionRepo.Suspend(ctx, userID, reason, now, until); err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.revokeAllAccessTokens(ctx, userID); err != nil {
+		return nil, err
+	}
+	s.invalidatePostCaches(ctx)
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityAccountSuspended, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{
+		"suspendedBy":    adminID,
+		"reason":         reason,
+		"suspendedUntil": until,
+	})
+
+	if s.emailService != nil && s.emailService.IsConfigured() {
+		if err := s.emailService.EnqueueAccountSuspendedEmail(user.Email, reason, until); err != nil {
+			authLog.Error("failed to queue account suspended email", "error", err, "user_id", userID)
+		}
+	}
+
+	return &dto.Suspension{Reason: reason, SuspendedAt: now, SuspendedUntil: until}, nil
+}
+
+// UnsuspendUser lets an admin lift a suspension before it ends.
+func (s *authService) UnsuspendUser(ctx context.Context, adminID, userID, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil || user == nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	lifted, err := s.suspensionRepo.Lift(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !lifted {
+		return apperrors.ErrUserNotSuspended
+	}
+
+	s.reinstated(ctx, user, ipAddress, userAgent, map[string]any{"reinstatedBy": adminID})
+	s.invalidatePostCaches(ctx)
+	return nil
+}
+
+// LiftExpiredSuspensions reinstates users whose suspension has ended.
+func (s *authService) LiftExpiredSuspensions(ctx context.Context) error {
+	users, err := s.suspensionRepo.LiftExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	for _, user := range users {
+		s.reinstated(ctx, user, "", "", map[string]any{"expired": true})
+	}
+	s.invalidatePostCaches(ctx)
+	return nil
+}
+
+func (s *authService) HandleLiftSuspensionsTask(ctx context.Context, _ []byte) error {
+	return s.LiftExpiredSuspensions(ctx)
+}
+
+// reinstated records and emails the end of a suspension.
+func (s *authService) reinstated(ctx context.Context, user *model.User, ipAddress, userAgent string, metadata map[string]any) {
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityAccountReinstated, model.StatusSuccess, ipAddress, userAgent, nil, metadata)
+
+	if s.emailService == nil || !s.emailService.IsConfigured() {
+		return
+	}
+	loginLink := s.frontendConfig.URL
+	if loginLink == "" {
+		loginLink = "http://localhost:3000"
+	}
+	if err := s.emailService.EnqueueAccountReinstatedEmail(user.Email, loginLink); err != nil {
+		authLog.Error("failed to queue account reinstated email", "error", err, "user_id", user.ID)
+	}
+}
+
+// invalidatePostCaches drops the cached post lists so that a suspended
+// author's posts leave them, or a reinstated author's return, right away.
+func (s *authService) invalidatePostCaches(ctx context.Context) {
+	invalidatePostLists(ctx, s.cache, authLog)
+}
+
+// refuseSuspendedLogin logs and returns the error of a sign-in by a
+// suspended user, or nil if the user is not suspended.
+func (s *authService) refuseSuspendedLogin(ctx context.Context, user *model.User, activityType, ipAddress, userAgent string, metadata map[string]any) error {
+	err := suspensionError(user)
+	if err != nil {
+		errMsg := "Account suspended"
+		s.activityService.LogActivity(ctx, &user.ID, activityType, model.StatusFailure, ipAddress, userAgent, &errMsg, metadata)
+	}
+	return err
+}
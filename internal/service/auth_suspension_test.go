@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+	"echobackend/pkg/jwtkeys"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ repository.UserSuspensionRepository = (*mockUserSuspensionRepo)(nil)
+
+// mockUserSuspensionRepo suspends the users it holds in place, so the auth
+// service sees the change on its next lookup.
+type mockUserSuspensionRepo struct {
+	users map[string]*model.User
+}
+
+func (m *mockUserSuspensionRepo) Suspend(ctx context.Context, userID, reason string, at time.Time, until *time.Time) error {
+	user, ok := m.users[userID]
+	if !ok {
+		return apperrors.ErrUserNotFound
+	}
+	user.SuspendedAt, user.SuspendedUntil, user.SuspensionReason = &at, until, &reason
+	return nil
+}
+
+func (m *mockUserSuspensionRepo) Lift(ctx context.Context, userID string) (bool, error) {
+	user, ok := m.users[userID]
+	if !ok || user.SuspendedAt == nil {
+		return false, nil
+	}
+	user.SuspendedAt, user.SuspendedUntil, user.SuspensionReason = nil, nil, nil
+	return true, nil
+}
+
+func (m *mockUserSuspensionRepo) LiftExpired(ctx context.Context, now time.Time) ([]*model.User, error) {
+	var lifted []*model.User
+	for _, user := range m.users {
+		if user.SuspendedAt != nil && user.SuspendedUntil != nil && !now.Before(*user.SuspendedUntil) {
+			user.SuspendedAt, user.SuspendedUntil, user.SuspensionReason = nil, nil, nil
+			lifted = append(lifted, user)
+		}
+	}
+	return lifted, nil
+}
+
+func TestSuspendUser_RevokesSessionsAndBlocksLoginAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	sessions := &mockSessionRepo{getByRefreshTokenFn: func(ctx context.Context, token string) (*model.Session, error) {
+		return &model.Session{ID: "sess-1", FamilyID: "family-1", UserID: "user-1"}, nil
+	}}
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &model.User{ID: "user-1", Email: "a@example.com", Password: new(string(hashed))}
+	users := &mockUserRepo{users: map[string]*model.User{user.ID: user}}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		authRepo: &mockAuthRepo{findUserByIdentifierFn: func(ctx context.Context, identifier string) (*model.User, error) {
+			return user, nil
+		}},
+		sessionRepo:        sessions,
+		userRepo:           users,
+		twoFactorRepo:      &mockTwoFactorRepo{},
+		suspensionRepo:     &mockUserSuspensionRepo{users: map[string]*model.User{user.ID: user}},
+		activityService:    activity,
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+
+	if _, err := svc.SuspendUser(ctx, "admin-1", "admin-1", "spam", nil, "", ""); !errors.Is(err, apperrors.ErrCannotSuspendSelf) {
+		t.Fatalf("err = %v, want ErrCannotSuspendSelf", err)
+	}
+
+	until := time.Now().Add(24 * time.Hour)
+	if _, err := svc.SuspendUser(ctx, "admin-1", "user-1", "spam", &until, "", ""); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if len(sessions.deletedUsers) != 1 || sessions.deletedUsers[0] != "user-1" {
+		t.Fatalf("deleted sessions of %v, want user-1", sessions.deletedUsers)
+	}
+	if _, ok := users.tokensValidAfter["user-1"]; !ok {
+		t.Fatal("expected access tokens to be revoked")
+	}
+	if !activity.has(model.ActivityAccountSuspended) {
+		t.Fatalf("expected account_suspended activity, got %+v", activity.activities)
+	}
+
+	_, _, _, err = svc.Login(ctx, "a@example.com", "secret123", "127.0.0.1", "test-agent")
+	var suspendedErr *apperrors.AccountSuspendedError
+	if !errors.As(err, &suspendedErr) || suspendedErr.Reason != "spam" || suspendedErr.Until == nil || !suspendedErr.Until.Equal(until) {
+		t.Fatalf("Login err = %v, want AccountSuspendedError with reason and end", err)
+	}
+
+	if _, _, _, err := svc.RefreshToken(ctx, "pl_old", "127.0.0.1", "test-agent"); !errors.Is(err, apperrors.ErrAccountSuspended) {
+		t.Fatalf("RefreshToken err = %v, want ErrAccountSuspended", err)
+	}
+	if len(sessions.deletedFamilies) != 1 || sessions.deletedFamilies[0] != "family-1" {
+		t.Fatalf("deleted families = %v, want family-1", sessions.deletedFamilies)
+	}
+}
+
+func TestLiftExpiredSuspensions_ReinstatesOnlyExpired(t *testing.T) {
+	ctx := context.Background()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &model.User{ID: "user-1", Email: "a@example.com", Password: new(string(hashed))}
+	activity := &mockActivityRecorder{}
+	svc := &authService{
+		authRepo: &mockAuthRepo{findUserByIdentifierFn: func(ctx context.Context, identifier string) (*model.User, error) {
+			return user, nil
+		}},
+		sessionRepo:        &mockSessionRepo{},
+		userRepo:           &mockUserRepo{users: map[string]*model.User{user.ID: user}},
+		twoFactorRepo:      &mockTwoFactorRepo{},
+		suspensionRepo:     &mockUserSuspensionRepo{users: map[string]*model.User{user.ID: user}},
+		activityService:    activity,
+		tokenKeys:          jwtkeys.NewHMAC([]byte("test-secret")),
+		jwtExpiry:          time.Hour,
+		refreshTokenExpiry: 24 * time.Hour,
+		deniedAccessTokens: make(map[string]time.Time),
+	}
+
+	until := time.Now().Add(time.Hour)
+	if _, err := svc.SuspendUser(ctx, "admin-1", "user-1", "spam", &until, "", ""); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if err := svc.LiftExpiredSuspensions(ctx); err != nil {
+		t.Fatalf("LiftExpiredSuspensions: %v", err)
+	}
+	if user.SuspendedAt == nil || activity.has(model.ActivityAccountReinstated) {
+		t.Fatal("a suspension that has not ended must stay")
+	}
+
+	user.SuspendedUntil = new(time.Now().Add(-time.Minute))
+	if err := svc.LiftExpiredSuspensions(ctx); err != nil {
+		t.Fatalf("LiftExpiredSuspensions: %v", err)
+	}
+	if user.SuspendedAt != nil || !activity.has(model.ActivityAccountReinstated) {
+		t.Fatalf("expected the ended suspension to be lifted, got %+v", activity.activities)
+	}
+	if _, _, _, err := svc.Login(ctx, "a@example.com", "secret123", "127.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("Login after reinstatement: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+)
+
+// tokensValidAfterMissTTL bounds how long "no cut-off" is cached, in case it
+// races with a revocation.
+const tokensValidAfterMissTTL = time.Minute
+
+// AccessTokenRevocationChecker tells the auth middleware whether a signed
+// access token was revoked before it expired.
+type AccessTokenRevocationChecker interface {
+	IsAccessTokenRevoked(ctx context.Context, userID, tokenID string, issuedAt time.Time) (bool, error)
+}
+
+func newAccessTokenID() (string, error) {
+	idBytes, err := generateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(idBytes), nil
+}
+
+// accessTokenLifetime is the longest an access token can live, and so how
+// long revocation state has to be kept.
+func (s *authService) accessTokenLifetime() time.Duration {
+	return max(s.jwtExpiry, s.impersonationTTL)
+}
+
+// denyAccessToken puts the token's "jti" on the denylist until the token
+// expires. The entry is kept in Redis so every instance sees it, and in
+// memory so it also works without Redis.
+func (s *authService) denyAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return
+	}
+
+	s.deniedAccessTokensMu.Lock()
+	now := time.Now()
+	for id, until := range s.deniedAccessTokens {
+		if now.After(until) {
+			delete(s.deniedAccessTokens, id)
+		}
+	}
+	s.deniedAccessTokens[tokenID] = expiresAt
+	s.deniedAccessTokensMu.Unlock()
+
+	if s.cache != nil {
+		if err := s.cache.SetJSONWithTTL(ctx, s.cache.BuildKey("denied_jti", tokenID), true, ttl); err != nil {
+			authLog.Warn("failed to denylist access token", "error", err)
+		}
+	}
+}
+
+// revokeAllAccessTokens rejects every access token of the user issued before
+// now. Sessions are not touched: callers decide whether refresh tokens stay.
+func (s *authService) revokeAllAccessTokens(ctx context.Context, userID string) error {
+	now := time.Now()
+	if err := s.userRepo.SetTokensValidAfter(ctx, userID, now); err != nil {
+		return err
+	}
+	s.cacheTokensValidAfter(ctx, userID, &now)
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether the token was denylisted on logout or
+// issued before the user's "tokens valid after" time. Token times have
+// second precision, so a token issued in the same second as the cut-off is
+// still accepted.
+func (s *authService) IsAccessTokenRevoked(ctx context.Context, userID, tokenID string, issuedAt time.Time) (bool, error) {
+	if tokenID != "" {
+		s.deniedAccessTokensMu.Lock()
+		_, denied := s.deniedAccessTokens[tokenID]
+		s.deniedAccessTokensMu.Unlock()
+		if denied {
+			return true, nil
+		}
+
+		if s.cache != nil {
+			var revoked bool
+			found, err := s.cache.GetJSON(ctx, s.cache.BuildKey("denied_jti", tokenID), &revoked)
+			if err == nil && found && revoked {
+				return true, nil
+			}
+		}
+	}
+
+	validAfter, err := s.tokensValidAfter(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return validAfter != nil && issuedAt.Before(validAfter.Truncate(time.Second)), nil
+}
+
+// tokensValidAfter reads the cut-off from Redis, falling back to the
+// database. A missing cut-off is cached as 0 so that most requests do not
+// reach the database.
+func (s *authService) tokensValidAfter(ctx context.Context, userID string) (*time.Time, error) {
+	if s.cache != nil {
+		var unix int64
+		found, err := s.cache.GetJSON(ctx, s.cache.BuildKey("tokens_valid_after", userID), &unix)
+		if err == nil && found {
+			if unix == 0 {
+				return nil, nil
+			}
+			validAfter := time.Unix(unix, 0)
+			return &validAfter, nil
+		}
+	}
+
+	validAfter, err := s.userRepo.GetTokensValidAfter(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheTokensValidAfter(ctx, userID, validAfter)
+	return validAfter, nil
+}
+
+func (s *authService) cacheTokensValidAfter(ctx context.Context, userID string, validAfter *time.Time) {
+	if s.cache == nil {
+		return
+	}
+
+	var unix int64
+	ttl := tokensValidAfterMissTTL
+	if validAfter != nil {
+		unix = validAfter.Truncate(time.Second).Unix()
+		ttl = s.accessTokenLifetime()
+	}
+	key := s.cache.BuildKey("tokens_valid_after", userID)
+	if err := s.cache.SetJSONWithTTL(ctx, key, unix, ttl); err != nil {
+		// A stale cached value would keep revoked tokens working.
+		_ = s.cache.Delete(ctx, key)
+	}
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TwoFactorChallengeTTL is how long a login challenge token stays valid.
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+const (
+	twoFactorChallengePurpose = "2fa_challenge"
+	recoveryCodeCount         = 10
+	recoveryCodeBytes         = 7
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// CreateTwoFactorChallenge returns a short-lived token proving that user has
+// passed the password step of login. It is signed with a key derived from the
+// JWT secret so it can never be accepted as an access token.
+func (s *authService) CreateTwoFactorChallenge(_ context.Context, user *model.User) (string, error) {
+	if user == nil {
+		return "", errors.New("two-factor challenge user is nil")
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":     user.ID,
+		"purpose": twoFactorChallengePurpose,
+		"iat":     now.Unix(),
+		"exp":     now.Add(TwoFactorChallengeTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.twoFactorChallengeKey())
+}
+
+func (s *authService) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (string, string, *model.User, error) {
+	userID, err := s.parseTwoFactorChallenge(challengeToken)
+	if err != nil {
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	twoFactor, err := s.twoFactorRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return "", "", nil, apperrors.ErrInvalidToken
+	}
+
+	// The user may have been suspended since the challenge was issued.
+	if err := s.refuseSuspendedLogin(ctx, user, model.ActivityLoginFailed, ipAddress, userAgent, map[string]any{"twoFactor": true}); err != nil {
+		return "", "", nil, err
+	}
+
+	if err := s.checkAccountLock(ctx, user.ID); err != nil {
+		return "", "", nil, err
+	}
+
+	method, err := s.checkSecondFactor(ctx, twoFactor, code)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+			s.activityService.LogActivity(ctx, &user.ID, model.ActivityTwoFactorFailed, model.StatusFailure, ipAddress, userAgent, nil, nil)
+			if lockErr := s.recordLoginFailure(ctx, user, ipAddress, userAgent); lockErr != nil {
+				return "", "", nil, lockErr
+			}
+		}
+		return "", "", nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &user.ID, model.ActivityTwoFactorSuccess, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{"method": method})
+
+	return s.completeLogin(ctx, user, ipAddress, userAgent, map[string]any{"twoFactor": true})
+}
+
+func (s *authService) EnrollTwoFactor(ctx context.Context, userID, ipAddress, userAgent string) (*dto.TwoFactorEnrollResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	existing, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.IsEnabled() {
+		return nil, apperrors.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.UpsertPending(ctx, &model.UserTwoFactor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorEnroll, model.StatusPending, ipAddress, userAgent, nil, nil)
+
+	account := user.Email
+	if user.Username != nil && *user.Username != "" {
+		account = *user.Username
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: buildTOTPURI(s.totpIssuer, account, secret),
+	}, nil
+}
+
+func (s *authService) ConfirmTwoFactor(ctx context.Context, userID, code, ipAddress, userAgent string) ([]string, error) {
+	twoFactor, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, apperrors.ErrTwoFactorNotEnrolled
+	}
+	if twoFactor.IsEnabled() {
+		return nil, apperrors.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := validateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorEnable, model.StatusFailure, ipAddress, userAgent, nil, nil)
+		return nil, apperrors.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Confirm(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorEnable, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+
+	return codes, nil
+}
+
+func (s *authService) DisableTwoFactor(ctx context.Context, userID, password, code, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	twoFactor, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.IsEnabled() {
+		return apperrors.ErrTwoFactorNotEnabled
+	}
+
+	// Accounts created through OAuth have no password; for them the second
+	// factor alone authorizes the change.
+	if user.Password != nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)); err != nil {
+			s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorDisable, model.StatusFailure, ipAddress, userAgent, nil, nil)
+			return apperrors.ErrInvalidCredentials
+		}
+	}
+
+	if _, err := s.checkSecondFactor(ctx, twoFactor, code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+			s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorDisable, model.StatusFailure, ipAddress, userAgent, nil, nil)
+		}
+		return err
+	}
+
+	if err := s.twoFactorRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityTwoFactorDisable, model.StatusSuccess, ipAddress, userAgent, nil, nil)
+
+	return nil
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code and
+// returns which one matched.
+func (s *authService) checkSecondFactor(ctx context.Context, twoFactor *model.UserTwoFactor, code string) (string, error) {
+	if step, ok := validateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		advanced, err := s.twoFactorRepo.AdvanceLastUsedStep(ctx, twoFactor.UserID, step)
+		if err != nil {
+			return "", err
+		}
+		if !advanced {
+			return "", apperrors.ErrInvalidTwoFactorCode
+		}
+		return "totp", nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return "", apperrors.ErrInvalidTwoFactorCode
+	}
+	used, err := s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, tokenHash(normalized))
+	if err != nil {
+		return "", err
+	}
+	if !used {
+		return "", apperrors.ErrInvalidTwoFactorCode
+	}
+	return "recovery_code", nil
+}
+
+func (s *authService) twoFactorChallengeKey() []byte {
+	sum := sha256.Sum256(append([]byte(twoFactorChallengePurpose+":"), s.jwtSecret...))
+	return sum[:]
+}
+
+func (s *authService) parseTwoFactorChallenge(challengeToken string) (string, error) {
+	token, err := jwt.Parse(challengeToken, func(*jwt.Token) (any, error) {
+		return s.twoFactorChallengeKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != twoFactorChallengePurpose {
+		return "", apperrors.ErrInvalidToken
+	}
+
+	userID, err := claims.GetSubject()
+	if err != nil || userID == "" {
+		return "", apperrors.ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// generateRecoveryCodes returns n codes formatted as "xxxxx-xxxxx" together
+// with the hashes that are persisted. Only the hashes are stored.
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for range n {
+		b, err := generateRandomBytes(recoveryCodeBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw := recoveryCodeEncoding.EncodeToString(b)[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, tokenHash(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
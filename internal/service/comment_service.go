@@ -22,13 +22,15 @@ type commentService struct {
 	commentRepo         repository.CommentRepository
 	postRepo            repository.PostRepository
 	notificationService NotificationService
+	blocks              UserBlockChecker
 }
 
-func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository, notificationService NotificationService) CommentService {
+func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository, notificationService NotificationService, blocks UserBlockChecker) CommentService {
 	return &commentService{
 		commentRepo:         commentRepo,
 		postRepo:            postRepo,
 		notificationService: notificationService,
+		blocks:              blocks,
 	}
 }
 
@@ -38,6 +40,16 @@ func (s *commentService) CreateComment(ctx context.Context, postID string, req *
 		return nil, apperrors.ErrPostNotFound
 	}
 
+	if s.blocks != nil && post.CreatedBy != nil && *post.CreatedBy != createdBy {
+		blocked, err := s.blocks.IsBlockedBetween(ctx, *post.CreatedBy, createdBy)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, apperrors.ErrUserBlocked
+		}
+	}
+
 	comment := &model.PostComment{
 		PostID:    postID,
 		Text:      req.Text,
@@ -58,6 +70,7 @@ func (s *commentService) CreateComment(ctx context.Context, postID string, req *
 		title := "New comment"
 		_, _ = s.notificationService.CreateNotification(ctx, &dto.CreateNotificationRequest{
 			UserID:  *post.CreatedBy,
+			ActorID: createdBy,
 			Type:    "comment",
 			Title:   title,
 			Message: &message,
@@ -88,7 +88,7 @@ func TestCreateComment(t *testing.T) {
 				return nil, apperrors.ErrPostNotFound
 			},
 		}
-		svc := NewCommentService(&mockCommentRepo{}, mockPost, nil)
+		svc := NewCommentService(&mockCommentRepo{}, mockPost, nil, nil)
 		_, err := svc.CreateComment(ctx, postID, &dto.CreateCommentRequest{Text: "Test comment"}, commenterID)
 		if !errors.Is(err, apperrors.ErrPostNotFound) {
 			t.Fatalf("expected ErrPostNotFound, got %v", err)
@@ -132,7 +132,7 @@ func TestCreateComment(t *testing.T) {
 			},
 		}
 
-		svc := NewCommentService(mockComment, mockPost, mockNotif)
+		svc := NewCommentService(mockComment, mockPost, mockNotif, nil)
 		resp, err := svc.CreateComment(ctx, postID, &dto.CreateCommentRequest{Text: commentText}, commenterID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -175,7 +175,7 @@ func TestCreateComment(t *testing.T) {
 			},
 		}
 
-		svc := NewCommentService(mockComment, mockPost, mockNotif)
+		svc := NewCommentService(mockComment, mockPost, mockNotif, nil)
 		_, err := svc.CreateComment(ctx, postID, &dto.CreateCommentRequest{Text: "Author's own comment"}, postAuthor)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -196,7 +196,7 @@ func TestGetCommentsByPostID(t *testing.T) {
 				return nil, apperrors.ErrPostNotFound
 			},
 		}
-		svc := NewCommentService(&mockCommentRepo{}, mockPost, nil)
+		svc := NewCommentService(&mockCommentRepo{}, mockPost, nil, nil)
 		_, err := svc.GetCommentsByPostID(ctx, postID)
 		if !errors.Is(err, apperrors.ErrPostNotFound) {
 			t.Fatalf("expected ErrPostNotFound, got %v", err)
@@ -217,7 +217,7 @@ func TestGetCommentsByPostID(t *testing.T) {
 				}, nil
 			},
 		}
-		svc := NewCommentService(mockComment, mockPost, nil)
+		svc := NewCommentService(mockComment, mockPost, nil, nil)
 		resp, err := svc.GetCommentsByPostID(ctx, postID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -239,7 +239,7 @@ func TestUpdateComment(t *testing.T) {
 				return &model.PostComment{ID: id, CreatedBy: "other-user"}, nil
 			},
 		}
-		svc := NewCommentService(mockComment, nil, nil)
+		svc := NewCommentService(mockComment, nil, nil, nil)
 		_, err := svc.UpdateComment(ctx, commentID, "New content", ownerID)
 		if !errors.Is(err, apperrors.ErrCommentNotOwned) {
 			t.Fatalf("expected ErrCommentNotOwned, got %v", err)
@@ -261,7 +261,7 @@ func TestUpdateComment(t *testing.T) {
 				return nil
 			},
 		}
-		svc := NewCommentService(mockComment, nil, nil)
+		svc := NewCommentService(mockComment, nil, nil, nil)
 		resp, err := svc.UpdateComment(ctx, commentID, "New text", ownerID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -283,7 +283,7 @@ func TestDeleteComment(t *testing.T) {
 				return &model.PostComment{ID: id, CreatedBy: "other-user"}, nil
 			},
 		}
-		svc := NewCommentService(mockComment, nil, nil)
+		svc := NewCommentService(mockComment, nil, nil, nil)
 		err := svc.DeleteComment(ctx, commentID, ownerID)
 		if !errors.Is(err, apperrors.ErrCommentNotOwned) {
 			t.Fatalf("expected ErrCommentNotOwned, got %v", err)
@@ -301,7 +301,7 @@ func TestDeleteComment(t *testing.T) {
 				return nil
 			},
 		}
-		svc := NewCommentService(mockComment, nil, nil)
+		svc := NewCommentService(mockComment, nil, nil, nil)
 		err := svc.DeleteComment(ctx, commentID, ownerID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
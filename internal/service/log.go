@@ -3,6 +3,11 @@ package service
 import "echobackend/pkg/applog"
 
 var (
+	accountLog    = applog.Component("account")
+	auditLog      = applog.Component("audit")
 	authLog       = applog.Component("auth")
+	followLog     = applog.Component("follow")
+	postLog       = applog.Component("post")
+	profileLog    = applog.Component("profile")
 	openRouterLog = applog.Component("openrouter")
 )
@@ -9,6 +9,7 @@ package service
 
 import (
 	"context"
+	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
 	"echobackend/internal/model"
 	"echobackend/internal/repository"
@@ -108,11 +109,14 @@ type mockPostRepo struct {
 	getPostBySlugAndUsernameFn func(ctx context.Context, slug string, username string) (*model.Post, error)
 	getPostsByCreatedByFn      func(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error)
 	deletePostByIDFn           func(ctx context.Context, id string) error
-	updatePostFn               func(ctx context.Context, id string, updates map[string]any) (*model.Post, error)
+	updatePostFn               func(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error)
 	getPostsForSitemapFn       func(ctx context.Context, limit int) ([]*dto.SitemapPost, error)
-	searchPostsFn              func(ctx context.Context, keyword string, limit int, offset int) ([]*model.Post, int64, error)
+	searchPostsFn              func(ctx context.Context, tsquery string, filter *dto.PostQueryFilter) ([]*dto.PostSearchHit, int64, error)
 	getPostsByTagFn            func(ctx context.Context, tag string, limit int, offset int) ([]*model.Post, int64, error)
 	getPostsForYouFn           func(ctx context.Context, userID string, offset int, limit int) ([]*model.Post, int64, error)
+	getScheduledByCreatedByFn  func(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error)
+	publishScheduledFn         func(ctx context.Context, id string, publishAt time.Time) (bool, error)
+	publishDueFn               func(ctx context.Context, now time.Time) (int64, error)
 }
 
 func (m *mockPostRepo) CreatePost(ctx context.Context, post *model.Post) error {
@@ -139,7 +143,7 @@ func (m *mockPostRepo) GetPostsFiltered(ctx context.Context, filter *dto.PostQue
 	}
 	panic("GetPostsFiltered not stubbed")
 }
-func (m *mockPostRepo) GetPostByUsername(ctx context.Context, username string, offset int, limit int) ([]*model.Post, int64, error) {
+func (m *mockPostRepo) GetPostByUsername(ctx context.Context, username string, viewerID string, offset int, limit int) ([]*model.Post, int64, error) {
 	if m.getPostByUsernameFn != nil {
 		return m.getPostByUsernameFn(ctx, username, offset, limit)
 	}
@@ -163,7 +167,7 @@ func (m *mockPostRepo) GetPostByID(ctx context.Context, id string) (*model.Post,
 	}
 	return nil, nil
 }
-func (m *mockPostRepo) GetPostBySlugAndUsername(ctx context.Context, slug string, username string) (*model.Post, error) {
+func (m *mockPostRepo) GetPostBySlugAndUsername(ctx context.Context, slug string, username string, viewerID string) (*model.Post, error) {
 	if m.getPostBySlugAndUsernameFn != nil {
 		return m.getPostBySlugAndUsernameFn(ctx, slug, username)
 	}
@@ -175,15 +179,33 @@ func (m *mockPostRepo) GetPostsByCreatedBy(ctx context.Context, createdBy string
 	}
 	panic("GetPostsByCreatedBy not stubbed")
 }
+func (m *mockPostRepo) GetScheduledByCreatedBy(ctx context.Context, createdBy string, offset int, limit int) ([]*model.Post, int64, error) {
+	if m.getScheduledByCreatedByFn != nil {
+		return m.getScheduledByCreatedByFn(ctx, createdBy, offset, limit)
+	}
+	panic("GetScheduledByCreatedBy not stubbed")
+}
+func (m *mockPostRepo) PublishScheduled(ctx context.Context, id string, publishAt time.Time) (bool, error) {
+	if m.publishScheduledFn != nil {
+		return m.publishScheduledFn(ctx, id, publishAt)
+	}
+	panic("PublishScheduled not stubbed")
+}
+func (m *mockPostRepo) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	if m.publishDueFn != nil {
+		return m.publishDueFn(ctx, now)
+	}
+	panic("PublishDue not stubbed")
+}
 func (m *mockPostRepo) DeletePostByID(ctx context.Context, id string) error {
 	if m.deletePostByIDFn != nil {
 		return m.deletePostByIDFn(ctx, id)
 	}
 	panic("DeletePostByID not stubbed")
 }
-func (m *mockPostRepo) UpdatePost(ctx context.Context, id string, updates map[string]any) (*model.Post, error) {
+func (m *mockPostRepo) UpdatePost(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
 	if m.updatePostFn != nil {
-		return m.updatePostFn(ctx, id, updates)
+		return m.updatePostFn(ctx, id, updates, tags, revision)
 	}
 	panic("UpdatePost not stubbed")
 }
@@ -193,9 +215,9 @@ func (m *mockPostRepo) GetPostsForSitemap(ctx context.Context, limit int) ([]*dt
 	}
 	panic("GetPostsForSitemap not stubbed")
 }
-func (m *mockPostRepo) SearchPosts(ctx context.Context, keyword string, limit int, offset int) ([]*model.Post, int64, error) {
+func (m *mockPostRepo) SearchPosts(ctx context.Context, tsquery string, filter *dto.PostQueryFilter) ([]*dto.PostSearchHit, int64, error) {
 	if m.searchPostsFn != nil {
-		return m.searchPostsFn(ctx, keyword, limit, offset)
+		return m.searchPostsFn(ctx, tsquery, filter)
 	}
 	panic("SearchPosts not stubbed")
 }
@@ -274,15 +296,20 @@ func (m *mockPostViewRepo) CountViewsByAuthorBefore(ctx context.Context, userID,
 // ---- UserRepository mock ------------------------------------------------------
 
 type mockUserRepo struct {
-	getByIDFn       func(ctx context.Context, id string, deletedOnly bool) (*model.User, error)
-	getByUsernameFn func(ctx context.Context, username string) (*model.User, error)
-	getUsersFn      func(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error)
-	softDeleteFn    func(ctx context.Context, id string) error
-	restoreByIDFn   func(ctx context.Context, id string) error
-	createFn        func(ctx context.Context, user *model.User) error
-	updateFn        func(ctx context.Context, user *model.User) error
-	existsFn        func(ctx context.Context, email string) (bool, error)
-	getByEmailFn    func(ctx context.Context, email string) (*model.User, error)
+	getByIDFn        func(ctx context.Context, id string, deletedOnly bool) (*model.User, error)
+	getByUsernameFn  func(ctx context.Context, username string) (*model.User, error)
+	getUsersFn       func(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error)
+	searchFn         func(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error)
+	softDeleteFn     func(ctx context.Context, id string) error
+	restoreByIDFn    func(ctx context.Context, id string) error
+	createFn         func(ctx context.Context, user *model.User) error
+	updateFn         func(ctx context.Context, user *model.User) error
+	existsFn         func(ctx context.Context, email string) (bool, error)
+	getByEmailFn     func(ctx context.Context, email string) (*model.User, error)
+	checkUsernameFn  func(ctx context.Context, username string) error
+	tokensValidAfter map[string]time.Time
+	// users, when set, serves GetByID and GetByUsername that have no fn.
+	users map[string]*model.User
 }
 
 func (m *mockUserRepo) Create(ctx context.Context, user *model.User) error {
@@ -295,14 +322,39 @@ func (m *mockUserRepo) GetByID(ctx context.Context, id string, deletedOnly bool)
 	if m.getByIDFn != nil {
 		return m.getByIDFn(ctx, id, deletedOnly)
 	}
+	if m.users != nil {
+		if u, ok := m.users[id]; ok {
+			return u, nil
+		}
+		return nil, apperrors.ErrUserNotFound
+	}
 	return nil, nil
 }
+func (m *mockUserRepo) GetByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	users := make([]*model.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := m.GetByID(ctx, id, false)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
 func (m *mockUserRepo) GetUsers(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error) {
 	if m.getUsersFn != nil {
 		return m.getUsersFn(ctx, offset, limit, deletedFilter)
 	}
 	return nil, 0, nil
 }
+func (m *mockUserRepo) Search(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error) {
+	if m.searchFn != nil {
+		return m.searchFn(ctx, query, offset, limit)
+	}
+	return nil, 0, nil
+}
 func (m *mockUserRepo) GetUsersByEmail(ctx context.Context, email string) ([]*model.User, error) {
 	panic("GetUsersByEmail not stubbed")
 }
@@ -316,6 +368,14 @@ func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*mod
 	if m.getByUsernameFn != nil {
 		return m.getByUsernameFn(ctx, username)
 	}
+	if m.users != nil {
+		for _, u := range m.users {
+			if u.Username != nil && *u.Username == username {
+				return u, nil
+			}
+		}
+		return nil, apperrors.ErrUserNotFound
+	}
 	return nil, nil
 }
 func (m *mockUserRepo) Update(ctx context.Context, user *model.User) error {
@@ -343,8 +403,24 @@ func (m *mockUserRepo) Exists(ctx context.Context, email string) (bool, error) {
 	return false, nil
 }
 func (m *mockUserRepo) CheckUserByUsername(ctx context.Context, username string) error {
+	if m.checkUsernameFn != nil {
+		return m.checkUsernameFn(ctx, username)
+	}
 	panic("CheckUserByUsername not stubbed")
 }
+func (m *mockUserRepo) GetTokensValidAfter(ctx context.Context, id string) (*time.Time, error) {
+	if at, ok := m.tokensValidAfter[id]; ok {
+		return &at, nil
+	}
+	return nil, nil
+}
+func (m *mockUserRepo) SetTokensValidAfter(ctx context.Context, id string, at time.Time) error {
+	if m.tokensValidAfter == nil {
+		m.tokensValidAfter = map[string]time.Time{}
+	}
+	m.tokensValidAfter[id] = at
+	return nil
+}
 
 // ---- TagRepository mock -------------------------------------------------------
 
@@ -20,13 +20,20 @@ type NotificationService interface {
 
 type notificationService struct {
 	notificationRepo repository.NotificationRepository
+	blocks           UserBlockChecker
 }
 
-func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
-	return &notificationService{notificationRepo: notificationRepo}
+func NewNotificationService(notificationRepo repository.NotificationRepository, blocks UserBlockChecker) NotificationService {
+	return &notificationService{notificationRepo: notificationRepo, blocks: blocks}
 }
 
+// CreateNotification stores the notification. It returns nil and no error
+// when the notification is suppressed because of a block or mute.
 func (s *notificationService) CreateNotification(ctx context.Context, req *dto.CreateNotificationRequest) (*dto.NotificationResponse, error) {
+	if suppressed, err := s.suppressed(ctx, req); err != nil || suppressed {
+		return nil, err
+	}
+
 	var encodedData *string
 	if len(req.Data) > 0 {
 		payload, err := json.Marshal(req.Data)
@@ -54,6 +61,17 @@ func (s *notificationService) CreateNotification(ctx context.Context, req *dto.C
 	return dto.NotificationToResponse(created), nil
 }
 
+func (s *notificationService) suppressed(ctx context.Context, req *dto.CreateNotificationRequest) (bool, error) {
+	if s.blocks == nil || req.ActorID == "" || req.ActorID == req.UserID {
+		return false, nil
+	}
+	blocked, err := s.blocks.IsBlockedBetween(ctx, req.UserID, req.ActorID)
+	if err != nil || blocked {
+		return blocked, err
+	}
+	return s.blocks.IsMuted(ctx, req.UserID, req.ActorID)
+}
+
 func (s *notificationService) GetNotifications(ctx context.Context, userID string, filter *dto.NotificationListFilter) ([]*dto.NotificationResponse, int64, error) {
 	notifications, total, err := s.notificationRepo.GetByUser(ctx, userID, filter.Unread, filter.Limit, filter.Offset)
 	if err != nil {
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+	"echobackend/pkg/textdiff"
+)
+
+// revisionDiffContext is the number of unchanged lines shown around each
+// change in a revision diff.
+const revisionDiffContext = 3
+
+type PostRevisionService interface {
+	ListRevisions(ctx context.Context, postID string, limit, offset int) ([]*dto.PostRevisionResponse, int64, error)
+	GetRevision(ctx context.Context, postID string, number int) (*dto.PostRevisionResponse, error)
+	// DiffRevisions returns the unified diff of the title, tags and body from
+	// revision from to revision to.
+	DiffRevisions(ctx context.Context, postID string, from, to int) (*dto.PostRevisionDiff, error)
+	// RestoreRevision makes the title, body and tags of a revision the
+	// post's current content, recorded as a new revision by editorID.
+	RestoreRevision(ctx context.Context, postID string, number int, editorID string) (*dto.PostResponse, error)
+}
+
+type postRevisionService struct {
+	revisionRepo repository.PostRevisionRepository
+	postRepo     repository.PostRepository
+	tagService   TagService
+}
+
+func NewPostRevisionService(revisionRepo repository.PostRevisionRepository, postRepo repository.PostRepository, tagService TagService) PostRevisionService {
+	return &postRevisionService{revisionRepo: revisionRepo, postRepo: postRepo, tagService: tagService}
+}
+
+func (s *postRevisionService) ListRevisions(ctx context.Context, postID string, limit, offset int) ([]*dto.PostRevisionResponse, int64, error) {
+	revisions, total, err := s.revisionRepo.List(ctx, postID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*dto.PostRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		responses = append(responses, dto.PostRevisionToResponse(revision, false))
+	}
+	return responses, total, nil
+}
+
+func (s *postRevisionService) GetRevision(ctx context.Context, postID string, number int) (*dto.PostRevisionResponse, error) {
+	revision, err := s.revisionRepo.GetByNumber(ctx, postID, number)
+	if err != nil {
+		return nil, err
+	}
+	return dto.PostRevisionToResponse(revision, true), nil
+}
+
+func (s *postRevisionService) DiffRevisions(ctx context.Context, postID string, from, to int) (*dto.PostRevisionDiff, error) {
+	fromRevision, err := s.revisionRepo.GetByNumber(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.revisionRepo.GetByNumber(ctx, postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := textdiff.Unified(
+		fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to),
+		revisionText(fromRevision), revisionText(toRevision),
+		revisionDiffContext,
+	)
+	return &dto.PostRevisionDiff{From: from, To: to, Diff: diff}, nil
+}
+
+func (s *postRevisionService) RestoreRevision(ctx context.Context, postID string, number int, editorID string) (*dto.PostResponse, error) {
+	revision, err := s.revisionRepo.GetByNumber(ctx, postID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags deleted since the revision are created again.
+	tags, err := resolveTags(ctx, s.tagService, revision.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.UpdatePost(ctx, postID,
+		map[string]any{"title": revision.Title, "body": revision.Body},
+		tags,
+		&model.PostRevision{EditorID: &editorID, RestoredFrom: &number},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return dto.PostToResponse(post), nil
+}
+
+// revisionText lays out a revision for diffing: the title and tags on the
+// first lines, then the body.
+func revisionText(revision *model.PostRevision) string {
+	return fmt.Sprintf("Title: %s\nTags: %s\n\n%s", revision.Title, strings.Join(revision.Tags, ", "), revision.Body)
+}
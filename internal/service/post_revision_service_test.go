@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+)
+
+type mockPostRevisionRepo struct {
+	revisions []*model.PostRevision
+}
+
+func (m *mockPostRevisionRepo) List(ctx context.Context, postID string, limit, offset int) ([]*model.PostRevision, int64, error) {
+	return m.revisions, int64(len(m.revisions)), nil
+}
+
+func (m *mockPostRevisionRepo) GetByNumber(ctx context.Context, postID string, number int) (*model.PostRevision, error) {
+	for _, revision := range m.revisions {
+		if revision.PostID == postID && revision.Number == number {
+			return revision, nil
+		}
+	}
+	return nil, apperrors.ErrRevisionNotFound
+}
+
+func TestDiffRevisions(t *testing.T) {
+	revisions := &mockPostRevisionRepo{revisions: []*model.PostRevision{
+		{PostID: "post-1", Number: 1, Title: "Hello", Tags: []string{"go"}, Body: "first line\nsecond line\n"},
+		{PostID: "post-1", Number: 2, Title: "Hello", Tags: []string{"go", "echo"}, Body: "first line\nsecond line, edited\n"},
+	}}
+	svc := NewPostRevisionService(revisions, &mockPostRepo{}, &mockTagService{})
+
+	diff, err := svc.DiffRevisions(context.Background(), "post-1", 1, 2)
+	if err != nil {
+		t.Fatalf("DiffRevisions: %v", err)
+	}
+	want := "--- revision 1\n+++ revision 2\n@@ -1,5 +1,5 @@\n Title: Hello\n-Tags: go\n+Tags: go, echo\n \n first line\n-second line\n+second line, edited\n"
+	if diff.From != 1 || diff.To != 2 || diff.Diff != want {
+		t.Fatalf("diff = %+v, want\n%s", diff, want)
+	}
+
+	if _, err := svc.DiffRevisions(context.Background(), "post-1", 1, 3); !errors.Is(err, apperrors.ErrRevisionNotFound) {
+		t.Fatalf("err = %v, want ErrRevisionNotFound", err)
+	}
+}
+
+func TestRestoreRevision_SavesContentAsNewEdit(t *testing.T) {
+	revisions := &mockPostRevisionRepo{revisions: []*model.PostRevision{
+		{PostID: "post-1", Number: 1, Title: "Original title", Tags: []string{"go"}, Body: "The long original body"},
+	}}
+	var gotUpdates map[string]any
+	var gotTags []model.Tag
+	var gotRevision *model.PostRevision
+	posts := &mockPostRepo{updatePostFn: func(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+		gotUpdates, gotTags, gotRevision = updates, tags, revision
+		return &model.Post{ID: id}, nil
+	}}
+	tags := &mockTagService{findOrCreateByNameFn: func(ctx context.Context, name string) (*model.Tag, error) {
+		return &model.Tag{ID: 7, Name: name}, nil
+	}}
+	svc := NewPostRevisionService(revisions, posts, tags)
+
+	if _, err := svc.RestoreRevision(context.Background(), "post-1", 1, "author-1"); err != nil {
+		t.Fatalf("RestoreRevision: %v", err)
+	}
+	if gotUpdates["title"] != "Original title" || gotUpdates["body"] != "The long original body" {
+		t.Fatalf("updates = %v, want the revision's title and body", gotUpdates)
+	}
+	if len(gotTags) != 1 || gotTags[0].Name != "go" {
+		t.Fatalf("tags = %v, want go", gotTags)
+	}
+	if gotRevision == nil || *gotRevision.EditorID != "author-1" || gotRevision.RestoredFrom == nil || *gotRevision.RestoredFrom != 1 {
+		t.Fatalf("revision = %+v, want a restore of revision 1 by author-1", gotRevision)
+	}
+
+	if _, err := svc.RestoreRevision(context.Background(), "post-1", 9, "author-1"); !errors.Is(err, apperrors.ErrRevisionNotFound) {
+		t.Fatalf("err = %v, want ErrRevisionNotFound", err)
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/platform/queue"
+)
+
+// Background tasks run by the post service. They are registered with the
+// queue in the DI container.
+const (
+	// TaskPublishPost publishes one scheduled post at its publish_at.
+	TaskPublishPost = "post:publish"
+	// TaskPublishDuePosts publishes scheduled posts whose task was lost,
+	// for example because Redis was unavailable when they were scheduled.
+	TaskPublishDuePosts = "post:publish_due"
+)
+
+// postPublishTask names the schedule it was queued for, so a task left over
+// from a moved or cancelled schedule does nothing.
+type postPublishTask struct {
+	PostID    string    `json:"post_id"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+func isPublished(post *model.Post) bool {
+	return post.Published != nil && *post.Published
+}
+
+// checkPublishAt rejects schedules that cannot run and drops the fraction of
+// a second, so that the stored time and the task payload compare equal.
+func (s *postService) checkPublishAt(at time.Time) (time.Time, error) {
+	if s.tasks == nil || !s.tasks.IsConfigured() {
+		return time.Time{}, apperrors.ErrSchedulingDisabled
+	}
+	at = at.Truncate(time.Second).UTC()
+	if !at.After(time.Now()) {
+		return time.Time{}, apperrors.ErrPublishAtNotFuture
+	}
+	return at, nil
+}
+
+// enqueuePublish queues the publication of a post scheduled for at. If that
+// fails, the periodic TaskPublishDuePosts publishes it instead.
+func (s *postService) enqueuePublish(postID string, at time.Time) {
+	task := postPublishTask{PostID: postID, PublishAt: at}
+	if err := s.tasks.EnqueueJSON(TaskPublishPost, task, queue.TaskOptions{ProcessAt: at}); err != nil {
+		postLog.Error("failed to queue scheduled publish", "error", err, "post_id", postID)
+	}
+}
+
+func (s *postService) GetScheduledPosts(ctx context.Context, userID string, offset int, limit int) ([]*dto.PostResponse, int64, error) {
+	posts, total, err := s.postRepo.GetScheduledByCreatedBy(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	postsResponse := make([]*dto.PostResponse, 0, len(posts))
+	for _, post := range posts {
+		postsResponse = append(postsResponse, dto.PostToResponse(post))
+	}
+	return postsResponse, total, nil
+}
+
+func (s *postService) CancelSchedule(ctx context.Context, id string, editorID string) (*dto.PostResponse, error) {
+	post, err := s.postRepo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if isPublished(post) {
+		return nil, apperrors.ErrPostAlreadyPublished
+	}
+	if post.PublishAt == nil {
+		return dto.PostToResponse(post), nil
+	}
+
+	// The queued task stays behind and finds the schedule gone.
+	updated, err := s.postRepo.UpdatePost(ctx, id, map[string]any{"publish_at": nil}, nil, &model.PostRevision{EditorID: &editorID})
+	if err != nil {
+		return nil, err
+	}
+	return dto.PostToResponse(updated), nil
+}
+
+func (s *postService) HandlePublishTask(ctx context.Context, payload []byte) error {
+	var task postPublishTask
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %w: %w", err, queue.SkipRetry)
+	}
+	if task.PostID == "" || task.PublishAt.IsZero() {
+		return fmt.Errorf("invalid post publish payload: %w", queue.SkipRetry)
+	}
+
+	published, err := s.postRepo.PublishScheduled(ctx, task.PostID, task.PublishAt)
+	if err != nil {
+		return err
+	}
+	if published {
+		s.invalidatePublishedCaches(ctx)
+	}
+	return nil
+}
+
+func (s *postService) HandlePublishDueTask(ctx context.Context, _ []byte) error {
+	published, err := s.postRepo.PublishDue(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if published > 0 {
+		postLog.Info("published overdue scheduled posts", "count", published)
+		s.invalidatePublishedCaches(ctx)
+	}
+	return nil
+}
+
+// invalidatePublishedCaches drops the cached lists a newly published post
+// belongs in.
+func (s *postService) invalidatePublishedCaches(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	invalidatePostLists(ctx, s.cache, postLog)
+	prefix := s.cache.BuildKey("tags", "trending")
+	if err := s.cache.DeleteByPrefix(ctx, prefix); err != nil {
+		postLog.Warn("failed to invalidate cached tags", "prefix", prefix, "error", err)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/platform/queue"
+)
+
+func TestCreatePost_Scheduled(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() *mockPostRepo {
+		return &mockPostRepo{createPostWithTagsFn: func(ctx context.Context, post *model.Post, tags []model.Tag) (*model.Post, error) {
+			post.ID = "post-1"
+			return post, nil
+		}}
+	}
+
+	t.Run("queues the publication at publish_at", func(t *testing.T) {
+		tasks := &mockTaskEnqueuer{configured: true}
+		svc := NewPostService(newRepo(), nil, nil, nil, tasks)
+
+		publishAt := time.Now().Add(time.Hour)
+		resp, err := svc.CreatePost(ctx, &dto.CreatePostRequest{Title: "t", Slug: "s", Body: "body text", PublishAt: &publishAt}, "user-1")
+		if err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+		want := publishAt.Truncate(time.Second)
+		if *resp.Published || resp.PublishedAt != nil || resp.PublishAt == nil || !resp.PublishAt.Equal(want) {
+			t.Fatalf("got published=%v published_at=%v publish_at=%v, want a post scheduled for %v", *resp.Published, resp.PublishedAt, resp.PublishAt, want)
+		}
+		if len(tasks.tasks) != 1 || tasks.tasks[0] != TaskPublishPost || !tasks.options[0].ProcessAt.Equal(want) {
+			t.Fatalf("queued %v with %+v, want %s at %v", tasks.tasks, tasks.options, TaskPublishPost, want)
+		}
+		if task := tasks.payloads[0].(postPublishTask); task.PostID != "post-1" || !task.PublishAt.Equal(want) {
+			t.Fatalf("payload = %+v", task)
+		}
+	})
+
+	cases := []struct {
+		name      string
+		tasks     *mockTaskEnqueuer
+		publishAt time.Time
+		published bool
+		want      error
+	}{
+		{"past time", &mockTaskEnqueuer{configured: true}, time.Now().Add(-time.Minute), false, apperrors.ErrPublishAtNotFuture},
+		{"published and scheduled", &mockTaskEnqueuer{configured: true}, time.Now().Add(time.Hour), true, apperrors.ErrPostAlreadyPublished},
+		{"queue not configured", &mockTaskEnqueuer{}, time.Now().Add(time.Hour), false, apperrors.ErrSchedulingDisabled},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewPostService(newRepo(), nil, nil, nil, tc.tasks)
+			_, err := svc.CreatePost(ctx, &dto.CreatePostRequest{Title: "t", Slug: "s", Body: "body text", Published: tc.published, PublishAt: &tc.publishAt}, "user-1")
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+			if len(tc.tasks.tasks) != 0 {
+				t.Fatalf("queued %v, want nothing", tc.tasks.tasks)
+			}
+		})
+	}
+}
+
+func TestUpdatePost_Schedule(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("publishing by hand drops the schedule", func(t *testing.T) {
+		var gotUpdates map[string]any
+		repo := &mockPostRepo{
+			getPostByIDFn: func(ctx context.Context, id string) (*model.Post, error) {
+				return &model.Post{ID: id, Published: new(false), PublishAt: new(time.Now().Add(time.Hour))}, nil
+			},
+			updatePostFn: func(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+				gotUpdates = updates
+				return &model.Post{ID: id, Published: new(true)}, nil
+			},
+		}
+		cache := &mockCacheStore{}
+		svc := NewPostService(repo, nil, nil, cache, &mockTaskEnqueuer{configured: true})
+
+		if _, err := svc.UpdatePost(ctx, "post-1", &dto.UpdatePostRequest{Published: new(true)}, "user-1"); err != nil {
+			t.Fatalf("UpdatePost: %v", err)
+		}
+		if v, ok := gotUpdates["publish_at"]; !ok || v != nil {
+			t.Fatalf("publish_at update = %v, want nil", v)
+		}
+		if _, ok := gotUpdates["published_at"]; !ok {
+			t.Fatal("expected published_at to be set")
+		}
+		if len(cache.deleted) == 0 {
+			t.Fatal("expected the post caches to be invalidated")
+		}
+	})
+
+	t.Run("a published post cannot be scheduled", func(t *testing.T) {
+		repo := &mockPostRepo{getPostByIDFn: func(ctx context.Context, id string) (*model.Post, error) {
+			return &model.Post{ID: id, Published: new(true)}, nil
+		}}
+		tasks := &mockTaskEnqueuer{configured: true}
+		svc := NewPostService(repo, nil, nil, nil, tasks)
+
+		_, err := svc.UpdatePost(ctx, "post-1", &dto.UpdatePostRequest{PublishAt: new(time.Now().Add(time.Hour))}, "user-1")
+		if !errors.Is(err, apperrors.ErrPostAlreadyPublished) {
+			t.Fatalf("err = %v, want ErrPostAlreadyPublished", err)
+		}
+		if len(tasks.tasks) != 0 {
+			t.Fatalf("queued %v, want nothing", tasks.tasks)
+		}
+	})
+}
+
+func TestCancelSchedule_IsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	post := &model.Post{ID: "post-1", Published: new(false), PublishAt: new(time.Now().Add(time.Hour))}
+	updates := 0
+	repo := &mockPostRepo{
+		getPostByIDFn: func(ctx context.Context, id string) (*model.Post, error) {
+			return post, nil
+		},
+		updatePostFn: func(ctx context.Context, id string, changes map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+			updates++
+			post.PublishAt = nil
+			return post, nil
+		},
+	}
+	svc := NewPostService(repo, nil, nil, nil, nil)
+
+	for range 2 {
+		resp, err := svc.CancelSchedule(ctx, "post-1", "user-1")
+		if err != nil {
+			t.Fatalf("CancelSchedule: %v", err)
+		}
+		if resp.PublishAt != nil {
+			t.Fatalf("publish_at = %v, want none", resp.PublishAt)
+		}
+	}
+	if updates != 1 {
+		t.Fatalf("updated %d times, want 1", updates)
+	}
+
+	post.Published = new(true)
+	if _, err := svc.CancelSchedule(ctx, "post-1", "user-1"); !errors.Is(err, apperrors.ErrPostAlreadyPublished) {
+		t.Fatalf("err = %v, want ErrPostAlreadyPublished", err)
+	}
+}
+
+func TestHandlePublishTask(t *testing.T) {
+	ctx := context.Background()
+	publishAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, published := range []bool{true, false} {
+		var gotID string
+		var gotAt time.Time
+		repo := &mockPostRepo{publishScheduledFn: func(ctx context.Context, id string, at time.Time) (bool, error) {
+			gotID, gotAt = id, at
+			return published, nil
+		}}
+		cache := &mockCacheStore{}
+		svc := NewPostService(repo, nil, nil, cache, nil)
+
+		if err := svc.HandlePublishTask(ctx, []byte(`{"post_id":"post-1","publish_at":"2030-01-02T03:04:05Z"}`)); err != nil {
+			t.Fatalf("HandlePublishTask: %v", err)
+		}
+		if gotID != "post-1" || !gotAt.Equal(publishAt) {
+			t.Fatalf("published %q at %v, want post-1 at %v", gotID, gotAt, publishAt)
+		}
+		// A task whose schedule was moved or cancelled publishes nothing and
+		// leaves the caches alone.
+		if invalidated := len(cache.deleted) > 0; invalidated != published {
+			t.Fatalf("published=%v but caches invalidated=%v", published, invalidated)
+		}
+	}
+
+	svc := NewPostService(&mockPostRepo{}, nil, nil, nil, nil)
+	for _, payload := range []string{`{`, `{"post_id":"post-1"}`} {
+		if err := svc.HandlePublishTask(ctx, []byte(payload)); !errors.Is(err, queue.SkipRetry) {
+			t.Fatalf("payload %s: err = %v, want SkipRetry", payload, err)
+		}
+	}
+}
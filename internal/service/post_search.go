@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+)
+
+func (s *postService) SearchPosts(ctx context.Context, filter *dto.PostQueryFilter) ([]*dto.PostSearchResult, int64, error) {
+	query := searchTSQuery(filter.Search)
+	if query == "" {
+		return nil, 0, apperrors.ErrEmptySearch
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	hits, total, err := s.postRepo.SearchPosts(ctx, query, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*dto.PostSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, &dto.PostSearchResult{
+			PostResponse: dto.PostToResponse(hit.Post),
+			Rank:         hit.Rank,
+			Headline:     headlineHTML(hit.Headline),
+		})
+	}
+	return results, total, nil
+}
+
+// headlineMarker turns the match delimiters of an escaped headline into marks.
+var headlineMarker = strings.NewReplacer(dto.HeadlineStartSel, "<mark>", dto.HeadlineStopSel, "</mark>")
+
+// headlineHTML escapes a plain-text search headline and wraps its matches in
+// <mark> and </mark>.
+func headlineHTML(headline string) string {
+	return headlineMarker.Replace(html.EscapeString(headline))
+}
+
+// searchTSQuery turns a search into a to_tsquery expression. Every term must
+// match unless OR stands between two terms; a "quoted phrase" matches its
+// words in order, a trailing * matches words starting with the term and a
+// leading - excludes the term. Anything but letters and digits separates
+// words, so the result is always valid tsquery syntax. It returns "" when
+// the search has no words.
+func searchTSQuery(search string) string {
+	var b strings.Builder
+	or := false
+	for rest := strings.TrimSpace(search); rest != ""; rest = strings.TrimSpace(rest) {
+		negate := false
+		if rest[0] == '-' {
+			negate = true
+			rest = rest[1:]
+		}
+
+		var term string
+		if rest != "" && rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			term, rest = tsPhrase(phrase, false), after
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			word := rest[:end]
+			rest = rest[end:]
+			if word == "OR" && !negate {
+				or = b.Len() > 0
+				continue
+			}
+			term = tsPhrase(word, strings.HasSuffix(word, "*"))
+		}
+		if term == "" {
+			continue
+		}
+
+		if b.Len() > 0 {
+			if or {
+				b.WriteString(" | ")
+			} else {
+				b.WriteString(" & ")
+			}
+		}
+		if negate {
+			b.WriteString("!")
+		}
+		b.WriteString(term)
+		or = false
+	}
+	return b.String()
+}
+
+// tsPhrase joins the words of text with the followed-by operator and marks
+// the last one as a prefix when prefix is set.
+func tsPhrase(text string, prefix bool) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+func TestSearchTSQuery(t *testing.T) {
+	tests := []struct {
+		search string
+		want   string
+	}{
+		{"golang", "golang"},
+		{"  go   echo ", "go & echo"},
+		{`"full text" search`, "(full <-> text) & search"},
+		{"post*", "post:*"},
+		{"go OR rust -java", "go | rust & !java"},
+		{`-"hello world"`, "!(hello <-> world)"},
+		{"e-mail api');DROP", "(e <-> mail) & (api <-> DROP)"},
+		{"kopi☕ susu", "kopi & susu"},
+		{"OR go OR", "go"},
+		{`"unclosed phrase`, "(unclosed <-> phrase)"},
+		{"!!! & |", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := searchTSQuery(tt.search); got != tt.want {
+			t.Errorf("searchTSQuery(%q) = %q, want %q", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPosts(t *testing.T) {
+	ctx := context.Background()
+	title := "Full-text search in Postgres"
+	var gotQuery string
+	var gotFilter *dto.PostQueryFilter
+	repo := &mockPostRepo{searchPostsFn: func(ctx context.Context, tsquery string, filter *dto.PostQueryFilter) ([]*dto.PostSearchHit, int64, error) {
+		gotQuery, gotFilter = tsquery, filter
+		return []*dto.PostSearchHit{{Post: &model.Post{ID: "post-1", Title: &title}, Rank: 0.6, Headline: dto.HeadlineStartSel + "postgres" + dto.HeadlineStopSel + ` <img src=x onerror="alert(1)"> & <mark>`}}, 1, nil
+	}}
+	svc := NewPostService(repo, nil, nil, nil, nil)
+
+	if _, _, err := svc.SearchPosts(ctx, &dto.PostQueryFilter{Search: " -- "}); !errors.Is(err, apperrors.ErrEmptySearch) {
+		t.Fatalf("err = %v, want ErrEmptySearch", err)
+	}
+
+	results, total, err := svc.SearchPosts(ctx, &dto.PostQueryFilter{Search: "postgres sea*", Limit: 500, Tags: []string{"go"}})
+	if err != nil {
+		t.Fatalf("SearchPosts: %v", err)
+	}
+	if gotQuery != "postgres & sea:*" || gotFilter.Limit != 100 || len(gotFilter.Tags) != 1 {
+		t.Fatalf("repo got query %q and filter %+v", gotQuery, gotFilter)
+	}
+	if total != 1 || len(results) != 1 || results[0].ID != "post-1" || results[0].Rank != 0.6 {
+		t.Fatalf("unexpected results: %+v (total %d)", results, total)
+	}
+	// The body is escaped, so the marks around matches are the only markup.
+	if want := `<mark>postgres</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt; &amp; &lt;mark&gt;`; results[0].Headline != want {
+		t.Fatalf("headline = %q, want %q", results[0].Headline, want)
+	}
+}
@@ -10,11 +10,13 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
 	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
 	"echobackend/internal/model"
 	"echobackend/internal/repository"
+	"echobackend/pkg/applog"
 )
 
 type FileUploader interface {
@@ -25,25 +27,40 @@ type CacheStore interface {
 	BuildKey(parts ...string) string
 	GetJSON(ctx context.Context, key string, dest any) (bool, error)
 	SetJSON(ctx context.Context, key string, value any) error
+	DeleteByPrefix(ctx context.Context, prefix string) error
 }
 
 type PostService interface {
 	GetPosts(ctx context.Context, limit int, offset int) ([]*dto.PostResponse, int64, error)
 	GetPostsFiltered(ctx context.Context, filter *dto.PostQueryFilter) ([]*dto.PostResponse, int64, error)
-	GetPostsByUsername(ctx context.Context, username string, offset int, limit int) ([]*dto.PostResponse, int64, error)
+	// SearchPosts runs a full-text search for filter.Search; see
+	// searchTSQuery for the query syntax.
+	SearchPosts(ctx context.Context, filter *dto.PostQueryFilter) ([]*dto.PostSearchResult, int64, error)
+	// GetPostsByUsername and GetPostBySlugAndUsername hide posts of private
+	// authors from viewers who do not follow them; viewerID may be empty.
+	GetPostsByUsername(ctx context.Context, username string, viewerID string, offset int, limit int) ([]*dto.PostResponse, int64, error)
 	GetPostsRandom(ctx context.Context, limit int) ([]*dto.PostResponse, error)
 	GetPostsTrending(ctx context.Context, limit int) ([]*dto.PostResponse, error)
 	GetPostByID(ctx context.Context, id string) (*dto.PostResponse, error)
-	GetPostBySlugAndUsername(ctx context.Context, slug string, username string) (*dto.PostResponse, error)
+	GetPostBySlugAndUsername(ctx context.Context, slug string, username string, viewerID string) (*dto.PostResponse, error)
 	GetPostsByCreatedBy(ctx context.Context, createdBy string, offset int, limit int) ([]*dto.PostResponse, int64, error)
 	GetPostsByTag(ctx context.Context, tag string, limit int, offset int) ([]*dto.PostResponse, int64, error)
 	GetPostsForYou(ctx context.Context, userID string, offset int, limit int) ([]*dto.PostResponse, int64, error)
 	DeletePostByID(ctx context.Context, id string) error
 	UploadImagePosts(ctx context.Context, file *multipart.FileHeader) error
 	CreatePost(ctx context.Context, req *dto.CreatePostRequest, creatorID string) (*dto.PostResponse, error)
-	UpdatePost(ctx context.Context, id string, req *dto.UpdatePostRequest) (*dto.PostResponse, error)
+	// UpdatePost applies req and records the result as a revision by
+	// editorID. Tags are replaced when req.Tags is set, so an empty list
+	// removes them.
+	UpdatePost(ctx context.Context, id string, req *dto.UpdatePostRequest, editorID string) (*dto.PostResponse, error)
 	IsAuthor(ctx context.Context, id string, userid string) error
 	GetPostsForSitemap(ctx context.Context, limit int) ([]*dto.SitemapPost, error)
+	GetScheduledPosts(ctx context.Context, userID string, offset int, limit int) ([]*dto.PostResponse, int64, error)
+	// CancelSchedule keeps a scheduled post unpublished. Posts that are not
+	// scheduled are returned unchanged.
+	CancelSchedule(ctx context.Context, id string, editorID string) (*dto.PostResponse, error)
+	HandlePublishTask(ctx context.Context, payload []byte) error
+	HandlePublishDueTask(ctx context.Context, payload []byte) error
 }
 
 type postService struct {
@@ -51,6 +68,7 @@ type postService struct {
 	tagService TagService
 	s3storage  FileUploader
 	cache      CacheStore
+	tasks      TaskEnqueuer
 }
 
 type trendingPostsCacheEntry struct {
@@ -60,8 +78,8 @@ type trendingPostsCacheEntry struct {
 const maxPostImageSize = 1 * 1024 * 1024
 const imageUploadPrefix = "posts/images"
 
-func NewPostService(postRepo repository.PostRepository, tagService TagService, storageclient FileUploader, redisCache CacheStore) PostService {
-	return &postService{postRepo: postRepo, tagService: tagService, s3storage: storageclient, cache: redisCache}
+func NewPostService(postRepo repository.PostRepository, tagService TagService, storageclient FileUploader, redisCache CacheStore, tasks TaskEnqueuer) PostService {
+	return &postService{postRepo: postRepo, tagService: tagService, s3storage: storageclient, cache: redisCache, tasks: tasks}
 }
 
 func (s *postService) IsAuthor(ctx context.Context, id string, userid string) error {
@@ -75,7 +93,7 @@ func (s *postService) IsAuthor(ctx context.Context, id string, userid string) er
 	return nil
 }
 
-func (s *postService) GetPostsByUsername(ctx context.Context, username string, offset int, limit int) ([]*dto.PostResponse, int64, error) {
+func (s *postService) GetPostsByUsername(ctx context.Context, username string, viewerID string, offset int, limit int) ([]*dto.PostResponse, int64, error) {
 	if limit < 0 {
 		limit = 0
 	}
@@ -86,7 +104,7 @@ func (s *postService) GetPostsByUsername(ctx context.Context, username string, o
 		return []*dto.PostResponse{}, 0, nil
 	}
 
-	posts, total, err := s.postRepo.GetPostByUsername(ctx, username, offset, limit)
+	posts, total, err := s.postRepo.GetPostByUsername(ctx, username, viewerID, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -100,21 +118,6 @@ func (s *postService) GetPostsByUsername(ctx context.Context, username string, o
 }
 
 func (s *postService) CreatePost(ctx context.Context, req *dto.CreatePostRequest, creatorID string) (*dto.PostResponse, error) {
-	var tags []model.Tag
-	if len(req.Tags) > 0 {
-		for _, tagName := range req.Tags {
-			if tagName == "" {
-				continue
-			}
-
-			tag, err := s.findOrCreateTagByName(ctx, tagName)
-			if err != nil {
-				return nil, err
-			}
-			tags = append(tags, *tag)
-		}
-	}
-
 	post := &model.Post{
 		Title:     &req.Title,
 		Slug:      &req.Slug,
@@ -123,17 +126,39 @@ func (s *postService) CreatePost(ctx context.Context, req *dto.CreatePostRequest
 		PhotoURL:  &req.PhotoURL,
 		Published: &req.Published,
 	}
+	if req.PublishAt != nil {
+		if req.Published {
+			return nil, apperrors.ErrPostAlreadyPublished
+		}
+		publishAt, err := s.checkPublishAt(*req.PublishAt)
+		if err != nil {
+			return nil, err
+		}
+		post.PublishAt = &publishAt
+	} else if req.Published {
+		post.PublishedAt = new(time.Now())
+	}
+
+	tags, err := resolveTags(ctx, s.tagService, req.Tags)
+	if err != nil {
+		return nil, err
+	}
 
 	created, err := s.postRepo.CreatePostWithTags(ctx, post, tags)
 	if err != nil {
 		return nil, err
 	}
 
+	if created.PublishAt != nil {
+		s.enqueuePublish(created.ID, *created.PublishAt)
+	} else if req.Published {
+		s.invalidatePublishedCaches(ctx)
+	}
 	return dto.PostToResponse(created), nil
 }
 
-func (s *postService) GetPostBySlugAndUsername(ctx context.Context, slug string, username string) (*dto.PostResponse, error) {
-	post, err := s.postRepo.GetPostBySlugAndUsername(ctx, slug, username)
+func (s *postService) GetPostBySlugAndUsername(ctx context.Context, slug string, username string, viewerID string) (*dto.PostResponse, error) {
+	post, err := s.postRepo.GetPostBySlugAndUsername(ctx, slug, username, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +170,7 @@ func (s *postService) DeletePostByID(ctx context.Context, id string) error {
 	return s.postRepo.DeletePostByID(ctx, id)
 }
 
-func (s *postService) UpdatePost(ctx context.Context, id string, req *dto.UpdatePostRequest) (*dto.PostResponse, error) {
+func (s *postService) UpdatePost(ctx context.Context, id string, req *dto.UpdatePostRequest, editorID string) (*dto.PostResponse, error) {
 	updates := make(map[string]any)
 	if req.Title != "" {
 		updates["title"] = req.Title
@@ -163,7 +188,32 @@ func (s *postService) UpdatePost(ctx context.Context, id string, req *dto.Update
 		updates["published"] = *req.Published
 	}
 
-	if len(updates) == 0 && len(req.Tags) == 0 {
+	var publishAt *time.Time
+	publishing := false
+	if req.PublishAt != nil || (req.Published != nil && *req.Published) {
+		current, err := s.postRepo.GetPostByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if req.PublishAt != nil {
+			if isPublished(current) || (req.Published != nil && *req.Published) {
+				return nil, apperrors.ErrPostAlreadyPublished
+			}
+			at, err := s.checkPublishAt(*req.PublishAt)
+			if err != nil {
+				return nil, err
+			}
+			publishAt = &at
+			updates["publish_at"] = at
+		} else if !isPublished(current) {
+			// Publishing by hand drops the schedule.
+			publishing = true
+			updates["published_at"] = time.Now()
+			updates["publish_at"] = nil
+		}
+	}
+
+	if len(updates) == 0 && req.Tags == nil {
 		post, err := s.postRepo.GetPostByID(ctx, id)
 		if err != nil {
 			return nil, err
@@ -171,10 +221,25 @@ func (s *postService) UpdatePost(ctx context.Context, id string, req *dto.Update
 		return dto.PostToResponse(post), nil
 	}
 
-	updatedPost, err := s.postRepo.UpdatePost(ctx, id, updates)
+	var tags []model.Tag
+	if req.Tags != nil {
+		var err error
+		if tags, err = resolveTags(ctx, s.tagService, req.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	updatedPost, err := s.postRepo.UpdatePost(ctx, id, updates, tags, &model.PostRevision{EditorID: &editorID})
 	if err != nil {
 		return nil, err
 	}
+
+	if publishAt != nil {
+		s.enqueuePublish(id, *publishAt)
+	}
+	if publishing {
+		s.invalidatePublishedCaches(ctx)
+	}
 	return dto.PostToResponse(updatedPost), nil
 }
 
@@ -427,8 +492,35 @@ func (s *postService) UploadImagePosts(ctx context.Context, file *multipart.File
 	return s.s3storage.Save(ctx, objectKey, bytes.NewReader(data), contentType)
 }
 
-func (s *postService) findOrCreateTagByName(ctx context.Context, tagName string) (*model.Tag, error) {
-	return s.tagService.FindOrCreateByName(ctx, tagName)
+// resolveTags finds or creates the tags named in names, skipping blank
+// names. The result is never nil, so it can clear a post's tags.
+func resolveTags(ctx context.Context, tagService TagService, names []string) ([]model.Tag, error) {
+	tags := make([]model.Tag, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		tag, err := tagService.FindOrCreateByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+	return tags, nil
+}
+
+// invalidatePostLists drops the cached random and trending post lists. They
+// embed each post's author, so any change to which posts they hold or how
+// their authors look has to drop them; failures are logged under log.
+func invalidatePostLists(ctx context.Context, cache ProfileCache, log applog.Logger) {
+	if cache == nil {
+		return
+	}
+	for _, prefix := range []string{cache.BuildKey("posts", "random", ""), cache.BuildKey("posts", "trending", "")} {
+		if err := cache.DeleteByPrefix(ctx, prefix); err != nil {
+			log.Warn("failed to invalidate cached posts", "prefix", prefix, "error", err)
+		}
+	}
 }
 
 func detectAllowedImage(data []byte) (contentType string, ext string, ok bool) {
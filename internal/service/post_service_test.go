@@ -48,6 +48,7 @@ type mockCacheStore struct {
 	buildKeyFn func(parts ...string) string
 	getJSONFn  func(ctx context.Context, key string, dest any) (bool, error)
 	setJSONFn  func(ctx context.Context, key string, value any) error
+	deleted    []string
 }
 
 func (m *mockCacheStore) BuildKey(parts ...string) string {
@@ -68,11 +69,15 @@ func (m *mockCacheStore) SetJSON(ctx context.Context, key string, value any) err
 	}
 	return nil
 }
+func (m *mockCacheStore) DeleteByPrefix(ctx context.Context, prefix string) error {
+	m.deleted = append(m.deleted, prefix)
+	return nil
+}
 
 // ---- Test Cases ---------------------------------------------------------------
 
 func TestUploadImagePostsRejectsFilesLargerThanOneMiB(t *testing.T) {
-	svc := NewPostService(&mockPostRepo{}, nil, nil, nil)
+	svc := NewPostService(&mockPostRepo{}, nil, nil, nil, nil)
 
 	err := svc.UploadImagePosts(context.Background(), &multipart.FileHeader{
 		Filename: "large.jpg",
@@ -94,7 +99,7 @@ func TestIsAuthor(t *testing.T) {
 				return &model.Post{ID: id, CreatedBy: &authorID}, nil
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		err := svc.IsAuthor(ctx, "post-id", authorID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -108,7 +113,7 @@ func TestIsAuthor(t *testing.T) {
 				return &model.Post{ID: id, CreatedBy: &wrongAuthor}, nil
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		err := svc.IsAuthor(ctx, "post-id", authorID)
 		if !errors.Is(err, apperrors.ErrNotAuthor) {
 			t.Fatalf("expected ErrNotAuthor, got %v", err)
@@ -121,7 +126,7 @@ func TestIsAuthor(t *testing.T) {
 				return nil, apperrors.ErrPostNotFound
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		err := svc.IsAuthor(ctx, "post-id", authorID)
 		if !errors.Is(err, apperrors.ErrPostNotFound) {
 			t.Fatalf("expected ErrPostNotFound, got %v", err)
@@ -140,7 +145,7 @@ func TestGetPostByID(t *testing.T) {
 				return &model.Post{ID: id, Title: &title}, nil
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		resp, err := svc.GetPostByID(ctx, postID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -156,7 +161,7 @@ func TestGetPostByID(t *testing.T) {
 				return nil, apperrors.ErrPostNotFound
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		_, err := svc.GetPostByID(ctx, postID)
 		if !errors.Is(err, apperrors.ErrPostNotFound) {
 			t.Fatalf("expected ErrPostNotFound, got %v", err)
@@ -191,7 +196,7 @@ func TestCreatePost(t *testing.T) {
 			},
 		}
 
-		svc := NewPostService(repo, mockTagSvc, nil, nil)
+		svc := NewPostService(repo, mockTagSvc, nil, nil, nil)
 		resp, err := svc.CreatePost(ctx, req, creatorID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -214,13 +219,16 @@ func TestUpdatePost(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		title := "Updated Title"
 		repo := &mockPostRepo{
-			updatePostFn: func(ctx context.Context, id string, updates map[string]any) (*model.Post, error) {
+			updatePostFn: func(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+				if tags != nil || revision == nil || revision.EditorID == nil || *revision.EditorID != "editor-1" {
+					t.Fatalf("tags = %v, revision = %+v; want tags left alone and editor-1 as editor", tags, revision)
+				}
 				return &model.Post{ID: id, Title: &title}, nil
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		req := &dto.UpdatePostRequest{Title: "Updated Title"}
-		resp, err := svc.UpdatePost(ctx, postID, req)
+		resp, err := svc.UpdatePost(ctx, postID, req, "editor-1")
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
 		}
@@ -228,6 +236,23 @@ func TestUpdatePost(t *testing.T) {
 			t.Fatalf("expected Title %s, got %s", title, *resp.Title)
 		}
 	})
+
+	t.Run("empty tag list clears tags", func(t *testing.T) {
+		var gotTags []model.Tag
+		repo := &mockPostRepo{
+			updatePostFn: func(ctx context.Context, id string, updates map[string]any, tags []model.Tag, revision *model.PostRevision) (*model.Post, error) {
+				gotTags = tags
+				return &model.Post{ID: id}, nil
+			},
+		}
+		svc := NewPostService(repo, &mockTagService{}, nil, nil, nil)
+		if _, err := svc.UpdatePost(ctx, postID, &dto.UpdatePostRequest{Tags: []string{}}, "editor-1"); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		if gotTags == nil || len(gotTags) != 0 {
+			t.Fatalf("tags = %#v, want an empty non-nil list", gotTags)
+		}
+	})
 }
 
 func TestDeletePostByID(t *testing.T) {
@@ -241,7 +266,7 @@ func TestDeletePostByID(t *testing.T) {
 				return nil
 			},
 		}
-		svc := NewPostService(repo, nil, nil, nil)
+		svc := NewPostService(repo, nil, nil, nil, nil)
 		err := svc.DeletePostByID(ctx, postID)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -274,7 +299,7 @@ func TestGetPostsTrending(t *testing.T) {
 
 		// repo is not set up with getPostsTrendingFn, so if it's called, it will panic.
 		// A successful test with no panic guarantees a cache hit was resolved.
-		svc := NewPostService(&mockPostRepo{}, nil, nil, mockCache)
+		svc := NewPostService(&mockPostRepo{}, nil, nil, mockCache, nil)
 		resp, err := svc.GetPostsTrending(ctx, limit)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
@@ -304,7 +329,7 @@ func TestGetPostsTrending(t *testing.T) {
 			},
 		}
 
-		svc := NewPostService(repo, nil, nil, mockCache)
+		svc := NewPostService(repo, nil, nil, mockCache, nil)
 		resp, err := svc.GetPostsTrending(ctx, limit)
 		if err != nil {
 			t.Fatalf("expected nil, got %v", err)
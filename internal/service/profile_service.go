@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"strings"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/repository"
+)
+
+const (
+	avatarUploadPrefix = "avatars"
+	maxAvatarSize      = 1 * 1024 * 1024
+)
+
+// AvatarStorage stores avatar images under a public URL.
+type AvatarStorage interface {
+	Save(ctx context.Context, path string, file io.Reader, contentType string) error
+	Delete(ctx context.Context, path string) error
+	ObjectURL(path string) string
+}
+
+// ProfileCache drops cached responses that embed user data.
+type ProfileCache interface {
+	BuildKey(parts ...string) string
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+type ProfileService interface {
+	UpdateProfile(ctx context.Context, userID string, req *dto.UpdateProfileRequest) (*dto.CurrentUserResponse, error)
+	// UploadAvatar stores file as the user's avatar and removes the previous
+	// one if it was uploaded here too.
+	UploadAvatar(ctx context.Context, userID string, file *multipart.FileHeader) (*dto.CurrentUserResponse, error)
+}
+
+type profileService struct {
+	profileRepo repository.ProfileRepository
+	userRepo    UserRepository
+	storage     AvatarStorage
+	cache       ProfileCache
+}
+
+func NewProfileService(profileRepo repository.ProfileRepository, userRepo UserRepository, storage AvatarStorage, cache ProfileCache) ProfileService {
+	return &profileService{profileRepo: profileRepo, userRepo: userRepo, storage: storage, cache: cache}
+}
+
+func (s *profileService) UpdateProfile(ctx context.Context, userID string, req *dto.UpdateProfileRequest) (*dto.CurrentUserResponse, error) {
+	userColumns := map[string]any{}
+	setProfileColumn(userColumns, "first_name", req.FirstName)
+	setProfileColumn(userColumns, "last_name", req.LastName)
+	if req.IsPrivate != nil {
+		userColumns["is_private"] = *req.IsPrivate
+	}
+	profileColumns := map[string]any{}
+	setProfileColumn(profileColumns, "bio", req.Bio)
+	setProfileColumn(profileColumns, "website", req.Website)
+	setProfileColumn(profileColumns, "location", req.Location)
+
+	if len(userColumns) > 0 || len(profileColumns) > 0 {
+		if err := s.profileRepo.Update(ctx, userID, userColumns, profileColumns); err != nil {
+			return nil, err
+		}
+		s.invalidateUserCaches(ctx)
+	}
+
+	return s.currentUser(ctx, userID)
+}
+
+func (s *profileService) UploadAvatar(ctx context.Context, userID string, file *multipart.FileHeader) (*dto.CurrentUserResponse, error) {
+	if file == nil {
+		return nil, apperrors.ErrFileNil
+	}
+	if file.Size > maxAvatarSize {
+		return nil, apperrors.ErrFileTooLarge
+	}
+	if s.storage == nil {
+		return nil, apperrors.ErrStorageUnavailable
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = src.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxAvatarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxAvatarSize {
+		return nil, apperrors.ErrFileTooLarge
+	}
+
+	contentType, ext, ok := detectAllowedImage(data)
+	if !ok {
+		return nil, apperrors.ErrInvalidFileType
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	userPrefix := avatarUploadPrefix + "/" + userID + "/"
+	objectKey := userPrefix + hex.EncodeToString(b) + ext
+	if err := s.storage.Save(ctx, objectKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.profileRepo.SetImage(ctx, userID, s.storage.ObjectURL(objectKey))
+	if err != nil {
+		_ = s.storage.Delete(ctx, objectKey)
+		return nil, err
+	}
+	s.invalidateUserCaches(ctx)
+
+	// Images set elsewhere, such as an OAuth provider's picture, are not ours
+	// to delete.
+	if previous != nil {
+		if oldKey, ok := strings.CutPrefix(*previous, s.storage.ObjectURL(userPrefix)); ok && oldKey != "" {
+			if err := s.storage.Delete(ctx, userPrefix+oldKey); err != nil {
+				profileLog.Warn("failed to delete previous avatar", "user_id", userID, "error", err)
+			}
+		}
+	}
+
+	return s.currentUser(ctx, userID)
+}
+
+func (s *profileService) currentUser(ctx context.Context, userID string) (*dto.CurrentUserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	return dto.UserToCurrentUserResponse(user), nil
+}
+
+// invalidateUserCaches drops the cached post lists, which embed the author's
+// username and image. There is no per-user cache.
+func (s *profileService) invalidateUserCaches(ctx context.Context) {
+	invalidatePostLists(ctx, s.cache, profileLog)
+}
+
+// setProfileColumn adds value to columns when it was sent; blank values
+// clear the column.
+func setProfileColumn(columns map[string]any, column string, value *string) {
+	if value == nil {
+		return
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		columns[column] = nil
+		return
+	}
+	columns[column] = trimmed
+}
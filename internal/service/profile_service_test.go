@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+type mockProfileRepo struct {
+	userColumns    map[string]any
+	profileColumns map[string]any
+	image          *string
+}
+
+func (m *mockProfileRepo) Update(ctx context.Context, userID string, userColumns, profileColumns map[string]any) error {
+	m.userColumns = userColumns
+	m.profileColumns = profileColumns
+	return nil
+}
+
+func (m *mockProfileRepo) SetImage(ctx context.Context, userID, image string) (*string, error) {
+	previous := m.image
+	m.image = &image
+	return previous, nil
+}
+
+type mockAvatarStorage struct {
+	mockExportStorage
+}
+
+func (m *mockAvatarStorage) ObjectURL(path string) string {
+	return "https://cdn.example.com/" + path
+}
+
+type mockProfileCache struct {
+	deleted []string
+}
+
+func (m *mockProfileCache) BuildKey(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+func (m *mockProfileCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	m.deleted = append(m.deleted, prefix)
+	return nil
+}
+
+func avatarFileHeader(t *testing.T, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	_, _ = part.Write(data)
+	_ = w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(int64(body.Len()))
+	if err != nil {
+		t.Fatalf("ReadForm: %v", err)
+	}
+	return form.File["avatar"][0]
+}
+
+func TestUpdateProfile_TrimsClearsAndInvalidatesCache(t *testing.T) {
+	repo := &mockProfileRepo{}
+	cache := &mockProfileCache{}
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id}, nil
+	}}
+	svc := NewProfileService(repo, users, &mockAvatarStorage{}, cache)
+
+	_, err := svc.UpdateProfile(context.Background(), "user-1", &dto.UpdateProfileRequest{
+		FirstName: new(" Ada "),
+		Bio:       new(""),
+		Website:   new("https://example.com"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+
+	if len(repo.userColumns) != 1 || repo.userColumns["first_name"] != "Ada" {
+		t.Fatalf("user columns = %v", repo.userColumns)
+	}
+	if bio, ok := repo.profileColumns["bio"]; !ok || bio != nil {
+		t.Fatalf("profile columns = %v, want bio cleared", repo.profileColumns)
+	}
+	if _, ok := repo.profileColumns["location"]; ok {
+		t.Fatalf("profile columns = %v, location was not sent", repo.profileColumns)
+	}
+	if len(cache.deleted) != 2 {
+		t.Fatalf("invalidated = %v", cache.deleted)
+	}
+}
+
+func TestUploadAvatar_RejectsNonImages(t *testing.T) {
+	storage := &mockAvatarStorage{}
+	svc := NewProfileService(&mockProfileRepo{}, &mockUserRepo{}, storage, &mockProfileCache{})
+
+	_, err := svc.UploadAvatar(context.Background(), "user-1", avatarFileHeader(t, []byte("<html>not an image</html>")))
+	if !errors.Is(err, apperrors.ErrInvalidFileType) {
+		t.Fatalf("err = %v, want ErrInvalidFileType", err)
+	}
+	if len(storage.files) != 0 {
+		t.Fatalf("stored = %v", storage.files)
+	}
+}
+
+func TestUploadAvatar_ReplacesOwnPreviousAvatar(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	previous := "https://cdn.example.com/avatars/user-1/old.png"
+	repo := &mockProfileRepo{image: &previous}
+	storage := &mockAvatarStorage{}
+	cache := &mockProfileCache{}
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id, Image: repo.image}, nil
+	}}
+	svc := NewProfileService(repo, users, storage, cache)
+
+	user, err := svc.UploadAvatar(context.Background(), "user-1", avatarFileHeader(t, png))
+	if err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+
+	if len(storage.files) != 1 {
+		t.Fatalf("stored = %v", storage.files)
+	}
+	for path, data := range storage.files {
+		if !strings.HasPrefix(path, "avatars/user-1/") || !strings.HasSuffix(path, ".png") || !bytes.Equal(data, png) {
+			t.Fatalf("stored %q", path)
+		}
+		if user.Image == nil || *user.Image != "https://cdn.example.com/"+path {
+			t.Fatalf("image = %v", user.Image)
+		}
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != "avatars/user-1/old.png" {
+		t.Fatalf("deleted = %v", storage.deleted)
+	}
+	if len(cache.deleted) == 0 {
+		t.Fatal("expected cached posts to be invalidated")
+	}
+}
+
+func TestUploadAvatar_KeepsExternalImage(t *testing.T) {
+	previous := "https://avatars.githubusercontent.com/u/1"
+	storage := &mockAvatarStorage{}
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id}, nil
+	}}
+	svc := NewProfileService(&mockProfileRepo{image: &previous}, users, storage, &mockProfileCache{})
+
+	if _, err := svc.UploadAvatar(context.Background(), "user-1", avatarFileHeader(t, []byte("\xff\xd8\xff\xe0\x00\x10JFIF"))); err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+	if len(storage.deleted) != 0 {
+		t.Fatalf("deleted = %v", storage.deleted)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+// permissionsCacheTTL bounds how long a role change can take to apply when
+// the cache could not be invalidated.
+const permissionsCacheTTL = 5 * time.Minute
+
+type roleCache interface {
+	BuildKey(parts ...string) string
+	GetJSON(ctx context.Context, key string, dest any) (bool, error)
+	SetJSONWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// PermissionChecker resolves permissions for the auth middleware.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
+
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]*dto.RoleResponse, error)
+	GetUserAccess(ctx context.Context, userID string) (*dto.UserAccessResponse, error)
+	AssignRole(ctx context.Context, actorID, userID, roleName string) error
+	RemoveRole(ctx context.Context, actorID, userID, roleName string) error
+	PermissionChecker
+}
+
+type roleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+	cache    roleCache
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository, cache ...roleCache) RoleService {
+	var c roleCache
+	if len(cache) > 0 {
+		c = cache[0]
+	}
+	return &roleService{roleRepo: roleRepo, userRepo: userRepo, cache: c}
+}
+
+func (s *roleService) ListRoles(ctx context.Context) ([]*dto.RoleResponse, error) {
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		responses = append(responses, dto.RoleToResponse(role))
+	}
+	return responses, nil
+}
+
+func (s *roleService) GetUserAccess(ctx context.Context, userID string) (*dto.UserAccessResponse, error) {
+	roles, err := s.roleRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.UserAccessResponse{
+		Roles:       make([]string, 0, len(roles)),
+		Permissions: permissions,
+	}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, role.Name)
+	}
+	return resp, nil
+}
+
+func (s *roleService) AssignRole(ctx context.Context, actorID, userID, roleName string) error {
+	role, err := s.findRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID, false); err != nil {
+		return err
+	}
+
+	assigned, err := s.roleRepo.AssignRole(ctx, userID, role, actorID)
+	if err != nil {
+		return err
+	}
+	if assigned {
+		authLog.Info("role assigned", "user_id", userID, "role", role.Name, "assigned_by", actorID)
+	}
+
+	s.invalidatePermissions(ctx, userID)
+	return nil
+}
+
+func (s *roleService) RemoveRole(ctx context.Context, actorID, userID, roleName string) error {
+	role, err := s.findRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	if role.Name == model.RoleAdmin {
+		admins, err := s.roleRepo.CountUsersWithRole(ctx, role.ID)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			held, err := s.roleRepo.ListUserRoles(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if slices.ContainsFunc(held, func(r *model.Role) bool { return r.ID == role.ID }) {
+				return apperrors.ErrLastAdmin
+			}
+		}
+	}
+
+	removed, err := s.roleRepo.RemoveRole(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	if removed {
+		authLog.Info("role removed", "user_id", userID, "role", role.Name, "removed_by", actorID)
+	}
+
+	s.invalidatePermissions(ctx, userID)
+	return nil
+}
+
+func (s *roleService) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(permissions, permission), nil
+}
+
+// userPermissions returns the user's permissions, cached in Redis.
+func (s *roleService) userPermissions(ctx context.Context, userID string) ([]string, error) {
+	var key string
+	if s.cache != nil {
+		key = s.cache.BuildKey("permissions", userID)
+		var cached []string
+		if found, err := s.cache.GetJSON(ctx, key, &cached); err == nil && found {
+			return cached, nil
+		}
+	}
+
+	permissions, err := s.roleRepo.ListUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	if s.cache != nil {
+		if err := s.cache.SetJSONWithTTL(ctx, key, permissions, permissionsCacheTTL); err != nil {
+			authLog.Warn("failed to cache permissions", "user_id", userID, "error", err)
+		}
+	}
+	return permissions, nil
+}
+
+func (s *roleService) invalidatePermissions(ctx context.Context, userID string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, s.cache.BuildKey("permissions", userID)); err != nil {
+		authLog.Warn("failed to invalidate cached permissions", "user_id", userID, "error", err)
+	}
+}
+
+func (s *roleService) findRole(ctx context.Context, name string) (*model.Role, error) {
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, apperrors.ErrRoleNotFound
+	}
+	return role, nil
+}
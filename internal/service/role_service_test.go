@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+var _ repository.RoleRepository = (*mockRoleRepo)(nil)
+
+// mockRoleRepo keeps role assignments in memory and counts permission
+// lookups so tests can tell cache hits from database reads.
+type mockRoleRepo struct {
+	roles           map[string]*model.Role
+	userRoles       map[string][]string
+	permissionReads int
+}
+
+func newMockRoleRepo() *mockRoleRepo {
+	return &mockRoleRepo{
+		roles: map[string]*model.Role{
+			"admin":     {ID: "role-admin", Name: "admin", Permissions: []model.Permission{{Name: model.PermissionPostsDelete}, {Name: model.PermissionRolesManage}}},
+			"moderator": {ID: "role-moderator", Name: "moderator", Permissions: []model.Permission{{Name: model.PermissionPostsDelete}}},
+		},
+		userRoles: map[string][]string{},
+	}
+}
+
+func (m *mockRoleRepo) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	return []*model.Role{m.roles["admin"], m.roles["moderator"]}, nil
+}
+func (m *mockRoleRepo) FindByName(ctx context.Context, name string) (*model.Role, error) {
+	return m.roles[name], nil
+}
+func (m *mockRoleRepo) ListUserRoles(ctx context.Context, userID string) ([]*model.Role, error) {
+	var roles []*model.Role
+	for _, name := range m.userRoles[userID] {
+		roles = append(roles, m.roles[name])
+	}
+	return roles, nil
+}
+func (m *mockRoleRepo) ListUserPermissions(ctx context.Context, userID string) ([]string, error) {
+	m.permissionReads++
+	var permissions []string
+	for _, name := range m.userRoles[userID] {
+		for _, p := range m.roles[name].Permissions {
+			permissions = append(permissions, p.Name)
+		}
+	}
+	return permissions, nil
+}
+func (m *mockRoleRepo) CountUsersWithRole(ctx context.Context, roleID string) (int64, error) {
+	var count int64
+	for _, names := range m.userRoles {
+		for _, name := range names {
+			if m.roles[name].ID == roleID {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+func (m *mockRoleRepo) AssignRole(ctx context.Context, userID string, role *model.Role, assignedBy string) (bool, error) {
+	m.userRoles[userID] = append(m.userRoles[userID], role.Name)
+	return true, nil
+}
+func (m *mockRoleRepo) RemoveRole(ctx context.Context, userID string, role *model.Role) (bool, error) {
+	kept := m.userRoles[userID][:0]
+	for _, name := range m.userRoles[userID] {
+		if name != role.Name {
+			kept = append(kept, name)
+		}
+	}
+	m.userRoles[userID] = kept
+	return true, nil
+}
+
+// memoryCache is a minimal in-memory stand-in for the Redis cache.
+type memoryCache struct {
+	values map[string][]byte
+}
+
+func (m *memoryCache) BuildKey(parts ...string) string { return strings.Join(parts, ":") }
+func (m *memoryCache) GetJSON(ctx context.Context, key string, dest any) (bool, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, dest)
+}
+func (m *memoryCache) SetJSONWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if m.values == nil {
+		m.values = map[string][]byte{}
+	}
+	m.values[key] = data
+	return nil
+}
+func (m *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(m.values, key)
+	}
+	return nil
+}
+
+// ---- Test Cases ---------------------------------------------------------------
+
+func TestHasPermission_CachesAndInvalidatesOnRoleChange(t *testing.T) {
+	ctx := context.Background()
+	roles := newMockRoleRepo()
+	svc := NewRoleService(roles, &mockUserRepo{}, &memoryCache{})
+
+	for range 2 {
+		allowed, err := svc.HasPermission(ctx, "user-1", model.PermissionPostsDelete)
+		if err != nil || allowed {
+			t.Fatalf("expected no permission before a role is assigned, got %v %v", allowed, err)
+		}
+	}
+	if roles.permissionReads != 1 {
+		t.Fatalf("permissions should be read once and then cached, got %d reads", roles.permissionReads)
+	}
+
+	if err := svc.AssignRole(ctx, "admin-1", "user-1", "moderator"); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	allowed, err := svc.HasPermission(ctx, "user-1", model.PermissionPostsDelete)
+	if err != nil || !allowed {
+		t.Fatalf("expected permission after assigning moderator, got %v %v", allowed, err)
+	}
+	if allowed, _ := svc.HasPermission(ctx, "user-1", model.PermissionRolesManage); allowed {
+		t.Fatal("moderator must not manage roles")
+	}
+}
+
+func TestAssignRole_UnknownRole(t *testing.T) {
+	svc := NewRoleService(newMockRoleRepo(), &mockUserRepo{}, &memoryCache{})
+
+	err := svc.AssignRole(context.Background(), "admin-1", "user-1", "owner")
+	if !errors.Is(err, apperrors.ErrRoleNotFound) {
+		t.Fatalf("expected ErrRoleNotFound, got %v", err)
+	}
+}
+
+func TestRemoveRole_RefusesLastAdmin(t *testing.T) {
+	ctx := context.Background()
+	roles := newMockRoleRepo()
+	roles.userRoles["admin-1"] = []string{"admin"}
+	svc := NewRoleService(roles, &mockUserRepo{}, &memoryCache{})
+
+	if err := svc.RemoveRole(ctx, "admin-1", "admin-1", "admin"); !errors.Is(err, apperrors.ErrLastAdmin) {
+		t.Fatalf("expected ErrLastAdmin, got %v", err)
+	}
+
+	roles.userRoles["admin-2"] = []string{"admin"}
+	if err := svc.RemoveRole(ctx, "admin-2", "admin-1", "admin"); err != nil {
+		t.Fatalf("RemoveRole with another admin left: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"echobackend/internal/model"
+)
+
+// RFC 6238 appendix B secret ("12345678901234567890") in base32.
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := totpCode(rfcTOTPSecret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode(%d) error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP_AllowsOneStepSkew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := totpCode(rfcTOTPSecret, totpStep(now)-1)
+	tooOld, _ := totpCode(rfcTOTPSecret, totpStep(now)-2)
+
+	step, ok := validateTOTP(rfcTOTPSecret, previous, now)
+	if !ok || step != totpStep(now)-1 {
+		t.Fatalf("expected previous step to validate, got step=%d ok=%v", step, ok)
+	}
+	if _, ok := validateTOTP(rfcTOTPSecret, tooOld, now); ok {
+		t.Fatal("expected code two steps old to be rejected")
+	}
+	if _, ok := validateTOTP(rfcTOTPSecret, "12345", now); ok {
+		t.Fatal("expected short code to be rejected")
+	}
+}
+
+func TestGenerateRecoveryCodes_HashesMatchNormalizedInput(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != 3 || len(hashes) != 3 {
+		t.Fatalf("got %d codes and %d hashes", len(codes), len(hashes))
+	}
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected code format %q", code)
+		}
+		if tokenHash(normalizeRecoveryCode(" "+strings.ToUpper(code)+" ")) != hashes[i] {
+			t.Errorf("hash for %q does not match normalized input", code)
+		}
+	}
+}
+
+func TestTwoFactorChallenge_RoundTrip(t *testing.T) {
+	svc := &authService{jwtSecret: []byte("test-secret")}
+
+	token, err := svc.CreateTwoFactorChallenge(t.Context(), &model.User{ID: "user-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	userID, err := svc.parseTwoFactorChallenge(token)
+	if err != nil || userID != "user-1" {
+		t.Fatalf("parseTwoFactorChallenge = (%q, %v)", userID, err)
+	}
+
+	other := &authService{jwtSecret: []byte("other-secret")}
+	if _, err := other.parseTwoFactorChallenge(token); err == nil {
+		t.Fatal("expected challenge signed with another secret to be rejected")
+	}
+}
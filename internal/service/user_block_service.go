@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+// UserBlockChecker answers block and mute lookups for services that refuse
+// or filter interactions between users.
+type UserBlockChecker interface {
+	// IsBlockedBetween reports whether either user has blocked the other.
+	IsBlockedBetween(ctx context.Context, userID1, userID2 string) (bool, error)
+	IsMuted(ctx context.Context, muterID, mutedID string) (bool, error)
+}
+
+type UserBlockService interface {
+	UserBlockChecker
+	// BlockUser blocks blockedID and removes follows in both directions.
+	BlockUser(ctx context.Context, blockerID, blockedID string) error
+	UnblockUser(ctx context.Context, blockerID, blockedID string) error
+	MuteUser(ctx context.Context, muterID, mutedID string) error
+	UnmuteUser(ctx context.Context, muterID, mutedID string) error
+	GetBlockedUsers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error)
+	GetMutedUsers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error)
+}
+
+type userBlockService struct {
+	blockRepo      repository.UserBlockRepository
+	userFollowRepo repository.UserFollowRepository
+	userRepo       repository.UserRepository
+	cache          followSuggestionCache
+}
+
+func NewUserBlockService(
+	blockRepo repository.UserBlockRepository,
+	userFollowRepo repository.UserFollowRepository,
+	userRepo repository.UserRepository,
+	cache ...followSuggestionCache,
+) UserBlockService {
+	var c followSuggestionCache
+	if len(cache) > 0 {
+		c = cache[0]
+	}
+	return &userBlockService{
+		blockRepo:      blockRepo,
+		userFollowRepo: userFollowRepo,
+		userRepo:       userRepo,
+		cache:          c,
+	}
+}
+
+func (s *userBlockService) BlockUser(ctx context.Context, blockerID, blockedID string) error {
+	if err := s.checkTarget(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	if err := s.blockRepo.Block(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	if err := s.userFollowRepo.RemoveBetween(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	invalidateFollowSuggestions(ctx, s.cache, blockerID, blockedID)
+	return nil
+}
+
+func (s *userBlockService) UnblockUser(ctx context.Context, blockerID, blockedID string) error {
+	return s.blockRepo.Unblock(ctx, blockerID, blockedID)
+}
+
+func (s *userBlockService) MuteUser(ctx context.Context, muterID, mutedID string) error {
+	if err := s.checkTarget(ctx, muterID, mutedID); err != nil {
+		return err
+	}
+	if err := s.blockRepo.Mute(ctx, muterID, mutedID); err != nil {
+		return err
+	}
+	invalidateFollowSuggestions(ctx, s.cache, muterID)
+	return nil
+}
+
+func (s *userBlockService) UnmuteUser(ctx context.Context, muterID, mutedID string) error {
+	return s.blockRepo.Unmute(ctx, muterID, mutedID)
+}
+
+func (s *userBlockService) IsBlockedBetween(ctx context.Context, userID1, userID2 string) (bool, error) {
+	return s.blockRepo.IsBlockedBetween(ctx, userID1, userID2)
+}
+
+func (s *userBlockService) IsMuted(ctx context.Context, muterID, mutedID string) (bool, error) {
+	return s.blockRepo.IsMuted(ctx, muterID, mutedID)
+}
+
+func (s *userBlockService) GetBlockedUsers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+	users, total, err := s.blockRepo.GetBlocked(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return usersToResponses(users), total, nil
+}
+
+func (s *userBlockService) GetMutedUsers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+	users, total, err := s.blockRepo.GetMuted(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return usersToResponses(users), total, nil
+}
+
+func (s *userBlockService) checkTarget(ctx context.Context, userID, targetID string) error {
+	if userID == targetID {
+		return apperrors.ErrCannotBlockSelf
+	}
+	if _, err := s.userRepo.GetByID(ctx, targetID, false); err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	return nil
+}
+
+func usersToResponses(users []*model.User) []*dto.UserResponse {
+	userResponses := make([]*dto.UserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = dto.UserToResponse(user)
+	}
+	return userResponses
+}
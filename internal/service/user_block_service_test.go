@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+type mockUserBlockRepo struct {
+	blocks map[[2]string]bool
+	mutes  map[[2]string]bool
+}
+
+func (m *mockUserBlockRepo) Block(ctx context.Context, blockerID, blockedID string) error {
+	if m.blocks == nil {
+		m.blocks = map[[2]string]bool{}
+	}
+	m.blocks[[2]string{blockerID, blockedID}] = true
+	return nil
+}
+
+func (m *mockUserBlockRepo) Unblock(ctx context.Context, blockerID, blockedID string) error {
+	delete(m.blocks, [2]string{blockerID, blockedID})
+	return nil
+}
+
+func (m *mockUserBlockRepo) IsBlockedBetween(ctx context.Context, userID1, userID2 string) (bool, error) {
+	return m.blocks[[2]string{userID1, userID2}] || m.blocks[[2]string{userID2, userID1}], nil
+}
+
+func (m *mockUserBlockRepo) GetBlocked(ctx context.Context, blockerID string, limit, offset int) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockUserBlockRepo) Mute(ctx context.Context, muterID, mutedID string) error {
+	if m.mutes == nil {
+		m.mutes = map[[2]string]bool{}
+	}
+	m.mutes[[2]string{muterID, mutedID}] = true
+	return nil
+}
+
+func (m *mockUserBlockRepo) Unmute(ctx context.Context, muterID, mutedID string) error {
+	delete(m.mutes, [2]string{muterID, mutedID})
+	return nil
+}
+
+func (m *mockUserBlockRepo) IsMuted(ctx context.Context, muterID, mutedID string) (bool, error) {
+	return m.mutes[[2]string{muterID, mutedID}], nil
+}
+
+func (m *mockUserBlockRepo) GetMuted(ctx context.Context, muterID string, limit, offset int) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+type mockUserFollowRepo struct {
+	follows     map[[2]string]bool
+	requests    map[[2]string]bool
+	suggestions []*dto.FollowSuggestionCandidate
+	// suggestionCalls counts GetSuggestions queries.
+	suggestionCalls int
+}
+
+func (m *mockUserFollowRepo) Follow(ctx context.Context, followerID, followingID string) error {
+	if m.follows == nil {
+		m.follows = map[[2]string]bool{}
+	}
+	m.follows[[2]string{followerID, followingID}] = true
+	return nil
+}
+
+func (m *mockUserFollowRepo) RequestFollow(ctx context.Context, followerID, followingID string) error {
+	if m.requests == nil {
+		m.requests = map[[2]string]bool{}
+	}
+	m.requests[[2]string{followerID, followingID}] = true
+	return nil
+}
+
+func (m *mockUserFollowRepo) Unfollow(ctx context.Context, followerID, followingID string) error {
+	delete(m.follows, [2]string{followerID, followingID})
+	delete(m.requests, [2]string{followerID, followingID})
+	return nil
+}
+
+func (m *mockUserFollowRepo) IsFollowing(ctx context.Context, followerID, followingID string) (bool, error) {
+	return m.follows[[2]string{followerID, followingID}], nil
+}
+
+func (m *mockUserFollowRepo) GetFollowStatus(ctx context.Context, followerID, followingID string) (string, error) {
+	switch key := [2]string{followerID, followingID}; {
+	case m.follows[key]:
+		return model.FollowStatusApproved, nil
+	case m.requests[key]:
+		return model.FollowStatusPending, nil
+	}
+	return "", nil
+}
+
+func (m *mockUserFollowRepo) GetFollowRequests(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockUserFollowRepo) ApproveFollowRequest(ctx context.Context, followerID, followingID string) error {
+	key := [2]string{followerID, followingID}
+	if !m.requests[key] {
+		return apperrors.ErrNoFollowRequest
+	}
+	delete(m.requests, key)
+	return m.Follow(ctx, followerID, followingID)
+}
+
+func (m *mockUserFollowRepo) DeleteFollowRequest(ctx context.Context, followerID, followingID string) error {
+	key := [2]string{followerID, followingID}
+	if !m.requests[key] {
+		return apperrors.ErrNoFollowRequest
+	}
+	delete(m.requests, key)
+	return nil
+}
+
+func (m *mockUserFollowRepo) GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockUserFollowRepo) GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*model.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockUserFollowRepo) GetFollowStats(ctx context.Context, userID string) (*dto.UserFollowStats, error) {
+	return &dto.UserFollowStats{UserID: userID}, nil
+}
+
+func (m *mockUserFollowRepo) UpdateFollowCounts(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (m *mockUserFollowRepo) GetMutualFollows(ctx context.Context, userID1, userID2 string) ([]*model.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserFollowRepo) FilterFollowing(ctx context.Context, followerID string, userIDs []string) ([]string, error) {
+	var following []string
+	for _, id := range userIDs {
+		if m.follows[[2]string{followerID, id}] {
+			following = append(following, id)
+		}
+	}
+	return following, nil
+}
+
+func (m *mockUserFollowRepo) GetSuggestions(ctx context.Context, userID string, limit int) ([]*dto.FollowSuggestionCandidate, error) {
+	m.suggestionCalls++
+	return m.suggestions, nil
+}
+
+func (m *mockUserFollowRepo) RemoveBetween(ctx context.Context, userID1, userID2 string) error {
+	for _, key := range [][2]string{{userID1, userID2}, {userID2, userID1}} {
+		delete(m.follows, key)
+		delete(m.requests, key)
+	}
+	return nil
+}
+
+type mockNotificationRepo struct {
+	created []*model.Notification
+}
+
+func (m *mockNotificationRepo) Create(ctx context.Context, notification *model.Notification) error {
+	notification.ID = "notification-1"
+	m.created = append(m.created, notification)
+	return nil
+}
+
+func (m *mockNotificationRepo) GetByID(ctx context.Context, id, userID string) (*model.Notification, error) {
+	return m.created[len(m.created)-1], nil
+}
+
+func (m *mockNotificationRepo) GetByUser(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]*model.Notification, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockNotificationRepo) GetUnreadCount(ctx context.Context, userID string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockNotificationRepo) Update(ctx context.Context, notification *model.Notification) error {
+	return nil
+}
+
+func (m *mockNotificationRepo) MarkAllAsRead(ctx context.Context, userID string) (int64, error) {
+	return 0, nil
+}
+
+func TestBlockUser_RemovesFollowsBothWaysAndPreventsNewOnes(t *testing.T) {
+	ctx := context.Background()
+	follows := &mockUserFollowRepo{follows: map[[2]string]bool{{"alice", "bob"}: true, {"bob", "alice"}: true}}
+	blocks := NewUserBlockService(&mockUserBlockRepo{}, follows, &mockUserRepo{})
+
+	if err := blocks.BlockUser(ctx, "alice", "alice"); !errors.Is(err, apperrors.ErrCannotBlockSelf) {
+		t.Fatalf("err = %v, want ErrCannotBlockSelf", err)
+	}
+	if err := blocks.BlockUser(ctx, "alice", "bob"); err != nil {
+		t.Fatalf("BlockUser: %v", err)
+	}
+	if len(follows.follows) != 0 {
+		t.Fatalf("follows = %v, want none", follows.follows)
+	}
+
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		return &model.User{ID: id}, nil
+	}}
+	followService := NewUserFollowService(follows, users, nil, blocks)
+	if _, err := followService.FollowUser(ctx, "bob", "alice"); !errors.Is(err, apperrors.ErrUserBlocked) {
+		t.Fatalf("err = %v, want ErrUserBlocked", err)
+	}
+}
+
+func TestCreateComment_RejectsBlockedUsers(t *testing.T) {
+	author := "alice"
+	posts := &mockPostRepo{getPostByIDFn: func(ctx context.Context, id string) (*model.Post, error) {
+		return &model.Post{ID: id, CreatedBy: &author}, nil
+	}}
+	blockRepo := &mockUserBlockRepo{}
+	_ = blockRepo.Block(context.Background(), "alice", "bob")
+	svc := NewCommentService(&mockCommentRepo{}, posts, nil, NewUserBlockService(blockRepo, &mockUserFollowRepo{}, &mockUserRepo{}))
+
+	_, err := svc.CreateComment(context.Background(), "post-1", &dto.CreateCommentRequest{Text: "hi"}, "bob")
+	if !errors.Is(err, apperrors.ErrUserBlocked) {
+		t.Fatalf("err = %v, want ErrUserBlocked", err)
+	}
+}
+
+func TestCreateNotification_SuppressesBlockedAndMutedActors(t *testing.T) {
+	ctx := context.Background()
+	blockRepo := &mockUserBlockRepo{}
+	_ = blockRepo.Block(ctx, "alice", "bob")
+	_ = blockRepo.Mute(ctx, "alice", "carol")
+	notifications := &mockNotificationRepo{}
+	svc := NewNotificationService(notifications, NewUserBlockService(blockRepo, &mockUserFollowRepo{}, &mockUserRepo{}))
+
+	for _, actor := range []string{"bob", "carol", "dave"} {
+		if _, err := svc.CreateNotification(ctx, &dto.CreateNotificationRequest{UserID: "alice", ActorID: actor, Type: "follow", Title: "New follower"}); err != nil {
+			t.Fatalf("CreateNotification(%s): %v", actor, err)
+		}
+	}
+	// Blocks work both ways, mutes only for the muter.
+	if _, err := svc.CreateNotification(ctx, &dto.CreateNotificationRequest{UserID: "bob", ActorID: "alice", Type: "follow", Title: "New follower"}); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+	if _, err := svc.CreateNotification(ctx, &dto.CreateNotificationRequest{UserID: "carol", ActorID: "alice", Type: "follow", Title: "New follower"}); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	if len(notifications.created) != 2 || notifications.created[0].UserID != "alice" || notifications.created[1].UserID != "carol" {
+		t.Fatalf("created = %+v, want only dave's notification to alice and alice's to carol", notifications.created)
+	}
+}
@@ -2,82 +2,160 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
 	apperrors "echobackend/internal/apperror"
 	"echobackend/internal/dto"
+	"echobackend/internal/model"
 	"echobackend/internal/repository"
 )
 
+const (
+	// maxFollowSuggestions is how many suggestions are computed and cached per
+	// user; requests page through this list.
+	maxFollowSuggestions = 50
+	followSuggestionsTTL = 15 * time.Minute
+
+	NotificationTypeFollow                = "follow"
+	NotificationTypeFollowRequest         = "follow_request"
+	NotificationTypeFollowRequestApproved = "follow_request_approved"
+)
+
+// followSuggestionCache stores computed suggestions per user.
+type followSuggestionCache interface {
+	BuildKey(parts ...string) string
+	GetJSON(ctx context.Context, key string, dest any) (bool, error)
+	SetJSONWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
 type UserFollowService interface {
+	// FollowUser follows a public account right away and sends a follow
+	// request to a private one.
 	FollowUser(ctx context.Context, followerID, followingID string) (*dto.FollowResponse, error)
+	// UnfollowUser removes the follow or withdraws a pending request.
 	UnfollowUser(ctx context.Context, followerID, followingID string) (*dto.FollowResponse, error)
 	IsFollowing(ctx context.Context, followerID, followingID string) (bool, error)
-	GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error)
-	GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error)
+	// GetFollowStatus returns model.FollowStatusApproved,
+	// model.FollowStatusPending or "".
+	GetFollowStatus(ctx context.Context, followerID, followingID string) (string, error)
+	// GetFollowers, GetFollowing and GetMutualFollows fail with
+	// ErrPrivateAccount when userID is private and viewerID is neither the
+	// user nor an approved follower. viewerID may be empty.
+	GetFollowers(ctx context.Context, userID, viewerID string, limit, offset int) ([]*dto.UserResponse, int64, error)
+	GetFollowing(ctx context.Context, userID, viewerID string, limit, offset int) ([]*dto.UserResponse, int64, error)
 	GetFollowStats(ctx context.Context, userID string) (*dto.UserFollowStats, error)
-	GetMutualFollows(ctx context.Context, userID1, userID2 string) ([]*dto.UserResponse, error)
+	GetMutualFollows(ctx context.Context, viewerID, userID string) ([]*dto.UserResponse, error)
+	GetFollowRequests(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error)
+	ApproveFollowRequest(ctx context.Context, userID, followerID string) error
+	RejectFollowRequest(ctx context.Context, userID, followerID string) error
 	GetUserWithFollowStatus(ctx context.Context, userID, currentUserID string, includeAdminFields bool) (*dto.UserResponse, error)
+	// SetFollowStatus fills IsFollowing of each user for currentUserID. The
+	// caller's own entry is left unset.
+	SetFollowStatus(ctx context.Context, currentUserID string, users []*dto.UserResponse) error
+	// GetSuggestions returns up to limit accounts userID may want to follow,
+	// best first, each with the reason it was suggested.
+	GetSuggestions(ctx context.Context, userID string, limit int) ([]*dto.FollowSuggestion, error)
 }
 
 type userFollowService struct {
 	userFollowRepo      repository.UserFollowRepository
 	userRepo            repository.UserRepository
 	notificationService NotificationService
+	blocks              UserBlockChecker
+	cache               followSuggestionCache
 }
 
 func NewUserFollowService(
 	userFollowRepo repository.UserFollowRepository,
 	userRepo repository.UserRepository,
 	notificationService NotificationService,
+	blocks UserBlockChecker,
+	cache ...followSuggestionCache,
 ) UserFollowService {
+	var c followSuggestionCache
+	if len(cache) > 0 {
+		c = cache[0]
+	}
 	return &userFollowService{
 		userFollowRepo:      userFollowRepo,
 		userRepo:            userRepo,
 		notificationService: notificationService,
+		blocks:              blocks,
+		cache:               c,
 	}
 }
 
 func (s *userFollowService) FollowUser(ctx context.Context, followerID, followingID string) (*dto.FollowResponse, error) {
-	_, err := s.userRepo.GetByID(ctx, followerID, false)
+	follower, err := s.userRepo.GetByID(ctx, followerID, false)
 	if err != nil {
 		return nil, apperrors.ErrUserNotFound
 	}
 
-	_, err = s.userRepo.GetByID(ctx, followingID, false)
+	following, err := s.userRepo.GetByID(ctx, followingID, false)
 	if err != nil {
 		return nil, apperrors.ErrUserNotFound
 	}
 
-	err = s.userFollowRepo.Follow(ctx, followerID, followingID)
+	if s.blocks != nil {
+		blocked, err := s.blocks.IsBlockedBetween(ctx, followerID, followingID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, apperrors.ErrUserBlocked
+		}
+	}
+
+	if following.IsPrivate {
+		err = s.userFollowRepo.RequestFollow(ctx, followerID, followingID)
+	} else {
+		err = s.userFollowRepo.Follow(ctx, followerID, followingID)
+	}
 	if err != nil {
 		return nil, err
 	}
+	invalidateFollowSuggestions(ctx, s.cache, followerID)
 
-	if s.notificationService != nil && followerID != followingID {
-		message := "You have a new follower"
-		title := "New follower"
-		_, _ = s.notificationService.CreateNotification(ctx, &dto.CreateNotificationRequest{
-			UserID:  followingID,
-			Type:    "follow",
-			Title:   title,
-			Message: &message,
-			Data: map[string]any{
-				"follower_id": followerID,
-			},
-		})
+	if following.IsPrivate {
+		s.notify(ctx, followingID, followerID, NotificationTypeFollowRequest, "New follow request", displayName(follower)+" requested to follow you")
+		return &dto.FollowResponse{
+			IsRequested: true,
+			Message:     "Follow request sent",
+		}, nil
 	}
 
+	s.notify(ctx, followingID, followerID, NotificationTypeFollow, "New follower", "You have a new follower")
 	return &dto.FollowResponse{
 		IsFollowing: true,
 		Message:     "Successfully followed user",
 	}, nil
 }
 
+func (s *userFollowService) notify(ctx context.Context, userID, actorID, notificationType, title, message string) {
+	if s.notificationService == nil || userID == actorID {
+		return
+	}
+	_, _ = s.notificationService.CreateNotification(ctx, &dto.CreateNotificationRequest{
+		UserID:  userID,
+		ActorID: actorID,
+		Type:    notificationType,
+		Title:   title,
+		Message: &message,
+		Data: map[string]any{
+			"follower_id": actorID,
+		},
+	})
+}
+
 func (s *userFollowService) UnfollowUser(ctx context.Context, followerID, followingID string) (*dto.FollowResponse, error) {
 	err := s.userFollowRepo.Unfollow(ctx, followerID, followingID)
 	if err != nil {
 		return nil, err
 	}
+	invalidateFollowSuggestions(ctx, s.cache, followerID)
 
 	return &dto.FollowResponse{
 		IsFollowing: false,
@@ -89,7 +167,40 @@ func (s *userFollowService) IsFollowing(ctx context.Context, followerID, followi
 	return s.userFollowRepo.IsFollowing(ctx, followerID, followingID)
 }
 
-func (s *userFollowService) GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+func (s *userFollowService) GetFollowStatus(ctx context.Context, followerID, followingID string) (string, error) {
+	return s.userFollowRepo.GetFollowStatus(ctx, followerID, followingID)
+}
+
+// checkConnectionsVisible returns ErrPrivateAccount unless viewerID may see
+// who userID follows and is followed by.
+func (s *userFollowService) checkConnectionsVisible(ctx context.Context, userID, viewerID string) error {
+	if userID == viewerID {
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return err
+	}
+	if !user.IsPrivate {
+		return nil
+	}
+	if viewerID != "" {
+		following, err := s.userFollowRepo.IsFollowing(ctx, viewerID, userID)
+		if err != nil {
+			return err
+		}
+		if following {
+			return nil
+		}
+	}
+	return apperrors.ErrPrivateAccount
+}
+
+func (s *userFollowService) GetFollowers(ctx context.Context, userID, viewerID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+	if err := s.checkConnectionsVisible(ctx, userID, viewerID); err != nil {
+		return nil, 0, err
+	}
+
 	users, total, err := s.userFollowRepo.GetFollowers(ctx, userID, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -103,7 +214,11 @@ func (s *userFollowService) GetFollowers(ctx context.Context, userID string, lim
 	return userResponses, total, nil
 }
 
-func (s *userFollowService) GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+func (s *userFollowService) GetFollowing(ctx context.Context, userID, viewerID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+	if err := s.checkConnectionsVisible(ctx, userID, viewerID); err != nil {
+		return nil, 0, err
+	}
+
 	users, total, err := s.userFollowRepo.GetFollowing(ctx, userID, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -121,8 +236,12 @@ func (s *userFollowService) GetFollowStats(ctx context.Context, userID string) (
 	return s.userFollowRepo.GetFollowStats(ctx, userID)
 }
 
-func (s *userFollowService) GetMutualFollows(ctx context.Context, userID1, userID2 string) ([]*dto.UserResponse, error) {
-	users, err := s.userFollowRepo.GetMutualFollows(ctx, userID1, userID2)
+func (s *userFollowService) GetMutualFollows(ctx context.Context, viewerID, userID string) ([]*dto.UserResponse, error) {
+	if err := s.checkConnectionsVisible(ctx, userID, viewerID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.userFollowRepo.GetMutualFollows(ctx, viewerID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +254,34 @@ func (s *userFollowService) GetMutualFollows(ctx context.Context, userID1, userI
 	return userResponses, nil
 }
 
+func (s *userFollowService) GetFollowRequests(ctx context.Context, userID string, limit, offset int) ([]*dto.UserResponse, int64, error) {
+	users, total, err := s.userFollowRepo.GetFollowRequests(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return usersToResponses(users), total, nil
+}
+
+func (s *userFollowService) ApproveFollowRequest(ctx context.Context, userID, followerID string) error {
+	if err := s.userFollowRepo.ApproveFollowRequest(ctx, followerID, userID); err != nil {
+		return err
+	}
+	invalidateFollowSuggestions(ctx, s.cache, followerID)
+
+	if user, err := s.userRepo.GetByID(ctx, userID, false); err == nil {
+		s.notify(ctx, followerID, userID, NotificationTypeFollowRequestApproved, "Follow request approved", displayName(user)+" approved your follow request")
+	}
+	return nil
+}
+
+func (s *userFollowService) RejectFollowRequest(ctx context.Context, userID, followerID string) error {
+	if err := s.userFollowRepo.DeleteFollowRequest(ctx, followerID, userID); err != nil {
+		return err
+	}
+	invalidateFollowSuggestions(ctx, s.cache, followerID)
+	return nil
+}
+
 func (s *userFollowService) GetUserWithFollowStatus(ctx context.Context, userID, currentUserID string, includeAdminFields bool) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByID(ctx, userID, false)
 	if err != nil {
@@ -158,3 +305,127 @@ func (s *userFollowService) GetUserWithFollowStatus(ctx context.Context, userID,
 
 	return userResponse, nil
 }
+
+func (s *userFollowService) SetFollowStatus(ctx context.Context, currentUserID string, users []*dto.UserResponse) error {
+	if currentUserID == "" || len(users) == 0 {
+		return nil
+	}
+
+	userIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	followingIDs, err := s.userFollowRepo.FilterFollowing(ctx, currentUserID, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.ID == currentUserID {
+			continue
+		}
+		isFollowing := slices.Contains(followingIDs, user.ID)
+		user.IsFollowing = &isFollowing
+	}
+	return nil
+}
+
+func (s *userFollowService) GetSuggestions(ctx context.Context, userID string, limit int) ([]*dto.FollowSuggestion, error) {
+	var suggestions []*dto.FollowSuggestion
+	cached := false
+	var cacheKey string
+	if s.cache != nil {
+		cacheKey = s.cache.BuildKey("follow_suggestions", userID)
+		if found, err := s.cache.GetJSON(ctx, cacheKey, &suggestions); err == nil && found {
+			cached = true
+		}
+	}
+
+	if !cached {
+		candidates, err := s.userFollowRepo.GetSuggestions(ctx, userID, maxFollowSuggestions)
+		if err != nil {
+			return nil, err
+		}
+		suggestions, err = s.buildSuggestions(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if s.cache != nil {
+			_ = s.cache.SetJSONWithTTL(ctx, cacheKey, suggestions, followSuggestionsTTL)
+		}
+	}
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// buildSuggestions loads the users of candidates, keeping their order and
+// dropping any that are gone.
+func (s *userFollowService) buildSuggestions(ctx context.Context, candidates []*dto.FollowSuggestionCandidate) ([]*dto.FollowSuggestion, error) {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.UserID
+	}
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[string]*dto.UserResponse, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = dto.UserToResponse(user)
+	}
+
+	suggestions := make([]*dto.FollowSuggestion, 0, len(candidates))
+	for _, candidate := range candidates {
+		user, ok := usersByID[candidate.UserID]
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, &dto.FollowSuggestion{
+			User:          user,
+			Reason:        followSuggestionReason(candidate),
+			MutualFollows: candidate.MutualFollows,
+			SharedTags:    candidate.SharedTags,
+		})
+	}
+	return suggestions, nil
+}
+
+func followSuggestionReason(candidate *dto.FollowSuggestionCandidate) string {
+	switch {
+	case candidate.MutualFollows == 1:
+		return "followed by 1 person you follow"
+	case candidate.MutualFollows > 1:
+		return fmt.Sprintf("followed by %d people you follow", candidate.MutualFollows)
+	default:
+		return "posts about topics you like"
+	}
+}
+
+// invalidateFollowSuggestions drops the cached suggestions of userIDs after
+// their follows, blocks or mutes change.
+func invalidateFollowSuggestions(ctx context.Context, cache followSuggestionCache, userIDs ...string) {
+	if cache == nil {
+		return
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = cache.BuildKey("follow_suggestions", userID)
+	}
+	if err := cache.Delete(ctx, keys...); err != nil {
+		followLog.Warn("failed to invalidate follow suggestions", "user_ids", userIDs, "error", err)
+	}
+}
+
+// displayName names user in notifications: the username, else the full name.
+func displayName(user *model.User) string {
+	if user.Username != nil && *user.Username != "" {
+		return *user.Username
+	}
+	if user.FirstName != nil && user.LastName != nil {
+		return *user.FirstName + " " + *user.LastName
+	}
+	return "Someone"
+}
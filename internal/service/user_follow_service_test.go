@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+)
+
+func TestSetFollowStatus_MarksFollowedUsersExceptCaller(t *testing.T) {
+	follows := &mockUserFollowRepo{follows: map[[2]string]bool{{"me", "alice"}: true}}
+	svc := NewUserFollowService(follows, &mockUserRepo{}, nil, nil)
+	users := []*dto.UserResponse{{ID: "alice"}, {ID: "bob"}, {ID: "me"}}
+
+	if err := svc.SetFollowStatus(context.Background(), "me", users); err != nil {
+		t.Fatalf("SetFollowStatus: %v", err)
+	}
+
+	if users[0].IsFollowing == nil || !*users[0].IsFollowing {
+		t.Errorf("alice IsFollowing = %v, want true", users[0].IsFollowing)
+	}
+	if users[1].IsFollowing == nil || *users[1].IsFollowing {
+		t.Errorf("bob IsFollowing = %v, want false", users[1].IsFollowing)
+	}
+	if users[2].IsFollowing != nil {
+		t.Errorf("caller IsFollowing = %v, want unset", users[2].IsFollowing)
+	}
+}
+
+func TestGetSuggestions_ExplainsCachesAndInvalidatesOnFollow(t *testing.T) {
+	ctx := context.Background()
+	follows := &mockUserFollowRepo{suggestions: []*dto.FollowSuggestionCandidate{
+		{UserID: "alice", MutualFollows: 3, SharedTags: 1},
+		{UserID: "bob", MutualFollows: 1},
+		{UserID: "gone", MutualFollows: 1},
+		{UserID: "carol", SharedTags: 2},
+	}}
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		if id == "gone" {
+			return nil, nil
+		}
+		return &model.User{ID: id}, nil
+	}}
+	svc := NewUserFollowService(follows, users, nil, nil, &memoryCache{})
+
+	suggestions, err := svc.GetSuggestions(ctx, "me", 10)
+	if err != nil {
+		t.Fatalf("GetSuggestions: %v", err)
+	}
+	want := []struct{ id, reason string }{
+		{"alice", "followed by 3 people you follow"},
+		{"bob", "followed by 1 person you follow"},
+		{"carol", "posts about topics you like"},
+	}
+	if len(suggestions) != len(want) {
+		t.Fatalf("suggestions = %d, want %d", len(suggestions), len(want))
+	}
+	for i, w := range want {
+		if suggestions[i].User.ID != w.id || suggestions[i].Reason != w.reason {
+			t.Errorf("suggestion %d = %s %q, want %s %q", i, suggestions[i].User.ID, suggestions[i].Reason, w.id, w.reason)
+		}
+	}
+
+	if suggestions, _ := svc.GetSuggestions(ctx, "me", 1); len(suggestions) != 1 || follows.suggestionCalls != 1 {
+		t.Fatalf("cached call: %d suggestions, %d queries", len(suggestions), follows.suggestionCalls)
+	}
+
+	if _, err := svc.FollowUser(ctx, "me", "alice"); err != nil {
+		t.Fatalf("FollowUser: %v", err)
+	}
+	if _, err := svc.GetSuggestions(ctx, "me", 10); err != nil || follows.suggestionCalls != 2 {
+		t.Fatalf("queries = %d, want suggestions recomputed after following", follows.suggestionCalls)
+	}
+}
+
+func TestFollowUser_PrivateAccountNeedsApproval(t *testing.T) {
+	ctx := context.Background()
+	follows := &mockUserFollowRepo{}
+	users := &mockUserRepo{getByIDFn: func(ctx context.Context, id string, deletedOnly bool) (*model.User, error) {
+		username := id
+		return &model.User{ID: id, Username: &username, IsPrivate: id == "alice"}, nil
+	}}
+	var notifications []*dto.CreateNotificationRequest
+	notifier := &mockNotificationService{createNotificationFn: func(ctx context.Context, req *dto.CreateNotificationRequest) (*dto.NotificationResponse, error) {
+		notifications = append(notifications, req)
+		return nil, nil
+	}}
+	svc := NewUserFollowService(follows, users, notifier, nil)
+
+	resp, err := svc.FollowUser(ctx, "bob", "alice")
+	if err != nil {
+		t.Fatalf("FollowUser: %v", err)
+	}
+	if resp.IsFollowing || !resp.IsRequested {
+		t.Fatalf("response = %+v, want a pending request", resp)
+	}
+	if len(notifications) != 1 || notifications[0].Type != NotificationTypeFollowRequest || *notifications[0].Message != "bob requested to follow you" {
+		t.Fatalf("notifications = %+v", notifications)
+	}
+
+	if _, _, err := svc.GetFollowers(ctx, "alice", "bob", 10, 0); !errors.Is(err, apperrors.ErrPrivateAccount) {
+		t.Fatalf("GetFollowers before approval: err = %v, want ErrPrivateAccount", err)
+	}
+	if err := svc.RejectFollowRequest(ctx, "alice", "carol"); !errors.Is(err, apperrors.ErrNoFollowRequest) {
+		t.Fatalf("RejectFollowRequest: err = %v, want ErrNoFollowRequest", err)
+	}
+
+	if err := svc.ApproveFollowRequest(ctx, "alice", "bob"); err != nil {
+		t.Fatalf("ApproveFollowRequest: %v", err)
+	}
+	if status, _ := svc.GetFollowStatus(ctx, "bob", "alice"); status != model.FollowStatusApproved {
+		t.Fatalf("status = %q, want approved", status)
+	}
+	if len(notifications) != 2 || notifications[1].UserID != "bob" || notifications[1].Type != NotificationTypeFollowRequestApproved {
+		t.Fatalf("notifications = %+v", notifications)
+	}
+	if _, _, err := svc.GetFollowers(ctx, "alice", "bob", 10, 0); err != nil {
+		t.Fatalf("GetFollowers after approval: %v", err)
+	}
+	if _, _, err := svc.GetFollowers(ctx, "alice", "", 10, 0); !errors.Is(err, apperrors.ErrPrivateAccount) {
+		t.Fatalf("GetFollowers anonymously: err = %v, want ErrPrivateAccount", err)
+	}
+}
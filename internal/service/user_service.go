@@ -11,6 +11,7 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string, deletedOnly bool) (*model.User, error)
 	GetUsers(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*model.User, int64, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error)
 	SoftDeleteByID(ctx context.Context, id string) error
 	RestoreByID(ctx context.Context, id string) error
 }
@@ -21,6 +22,8 @@ type UserService interface {
 	GetMe(ctx context.Context, id string) (*dto.CurrentUserResponse, error)
 	GetByUsername(ctx context.Context, username string) (*dto.UserResponse, error)
 	GetUsers(ctx context.Context, offset int, limit int, deletedFilter dto.UserDeletedFilter) ([]*dto.UserResponse, int64, error)
+	// SearchUsers finds active users by fuzzy username or display name.
+	SearchUsers(ctx context.Context, query string, offset, limit int) ([]*dto.UserResponse, int64, error)
 	Delete(ctx context.Context, id string) error
 	Restore(ctx context.Context, id string) (*dto.UserResponse, error)
 }
@@ -82,6 +85,19 @@ func (s *userService) GetUsers(ctx context.Context, offset int, limit int, delet
 	return userResponses, total, nil
 }
 
+func (s *userService) SearchUsers(ctx context.Context, query string, offset, limit int) ([]*dto.UserResponse, int64, error) {
+	users, total, err := s.userRepo.Search(ctx, query, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userResponses := make([]*dto.UserResponse, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, dto.UserToResponse(user))
+	}
+	return userResponses, total, nil
+}
+
 func (s *userService) Delete(ctx context.Context, id string) error {
 	return s.userRepo.SoftDeleteByID(ctx, id)
 }
@@ -250,3 +250,25 @@ func TestUserService_Restore_Conflict(t *testing.T) {
 		t.Fatalf("expected ErrUserExists, got %v", err)
 	}
 }
+
+func TestUserService_SearchUsers_ReturnsPublicShape(t *testing.T) {
+	repo := &mockUserRepo{
+		searchFn: func(ctx context.Context, query string, offset, limit int) ([]*model.User, int64, error) {
+			if query != "ali" || offset != 10 || limit != 5 {
+				t.Errorf("Search(%q, %d, %d)", query, offset, limit)
+			}
+			return []*model.User{{ID: "u1", Email: "a@x.com", IsSuperAdmin: new(true)}}, 11, nil
+		},
+	}
+	svc := NewUserService(repo)
+	resp, total, err := svc.SearchUsers(context.Background(), "ali", 10, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 11 || len(resp) != 1 {
+		t.Fatalf("total = %d, len = %d", total, len(resp))
+	}
+	if resp[0].Email != "" || resp[0].IsSuperAdmin != nil {
+		t.Fatalf("search result leaks admin fields: %+v", resp[0])
+	}
+}
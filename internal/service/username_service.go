@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/dto"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+type UsernameService interface {
+	ChangeUsername(ctx context.Context, userID, username, ipAddress, userAgent string) (*dto.CurrentUserResponse, error)
+	// ResolveMoved returns the current username of the account that was last
+	// called username. It returns ErrUserNotFound if no account gave it up.
+	ResolveMoved(ctx context.Context, username string) (string, error)
+}
+
+type usernameService struct {
+	userRepo        repository.UserRepository
+	historyRepo     repository.UsernameHistoryRepository
+	activityService AuthActivityService
+	cooldown        time.Duration
+	reservation     time.Duration
+}
+
+func NewUsernameService(
+	userRepo repository.UserRepository,
+	historyRepo repository.UsernameHistoryRepository,
+	activityService AuthActivityService,
+	cooldown, reservation time.Duration,
+) UsernameService {
+	return &usernameService{
+		userRepo:        userRepo,
+		historyRepo:     historyRepo,
+		activityService: activityService,
+		cooldown:        cooldown,
+		reservation:     reservation,
+	}
+}
+
+// ChangeUsername renames the user. Changes are rate limited by the cooldown,
+// and a name released by another account stays unavailable until its
+// reservation ends; the previous owner may take it back at any time.
+func (s *usernameService) ChangeUsername(ctx context.Context, userID, username, ipAddress, userAgent string) (*dto.CurrentUserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	current := ""
+	if user.Username != nil {
+		current = *user.Username
+	}
+	if username == current {
+		return nil, apperrors.ErrUsernameUnchanged
+	}
+
+	now := time.Now()
+	if s.cooldown > 0 {
+		last, err := s.historyRepo.FindLatestByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && now.Before(last.ChangedAt.Add(s.cooldown)) {
+			return nil, &apperrors.UsernameCooldownError{Until: last.ChangedAt.Add(s.cooldown)}
+		}
+	}
+
+	owner, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil && !errors.Is(err, apperrors.ErrUserNotFound) {
+		return nil, err
+	}
+	if owner != nil {
+		return nil, apperrors.ErrUsernameUnavailable
+	}
+
+	released, err := s.historyRepo.FindLatestByOldUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if released != nil && released.UserID != userID && now.Before(released.ReservedUntil) {
+		return nil, apperrors.ErrUsernameUnavailable
+	}
+
+	if err := s.historyRepo.ChangeUsername(ctx, &model.UsernameHistory{
+		UserID:        userID,
+		OldUsername:   current,
+		NewUsername:   username,
+		ChangedAt:     now,
+		ReservedUntil: now.Add(s.reservation),
+	}); err != nil {
+		return nil, err
+	}
+
+	s.activityService.LogActivity(ctx, &userID, model.ActivityUsernameChange, model.StatusSuccess, ipAddress, userAgent, nil, map[string]any{
+		"oldUsername": current,
+		"newUsername": username,
+	})
+
+	user.Username = &username
+	return dto.UserToCurrentUserResponse(user), nil
+}
+
+func (s *usernameService) ResolveMoved(ctx context.Context, username string) (string, error) {
+	entry, err := s.historyRepo.FindLatestByOldUsername(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", apperrors.ErrUserNotFound
+	}
+
+	user, err := s.userRepo.GetByID(ctx, entry.UserID, false)
+	if err != nil {
+		return "", err
+	}
+	if user.Username == nil || *user.Username == "" || *user.Username == username {
+		return "", apperrors.ErrUserNotFound
+	}
+	return *user.Username, nil
+}
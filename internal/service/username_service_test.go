@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "echobackend/internal/apperror"
+	"echobackend/internal/model"
+	"echobackend/internal/repository"
+)
+
+var _ repository.UsernameHistoryRepository = (*mockUsernameHistoryRepo)(nil)
+
+type mockUsernameHistoryRepo struct {
+	entries []*model.UsernameHistory
+}
+
+func (m *mockUsernameHistoryRepo) FindLatestByOldUsername(ctx context.Context, username string) (*model.UsernameHistory, error) {
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].OldUsername == username {
+			return m.entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUsernameHistoryRepo) FindLatestByUserID(ctx context.Context, userID string) (*model.UsernameHistory, error) {
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].UserID == userID {
+			return m.entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUsernameHistoryRepo) ChangeUsername(ctx context.Context, entry *model.UsernameHistory) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func TestChangeUsername_RecordsHistoryAndReservation(t *testing.T) {
+	history := &mockUsernameHistoryRepo{}
+	users := map[string]*model.User{"user-1": {ID: "user-1", Username: new("alice")}}
+	svc := NewUsernameService(&mockUserRepo{users: users}, history, &mockActivityRecorder{}, 30*24*time.Hour, 90*24*time.Hour)
+
+	resp, err := svc.ChangeUsername(context.Background(), "user-1", "alice2", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("ChangeUsername: %v", err)
+	}
+	if resp.Username == nil || *resp.Username != "alice2" {
+		t.Fatalf("username = %v, want alice2", resp.Username)
+	}
+	if len(history.entries) != 1 {
+		t.Fatalf("history = %+v", history.entries)
+	}
+	entry := history.entries[0]
+	if entry.OldUsername != "alice" || entry.NewUsername != "alice2" {
+		t.Fatalf("entry = %+v", entry)
+	}
+	if d := entry.ReservedUntil.Sub(entry.ChangedAt); d != 90*24*time.Hour {
+		t.Fatalf("reservation = %v, want 90 days", d)
+	}
+}
+
+func TestChangeUsername_EnforcesCooldown(t *testing.T) {
+	changedAt := time.Now().Add(-24 * time.Hour)
+	history := &mockUsernameHistoryRepo{entries: []*model.UsernameHistory{
+		{UserID: "user-1", OldUsername: "alice", NewUsername: "alice2", ChangedAt: changedAt, ReservedUntil: changedAt.Add(90 * 24 * time.Hour)},
+	}}
+	users := map[string]*model.User{"user-1": {ID: "user-1", Username: new("alice2")}}
+	svc := NewUsernameService(&mockUserRepo{users: users}, history, &mockActivityRecorder{}, 30*24*time.Hour, 90*24*time.Hour)
+
+	_, err := svc.ChangeUsername(context.Background(), "user-1", "alice3", "127.0.0.1", "test")
+	var cooldown *apperrors.UsernameCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("err = %v, want UsernameCooldownError", err)
+	}
+	if !cooldown.Until.Equal(changedAt.Add(30 * 24 * time.Hour)) {
+		t.Fatalf("until = %v", cooldown.Until)
+	}
+}
+
+func TestChangeUsername_ReservedNameOnlyForPreviousOwner(t *testing.T) {
+	changedAt := time.Now().Add(-60 * 24 * time.Hour)
+	history := &mockUsernameHistoryRepo{entries: []*model.UsernameHistory{
+		{UserID: "user-1", OldUsername: "alice", NewUsername: "alice2", ChangedAt: changedAt, ReservedUntil: changedAt.Add(90 * 24 * time.Hour)},
+	}}
+	users := map[string]*model.User{
+		"user-1": {ID: "user-1", Username: new("alice2")},
+		"user-2": {ID: "user-2", Username: new("bob")},
+	}
+	svc := NewUsernameService(&mockUserRepo{users: users}, history, &mockActivityRecorder{}, 30*24*time.Hour, 90*24*time.Hour)
+	ctx := context.Background()
+
+	if _, err := svc.ChangeUsername(ctx, "user-2", "alice", "127.0.0.1", "test"); !errors.Is(err, apperrors.ErrUsernameUnavailable) {
+		t.Fatalf("other user: err = %v, want ErrUsernameUnavailable", err)
+	}
+	if _, err := svc.ChangeUsername(ctx, "user-2", "alice2", "127.0.0.1", "test"); !errors.Is(err, apperrors.ErrUsernameUnavailable) {
+		t.Fatalf("taken name: err = %v, want ErrUsernameUnavailable", err)
+	}
+	if _, err := svc.ChangeUsername(ctx, "user-1", "alice", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("previous owner: %v", err)
+	}
+}
+
+func TestResolveMoved_FollowsRenamesToCurrentUsername(t *testing.T) {
+	history := &mockUsernameHistoryRepo{entries: []*model.UsernameHistory{
+		{UserID: "user-1", OldUsername: "alice", NewUsername: "alice2"},
+		{UserID: "user-1", OldUsername: "alice2", NewUsername: "alice3"},
+	}}
+	users := map[string]*model.User{"user-1": {ID: "user-1", Username: new("alice3")}}
+	svc := NewUsernameService(&mockUserRepo{users: users}, history, &mockActivityRecorder{}, 30*24*time.Hour, 90*24*time.Hour)
+	ctx := context.Background()
+
+	for _, old := range []string{"alice", "alice2"} {
+		canonical, err := svc.ResolveMoved(ctx, old)
+		if err != nil || canonical != "alice3" {
+			t.Fatalf("ResolveMoved(%q) = %q, %v; want alice3", old, canonical, err)
+		}
+	}
+	if _, err := svc.ResolveMoved(ctx, "nobody"); !errors.Is(err, apperrors.ErrUserNotFound) {
+		t.Fatalf("unknown name: err = %v, want ErrUserNotFound", err)
+	}
+}
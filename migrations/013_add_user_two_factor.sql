@@ -0,0 +1,27 @@
+-- +goose Up
+-- ============================================
+-- TOTP second factor and one-time recovery codes
+-- ============================================
+CREATE TABLE IF NOT EXISTS user_two_factors (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    confirmed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_recovery_codes_code_hash ON user_recovery_codes(code_hash);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_two_factors;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================
+-- Stable, non-secret session IDs plus client metadata so users can list and
+-- revoke their sessions. The refresh token hash stays unique but is no longer
+-- the primary key because it changes on every refresh.
+-- ============================================
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id UUID NOT NULL DEFAULT uuidv7();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ;
+
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_pkey;
+ALTER TABLE sessions ADD CONSTRAINT sessions_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token ON sessions(refresh_token);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_refresh_token;
+
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_pkey;
+ALTER TABLE sessions ADD CONSTRAINT sessions_pkey PRIMARY KEY (refresh_token);
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE sessions DROP COLUMN IF EXISTS id;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================
+-- Refresh token families. Every refresh inserts a new session row in the same
+-- family and marks its parent as rotated. Presenting a rotated token again is
+-- treated as theft and revokes the whole family.
+-- ============================================
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id UUID;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS parent_id UUID;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+UPDATE sessions SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE sessions ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_active ON sessions(user_id) WHERE rotated_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_user_active;
+DROP INDEX IF EXISTS idx_sessions_family_id;
+
+DELETE FROM sessions WHERE rotated_at IS NOT NULL;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- ============================================
+-- Single-use passwordless login links
+-- ============================================
+CREATE TABLE IF NOT EXISTS magic_link_tokens (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_magic_link_tokens_token ON magic_link_tokens(token);
+CREATE INDEX IF NOT EXISTS idx_magic_link_tokens_user_id ON magic_link_tokens(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS magic_link_tokens;
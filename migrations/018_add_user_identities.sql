@@ -0,0 +1,37 @@
+-- +goose Up
+-- ============================================
+-- External sign-in identities (GitHub, Google, GitLab, OIDC)
+-- ============================================
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(32) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities(provider, subject);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_user_provider ON user_identities(user_id, provider);
+
+INSERT INTO user_identities (user_id, provider, subject, email)
+SELECT id, 'github', github_id::TEXT, email
+FROM users
+WHERE github_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+DROP INDEX IF EXISTS idx_users_github_id_unique;
+ALTER TABLE users DROP COLUMN IF EXISTS github_id;
+
+-- +goose Down
+ALTER TABLE users ADD COLUMN IF NOT EXISTS github_id BIGINT;
+
+UPDATE users u
+SET github_id = ui.subject::BIGINT
+FROM user_identities ui
+WHERE ui.user_id = u.id AND ui.provider = 'github';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_github_id_unique ON users(github_id) WHERE github_id IS NOT NULL;
+
+DROP TABLE IF EXISTS user_identities;
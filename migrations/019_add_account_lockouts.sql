@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================
+-- Per-account failed login tracking and lockout
+-- ============================================
+CREATE TABLE IF NOT EXISTS account_lockouts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_locked_until ON account_lockouts(locked_until);
+
+-- +goose Down
+DROP TABLE IF EXISTS account_lockouts;
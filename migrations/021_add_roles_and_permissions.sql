@@ -0,0 +1,75 @@
+-- +goose Up
+-- ============================================
+-- Role-based access control
+-- ============================================
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_name VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_name)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('posts.read', 'Read any post, including drafts'),
+    ('posts.update', 'Edit any post'),
+    ('posts.delete', 'Delete any post'),
+    ('tags.update', 'Rename tags'),
+    ('tags.delete', 'Delete tags'),
+    ('users.read', 'List users and view account details'),
+    ('users.delete', 'Delete user accounts'),
+    ('users.restore', 'Restore deleted user accounts'),
+    ('reports.read', 'View admin reports'),
+    ('auth.audit', 'View failed logins across all accounts'),
+    ('accounts.unlock', 'View and lift account lockouts'),
+    ('roles.manage', 'Assign and remove user roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access to every admin feature'),
+    ('moderator', 'Moderates posts, tags and locked accounts'),
+    ('editor', 'Edits posts and tags')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.id, p.name
+FROM roles r
+JOIN permissions p ON
+    r.name = 'admin'
+    OR (r.name = 'moderator' AND p.name IN ('posts.read', 'posts.update', 'posts.delete', 'tags.update', 'tags.delete', 'users.read', 'accounts.unlock'))
+    OR (r.name = 'editor' AND p.name IN ('posts.read', 'posts.update', 'tags.update'))
+ON CONFLICT DO NOTHING;
+
+-- Existing super admins become admins.
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+JOIN roles r ON r.name = 'admin'
+WHERE u.is_super_admin = TRUE
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
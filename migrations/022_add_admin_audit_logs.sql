@@ -0,0 +1,32 @@
+-- +goose Up
+-- ============================================
+-- Audit trail of privileged (admin) actions
+-- ============================================
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id TEXT,
+    changes JSONB,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_actor_id ON admin_audit_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_action ON admin_audit_logs(action);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_target ON admin_audit_logs(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs(created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit.read', 'View the admin audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT id, 'audit.read' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'audit.read';
+DROP TABLE IF EXISTS admin_audit_logs;
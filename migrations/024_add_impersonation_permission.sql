@@ -0,0 +1,14 @@
+-- +goose Up
+-- ============================================
+-- Permission to impersonate users for support
+-- ============================================
+INSERT INTO permissions (name, description) VALUES
+    ('users.impersonate', 'Act as another user with a short-lived access token')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT id, 'users.impersonate' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'users.impersonate';
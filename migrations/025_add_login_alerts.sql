@@ -0,0 +1,34 @@
+-- +goose Up
+-- ============================================
+-- New-device sign-in alerts
+-- ============================================
+-- Devices (user agent + IP network) each user has signed in from.
+CREATE TABLE IF NOT EXISTS known_devices (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_known_devices_user_fingerprint ON known_devices(user_id, fingerprint);
+
+-- One-click "this wasn't me" links sent with new sign-in alerts.
+CREATE TABLE IF NOT EXISTS session_revoke_tokens (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_revoke_tokens_token ON session_revoke_tokens(token);
+CREATE INDEX IF NOT EXISTS idx_session_revoke_tokens_user_id ON session_revoke_tokens(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS session_revoke_tokens;
+DROP TABLE IF EXISTS known_devices;
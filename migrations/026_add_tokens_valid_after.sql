@@ -0,0 +1,8 @@
+-- +goose Up
+-- ============================================
+-- Access tokens issued before this time are rejected
+-- ============================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
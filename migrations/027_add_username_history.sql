@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================
+-- Username changes: old usernames keep resolving to their user and stay
+-- reserved for a while
+-- ============================================
+CREATE TABLE IF NOT EXISTS username_history (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_username VARCHAR(255) NOT NULL,
+    new_username VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reserved_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_username_history_old_username ON username_history(old_username, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_username_history_user_id ON username_history(user_id, changed_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS username_history;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- ============================================
+-- Blocks (both users stop interacting) and mutes (one-sided feed and
+-- notification filter)
+-- ============================================
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_user_blocks_no_self_block CHECK (blocker_id <> blocked_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_blocks_pair ON user_blocks(blocker_id, blocked_id);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks(blocked_id);
+
+CREATE TABLE IF NOT EXISTS user_mutes (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_user_mutes_no_self_mute CHECK (muter_id <> muted_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_mutes_pair ON user_mutes(muter_id, muted_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_mutes;
+DROP TABLE IF EXISTS user_blocks;
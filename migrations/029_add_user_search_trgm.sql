@@ -0,0 +1,17 @@
+-- +goose Up
+-- ============================================
+-- Fuzzy user search: trigram indexes on username and display name
+-- ============================================
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_username_trgm
+    ON users USING GIN (username gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_display_name_trgm
+    ON users USING GIN ((COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_display_name_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
@@ -0,0 +1,106 @@
+-- +goose Up
+-- ============================================
+-- Private accounts: follows of a private user start as pending requests and
+-- only approved follows are counted.
+-- ============================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE user_follows ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'approved';
+ALTER TABLE user_follows DROP CONSTRAINT IF EXISTS chk_user_follows_status;
+ALTER TABLE user_follows ADD CONSTRAINT chk_user_follows_status CHECK (status IN ('pending', 'approved'));
+
+CREATE INDEX IF NOT EXISTS idx_user_follows_pending
+ON user_follows(following_id, created_at)
+WHERE status = 'pending' AND deleted_at IS NULL;
+
+-- A follow counts while it is approved and not soft-deleted. The UPDATE
+-- trigger covers approvals and soft deletes (unfollows), which the INSERT and
+-- DELETE triggers from migration 002 never saw.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_user_follow_counts()
+RETURNS TRIGGER AS $$
+DECLARE
+    old_counted BOOLEAN := FALSE;
+    new_counted BOOLEAN := FALSE;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        old_counted := OLD.status = 'approved' AND OLD.deleted_at IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        new_counted := NEW.status = 'approved' AND NEW.deleted_at IS NULL;
+    END IF;
+
+    IF old_counted AND NOT new_counted THEN
+        UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
+        UPDATE users SET followers_count = followers_count - 1 WHERE id = OLD.following_id;
+    ELSIF new_counted AND NOT old_counted THEN
+        UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+        UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.following_id;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS trigger_update_follow_counts_update ON user_follows;
+CREATE TRIGGER trigger_update_follow_counts_update
+    AFTER UPDATE OF status, deleted_at ON user_follows
+    FOR EACH ROW
+    EXECUTE FUNCTION update_user_follow_counts();
+
+-- Soft-deleted follows were never subtracted; start from the real counts.
+UPDATE users u
+SET following_count = COALESCE(c.cnt, 0)
+FROM (
+    SELECT u2.id, COUNT(uf.follower_id)::bigint AS cnt
+    FROM users u2
+    LEFT JOIN user_follows uf
+      ON uf.follower_id = u2.id AND uf.status = 'approved' AND uf.deleted_at IS NULL
+    GROUP BY u2.id
+) c
+WHERE u.id = c.id;
+
+UPDATE users u
+SET followers_count = COALESCE(c.cnt, 0)
+FROM (
+    SELECT u2.id, COUNT(uf.following_id)::bigint AS cnt
+    FROM users u2
+    LEFT JOIN user_follows uf
+      ON uf.following_id = u2.id AND uf.status = 'approved' AND uf.deleted_at IS NULL
+    GROUP BY u2.id
+) c
+WHERE u.id = c.id;
+
+-- +goose Down
+-- Pending requests would otherwise turn into follows. They are removed while
+-- the new function, which never counted them, is still in place.
+DELETE FROM user_follows WHERE status = 'pending';
+
+DROP TRIGGER IF EXISTS trigger_update_follow_counts_update ON user_follows;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_user_follow_counts()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+        UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.following_id;
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
+        UPDATE users SET followers_count = followers_count - 1 WHERE id = OLD.following_id;
+        RETURN OLD;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS idx_user_follows_pending;
+ALTER TABLE user_follows DROP CONSTRAINT IF EXISTS chk_user_follows_status;
+ALTER TABLE user_follows DROP COLUMN IF EXISTS status;
+ALTER TABLE users DROP COLUMN IF EXISTS is_private;
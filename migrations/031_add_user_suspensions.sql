@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================
+-- Account suspensions: a suspended user cannot sign in and their posts are
+-- hidden from public listings. A NULL suspended_until means indefinitely.
+-- ============================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason VARCHAR(500);
+
+-- The lift job looks for expired suspensions.
+CREATE INDEX IF NOT EXISTS idx_users_suspended_until
+ON users(suspended_until)
+WHERE suspended_at IS NOT NULL AND suspended_until IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users.suspend', 'Suspend and reinstate user accounts')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT id, 'users.suspend' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'users.suspend';
+DROP INDEX IF EXISTS idx_users_suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,97 @@
+-- +goose Up
+-- ============================================
+-- Full-text post search: posts.search_vector is generated from the title
+-- (weight A), the tag names (B) and the body (C). A generated column cannot
+-- read posts_to_tags, so the tag names are copied into posts.tag_names by
+-- triggers and the vector is generated from that copy.
+--
+-- The 'simple' configuration is used because posts are written in more than
+-- one language; it lowercases words but does not stem them or drop stop words.
+-- ============================================
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS tag_names TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('simple', tag_names), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(body, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION refresh_post_tag_names(target_post_id UUID)
+RETURNS VOID AS $$
+BEGIN
+    UPDATE posts
+    SET tag_names = COALESCE((
+        SELECT string_agg(t.name, ' ' ORDER BY t.name)
+        FROM posts_to_tags pt
+        JOIN tags t ON t.id = pt.tag_id
+        WHERE pt.post_id = target_post_id
+    ), '')
+    WHERE id = target_post_id;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION posts_to_tags_refresh_tag_names()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_post_tag_names(OLD.post_id);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.post_id <> OLD.post_id) THEN
+        PERFORM refresh_post_tag_names(NEW.post_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION tags_refresh_tag_names()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_post_tag_names(pt.post_id)
+    FROM posts_to_tags pt
+    WHERE pt.tag_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS trigger_posts_to_tags_refresh_tag_names ON posts_to_tags;
+CREATE TRIGGER trigger_posts_to_tags_refresh_tag_names
+    AFTER INSERT OR UPDATE OR DELETE ON posts_to_tags
+    FOR EACH ROW
+    EXECUTE FUNCTION posts_to_tags_refresh_tag_names();
+
+-- Deleted tags go through the posts_to_tags cascade; renames need their own.
+DROP TRIGGER IF EXISTS trigger_tags_refresh_tag_names ON tags;
+CREATE TRIGGER trigger_tags_refresh_tag_names
+    AFTER UPDATE OF name ON tags
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION tags_refresh_tag_names();
+
+UPDATE posts p
+SET tag_names = c.names
+FROM (
+    SELECT pt.post_id, string_agg(t.name, ' ' ORDER BY t.name) AS names
+    FROM posts_to_tags pt
+    JOIN tags t ON t.id = pt.tag_id
+    GROUP BY pt.post_id
+) c
+WHERE p.id = c.post_id;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trigger_tags_refresh_tag_names ON tags;
+DROP TRIGGER IF EXISTS trigger_posts_to_tags_refresh_tag_names ON posts_to_tags;
+DROP FUNCTION IF EXISTS tags_refresh_tag_names();
+DROP FUNCTION IF EXISTS posts_to_tags_refresh_tag_names();
+DROP FUNCTION IF EXISTS refresh_post_tag_names(UUID);
+DROP INDEX IF EXISTS idx_posts_search_vector;
+ALTER TABLE posts DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE posts DROP COLUMN IF EXISTS tag_names;
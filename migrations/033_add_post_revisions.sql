@@ -0,0 +1,57 @@
+-- +goose Up
+-- ============================================
+-- Post revisions: the title, body and tags of a post after each edit,
+-- numbered from 1 per post. Rows are never updated.
+-- ============================================
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id UUID PRIMARY KEY DEFAULT uuidv7(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    tags JSONB NOT NULL DEFAULT '[]',
+    editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    restored_from INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_post_revisions_post_number UNIQUE (post_id, number)
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_post_revision_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'post revisions cannot be modified';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- editor_id is left out so that deleting an editor can still null it.
+DROP TRIGGER IF EXISTS trigger_prevent_post_revision_update ON post_revisions;
+CREATE TRIGGER trigger_prevent_post_revision_update
+    BEFORE UPDATE OF post_id, number, title, body, tags, restored_from, created_at ON post_revisions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_post_revision_update();
+
+-- Existing posts start their history with their current content.
+INSERT INTO post_revisions (post_id, number, title, body, tags, editor_id, created_at)
+SELECT
+    p.id,
+    1,
+    p.title,
+    COALESCE(p.body, ''),
+    COALESCE((
+        SELECT jsonb_agg(t.name ORDER BY t.name)
+        FROM posts_to_tags pt
+        JOIN tags t ON t.id = pt.tag_id
+        WHERE pt.post_id = p.id
+    ), '[]'::jsonb),
+    p.created_by,
+    COALESCE(p.updated_at, p.created_at, NOW())
+FROM posts p
+WHERE p.deleted_at IS NULL
+ON CONFLICT (post_id, number) DO NOTHING;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trigger_prevent_post_revision_update ON post_revisions;
+DROP FUNCTION IF EXISTS prevent_post_revision_update();
+DROP TABLE IF EXISTS post_revisions;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- ============================================
+-- Scheduled publishing: an unpublished post with publish_at set is published
+-- by a background job at that time.
+-- ============================================
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_posts_publish_at
+ON posts(publish_at)
+WHERE publish_at IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_posts_publish_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,324 @@
+// Package jwtkeys signs and verifies access tokens with a rotating set of
+// asymmetric keys and publishes their public halves as a JSON Web Key Set.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported asymmetric signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// minRSABits rejects RSA keys too small to be trusted for signing.
+const minRSABits = 2048
+
+// Key is one asymmetric key. Private is nil for verify-only keys.
+type Key struct {
+	// ID is the RFC 7638 thumbprint of the public key, used as the "kid".
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	Private   crypto.Signer
+}
+
+// KeySet holds the active signing key, retired keys that are still accepted
+// for verification, and the legacy HS256 secret.
+type KeySet struct {
+	active     *Key
+	keys       map[string]*Key
+	hmacSecret []byte
+	acceptHMAC bool
+}
+
+// Config describes where the keys come from.
+type Config struct {
+	// SigningKeyFile is a PEM private key (RSA or Ed25519). When empty, tokens
+	// are signed HS256 with HMACSecret.
+	SigningKeyFile string
+	// RetiredKeyFiles are PEM public or private keys that verify tokens issued
+	// before a rotation but are never used to sign.
+	RetiredKeyFiles []string
+	// HMACSecret is the shared HS256 secret.
+	HMACSecret string
+	// AcceptHMAC keeps HS256 tokens valid while clients migrate. It is forced
+	// on when there is no signing key.
+	AcceptHMAC bool
+}
+
+// Load reads the keys named in cfg.
+func Load(cfg Config) (*KeySet, error) {
+	var active *Key
+	if cfg.SigningKeyFile != "" {
+		key, err := LoadKeyFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("signing key: %w", err)
+		}
+		if key.Private == nil {
+			return nil, fmt.Errorf("signing key %s is a public key", cfg.SigningKeyFile)
+		}
+		active = key
+	}
+
+	retired := make([]*Key, 0, len(cfg.RetiredKeyFiles))
+	for _, path := range cfg.RetiredKeyFiles {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("retired key: %w", err)
+		}
+		retired = append(retired, key)
+	}
+
+	return New(active, retired, []byte(cfg.HMACSecret), cfg.AcceptHMAC)
+}
+
+// New builds a key set. active may be nil, in which case tokens are signed
+// HS256 with hmacSecret.
+func New(active *Key, retired []*Key, hmacSecret []byte, acceptHMAC bool) (*KeySet, error) {
+	if active == nil {
+		if len(hmacSecret) == 0 {
+			return nil, errors.New("either a signing key or an HMAC secret is required")
+		}
+		acceptHMAC = true
+	}
+
+	ks := &KeySet{
+		active:     active,
+		keys:       make(map[string]*Key, len(retired)+1),
+		hmacSecret: hmacSecret,
+		acceptHMAC: acceptHMAC,
+	}
+	if active != nil {
+		ks.keys[active.ID] = active
+	}
+	for _, key := range retired {
+		if _, ok := ks.keys[key.ID]; ok {
+			continue
+		}
+		// Retired keys only verify.
+		ks.keys[key.ID] = &Key{ID: key.ID, Algorithm: key.Algorithm, Public: key.Public}
+	}
+	return ks, nil
+}
+
+// NewHMAC returns a key set that signs and verifies HS256 only.
+func NewHMAC(secret []byte) *KeySet {
+	return &KeySet{keys: map[string]*Key{}, hmacSecret: secret, acceptHMAC: true}
+}
+
+// Sign signs claims with the active key, or HS256 when there is none.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.active.Algorithm), claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Private)
+}
+
+// Parse verifies tokenString and returns its claims.
+func (ks *KeySet) Parse(tokenString string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	opts = append([]jwt.ParserOption{jwt.WithValidMethods(ks.validMethods())}, opts...)
+	token, err := jwt.Parse(tokenString, ks.keyfunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// keyfunc selects the verification key: the HS256 secret for HMAC tokens,
+// otherwise the key named by the token's "kid".
+func (ks *KeySet) keyfunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() == AlgHS256 {
+		if !ks.acceptHMAC {
+			return nil, errors.New("HS256 tokens are no longer accepted")
+		}
+		return ks.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("kid %q does not use %s", kid, token.Method.Alg())
+	}
+	return key.Public, nil
+}
+
+func (ks *KeySet) validMethods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	if ks.acceptHMAC {
+		seen[AlgHS256] = true
+		methods = append(methods, AlgHS256)
+	}
+	for _, key := range ks.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			methods = append(methods, key.Algorithm)
+		}
+	}
+	return methods
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys, active and retired. The HS256 secret is
+// never published.
+func (ks *KeySet) JWKS() JWKS {
+	doc := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := publicJWK(key.Public)
+		jwk.Kid = key.ID
+		jwk.Use = "sig"
+		jwk.Alg = key.Algorithm
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	sort.Slice(doc.Keys, func(i, j int) bool { return doc.Keys[i].Kid < doc.Keys[j].Kid })
+	return doc
+}
+
+// LoadKeyFile reads a PEM file holding a private key (PKCS#8 or PKCS#1) or a
+// public key (PKIX).
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKey parses a PEM encoded RSA or Ed25519 key.
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKey(parsed)
+}
+
+// NewKey wraps an RSA or Ed25519 key, public or private.
+func NewKey(parsed any) (*Key, error) {
+	key := &Key{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgRS256, &k.PublicKey, k
+	case *rsa.PublicKey:
+		key.Algorithm, key.Public = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.Public, key.Private = AlgEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.Algorithm, key.Public = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", parsed)
+	}
+
+	if pub, ok := key.Public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+	}
+
+	key.ID = thumbprint(publicJWK(key.Public))
+	return key, nil
+}
+
+func publicJWK(pub crypto.PublicKey) JWK {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+	}
+	return JWK{}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint: the hash of the required
+// members in lexicographic order.
+func thumbprint(jwk JWK) string {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newRSAKey(t *testing.T) *Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	key, err := NewKey(priv)
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	key, err := NewKey(priv)
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	return key
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestKeySetSignsWithActiveKeyAndKid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		key  func(*testing.T) *Key
+	}{
+		{"RS256", newRSAKey},
+		{"EdDSA", newEd25519Key},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			active := tc.key(t)
+			ks, err := New(active, nil, []byte("secret"), false)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			signed, err := ks.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if token.Header["kid"] != active.ID || token.Method.Alg() != tc.name {
+				t.Fatalf("header = %v, want kid %q alg %s", token.Header, active.ID, tc.name)
+			}
+
+			claims, err := ks.Parse(signed)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if claims["user_id"] != "user-1" {
+				t.Fatalf("claims = %v", claims)
+			}
+		})
+	}
+}
+
+func TestKeySetVerifiesRetiredKeys(t *testing.T) {
+	oldKey := newRSAKey(t)
+	oldSet, _ := New(oldKey, nil, []byte("secret"), false)
+	signed, err := oldSet.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	rotated, _ := New(newEd25519Key(t), []*Key{oldKey}, []byte("secret"), false)
+	if _, err := rotated.Parse(signed); err != nil {
+		t.Fatalf("token from retired key rejected: %v", err)
+	}
+
+	withoutOld, _ := New(newEd25519Key(t), nil, []byte("secret"), false)
+	if _, err := withoutOld.Parse(signed); err == nil {
+		t.Fatal("expected token from a dropped key to be rejected")
+	}
+}
+
+func TestKeySetHS256Migration(t *testing.T) {
+	legacy := NewHMAC([]byte("secret"))
+	signed, err := legacy.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	accepting, _ := New(newRSAKey(t), nil, []byte("secret"), true)
+	if _, err := accepting.Parse(signed); err != nil {
+		t.Fatalf("HS256 token rejected during migration: %v", err)
+	}
+
+	strict, _ := New(newRSAKey(t), nil, []byte("secret"), false)
+	if _, err := strict.Parse(signed); err == nil {
+		t.Fatal("expected HS256 token to be rejected once migration is over")
+	}
+}
+
+func TestKeySetRejectsAlgorithmMismatch(t *testing.T) {
+	key := newRSAKey(t)
+	ks, _ := New(key, nil, []byte("secret"), false)
+
+	// An HMAC token naming the RSA kid must not be verified with the RSA key.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS384, testClaims())
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ks.Parse(signed); err == nil {
+		t.Fatal("expected token with mismatched algorithm to be rejected")
+	}
+}
+
+func TestJWKSPublishesPublicKeysOnly(t *testing.T) {
+	active := newEd25519Key(t)
+	retired := newRSAKey(t)
+	ks, _ := New(active, []*Key{retired}, []byte("secret"), true)
+
+	doc := ks.JWKS()
+	if len(doc.Keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(doc.Keys))
+	}
+	for _, jwk := range doc.Keys {
+		switch jwk.Kid {
+		case active.ID:
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.X == "" || jwk.Alg != AlgEdDSA {
+				t.Fatalf("unexpected Ed25519 JWK: %+v", jwk)
+			}
+		case retired.ID:
+			if jwk.Kty != "RSA" || jwk.N == "" || jwk.E == "" || jwk.Alg != AlgRS256 {
+				t.Fatalf("unexpected RSA JWK: %+v", jwk)
+			}
+		default:
+			t.Fatalf("unexpected kid %q", jwk.Kid)
+		}
+	}
+}
+
+func TestParseKeyAcceptsPublicKeyPEM(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	key, err := ParseKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParseKey: %v", err)
+	}
+	if key.Private != nil || key.Algorithm != AlgRS256 {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+
+	fromPrivate, _ := NewKey(priv)
+	if key.ID != fromPrivate.ID {
+		t.Fatal("kid must depend only on the public key")
+	}
+}
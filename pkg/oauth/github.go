@@ -0,0 +1,184 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubAPIURL       = "https://api.github.com"
+)
+
+// ClientConfig holds the OAuth client registration for a provider.
+type ClientConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+}
+
+// GitHubProvider signs users in with GitHub. GitHub does not implement OpenID
+// Connect, so the profile and emails are read from its REST API.
+type GitHubProvider struct {
+	cfg          ClientConfig
+	httpClient   *http.Client
+	authorizeURL string
+	tokenURL     string
+	apiURL       string
+}
+
+func NewGitHubProvider(cfg ClientConfig, httpClient *http.Client) *GitHubProvider {
+	return &GitHubProvider{
+		cfg:          cfg,
+		httpClient:   defaultHTTPClient(httpClient),
+		authorizeURL: githubAuthorizeURL,
+		tokenURL:     githubTokenURL,
+		apiURL:       githubAPIURL,
+	}
+}
+
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *GitHubProvider) AuthCodeURL(_ context.Context, state string) (string, error) {
+	authURL, err := url.Parse(p.authorizeURL)
+	if err != nil {
+		return "", err
+	}
+	q := authURL.Query()
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURI)
+	q.Set("scope", "user:email")
+	q.Set("state", state)
+	authURL.RawQuery = q.Encode()
+	return authURL.String(), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code, _ string) (*Identity, error) {
+	accessToken, err := p.exchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID        int64   `json:"id"`
+		Login     string  `json:"login"`
+		Name      string  `json:"name"`
+		AvatarURL string  `json:"avatar_url"`
+		Email     *string `json:"email"`
+	}
+	if err := p.getJSON(ctx, accessToken, "/user", &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("GitHub user has no id")
+	}
+
+	identity := &Identity{
+		Provider:  p.Name(),
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Username:  user.Login,
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+	}
+
+	// The public profile email carries no verification status; the emails
+	// endpoint does, so it is preferred.
+	email, verified, err := p.primaryEmail(ctx, accessToken)
+	if err == nil && email != "" {
+		identity.Email, identity.EmailVerified = email, verified
+	} else if user.Email != nil {
+		identity.Email = *user.Email
+	}
+
+	return identity, nil
+}
+
+func (p *GitHubProvider) exchangeCode(ctx context.Context, code string) (string, error) {
+	data := url.Values{}
+	data.Set("client_id", p.cfg.ClientID)
+	data.Set("client_secret", p.cfg.ClientSecret)
+	data.Set("code", code)
+	data.Set("redirect_uri", p.cfg.RedirectURI)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	accessToken := values.Get("access_token")
+	if accessToken == "" {
+		return "", errors.New("no access_token in GitHub response")
+	}
+
+	return accessToken, nil
+}
+
+func (p *GitHubProvider) primaryEmail(ctx context.Context, accessToken string) (string, bool, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, accessToken, "/user/emails", &emails); err != nil {
+		return "", false, err
+	}
+
+	for _, e := range emails {
+		if e.Primary {
+			return e.Email, e.Verified, nil
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Email, emails[0].Verified, nil
+	}
+	return "", false, nil
+}
+
+func (p *GitHubProvider) getJSON(ctx context.Context, accessToken, path string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := checkStatus(resp, "GitHub API"); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -0,0 +1,324 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCConfig configures a generic OpenID Connect provider.
+type OIDCConfig struct {
+	// Name is the registry name, e.g. "google" or "okta".
+	Name string
+	// Issuer is the issuer URL; discovery is read from
+	// <Issuer>/.well-known/openid-configuration.
+	Issuer string
+	ClientConfig
+	// Scopes defaults to openid, email and profile.
+	Scopes []string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider signs users in with any OpenID Connect issuer. Discovery and
+// signing keys are fetched on first use and cached.
+type OIDCProvider struct {
+	cfg        OIDCConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      *keySet
+}
+
+func NewOIDCProvider(cfg OIDCConfig, httpClient *http.Client) *OIDCProvider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{
+		cfg:        cfg,
+		httpClient: defaultHTTPClient(httpClient),
+	}
+}
+
+// NewGoogleProvider returns an OIDC provider preset for Google accounts.
+func NewGoogleProvider(cfg ClientConfig, httpClient *http.Client) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "google",
+		Issuer:       "https://accounts.google.com",
+		ClientConfig: cfg,
+	}, httpClient)
+}
+
+// NewGitLabProvider returns an OIDC provider preset for GitLab. issuer is
+// https://gitlab.com unless the instance is self-hosted.
+func NewGitLabProvider(issuer string, cfg ClientConfig, httpClient *http.Client) *OIDCProvider {
+	if issuer == "" {
+		issuer = "https://gitlab.com"
+	}
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "gitlab",
+		Issuer:       issuer,
+		ClientConfig: cfg,
+	}, httpClient)
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(disc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURI)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonceForState(state))
+	authURL.RawQuery = q.Encode()
+	return authURL.String(), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, state string) (*Identity, error) {
+	disc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := p.exchangeCode(ctx, disc, code)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("no id_token in token response")
+	}
+
+	claims, err := p.verifyIDToken(ctx, disc, tokens.IDToken, nonceForState(state))
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Username:      claims.PreferredUsername,
+		Name:          claims.Name,
+		AvatarURL:     claims.Picture,
+	}
+
+	// Some issuers keep the ID token minimal and only expose the email through
+	// the userinfo endpoint.
+	if identity.Email == "" && disc.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		info, err := p.userinfo(ctx, disc, tokens.AccessToken)
+		if err == nil && info.Subject == identity.Subject {
+			identity.Email = info.Email
+			identity.EmailVerified = bool(info.EmailVerified)
+			if identity.Username == "" {
+				identity.Username = info.PreferredUsername
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+func (p *OIDCProvider) exchangeCode(ctx context.Context, disc *oidcDiscovery, code string) (*oidcTokenResponse, error) {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", p.cfg.RedirectURI)
+	data.Set("client_id", p.cfg.ClientID)
+	data.Set("client_secret", p.cfg.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disc.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := checkStatus(resp, "token endpoint"); err != nil {
+		return nil, err
+	}
+
+	var tokens oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	return &tokens, nil
+}
+
+// idTokenClaims are the standard claims read from ID tokens and userinfo.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+	Picture           string   `json:"picture"`
+}
+
+// flexBool accepts both true and "true"; some issuers send email_verified as
+// a string.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, disc *oidcDiscovery, rawToken, nonce string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, disc, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(disc.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id_token: missing subject")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) userinfo(ctx context.Context, disc *oidcDiscovery, accessToken string) (*idTokenClaims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disc.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := checkStatus(resp, "userinfo endpoint"); err != nil {
+		return nil, err
+	}
+
+	var info idTokenClaims
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := checkStatus(resp, "OIDC discovery"); err != nil {
+		return nil, err
+	}
+
+	var disc oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&disc); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC discovery: %w", err)
+	}
+	if strings.TrimRight(disc.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery issuer %q does not match %q", disc.Issuer, p.cfg.Issuer)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is missing required endpoints")
+	}
+
+	p.discovery = &disc
+	return p.discovery, nil
+}
+
+// signingKey returns the issuer key for kid. The key set is refetched once
+// when kid is unknown, which picks up key rotation.
+func (p *OIDCProvider) signingKey(ctx context.Context, disc *oidcDiscovery, kid string) (any, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	if keys != nil {
+		if key, ok := keys.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	keys, err := fetchKeySet(ctx, p.httpClient, disc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key for kid %q", kid)
+}
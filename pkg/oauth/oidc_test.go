@@ -0,0 +1,166 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIssuer is a minimal OpenID Connect issuer. It answers every code with an
+// ID token built from claims.
+type fakeIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, f.claims)
+		token.Header["kid"] = "test-key"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Errorf("failed to sign id token: %v", err)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"id_token":     idToken,
+		})
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:   "fake",
+		Issuer: f.server.URL,
+		ClientConfig: ClientConfig{
+			ClientID:     "client-1",
+			ClientSecret: "secret",
+			RedirectURI:  "http://localhost/callback",
+		},
+	}, f.server.Client())
+}
+
+func (f *fakeIssuer) validClaims(state string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.server.URL,
+		"sub":            "user-123",
+		"aud":            "client-1",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonceForState(state),
+		"email":          "jane@example.com",
+		"email_verified": "true",
+		"name":           "Jane",
+	}
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	authURL, err := f.provider().AuthCodeURL(context.Background(), "state-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL returned error: %v", err)
+	}
+	if !strings.HasPrefix(authURL, f.server.URL+"/authorize?") {
+		t.Fatalf("unexpected auth URL: %s", authURL)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	q := parsed.Query()
+	if q.Get("state") != "state-1" || q.Get("nonce") != nonceForState("state-1") {
+		t.Fatalf("state or nonce missing from auth URL: %s", authURL)
+	}
+	if q.Get("scope") != "openid email profile" {
+		t.Fatalf("scope = %q", q.Get("scope"))
+	}
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+	f.claims = f.validClaims("state-1")
+
+	identity, err := f.provider().Exchange(context.Background(), "good-code", "state-1")
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+
+	if identity.Provider != "fake" || identity.Subject != "user-123" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if identity.Email != "jane@example.com" || !identity.EmailVerified {
+		t.Fatalf("expected verified email, got %+v", identity)
+	}
+}
+
+func TestOIDCProviderExchangeRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+	}{
+		{"nonce from another flow", func(c jwt.MapClaims) { c["nonce"] = nonceForState("other-state") }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeIssuer(t)
+			f.claims = f.validClaims("state-1")
+			tt.mutate(f.claims)
+
+			if _, err := f.provider().Exchange(context.Background(), "good-code", "state-1"); err == nil {
+				t.Fatal("expected Exchange to reject the ID token")
+			}
+		})
+	}
+}
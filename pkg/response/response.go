@@ -58,6 +58,27 @@ func Created(c *echo.Context, message string, data any) error {
 	})
 }
 
+// Accepted sends a response for work that continues in the background
+func Accepted(c *echo.Context, message string, data any) error {
+	return c.JSON(http.StatusAccepted, APIResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// MovedPermanently points the client at the canonical location of a renamed
+// resource. The body repeats it for clients that do not follow redirects.
+func MovedPermanently(c *echo.Context, message, location string, data any) error {
+	c.Response().Header().Set(echo.HeaderLocation, location)
+	return c.JSON(http.StatusMovedPermanently, APIResponse{
+		Success: false,
+		Message: message,
+		Data:    data,
+		Error:   "Moved permanently",
+	})
+}
+
 // BadRequest sends a bad request error response
 func BadRequest(c *echo.Context, message string, err error) error {
 	errorMsg := ""
@@ -103,6 +124,22 @@ func Forbidden(c *echo.Context, message string) error {
 	})
 }
 
+// ForbiddenWithCode sends a forbidden response whose error is a stable,
+// machine-readable code, with data explaining the refusal.
+func ForbiddenWithCode(c *echo.Context, message, code string, data any) error {
+	log.Warn("forbidden",
+		"message", message,
+		"code", code,
+	)
+
+	return c.JSON(http.StatusForbidden, APIResponse{
+		Success: false,
+		Message: message,
+		Data:    data,
+		Error:   code,
+	})
+}
+
 // TooManyRequests sends a 429 rate-limit response.
 func TooManyRequests(c *echo.Context, message string) error {
 	log.Warn("too many requests",
@@ -116,6 +153,20 @@ func TooManyRequests(c *echo.Context, message string) error {
 	})
 }
 
+// ServiceUnavailable sends a 503 response for features that are disabled or
+// temporarily unavailable.
+func ServiceUnavailable(c *echo.Context, message string) error {
+	log.Warn("service unavailable",
+		"message", message,
+	)
+
+	return c.JSON(http.StatusServiceUnavailable, APIResponse{
+		Success: false,
+		Message: message,
+		Error:   "Service unavailable",
+	})
+}
+
 // NotFound sends a not found error response
 func NotFound(c *echo.Context, message string, err error) error {
 	errorMsg := "Resource not found"
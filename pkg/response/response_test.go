@@ -77,6 +77,29 @@ func TestCreated(t *testing.T) {
 	}
 }
 
+func TestAccepted(t *testing.T) {
+	c, rec := newCtx(t)
+	if err := Accepted(c, "queued", nil); err != nil {
+		t.Fatalf("Accepted returned error: %v", err)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+}
+
+func TestMovedPermanently(t *testing.T) {
+	c, rec := newCtx(t)
+	if err := MovedPermanently(c, "moved", "/api/users/username/new", nil); err != nil {
+		t.Fatalf("MovedPermanently returned error: %v", err)
+	}
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+	}
+	if got := rec.Header().Get("Location"); got != "/api/users/username/new" {
+		t.Fatalf("Location = %q", got)
+	}
+}
+
 func TestBadRequest(t *testing.T) {
 	c, rec := newCtx(t)
 	if err := BadRequest(c, "bad input", errors.New("missing field")); err != nil {
@@ -123,6 +146,21 @@ func TestForbidden(t *testing.T) {
 	}
 }
 
+func TestForbiddenWithCode(t *testing.T) {
+	c, rec := newCtx(t)
+	_ = ForbiddenWithCode(c, "suspended", "account_suspended", map[string]any{"reason": "spam"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := decode(t, rec.Body.Bytes())
+	if body.Error != "account_suspended" {
+		t.Errorf("Error = %q", body.Error)
+	}
+	if data, ok := body.Data.(map[string]any); !ok || data["reason"] != "spam" {
+		t.Errorf("Data = %v", body.Data)
+	}
+}
+
 func TestNotFound(t *testing.T) {
 	c, rec := newCtx(t)
 	_ = NotFound(c, "missing", errors.New("post not found"))
@@ -213,6 +251,18 @@ func TestConflict(t *testing.T) {
 	}
 }
 
+func TestServiceUnavailable(t *testing.T) {
+	c, rec := newCtx(t)
+	_ = ServiceUnavailable(c, "disabled")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := decode(t, rec.Body.Bytes())
+	if body.Success || body.Error != "Service unavailable" {
+		t.Errorf("body = %+v", body)
+	}
+}
+
 func TestCalculatePaginationMeta(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,238 @@
+// Package textdiff compares texts line by line and formats the result as a
+// unified diff.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified returns the unified diff that turns a into b, with context lines
+// of unchanged text around each change. fromName and toName label the
+// texts in the --- and +++ header lines. It returns "" when a and b have the
+// same lines.
+func Unified(fromName, toName, a, b string, context int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for _, h := range hunks(ops, context) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, op := range ops[h.first:h.last] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+// op is one line of an edit script: ' ' keeps a line of both texts, '-'
+// deletes a line of a and '+' inserts a line of b.
+type op struct {
+	kind byte
+	line string
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// maxEdits bounds the search for a shortest edit script. The backtrack keeps
+// the diagonals reached by each step, so memory grows with the square of the
+// number of edits; texts that differ by more are replaced wholesale.
+const maxEdits = 1000
+
+// diffLines returns a shortest edit script from a to b, using Myers'
+// algorithm, or one that deletes all of a and inserts all of b when the
+// texts differ by more than maxEdits lines (past their common prefix and
+// suffix).
+func diffLines(a, b []string) []op {
+	// The common prefix and suffix need no search.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{' ', line})
+	}
+	return ops
+}
+
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k = x - y. trace[d]
+	// holds diagonals -d..d of v as they were before step d.
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// Walk back from the end, collecting the script in reverse.
+	var reversed []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = prev[prevK+d]
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, op{'+', b[y]})
+		} else {
+			x--
+			reversed = append(reversed, op{'-', a[x]})
+		}
+	}
+
+	ops := make([]op, len(reversed))
+	for i, o := range reversed {
+		ops[len(reversed)-1-i] = o
+	}
+	return ops
+}
+
+// replaceAll returns the script that deletes every line of a, then inserts
+// every line of b.
+func replaceAll(a, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, op{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, op{'+', line})
+	}
+	return ops
+}
+
+// hunk is ops[first:last] with the lines it covers in a and b; the starts
+// are 1-based.
+type hunk struct {
+	first, last  int
+	aStart, aLen int
+	bStart, bLen int
+}
+
+// hunks groups the changes in ops with up to context unchanged lines on each
+// side. Changes closer than twice the context share a hunk.
+func hunks(ops []op, context int) []hunk {
+	var out []hunk
+	aLine, bLine := 1, 1
+	var cur *hunk
+	lastChange := -1
+	for i, o := range ops {
+		if o.kind != ' ' {
+			if cur != nil && i-lastChange > 2*context+1 {
+				out = append(out, closeHunk(ops, *cur, lastChange, context))
+				cur = nil
+			}
+			if cur == nil {
+				first := max(i-context, 0)
+				kept := i - first
+				cur = &hunk{first: first, aStart: aLine - kept, bStart: bLine - kept}
+			}
+			lastChange = i
+		}
+		switch o.kind {
+		case ' ':
+			aLine++
+			bLine++
+		case '-':
+			aLine++
+		case '+':
+			bLine++
+		}
+	}
+	if cur != nil {
+		out = append(out, closeHunk(ops, *cur, lastChange, context))
+	}
+	return out
+}
+
+func closeHunk(ops []op, h hunk, lastChange, context int) hunk {
+	h.last = min(lastChange+context+1, len(ops))
+	for _, o := range ops[h.first:h.last] {
+		if o.kind != '+' {
+			h.aLen++
+		}
+		if o.kind != '-' {
+			h.bLen++
+		}
+	}
+	return h
+}
+
+// hunkRange formats the start and length of a hunk the way diff -u does: an
+// empty range starts at the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
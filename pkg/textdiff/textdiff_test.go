@@ -0,0 +1,104 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "one\ntwo\n", "one\ntwo", ""},
+		{
+			"changed line",
+			"one\ntwo\nthree\n",
+			"one\n2\nthree\n",
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			"from empty",
+			"",
+			"hello\n",
+			"--- a\n+++ b\n@@ -0,0 +1 @@\n+hello\n",
+		},
+		{
+			"to empty",
+			"hello\nworld\n",
+			"",
+			"--- a\n+++ b\n@@ -1,2 +0,0 @@\n-hello\n-world\n",
+		},
+		{
+			"distant changes split into hunks",
+			"a\nb\nc\nd\ne\nf\ng\nh\ni\n",
+			"A\nb\nc\nd\ne\nf\ng\nh\nI\n",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -8,2 +8,2 @@\n h\n-i\n+I\n",
+		},
+		{
+			"close changes share a hunk",
+			"a\nb\nc\nd\n",
+			"A\nb\nc\nD\n",
+			"--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n-d\n+D\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b, 1); got != tt.want {
+				t.Fatalf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUnifiedAppliesCleanly rebuilds both texts from the diff of texts with
+// interleaved insertions, deletions and moves.
+func TestUnifiedAppliesCleanly(t *testing.T) {
+	a := "the\nquick\nbrown\nfox\njumps\nover\nthe\nlazy\ndog\n"
+	b := "a\nquick\nfox\nbrown\njumps\nover\nthe\nvery\nlazy\ncat\n"
+
+	var gotA, gotB []string
+	for _, line := range strings.Split(strings.TrimSuffix(Unified("a", "b", a, b, 100), "\n"), "\n")[3:] {
+		switch line[0] {
+		case ' ':
+			gotA, gotB = append(gotA, line[1:]), append(gotB, line[1:])
+		case '-':
+			gotA = append(gotA, line[1:])
+		case '+':
+			gotB = append(gotB, line[1:])
+		}
+	}
+	if strings.Join(gotA, "\n")+"\n" != a || strings.Join(gotB, "\n")+"\n" != b {
+		t.Fatalf("diff does not rebuild the texts:\n%v\n%v", gotA, gotB)
+	}
+}
+
+// TestDiffLinesCapsEdits checks that texts differing by more than maxEdits
+// lines are replaced wholesale instead of searched.
+func TestDiffLinesCapsEdits(t *testing.T) {
+	a := []string{"same"}
+	b := []string{"same"}
+	for i := range maxEdits {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != 2*maxEdits+1 {
+		t.Fatalf("got %d ops, want %d", len(ops), 2*maxEdits+1)
+	}
+	want := []op{{' ', "same"}}
+	for _, line := range a[1:] {
+		want = append(want, op{'-', line})
+	}
+	for _, line := range b[1:] {
+		want = append(want, op{'+', line})
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Fatalf("op %d = %+v, want %+v", i, ops[i], want[i])
+		}
+	}
+}